@@ -0,0 +1,73 @@
+package middlewares
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/npushpakumara/go-backend-template/pkg/logging"
+)
+
+// RequestIDHeader is the header carrying the request ID. An incoming value is reused so that
+// IDs assigned by a load balancer or proxy stay consistent; otherwise a new one is generated.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds incoming request IDs so a client can't bloat every log line.
+const maxRequestIDLength = 128
+
+// accessLogSkipPaths are probed or scraped constantly, so logging them only adds noise.
+var accessLogSkipPaths = map[string]struct{}{
+	"/healthz": {},
+	"/livez":   {},
+	"/readyz":  {},
+	"/metrics": {},
+}
+
+// AccessLog assigns every request an ID, attaches a logger carrying that ID to the request context
+// so downstream logs can be correlated, and logs one line per request once it has been handled.
+// Successful and redirect responses are logged at info level, client errors at warn and server errors at error.
+func AccessLog() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		requestID := ctx.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = uuid.NewString()
+		}
+		ctx.Header(RequestIDHeader, requestID)
+
+		logger := logging.FromContext(ctx).With("request_id", requestID)
+		reqCtx := logging.WithRequestID(ctx, requestID)
+		ctx.Request = ctx.Request.WithContext(logging.WithLogger(reqCtx, logger))
+
+		ctx.Next()
+
+		path := ctx.Request.URL.Path
+		if _, skip := accessLogSkipPaths[path]; skip {
+			return
+		}
+
+		status := ctx.Writer.Status()
+		fields := []interface{}{
+			"method", ctx.Request.Method,
+			"path", path,
+			"status", status,
+			"latency", time.Since(start),
+			"bytes", max(ctx.Writer.Size(), 0),
+			"client_ip", ctx.ClientIP(),
+		}
+		if len(ctx.Errors) > 0 {
+			fields = append(fields, "errors", ctx.Errors.String())
+		}
+
+		switch {
+		case status >= http.StatusInternalServerError:
+			logger.Errorw("http request", fields...)
+		case status >= http.StatusBadRequest:
+			logger.Warnw("http request", fields...)
+		default:
+			logger.Infow("http request", fields...)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/npushpakumara/go-backend-template/pkg/logging"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// observeLogs returns a middleware that gives each request a logger recording its entries in the returned logs.
+func observeLogs() (gin.HandlerFunc, *observer.ObservedLogs) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(core).Sugar()
+	return func(ctx *gin.Context) {
+		ctx.Request = ctx.Request.WithContext(logging.WithLogger(ctx, logger))
+		ctx.Next()
+	}, logs
+}
+
+// newAccessLogRouter serves a few routes behind AccessLog, whose log entries are recorded in the returned logs.
+func newAccessLogRouter() (*gin.Engine, *observer.ObservedLogs) {
+	gin.SetMode(gin.TestMode)
+
+	observe, logs := observeLogs()
+	router := gin.New()
+	router.Use(observe, AccessLog())
+	router.GET("/users/:id", func(ctx *gin.Context) {
+		logging.FromContext(ctx).Infow("handler ran")
+		ctx.String(http.StatusOK, "hello")
+	})
+	router.GET("/missing", func(ctx *gin.Context) { ctx.Status(http.StatusNotFound) })
+	router.GET("/broken", func(ctx *gin.Context) { ctx.Status(http.StatusInternalServerError) })
+	router.GET("/healthz", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+	return router, logs
+}
+
+func TestAccessLogFields(t *testing.T) {
+	router, logs := newAccessLogRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.Header.Set(RequestIDHeader, "req-123")
+	req.RemoteAddr = "203.0.113.7:51234"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if got := w.Header().Get(RequestIDHeader); got != "req-123" {
+		t.Errorf("%s response header = %q, want %q", RequestIDHeader, got, "req-123")
+	}
+
+	entries := logs.FilterMessage("http request").All()
+	if len(entries) != 1 {
+		t.Fatalf("logged %d access log entries, want 1", len(entries))
+	}
+	entry := entries[0]
+	if entry.Level != zapcore.InfoLevel {
+		t.Errorf("level = %s, want info", entry.Level)
+	}
+	fields := entry.ContextMap()
+	want := map[string]interface{}{
+		"request_id": "req-123",
+		"method":     http.MethodGet,
+		"path":       "/users/42",
+		"status":     int64(http.StatusOK),
+		"bytes":      int64(len("hello")),
+		"client_ip":  "203.0.113.7",
+	}
+	for key, value := range want {
+		if fields[key] != value {
+			t.Errorf("field %s = %#v, want %#v", key, fields[key], value)
+		}
+	}
+	if _, ok := fields["latency"]; !ok {
+		t.Error("entry has no latency field")
+	}
+
+	// Logs written while handling the request carry its ID too.
+	handlerEntries := logs.FilterMessage("handler ran").All()
+	if len(handlerEntries) != 1 || handlerEntries[0].ContextMap()["request_id"] != "req-123" {
+		t.Errorf("handler log entries = %v, want one with the request ID", handlerEntries)
+	}
+}
+
+func TestAccessLogLevels(t *testing.T) {
+	tests := []struct {
+		path  string
+		level zapcore.Level
+	}{
+		{path: "/users/42", level: zapcore.InfoLevel},
+		{path: "/missing", level: zapcore.WarnLevel},
+		{path: "/broken", level: zapcore.ErrorLevel},
+	}
+	for _, tt := range tests {
+		router, logs := newAccessLogRouter()
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		entries := logs.FilterMessage("http request").All()
+		if len(entries) != 1 || entries[0].Level != tt.level {
+			t.Errorf("GET %s logged %v, want one entry at %s", tt.path, entries, tt.level)
+		}
+	}
+}
+
+func TestAccessLogRequestID(t *testing.T) {
+	router, _ := newAccessLogRouter()
+
+	// Missing and oversized IDs are replaced with a generated one.
+	for _, incoming := range []string{"", strings.Repeat("x", maxRequestIDLength+1)} {
+		req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+		if incoming != "" {
+			req.Header.Set(RequestIDHeader, incoming)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if got := w.Header().Get(RequestIDHeader); got == "" || got == incoming {
+			t.Errorf("%s for incoming %q = %q, want a generated ID", RequestIDHeader, incoming, got)
+		}
+	}
+}
+
+func TestAccessLogSkipsHealthChecks(t *testing.T) {
+	router, logs := newAccessLogRouter()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	if entries := logs.FilterMessage("http request").All(); len(entries) != 0 {
+		t.Errorf("logged %d access log entries for a health check, want none", len(entries))
+	}
+	if w.Header().Get(RequestIDHeader) == "" {
+		t.Error("health check response has no request ID")
+	}
+}
@@ -1,31 +1,50 @@
 package middlewares
 
 import (
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/npushpakumara/go-backend-template/internal/features/auth"
+	"github.com/npushpakumara/go-backend-template/internal/features/auth/tokens"
+	"github.com/npushpakumara/go-backend-template/internal/features/loginhistory"
+	loginHistoryEntity "github.com/npushpakumara/go-backend-template/internal/features/loginhistory/entity"
 	userDto "github.com/npushpakumara/go-backend-template/internal/features/user/dto"
+	userEntity "github.com/npushpakumara/go-backend-template/internal/features/user/entity"
 	apiError "github.com/npushpakumara/go-backend-template/pkg/errors"
 
 	jwt "github.com/appleboy/gin-jwt/v2"
 	"github.com/gin-gonic/gin"
+	gojwt "github.com/golang-jwt/jwt/v4"
 	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/internal/csrf"
 	"github.com/npushpakumara/go-backend-template/internal/features/auth/dto"
+	"github.com/npushpakumara/go-backend-template/internal/rbac"
+	"github.com/npushpakumara/go-backend-template/pkg/httpx"
 	"github.com/npushpakumara/go-backend-template/pkg/logging"
 )
 
 // identityKey is the key used to store the user identity in the JWT claims.
-var identityKey = "id"
+var identityKey = rbac.IdentityKey
+
+// authTimeKey is the claim holding the time the user originally signed in.
+var authTimeKey = rbac.AuthTimeKey
 
 // NewAuthMiddleware creates and configures a new JWT middleware instance for handling authentication.
-func NewAuthMiddleware(as auth.Service, cfg *config.Config) (*jwt.GinJWTMiddleware, error) {
+// Successful sign-ins are recorded in the user's login history.
+// Session tokens are signed with the current key of the JWT keyring and accepted if any key in the ring verifies them.
+func NewAuthMiddleware(as auth.Service, loginHistory loginhistory.Service, cfg *config.Config) (*jwt.GinJWTMiddleware, error) {
+	keyring := tokens.NewKeyring(&cfg.JWT)
+	_, signingKey := keyring.Current()
+
 	return jwt.New(&jwt.GinJWTMiddleware{
 		Realm:       "test zone",
-		Key:         []byte(cfg.JWT.Secret),
+		Key:         signingKey,
+		KeyFunc:     keyFunc(keyring),
 		Timeout:     cfg.JWT.AccessTokenExpiry,
 		MaxRefresh:  cfg.JWT.RefreshTokenExpiry,
 		IdentityKey: identityKey,
-		TokenLookup: "cookie:access_token",
+		TokenLookup: "cookie:" + auth.AccessTokenCookie,
 		Authenticator: func(ctx *gin.Context) (interface{}, error) {
 			logger := logging.FromContext(ctx)
 			var requestBody dto.SignInRequestDto
@@ -35,20 +54,43 @@ func NewAuthMiddleware(as auth.Service, cfg *config.Config) (*jwt.GinJWTMiddlewa
 				return nil, jwt.ErrMissingLoginValues
 			}
 
-			userID, err := as.LoginUser(ctx, &requestBody)
+			user, err := as.LoginUser(ctx, &requestBody)
 			if err != nil {
+				// Suspended and banned accounts get a distinct message so the user knows signing in again won't help.
+				if errors.Is(err, apiError.ErrAccountSuspended) || errors.Is(err, apiError.ErrAccountBanned) {
+					return nil, err
+				}
 				return nil, jwt.ErrFailedAuthentication
 			}
-			return &userDto.UserResponseDto{ID: userID}, nil
+
+			// A failure to record the sign-in must not prevent it.
+			if err := loginHistory.Record(ctx, loginhistory.Event{
+				UserID:    user.ID,
+				Method:    loginHistoryEntity.MethodPassword,
+				IPAddress: ctx.ClientIP(),
+				UserAgent: ctx.Request.UserAgent(),
+			}); err != nil {
+				logger.Errorw("api.middlewares.AuthMiddleware failed to record login: %v", err)
+			}
+			return &userDto.UserResponseDto{ID: user.ID, OrgID: user.OrgID}, nil
 		},
 		Unauthorized: func(c *gin.Context, code int, message string) {
 			c.JSON(code, apiError.ErrorResponse{Status: "error", Message: message})
 		},
 		PayloadFunc: func(data interface{}) jwt.MapClaims {
-			if v, ok := data.(*userDto.UserResponseDto); ok {
-				return jwt.MapClaims{
+			switch v := data.(type) {
+			case *userDto.UserResponseDto:
+				return withOrgClaim(jwt.MapClaims{
 					identityKey: v.ID,
-				}
+					authTimeKey: time.Now().Unix(),
+				}, v.OrgID)
+			case *dto.ImpersonationDto:
+				// The impersonation starts now, so auth_time marks its start rather than the administrator's sign-in.
+				return withOrgClaim(jwt.MapClaims{
+					identityKey:   v.UserID,
+					authTimeKey:   time.Now().Unix(),
+					rbac.ActorKey: rbac.ActorClaim(v.ActorID),
+				}, v.OrgID)
 			}
 			return jwt.MapClaims{}
 		},
@@ -60,25 +102,107 @@ func NewAuthMiddleware(as auth.Service, cfg *config.Config) (*jwt.GinJWTMiddlewa
 			}
 		},
 
+		// Authorizator loads the user behind the token so that downstream handlers
+		// and role checks see the current role rather than what was true at login.
+		// This is the request's only lookup of the user: handlers and services read it back with rbac.CurrentUser.
+		// It also rejects suspended or banned users and tokens issued before the user's sessions were revoked.
+		// The request is scoped to the organization named by the org claim, which must still be the user's.
+		// Impersonated sessions are flagged for downstream handlers and end once the impersonation lifetime has passed.
 		Authorizator: func(data interface{}, c *gin.Context) bool {
-			if v, ok := data.(*userDto.UserResponseDto); ok && v.ID != "" {
-				return true
+			v, ok := data.(*userDto.UserResponseDto)
+			if !ok || v.ID == "" {
+				return false
+			}
+
+			user, err := as.GetUserByID(c, v.ID)
+			if err != nil {
+				return false
+			}
+
+			if user.Status == userEntity.StatusSuspended || user.Status == userEntity.StatusBanned {
+				return false
+			}
+
+			claims := jwt.ExtractClaims(c)
+			if user.SessionsRevokedAt != nil {
+				authTime, ok := claims[authTimeKey].(float64)
+				if !ok || int64(authTime) < user.SessionsRevokedAt.Unix() {
+					return false
+				}
 			}
-			return false
+
+			// A user who has moved to another organization, or left theirs, signs in again rather than
+			// keep a session scoped to the old one.
+			orgID, _ := claims[rbac.OrgKey].(string)
+			if orgID != user.OrgID {
+				return false
+			}
+
+			// Refreshing a token carries the act and auth_time claims over, so the impersonation is
+			// bounded by when it started rather than by the expiry of the current token.
+			if actorID, ok := rbac.Actor(claims); ok {
+				authTime, ok := claims[authTimeKey].(float64)
+				if !ok || time.Since(time.Unix(int64(authTime), 0)) > cfg.JWT.ImpersonationTokenExpiry {
+					return false
+				}
+				rbac.SetImpersonator(c, actorID)
+			}
+
+			rbac.SetIdentity(c, user)
+			// Requests of users outside any organization aren't scoped, so the administrators seeded
+			// without one manage the users of every organization.
+			if orgID != "" {
+				rbac.SetOrg(c, orgID)
+			}
+			return true
 		},
 		LoginResponse: func(c *gin.Context, code int, token string, expires time.Time) {
-			c.SetCookie("access_token", token, int(time.Until(expires).Seconds()), "/", "", false, true)
+			httpx.SetCookie(c.Writer, auth.AccessTokenCookie, token, int(time.Until(expires).Seconds()), cfg.Cookie.Options())
+			// Every new session gets a fresh CSRF token.
+			if _, err := csrf.Issue(c, &cfg.Cookie); err != nil {
+				logging.FromContext(c).Errorw("api.middlewares.AuthMiddleware failed to issue CSRF token: %v", err)
+			}
 			c.JSON(code, apiError.ErrorResponse{Status: "success", Message: "Login successfully"})
 		},
 		LogoutResponse: func(c *gin.Context, code int) {
-			c.SetCookie("access_token", "", -1, "/", "", false, true)
+			httpx.SetCookie(c.Writer, auth.AccessTokenCookie, "", -1, cfg.Cookie.Options())
+			csrf.Clear(c, &cfg.Cookie)
 			c.JSON(code, apiError.ErrorResponse{Status: "success", Message: "Logout successfully"})
 		},
 
 		RefreshResponse: func(c *gin.Context, code int, token string, expires time.Time) {
-			c.SetCookie("access_token", token, int(time.Until(expires).Seconds()), "/", "", false, true) // Set as HTTP-only
+			httpx.SetCookie(c.Writer, auth.AccessTokenCookie, token, int(time.Until(expires).Seconds()), cfg.Cookie.Options())
+			// Sessions that predate CSRF protection pick up a token on their next refresh.
+			if _, err := c.Cookie(csrf.CookieName); err != nil {
+				if _, err := csrf.Issue(c, &cfg.Cookie); err != nil {
+					logging.FromContext(c).Errorw("api.middlewares.AuthMiddleware failed to issue CSRF token: %v", err)
+				}
+			}
 			c.JSON(code, apiError.ErrorResponse{Status: "success", Message: "Token refresh successfully"})
 
 		},
 	})
 }
+
+// withOrgClaim adds the org claim to the claims of a session of a user who belongs to an organization.
+func withOrgClaim(claims jwt.MapClaims, orgID string) jwt.MapClaims {
+	if orgID != "" {
+		claims[rbac.OrgKey] = orgID
+	}
+	return claims
+}
+
+// keyFunc selects the keyring key that verifies a session token. The middleware can't set a kid header
+// on the tokens it signs, so unless the token names its key, each key in the ring is tried in turn.
+func keyFunc(keyring *tokens.Keyring) func(token *gojwt.Token) (interface{}, error) {
+	return func(token *gojwt.Token) (interface{}, error) {
+		if token.Method != gojwt.SigningMethodHS256 {
+			return nil, jwt.ErrInvalidSigningAlgorithm
+		}
+		kid, _ := token.Header["kid"].(string)
+		dot := strings.LastIndex(token.Raw, ".")
+		return keyring.Key(kid, func(key []byte) bool {
+			return token.Method.Verify(token.Raw[:dot], token.Raw[dot+1:], key) == nil
+		})
+	}
+}
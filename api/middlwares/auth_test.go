@@ -0,0 +1,291 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+	gojwt "github.com/golang-jwt/jwt/v4"
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/internal/csrf"
+	"github.com/npushpakumara/go-backend-template/internal/features/account"
+	"github.com/npushpakumara/go-backend-template/internal/features/apikey"
+	"github.com/npushpakumara/go-backend-template/internal/features/auth"
+	"github.com/npushpakumara/go-backend-template/internal/features/auth/dto"
+	"github.com/npushpakumara/go-backend-template/internal/features/loginhistory"
+	userDto "github.com/npushpakumara/go-backend-template/internal/features/user/dto"
+	userEntity "github.com/npushpakumara/go-backend-template/internal/features/user/entity"
+	"github.com/npushpakumara/go-backend-template/internal/idempotency"
+	"github.com/npushpakumara/go-backend-template/pkg"
+)
+
+// stubAuthService is an auth.Service that signs in and finds a single active user. Other methods aren't used.
+type stubAuthService struct {
+	auth.Service
+}
+
+func (stubAuthService) LoginUser(context.Context, *dto.SignInRequestDto) (*userDto.UserResponseDto, error) {
+	return &userDto.UserResponseDto{ID: "user-1"}, nil
+}
+
+func (stubAuthService) GetUserByID(_ context.Context, id string) (*userDto.UserResponseDto, error) {
+	return &userDto.UserResponseDto{ID: id, Status: userEntity.StatusActive, IsActive: true}, nil
+}
+
+// stubLoginHistory is a loginhistory.Service that discards the sign-ins it is given.
+type stubLoginHistory struct {
+	loginhistory.Service
+}
+
+func (stubLoginHistory) Record(context.Context, loginhistory.Event) error { return nil }
+
+// newCookieRouter serves the sign-in, refresh and sign-out routes with the cookie settings in cookieCfg.
+func newCookieRouter(t *testing.T, cookieCfg config.CookieConfig) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{Cookie: cookieCfg}
+	cfg.JWT.Secret = "test-secret"
+	cfg.JWT.AccessTokenExpiry = time.Hour
+	cfg.JWT.RefreshTokenExpiry = 24 * time.Hour
+	authMiddleware, err := NewAuthMiddleware(stubAuthService{}, stubLoginHistory{}, cfg)
+	if err != nil {
+		t.Fatalf("NewAuthMiddleware() error = %v", err)
+	}
+
+	router := gin.New()
+	router.POST("/sign-in", authMiddleware.LoginHandler)
+	router.POST("/refresh", authMiddleware.RefreshHandler)
+	router.POST("/sign-out", authMiddleware.LogoutHandler)
+	return router
+}
+
+// responseCookies sends the request and returns the cookies set by the response, by name.
+func responseCookies(router *gin.Engine, req *http.Request) (int, map[string]*http.Cookie) {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	cookies := map[string]*http.Cookie{}
+	for _, c := range w.Result().Cookies() {
+		cookies[c.Name] = c
+	}
+	return w.Code, cookies
+}
+
+func signInRequest() *http.Request {
+	return httptest.NewRequest(http.MethodPost, "/sign-in", strings.NewReader(`{"email":"ada@example.com","password":"Correct-Horse-9"}`))
+}
+
+func TestSessionCookiesFollowConfig(t *testing.T) {
+	secure, insecure := true, false
+	tests := []struct {
+		name     string
+		cfg      config.CookieConfig
+		secure   bool
+		domain   string
+		path     string
+		sameSite http.SameSite
+	}{
+		{
+			name:     "production",
+			cfg:      config.CookieConfig{Secure: &secure, Domain: ".example.com", Path: "/", SameSite: "lax"},
+			secure:   true,
+			domain:   "example.com",
+			path:     "/",
+			sameSite: http.SameSiteLaxMode,
+		},
+		{
+			name:     "strict and scoped to the API",
+			cfg:      config.CookieConfig{Secure: &secure, Domain: "api.example.com", Path: "/api", SameSite: "strict"},
+			secure:   true,
+			domain:   "api.example.com",
+			path:     "/api",
+			sameSite: http.SameSiteStrictMode,
+		},
+		{
+			name:     "cross-site",
+			cfg:      config.CookieConfig{Secure: &secure, Path: "/", SameSite: "none"},
+			secure:   true,
+			path:     "/",
+			sameSite: http.SameSiteNoneMode,
+		},
+		{
+			name:     "local development",
+			cfg:      config.CookieConfig{Secure: &insecure, Path: "/", SameSite: "lax"},
+			secure:   false,
+			path:     "/",
+			sameSite: http.SameSiteLaxMode,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newCookieRouter(t, tt.cfg)
+			check := func(route string, c *http.Cookie, httpOnly bool) {
+				t.Helper()
+				if c == nil {
+					t.Fatalf("%s didn't set the cookie", route)
+				}
+				if c.Secure != tt.secure || c.Domain != tt.domain || c.Path != tt.path || c.SameSite != tt.sameSite || c.HttpOnly != httpOnly {
+					t.Errorf("%s set %s with Secure=%v Domain=%q Path=%q SameSite=%v HttpOnly=%v, want %v %q %q %v %v",
+						route, c.Name, c.Secure, c.Domain, c.Path, c.SameSite, c.HttpOnly, tt.secure, tt.domain, tt.path, tt.sameSite, httpOnly)
+				}
+			}
+
+			code, cookies := responseCookies(router, signInRequest())
+			if code != http.StatusOK {
+				t.Fatalf("sign-in status = %d, want %d", code, http.StatusOK)
+			}
+			session := cookies[auth.AccessTokenCookie]
+			check("sign-in", session, true)
+			check("sign-in", cookies[csrf.CookieName], false)
+			if session.MaxAge <= 0 || session.MaxAge > int(time.Hour.Seconds()) {
+				t.Errorf("sign-in cookie Max-Age = %d, want the access token lifetime", session.MaxAge)
+			}
+
+			refresh := httptest.NewRequest(http.MethodPost, "/refresh", nil)
+			refresh.AddCookie(&http.Cookie{Name: auth.AccessTokenCookie, Value: session.Value})
+			code, cookies = responseCookies(router, refresh)
+			if code != http.StatusOK {
+				t.Fatalf("refresh status = %d, want %d", code, http.StatusOK)
+			}
+			check("refresh", cookies[auth.AccessTokenCookie], true)
+
+			// Deleting a cookie only works with the attributes it was set with.
+			code, cookies = responseCookies(router, httptest.NewRequest(http.MethodPost, "/sign-out", nil))
+			if code != http.StatusOK {
+				t.Fatalf("sign-out status = %d, want %d", code, http.StatusOK)
+			}
+			for _, name := range []string{auth.AccessTokenCookie, csrf.CookieName} {
+				c := cookies[name]
+				check("sign-out", c, name == auth.AccessTokenCookie)
+				if c.MaxAge >= 0 {
+					t.Errorf("sign-out left %s with Max-Age %d, want it deleted", name, c.MaxAge)
+				}
+			}
+		})
+	}
+}
+
+// The middleware reads the session from the cookie only.
+func TestSessionTokenLookup(t *testing.T) {
+	router := newCookieRouter(t, config.CookieConfig{Path: "/", SameSite: "lax"})
+	_, cookies := responseCookies(router, signInRequest())
+	token := cookies[auth.AccessTokenCookie].Value
+
+	refresh := httptest.NewRequest(http.MethodPost, "/refresh", nil)
+	refresh.Header.Set("Authorization", "Bearer "+token)
+	if code, _ := responseCookies(router, refresh); code != http.StatusUnauthorized {
+		t.Errorf("refresh with a bearer token status = %d, want %d", code, http.StatusUnauthorized)
+	}
+}
+
+// newSessionRouter serves the authentication and account routes behind the auth middleware,
+// with impersonated sessions lasting impersonationExpiry.
+func newSessionRouter(t *testing.T, impersonationExpiry time.Duration) (*gin.Engine, *jwt.GinJWTMiddleware) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{}
+	cfg.JWT.Secret = "test-secret"
+	cfg.JWT.AccessTokenExpiry = time.Hour
+	cfg.JWT.RefreshTokenExpiry = 24 * time.Hour
+	cfg.JWT.ImpersonationTokenExpiry = impersonationExpiry
+	cfg.Server.IdempotencyTTL = time.Hour
+	if err := pkg.RegisterValidations(); err != nil {
+		t.Fatalf("RegisterValidations() error = %v", err)
+	}
+	authMiddleware, err := NewAuthMiddleware(stubAuthService{}, stubLoginHistory{}, cfg)
+	if err != nil {
+		t.Fatalf("NewAuthMiddleware() error = %v", err)
+	}
+
+	router := gin.New()
+	auth.Router(router, auth.NewAuthHandler(stubAuthService{}, nil, cfg), authMiddleware, apikey.NewAPIKeyMiddleware(nil, nil, authMiddleware), idempotency.NewIdempotencyMiddleware(cfg))
+	account.Router(router, account.NewAccountHandler(nil, cfg), authMiddleware)
+	return router, authMiddleware
+}
+
+// sessionToken signs an access token for the data, as the middleware would, for a session that started at startedAt.
+// The token itself hasn't expired, as if it had just been refreshed.
+func sessionToken(t *testing.T, authMiddleware *jwt.GinJWTMiddleware, data interface{}, startedAt time.Time) string {
+	t.Helper()
+
+	claims := gojwt.MapClaims(authMiddleware.PayloadFunc(data))
+	claims[authTimeKey] = startedAt.Unix()
+	claims["orig_iat"] = startedAt.Unix()
+	claims["exp"] = time.Now().Add(time.Hour).Unix()
+	token, err := gojwt.NewWithClaims(gojwt.SigningMethodHS256, claims).SignedString(authMiddleware.Key)
+	if err != nil {
+		t.Fatalf("SignedString() error = %v", err)
+	}
+	return token
+}
+
+// sendWithSession sends the request with the token as the session cookie and returns the response status.
+func sendWithSession(router *gin.Engine, method, path, body, token string) int {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(&http.Cookie{Name: auth.AccessTokenCookie, Value: token})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Code
+}
+
+// An administrator impersonating a user can't change their password, delete their account or export their data.
+func TestImpersonatedSessionsCantManageTheAccount(t *testing.T) {
+	router, authMiddleware := newSessionRouter(t, 15*time.Minute)
+	impersonated := sessionToken(t, authMiddleware, &dto.ImpersonationDto{UserID: "user-1", ActorID: "admin-1"}, time.Now())
+
+	routes := []struct {
+		method string
+		path   string
+	}{
+		{method: http.MethodPost, path: "/api/v1/users/me/change-password"},
+		{method: http.MethodDelete, path: "/api/v1/users/me"},
+		{method: http.MethodGet, path: "/api/v1/users/me/export"},
+	}
+	for _, route := range routes {
+		t.Run(route.method+" "+route.path, func(t *testing.T) {
+			if code := sendWithSession(router, route.method, route.path, `{}`, impersonated); code != http.StatusForbidden {
+				t.Errorf("status = %d, want %d", code, http.StatusForbidden)
+			}
+		})
+	}
+
+	// The user's own session gets past the check, to the handler's validation of the empty body.
+	own := sessionToken(t, authMiddleware, &userDto.UserResponseDto{ID: "user-1"}, time.Now())
+	if code := sendWithSession(router, http.MethodPost, "/api/v1/users/me/change-password", `{}`, own); code != http.StatusBadRequest {
+		t.Errorf("change-password with the user's own session status = %d, want %d", code, http.StatusBadRequest)
+	}
+}
+
+// An impersonated session ends once the impersonation lifetime has passed since it started, however often it was refreshed.
+func TestImpersonatedSessionsExpire(t *testing.T) {
+	const lifetime = 15 * time.Minute
+	router, authMiddleware := newSessionRouter(t, lifetime)
+	impersonation := &dto.ImpersonationDto{UserID: "user-1", ActorID: "admin-1"}
+
+	tests := []struct {
+		name      string
+		data      interface{}
+		startedAt time.Time
+		want      int
+	}{
+		{name: "impersonation within its lifetime", data: impersonation, startedAt: time.Now().Add(-lifetime + time.Minute), want: http.StatusOK},
+		// gin-jwt answers 403 when the Authorizator refuses a valid token.
+		{name: "impersonation past its lifetime", data: impersonation, startedAt: time.Now().Add(-lifetime - time.Minute), want: http.StatusForbidden},
+		{name: "own session of the same age", data: &userDto.UserResponseDto{ID: "user-1"}, startedAt: time.Now().Add(-lifetime - time.Minute), want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := sessionToken(t, authMiddleware, tt.data, tt.startedAt)
+			if code := sendWithSession(router, http.MethodGet, "/api/v1/auth/me", "", token); code != tt.want {
+				t.Errorf("status = %d, want %d", code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package middlewares
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strings"
+	"syscall"
+
+	"github.com/gin-gonic/gin"
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	apiError "github.com/npushpakumara/go-backend-template/pkg/errors"
+	"github.com/npushpakumara/go-backend-template/pkg/logging"
+)
+
+// Recovery recovers from panics in later handlers, logs the panic and its stack trace through the
+// request logger and responds with the standard JSON error body and a 500 status.
+// Outside production the panic value is included in the response to ease debugging.
+func Recovery(cfg *config.Config) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+
+			logger := logging.FromContext(ctx)
+
+			// A client that went away can't be sent a response, and its stack trace is just noise.
+			if isBrokenPipe(rec) {
+				logger.Warnw("http client connection lost", "path", ctx.Request.URL.Path, "error", rec)
+				_ = ctx.Error(fmt.Errorf("%v", rec))
+				ctx.Abort()
+				return
+			}
+
+			logger.Errorw("http handler panicked",
+				"method", ctx.Request.Method,
+				"path", ctx.Request.URL.Path,
+				"panic", rec,
+				"stack", string(debug.Stack()),
+			)
+
+			resp := apiError.ErrorResponse{Status: "error", Message: "Internal server error"}
+			if !cfg.Server.Production {
+				resp.Message = fmt.Sprintf("Internal server error: %v", rec)
+			}
+			if ctx.Writer.Written() {
+				ctx.Abort()
+				return
+			}
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, resp)
+		}()
+
+		ctx.Next()
+	}
+}
+
+// isBrokenPipe reports whether the recovered value is a write error caused by the client closing the connection.
+func isBrokenPipe(rec interface{}) bool {
+	err, ok := rec.(error)
+	if !ok {
+		return false
+	}
+
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		return false
+	}
+	var sysErr *os.SyscallError
+	if errors.As(opErr, &sysErr) {
+		return errors.Is(sysErr.Err, syscall.EPIPE) || errors.Is(sysErr.Err, syscall.ECONNRESET)
+	}
+	msg := strings.ToLower(opErr.Error())
+	return strings.Contains(msg, "broken pipe") || strings.Contains(msg, "connection reset by peer")
+}
@@ -0,0 +1,119 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	apiError "github.com/npushpakumara/go-backend-template/pkg/errors"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// newPanickingRouter serves routes that panic with the given value behind AccessLog and Recovery.
+func newPanickingRouter(production bool, value interface{}) (*gin.Engine, *observer.ObservedLogs) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{}
+	cfg.Server.Production = production
+	observe, logs := observeLogs()
+	router := gin.New()
+	router.Use(observe, AccessLog(), Recovery(cfg))
+	router.GET("/panic", func(*gin.Context) { panic(value) })
+	router.GET("/panic-after-write", func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, "partial")
+		panic(value)
+	})
+	return router, logs
+}
+
+func TestRecoveryRespondsWithJSONError(t *testing.T) {
+	tests := []struct {
+		name        string
+		production  bool
+		wantMessage string
+	}{
+		{name: "development", production: false, wantMessage: "Internal server error: database exploded"},
+		{name: "production", production: true, wantMessage: "Internal server error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, logs := newPanickingRouter(tt.production, "database exploded")
+
+			req := httptest.NewRequest(http.MethodGet, "/panic", nil)
+			req.Header.Set(RequestIDHeader, "req-123")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusInternalServerError {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+			}
+			if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+				t.Errorf("Content-Type = %q, want JSON", ct)
+			}
+			var body apiError.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response %q isn't an ErrorResponse: %v", w.Body.String(), err)
+			}
+			if body.Status != "error" || body.Message != tt.wantMessage {
+				t.Errorf("response = %+v, want status error and message %q", body, tt.wantMessage)
+			}
+
+			entries := logs.FilterMessage("http handler panicked").All()
+			if len(entries) != 1 {
+				t.Fatalf("logged %d panic entries, want 1", len(entries))
+			}
+			fields := entries[0].ContextMap()
+			if fields["request_id"] != "req-123" || fields["panic"] != "database exploded" || fields["path"] != "/panic" {
+				t.Errorf("panic entry fields = %v, want the request ID, panic value and path", fields)
+			}
+			if stack, _ := fields["stack"].(string); !strings.Contains(stack, "recovery_test.go") {
+				t.Errorf("panic entry stack doesn't reach the handler:\n%s", stack)
+			}
+
+			// The request is still access-logged, as a server error.
+			if got := logs.FilterMessage("http request").All(); len(got) != 1 || got[0].ContextMap()["status"] != int64(http.StatusInternalServerError) {
+				t.Errorf("access log entries = %v, want one with status 500", got)
+			}
+		})
+	}
+}
+
+func TestRecoveryKeepsWrittenResponse(t *testing.T) {
+	router, logs := newPanickingRouter(false, "late failure")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic-after-write", nil))
+
+	// The status and body were already sent, so nothing is appended to them.
+	if w.Code != http.StatusOK || w.Body.String() != "partial" {
+		t.Errorf("response = %d %q, want the response written before the panic", w.Code, w.Body.String())
+	}
+	if got := len(logs.FilterMessage("http handler panicked").All()); got != 1 {
+		t.Errorf("logged %d panic entries, want 1", got)
+	}
+}
+
+func TestRecoveryTreatsBrokenPipeAsLostClient(t *testing.T) {
+	brokenPipe := &net.OpError{Op: "write", Net: "tcp", Err: os.NewSyscallError("write", syscall.EPIPE)}
+	router, logs := newPanickingRouter(false, brokenPipe)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	if got := len(logs.FilterMessage("http handler panicked").All()); got != 0 {
+		t.Errorf("logged %d panic entries for a lost client, want none", got)
+	}
+	if got := len(logs.FilterMessage("http client connection lost").All()); got != 1 {
+		t.Errorf("logged %d lost connection entries, want 1", got)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("response body = %q, want none for a lost client", w.Body.String())
+	}
+}
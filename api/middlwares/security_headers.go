@@ -0,0 +1,44 @@
+package middlewares
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/npushpakumara/go-backend-template/internal/config"
+)
+
+// SecurityHeaders adds the security headers configured under security.headers to every response.
+// Strict-Transport-Security is only sent in production, where TLS is expected to end at a proxy,
+// or when the request itself arrived over TLS, so local development over plain HTTP isn't pinned to HTTPS.
+func SecurityHeaders(cfg *config.Config) gin.HandlerFunc {
+	headers := cfg.Security.Headers
+
+	var hsts string
+	if headers.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.FormatInt(int64(headers.HSTSMaxAge.Seconds()), 10)
+		if headers.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+	}
+
+	return func(c *gin.Context) {
+		h := c.Writer.Header()
+		if headers.ContentTypeOptions {
+			h.Set("X-Content-Type-Options", "nosniff")
+		}
+		if headers.FrameOptions != "" {
+			h.Set("X-Frame-Options", headers.FrameOptions)
+		}
+		if headers.ReferrerPolicy != "" {
+			h.Set("Referrer-Policy", headers.ReferrerPolicy)
+		}
+		if headers.ContentSecurityPolicy != "" {
+			h.Set("Content-Security-Policy", headers.ContentSecurityPolicy)
+		}
+		if hsts != "" && (cfg.Server.Production || c.Request.TLS != nil) {
+			h.Set("Strict-Transport-Security", hsts)
+		}
+
+		c.Next()
+	}
+}
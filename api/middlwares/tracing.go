@@ -0,0 +1,62 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/pkg/logging"
+	"github.com/npushpakumara/go-backend-template/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing records a server span for every request, as a child of the span named by an incoming
+// traceparent header or as the root of a new trace. The span is stored on the request context, so
+// database queries and queued work become its children, and its IDs are added to the request's
+// logger. The request ID is recorded on the span once AccessLog has assigned it.
+// It must run before AccessLog, which builds on the logger set here. It does nothing when tracing is disabled.
+func Tracing(cfg *config.Config) gin.HandlerFunc {
+	if !cfg.Tracing.Enabled {
+		return func(ctx *gin.Context) {
+			ctx.Next()
+		}
+	}
+
+	return func(ctx *gin.Context) {
+		// Unmatched requests are named by their method alone, so scanners can't flood the trace store with names.
+		name := ctx.Request.Method
+		if route := ctx.FullPath(); route != "" {
+			name += " " + route
+		}
+
+		reqCtx := tracing.Extract(ctx.Request.Context(), ctx.Request.Header)
+		reqCtx, span := tracing.Tracer().Start(reqCtx, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(ctx.Request.Method),
+				semconv.HTTPRoute(ctx.FullPath()),
+				semconv.URLPath(ctx.Request.URL.Path),
+				semconv.ClientAddress(ctx.ClientIP()),
+			),
+		)
+		defer span.End()
+
+		sc := span.SpanContext()
+		logger := logging.FromContext(ctx).With("trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String())
+		ctx.Request = ctx.Request.WithContext(logging.WithLogger(reqCtx, logger))
+
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		span.SetAttributes(
+			semconv.HTTPResponseStatusCode(status),
+			attribute.String("request_id", logging.RequestIDFromContext(ctx.Request.Context())),
+		)
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordSpans installs a global tracer provider that records every span in memory until the test ends.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	previous, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	tracing.SetGlobal(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+	return recorder
+}
+
+// newTracedRouter serves GET /users/:id behind the Tracing and AccessLog middlewares.
+func newTracedRouter(enabled bool) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{}
+	cfg.Tracing.Enabled = enabled
+	router := gin.New()
+	router.Use(Tracing(cfg), AccessLog())
+	router.GET("/users/:id", func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+	return router
+}
+
+// attributes returns the span's attributes by key.
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestTracingRecordsRequestSpan(t *testing.T) {
+	recorder := recordSpans(t)
+	router := newTracedRouter(true)
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.Header.Set(RequestIDHeader, "req-123")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "GET /users/:id" {
+		t.Errorf("span name = %q, want %q", span.Name(), "GET /users/:id")
+	}
+	if span.SpanKind() != trace.SpanKindServer {
+		t.Errorf("span kind = %v, want %v", span.SpanKind(), trace.SpanKindServer)
+	}
+	if span.Parent().IsValid() {
+		t.Errorf("span has parent %v, want a new trace", span.Parent())
+	}
+
+	attrs := attributes(span)
+	if got := attrs["request_id"].AsString(); got != "req-123" {
+		t.Errorf("request_id attribute = %q, want %q", got, "req-123")
+	}
+	if got := attrs["http.response.status_code"].AsInt64(); got != http.StatusOK {
+		t.Errorf("http.response.status_code attribute = %d, want %d", got, http.StatusOK)
+	}
+}
+
+func TestTracingContinuesCallersTrace(t *testing.T) {
+	recorder := recordSpans(t)
+	router := newTracedRouter(true)
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(spans))
+	}
+	if got := spans[0].SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("trace ID = %s, want the caller's", got)
+	}
+	if got := spans[0].Parent().SpanID().String(); got != "00f067aa0ba902b7" {
+		t.Errorf("parent span ID = %s, want the caller's", got)
+	}
+}
+
+func TestTracingDisabledRecordsNothing(t *testing.T) {
+	recorder := recordSpans(t)
+	router := newTracedRouter(false)
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/42", nil))
+
+	if spans := recorder.Ended(); len(spans) != 0 {
+		t.Errorf("recorded %d spans with tracing disabled, want none", len(spans))
+	}
+}
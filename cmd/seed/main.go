@@ -0,0 +1,12 @@
+package main
+
+import "flag"
+
+// main function is the entry point of the seed command.
+// It parses the command-line flags and calls the Run function to seed development data.
+func main() {
+	force := flag.Bool("force", false, "seed even when server.production is true")
+	flag.Parse()
+
+	Run(*force)
+}
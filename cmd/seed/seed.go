@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/internal/features/auth"
+	"github.com/npushpakumara/go-backend-template/internal/features/user"
+	"github.com/npushpakumara/go-backend-template/internal/features/user/entity"
+	"github.com/npushpakumara/go-backend-template/internal/postgres"
+)
+
+// seedUser describes a user inserted by the seed command.
+type seedUser struct {
+	FirstName string
+	LastName  string
+	Email     string
+	Password  string
+	Role      string
+}
+
+// sampleUsers are regular accounts created alongside the admin for local development.
+// They all share the seed.sample_password password.
+var sampleUsers = []seedUser{
+	{FirstName: "Alice", LastName: "Example", Email: "alice@example.com"},
+	{FirstName: "Bob", LastName: "Example", Email: "bob@example.com"},
+	{FirstName: "Carol", LastName: "Example", Email: "carol@example.com"},
+}
+
+// Run loads the configuration, connects to the database and inserts the development users.
+// It refuses to run against a production configuration unless force is set.
+func Run(force bool) {
+	conf, err := config.LoadConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if conf.Server.Production && !force {
+		log.Fatal("refusing to seed a production environment, pass --force to override")
+	}
+
+	db, err := postgres.NewDatabase(conf, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	created, err := seedUsers(context.Background(), user.NewUserRepository(db), auth.NewPasswordHasher(conf), usersToSeed(&conf.Seed))
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("seed complete: %d user(s) created", created)
+}
+
+// usersToSeed returns the configured admin user followed by the sample users.
+func usersToSeed(cfg *config.SeedConfig) []seedUser {
+	users := []seedUser{{
+		FirstName: cfg.AdminFirstName,
+		LastName:  cfg.AdminLastName,
+		Email:     cfg.AdminEmail,
+		Password:  cfg.AdminPassword,
+		Role:      entity.RoleAdmin,
+	}}
+	for _, u := range sampleUsers {
+		u.Password = cfg.SamplePassword
+		u.Role = entity.RoleUser
+		users = append(users, u)
+	}
+	return users
+}
+
+// seedUsers inserts every user whose email isn't already registered and returns how many were created.
+// Existing users are left untouched, so running the command repeatedly is safe.
+// Passwords are hashed by the same hasher as sign-up, so seeded users sign in like any other.
+func seedUsers(ctx context.Context, repo user.Repository, hasher auth.PasswordHasher, users []seedUser) (int, error) {
+	created := 0
+	for _, u := range users {
+		email := strings.ToLower(u.Email)
+
+		_, err := repo.FindByEmail(ctx, email)
+		if err == nil {
+			log.Printf("skipping %s: user already exists", email)
+			continue
+		}
+		if !errors.Is(err, postgres.ErrRecordNotFound) {
+			return created, err
+		}
+
+		hashedPassword, err := hasher.Hash(u.Password)
+		if err != nil {
+			return created, err
+		}
+
+		_, err = repo.Insert(ctx, &entity.User{
+			FirstName: u.FirstName,
+			LastName:  u.LastName,
+			Email:     email,
+			Password:  hashedPassword,
+			Status:    entity.StatusActive,
+			Role:      u.Role,
+			Locale:    entity.DefaultLocale,
+		})
+		if err != nil {
+			return created, err
+		}
+		log.Printf("created %s user %s", u.Role, email)
+		created++
+	}
+	return created, nil
+}
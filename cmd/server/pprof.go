@@ -0,0 +1,31 @@
+package main
+
+import (
+	"net/http/pprof"
+
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+	userEntity "github.com/npushpakumara/go-backend-template/internal/features/user/entity"
+	"github.com/npushpakumara/go-backend-template/internal/rbac"
+)
+
+// pprofProfiles are the runtime profiles served by name under /debug/pprof.
+var pprofProfiles = []string{"allocs", "block", "goroutine", "heap", "mutex", "threadcreate"}
+
+// registerPprof mounts the net/http/pprof handlers under /debug/pprof for administrators outside any organization.
+// CPU profiles and traces run for ?seconds=N, which must stay below server.write_timeout.
+func registerPprof(router *gin.Engine, authMiddleware *jwt.GinJWTMiddleware) {
+	debug := router.Group("/debug/pprof")
+	debug.Use(authMiddleware.MiddlewareFunc(), rbac.RequireRole(userEntity.RoleAdmin), rbac.DenyOrgScoped())
+	{
+		debug.GET("/", gin.WrapF(pprof.Index))
+		debug.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+		debug.GET("/profile", gin.WrapF(pprof.Profile))
+		debug.GET("/symbol", gin.WrapF(pprof.Symbol))
+		debug.POST("/symbol", gin.WrapF(pprof.Symbol))
+		debug.GET("/trace", gin.WrapF(pprof.Trace))
+		for _, name := range pprofProfiles {
+			debug.GET("/"+name, gin.WrapH(pprof.Handler(name)))
+		}
+	}
+}
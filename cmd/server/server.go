@@ -6,17 +6,32 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"slices"
 	"time"
 
 	middlewares "github.com/npushpakumara/go-backend-template/api/middlwares"
 	awsclient "github.com/npushpakumara/go-backend-template/internal/aws_client"
+	"github.com/npushpakumara/go-backend-template/internal/features/account"
+	"github.com/npushpakumara/go-backend-template/internal/features/apikey"
+	"github.com/npushpakumara/go-backend-template/internal/features/audit"
 	"github.com/npushpakumara/go-backend-template/internal/features/auth"
 	"github.com/npushpakumara/go-backend-template/internal/features/email"
+	"github.com/npushpakumara/go-backend-template/internal/features/loginhistory"
 
+	jwt "github.com/appleboy/gin-jwt/v2"
 	"github.com/gin-gonic/gin"
 	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/internal/cors"
+	"github.com/npushpakumara/go-backend-template/internal/csrf"
 	"github.com/npushpakumara/go-backend-template/internal/features/user"
+	"github.com/npushpakumara/go-backend-template/internal/features/webhook"
+	"github.com/npushpakumara/go-backend-template/internal/health"
+	"github.com/npushpakumara/go-backend-template/internal/idempotency"
+	"github.com/npushpakumara/go-backend-template/internal/metrics"
 	"github.com/npushpakumara/go-backend-template/internal/postgres"
+	"github.com/npushpakumara/go-backend-template/internal/ratelimit"
+	"github.com/npushpakumara/go-backend-template/pkg"
+	"github.com/npushpakumara/go-backend-template/pkg/clock"
 	"github.com/npushpakumara/go-backend-template/pkg/logging"
 	"go.uber.org/fx"
 	"go.uber.org/fx/fxevent"
@@ -34,11 +49,30 @@ func Run() {
 		log.Fatal(err)
 	}
 
+	// Register custom request validation rules before any request is bound.
+	if err := pkg.RegisterValidations(); err != nil {
+		log.Fatal(err)
+	}
+
 	// Set up logging with the configuration loaded.
 	logging.SetConfig(&logging.Config{
-		Encoding:    conf.Logging.Encoding,
-		Level:       zapcore.Level(conf.Logging.Level),
-		Development: !conf.Server.Production,
+		Encoding:     conf.Logging.Encoding,
+		Level:        zapcore.Level(conf.Logging.Level),
+		Development:  !conf.Server.Production,
+		Production:   conf.Server.Production,
+		LogToFile:    *conf.Logging.ToFile,
+		LogDirectory: conf.Logging.Directory,
+		MaxSize:      conf.Logging.MaxSize,
+		MaxBackups:   conf.Logging.MaxBackups,
+		MaxAge:       conf.Logging.MaxAge,
+
+		DisableCaller:      conf.Logging.DisableCaller,
+		StacktraceLevel:    zapcore.Level(*conf.Logging.StacktraceLevel),
+		DisableStacktrace:  conf.Logging.DisableStacktrace,
+		DevelopmentEncoder: conf.Logging.DevelopmentEncoder,
+
+		SamplingInitial:    conf.Logging.Sampling.Initial,
+		SamplingThereafter: conf.Logging.Sampling.Thereafter,
 	})
 
 	// Ensure that the logger is synced and flushes any pending logs before the application exits.
@@ -63,10 +97,28 @@ func Run() {
 		fx.StopTimeout(conf.Server.GracefulShutdown+time.Second),
 		// Provide dependencies needed by the application.
 		fx.Provide(
+			clock.New,
+			metrics.NewRegistry,
+			health.NewReadiness,
 			awsclient.NewAWSClient,
+			postgres.NewSlowQueryRecorder,
 			postgres.NewDatabase,
 			postgres.NewTransactionManager,
+			email.NewSuppressionRepository,
+			email.NewFailedEmailRepository,
 			email.NewEmailService,
+			email.NewFeedbackService,
+			email.NewDeadLetterService,
+			email.NewEmailHandler,
+
+			// Audit dependencies
+			audit.NewAuditRepository,
+			audit.NewAuditService,
+
+			// Webhook dependencies
+			webhook.NewWebhookRepository,
+			webhook.NewWebhookService,
+			webhook.NewWebhookHandler,
 
 			// User dependencies
 			user.NewUserRepository,
@@ -74,17 +126,54 @@ func Run() {
 			user.NewUserHandler,
 
 			// Auth dependencies
+			auth.NewBreachChecker,
+			auth.NewResetThrottle,
+			auth.NewPasswordHasher,
+			auth.NewMetrics,
 			auth.NewAuthService,
 			auth.NewAuthHandler,
 
+			// Login history dependencies
+			loginhistory.NewLoginHistoryRepository,
+			loginhistory.NewLoginHistoryService,
+			loginhistory.NewLoginHistoryHandler,
+
+			// API key dependencies
+			apikey.NewAPIKeyRepository,
+			apikey.NewAPIKeyService,
+			apikey.NewAPIKeyHandler,
+			func(userService user.Service) apikey.Owners { return userService },
+
+			// Account dependencies
+			account.NewAccountService,
+			account.NewAccountHandler,
+
+			// Health dependencies
+			email.NewProviderCheck,
+			health.NewHealthHandler,
+
 			middlewares.NewAuthMiddleware,
+			idempotency.NewIdempotencyMiddleware,
+			apikey.NewAPIKeyMiddleware,
 			newServer,
 		),
 		// Invoke functions to set up routes and start the application.
 		fx.Invoke(
+			setupTracing,
 			auth.NewOAuthProviders,
+			email.VerifyProviderOnStart,
+			health.MarkReadyWhenMigrated,
+			postgres.StartSlowQueryReport,
+			health.Router,
+			metrics.Router,
 			user.Router,
 			auth.Router,
+			apikey.Router,
+			email.Router,
+			webhook.Router,
+			loginhistory.Router,
+			account.Router,
+			registerSlowQueries,
 			func(r *gin.Engine) {},
 		),
 	)
@@ -95,15 +184,33 @@ func Run() {
 
 // newServer creates and configures a new HTTP server using Gin.
 // It also sets up lifecycle hooks for starting and stopping the server.
-func newServer(lc fx.Lifecycle, cfg *config.Config) *gin.Engine {
+func newServer(lc fx.Lifecycle, cfg *config.Config, clk clock.Clock, authMiddleware *jwt.GinJWTMiddleware) (*gin.Engine, error) {
 	g := gin.New()
-	g.Use(gin.Recovery())
+	// Only the configured proxies may report the client IP; with none, the peer address is used.
+	if err := g.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid server.trusted_proxies: %w", err)
+	}
+	g.Use(middlewares.Recovery(cfg))
+	g.Use(middlewares.SecurityHeaders(cfg))
+	g.Use(middlewares.Tracing(cfg))
+	g.Use(middlewares.AccessLog())
+	g.Use(cors.Middleware(cfg))
+	g.Use(ratelimit.Middleware(cfg, clk))
+	g.Use(csrf.Middleware(cfg, slices.Concat(auth.CSRFExemptRoutes, email.CSRFExemptRoutes)...))
+
+	// Profiling endpoints are only mounted when explicitly enabled, and then only for administrators.
+	if cfg.Server.EnablePprof {
+		registerPprof(g, authMiddleware)
+	}
 
 	srv := &http.Server{
-		Addr:         fmt.Sprintf(":%d", cfg.Server.Port),
-		Handler:      g,
-		ReadTimeout:  cfg.Server.ReadTimeout,
-		WriteTimeout: cfg.Server.WriteTimeout,
+		Addr:              fmt.Sprintf(":%d", cfg.Server.Port),
+		Handler:           g,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		MaxHeaderBytes:    cfg.Server.MaxHeaderBytes,
 	}
 
 	// Append hooks to the lifecycle for starting and stopping the server.
@@ -122,5 +229,5 @@ func newServer(lc fx.Lifecycle, cfg *config.Config) *gin.Engine {
 			return srv.Shutdown(ctx)
 		},
 	})
-	return g
+	return g, nil
 }
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/pkg/clock"
+	"go.uber.org/fx/fxtest"
+)
+
+// X-Forwarded-For is only believed when the request comes from a configured proxy.
+func TestClientIPHonoursOnlyTrustedProxies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		trusted    []string
+		remoteAddr string
+		want       string
+	}{
+		{name: "no trusted proxies", remoteAddr: "10.0.0.5:4000", want: "10.0.0.5"},
+		{name: "untrusted peer", trusted: []string{"10.0.0.0/24"}, remoteAddr: "192.0.2.7:4000", want: "192.0.2.7"},
+		{name: "trusted proxy", trusted: []string{"10.0.0.0/24"}, remoteAddr: "10.0.0.5:4000", want: "203.0.113.9"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Server.TrustedProxies = tt.trusted
+			router, err := newServer(fxtest.NewLifecycle(t), cfg, clock.New(), nil)
+			if err != nil {
+				t.Fatalf("newServer() error = %v", err)
+			}
+			router.GET("/ip", func(c *gin.Context) { c.String(http.StatusOK, c.ClientIP()) })
+
+			req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("X-Forwarded-For", "203.0.113.9")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if got := rec.Body.String(); got != tt.want {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewServerRejectsInvalidTrustedProxies(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Server.TrustedProxies = []string{"not-an-ip"}
+
+	if _, err := newServer(fxtest.NewLifecycle(t), cfg, clock.New(), nil); err == nil {
+		t.Fatal("newServer() error = nil, want an error for an invalid proxy")
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+	userEntity "github.com/npushpakumara/go-backend-template/internal/features/user/entity"
+	"github.com/npushpakumara/go-backend-template/internal/postgres"
+	"github.com/npushpakumara/go-backend-template/internal/rbac"
+	apiError "github.com/npushpakumara/go-backend-template/pkg/errors"
+)
+
+// defaultSlowQueryWindow is the window reported when the request doesn't choose one.
+const defaultSlowQueryWindow = time.Hour
+
+// registerSlowQueries serves the slow query report at /api/v1/admin/db/slow-queries for administrators
+// outside any organization, since the queries may carry other organizations' data.
+// ?window= selects how far back it looks, e.g. "15m"; it can't reach past the queries still in the buffer.
+func registerSlowQueries(router *gin.Engine, recorder *postgres.SlowQueryRecorder, authMiddleware *jwt.GinJWTMiddleware) {
+	admin := router.Group("/api/v1/admin/db")
+	admin.Use(authMiddleware.MiddlewareFunc(), rbac.RequireRole(userEntity.RoleAdmin), rbac.DenyOrgScoped())
+	{
+		admin.GET("/slow-queries", func(ctx *gin.Context) {
+			window := defaultSlowQueryWindow
+			if value := ctx.Query("window"); value != "" {
+				parsed, err := time.ParseDuration(value)
+				if err != nil || parsed <= 0 {
+					ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "error", Message: "window must be a positive duration such as 15m"})
+					return
+				}
+				window = parsed
+			}
+			ctx.JSON(http.StatusOK, recorder.Report(window))
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/pkg/tracing"
+	"go.uber.org/fx"
+)
+
+// setupTracing installs the tracer provider that exports spans to the configured OTLP collector when
+// tracing.enabled is set. Otherwise the global provider stays OpenTelemetry's no-op one. The spans still
+// buffered at shutdown are flushed once the server has stopped.
+func setupTracing(lc fx.Lifecycle, cfg *config.Config) error {
+	if !cfg.Tracing.Enabled {
+		return nil
+	}
+
+	provider, err := tracing.NewProvider(context.Background(), tracing.Options{
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		ServiceName: cfg.Tracing.ServiceName,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		return err
+	}
+	tracing.SetGlobal(provider)
+
+	lc.Append(fx.Hook{
+		OnStop: provider.Shutdown,
+	})
+	return nil
+}
@@ -2,43 +2,69 @@ package awsclient
 
 import (
 	"context"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"log"
-	"sync"
+	"errors"
+	"fmt"
 
+	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/ses"
 )
 
-// Define a global variable to hold the AWSClient instance
-// and a sync.Once variable to ensure the client is created only once.
-var (
-	client *AWSClient
-	once   sync.Once
-)
+// ErrSESSendingDisabled is returned when SES reports that sending is paused for the account in the region.
+var ErrSESSendingDisabled = errors.New("SES sending is disabled for this account")
+
+// SESAPI is the subset of the SES client used by the application.
+// Depending on it rather than *ses.Client lets tests substitute a fake.
+type SESAPI interface {
+	SendEmail(ctx context.Context, params *ses.SendEmailInput, optFns ...func(*ses.Options)) (*ses.SendEmailOutput, error)
+	SendRawEmail(ctx context.Context, params *ses.SendRawEmailInput, optFns ...func(*ses.Options)) (*ses.SendRawEmailOutput, error)
+	SendBulkTemplatedEmail(ctx context.Context, params *ses.SendBulkTemplatedEmailInput, optFns ...func(*ses.Options)) (*ses.SendBulkTemplatedEmailOutput, error)
+	GetAccountSendingEnabled(ctx context.Context, params *ses.GetAccountSendingEnabledInput, optFns ...func(*ses.Options)) (*ses.GetAccountSendingEnabledOutput, error)
+}
+
+var _ SESAPI = (*ses.Client)(nil)
 
 // AWSClient wraps the AWS Service's clients
 type AWSClient struct {
-	ses *ses.Client
+	region string
+	ses    SESAPI
 }
 
 // NewAWSClient initializes a new AWSClient instance with the specified AWS region.
-// It uses sync.Once to ensure that the client is created only once, even if called concurrently.
-func NewAWSClient(region string) *AWSClient {
-	once.Do(func() {
-		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(region))
-		if err != nil {
-			log.Fatalf("unable to load AWS SDK config, %v", err)
-		}
-		client = &AWSClient{
-			ses: ses.NewFromConfig(cfg),
-		}
-	})
-
-	return client
+// Every call returns a fresh client; fx provides a single one to the application.
+// Loading the shared AWS configuration doesn't contact AWS, so it only fails on malformed local settings;
+// use VerifySES to check that the credentials actually work.
+func NewAWSClient(region string) (*AWSClient, error) {
+	cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("unable to load AWS SDK config for region %q: %w", region, err)
+	}
+
+	return &AWSClient{
+		region: region,
+		ses:    ses.NewFromConfig(cfg),
+	}, nil
+}
+
+// NewAWSClientWithSES wraps an existing SES client, such as a fake in tests, for the given region.
+func NewAWSClientWithSES(region string, sesClient SESAPI) *AWSClient {
+	return &AWSClient{region: region, ses: sesClient}
 }
 
 // GetSESClient returns the SES client from the AWSClient instance.
 // This allows access to SES functionality for sending emails, etc.
-func (c *AWSClient) GetSESClient() *ses.Client {
+func (c *AWSClient) GetSESClient() SESAPI {
 	return c.ses
 }
+
+// VerifySES makes a cheap SES call to confirm that the credentials are valid, that SES is
+// available in the configured region and that sending is enabled for the account.
+func (c *AWSClient) VerifySES(ctx context.Context) error {
+	output, err := c.ses.GetAccountSendingEnabled(ctx, &ses.GetAccountSendingEnabledInput{})
+	if err != nil {
+		return fmt.Errorf("SES is not reachable in region %q, check the AWS credentials and AWS_REGION: %w", c.region, err)
+	}
+	if !output.Enabled {
+		return fmt.Errorf("%w in region %q", ErrSESSendingDisabled, c.region)
+	}
+	return nil
+}
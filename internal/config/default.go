@@ -25,41 +25,95 @@ var defaultConfigs = map[string]interface{}{
 	// Default value is "30s" (30 seconds).
 	"server.graceful_shutdown": "30s",
 
+	// server.read_header_timeout sets how long the server waits for the request headers.
+	// Keeping it short stops slowloris clients from holding connections open.
+	// Default value is "2s" (2 seconds).
+	"server.read_header_timeout": "2s",
+
+	// server.idle_timeout is how long an idle keep-alive connection is kept open.
+	// Default value is "60s" (60 seconds).
+	"server.idle_timeout": "60s",
+
+	// server.max_header_bytes caps the size of the request line and headers.
+	// Default value is 1048576 (1 MB).
+	"server.max_header_bytes": 1 << 20,
+
+	// server.idempotency_ttl is how long a response to a request with an Idempotency-Key header is kept
+	// and replayed for repeats of that request.
+	// Default value is "1h" (1 hour).
+	"server.idempotency_ttl": "1h",
+
 	// server.domain specifies the domain on which the server is accessible.
 	// Default value is "http://localhost:4000".
 	"server.domain": "http://localhost:4000",
 
+	// server.frontend_url is the page browsers are redirected to after clicking an account verification link,
+	// with ?verified=true or ?error=<reason> appended. When empty, verification responds with JSON.
+	// Default value is "" (respond with JSON).
+	"server.frontend_url": "",
+
+	// server.login_url is the sign-in page browsers are redirected to, with ?error=<reason> appended, when an
+	// OAuth callback can't be completed. Default value is "" (respond with JSON).
+	"server.login_url": "",
+
+	// server.invite_url is the frontend page linked from invitation emails, with ?token=<invite token> appended.
+	// The page collects the user's name and password and posts them with the token to /api/v1/auth/accept-invite.
+	// Default value is "http://localhost:3000/accept-invite".
+	"server.invite_url": "http://localhost:3000/accept-invite",
+
+	// server.password_reset_url is the frontend page linked from password reset emails, with ?token=<reset token>
+	// appended. The page collects the new password and sends it with the token to /api/v1/auth/reset-password.
+	// Default value is "http://localhost:3000/reset-password".
+	"server.password_reset_url": "http://localhost:3000/reset-password",
+
+	// server.trusted_proxies lists the reverse proxies, as IPs or CIDRs, allowed to report the client IP
+	// through X-Forwarded-For or X-Real-IP, e.g. "10.0.0.0/8,192.168.1.1". Rate limiting and the IPs
+	// recorded for sign-ins rely on it. Default value is empty (trust no proxy; use the peer address).
+	"server.trusted_proxies": []string{},
+
+	// server.enable_pprof mounts the Go profiler under /debug/pprof, restricted to signed-in admins.
+	// Keep it off unless investigating a performance problem. Default value is false.
+	"server.enable_pprof": false,
+
 	// Google OAuth configuration
+	// A provider is only enabled once both its client ID and client secret are set.
 	// The Client ID for the Google OAuth application.
 	//This is used to identify your app when making OAuth requests.
-	"oauth.google.client_id": "client-id",
+	"oauth.google.client_id": "",
 
 	// The Client Secret for the Google OAuth application.
 	//This is used to authenticate your app with Google.
-	"oauth.google.client_secret": "secret",
+	"oauth.google.client_secret": "",
 
 	// The URL where users will be redirected after successfully authenticating with Google.
 	"oauth.google.redirect_url": "http://localhost:4000/api/v1/oauth/google/callback",
 
-	// The scopes specify the permissions your app is requesting.
-	//'email' gives access to the user's email, and 'profile' gives access to basic profile information.
-	"oauth.google.scopes": "email,profile",
+	// The scopes specify the permissions your app is requesting, as a comma-separated list.
+	// Empty requests the provider's default scopes: 'openid', 'email' and 'profile' for Google.
+	"oauth.google.scopes": "",
 
 	// Microsoft OAuth configuration
+	// A provider is only enabled once both its client ID and client secret are set.
 	// The Client ID for the Microsoft OAuth application.
 	//This is used to identify your app when making OAuth requests.
-	"oauth.microsoft.client_id": "client-id",
+	"oauth.microsoft.client_id": "",
 
 	// The Client Secret for the Microsoft OAuth application.
 	//This is used to authenticate your app with Microsoft.
-	"oauth.microsoft.client_secret": "secret",
+	"oauth.microsoft.client_secret": "",
 
 	// The URL where users will be redirected after successfully authenticating with Microsoft.
 	"oauth.microsoft.redirect_url": "http://localhost:4000/api/v1/oauth/microsoft/callback",
 
-	// The scopes specify the permissions your app is requesting.
-	//'User.Read' gives access to the user's profile data, and 'openid' is used for authentication.
-	"oauth.microsoft.scopes": "User.Read,openid",
+	// The scopes specify the permissions your app is requesting, as a comma-separated list.
+	// Empty requests the provider's default scopes: 'openid' and 'User.Read', which gives access to the user's profile, for Microsoft.
+	"oauth.microsoft.scopes": "",
+
+	// db.url is a full postgres:// or postgresql:// connection string that, when set, overrides the
+	// individual db.* connection fields. Pool limits may be tuned with the pool_max_conns,
+	// pool_max_idle_conns and pool_max_conn_lifetime query parameters. Falls back to DATABASE_URL.
+	// Default value is "" (use the individual fields).
+	"db.url": "",
 
 	// db.host indicates the hostname or IP address of the database server.
 	// Default value is "localhost".
@@ -81,6 +135,11 @@ var defaultConfigs = map[string]interface{}{
 	// Default value is "test".
 	"db.name": "test",
 
+	// db.schema is the Postgres schema that holds every table. It is created on startup if missing
+	// and set as the connection's search_path. Must be a lowercase identifier (letters, digits, underscores).
+	// Default value is "public".
+	"db.schema": "public",
+
 	// db.migration_enabled is a boolean flag that determines whether database migrations should be applied automatically on application startup.
 	// Default value is false.
 	"db.migrations": false,
@@ -101,10 +160,56 @@ var defaultConfigs = map[string]interface{}{
 	// Default value is "5m" (5 minutes).
 	"db.pool.max_lifetime": "5m",
 
+	// db.retry.attempts is the maximum number of attempts made to connect to the database on startup.
+	// Default value is 10.
+	"db.retry.attempts": 10,
+
+	// db.retry.strategy selects the delay between attempts: "fixed" waits base_delay every time,
+	// "exponential" doubles it after each failed attempt.
+	// Default value is "fixed".
+	"db.retry.strategy": "fixed",
+
+	// db.retry.base_delay is the delay after the first failed attempt.
+	// Default value is "500ms".
+	"db.retry.base_delay": "500ms",
+
+	// db.retry.max_delay caps the delay between attempts. "0s" means no cap.
+	// Default value is "10s".
+	"db.retry.max_delay": "10s",
+
+	// db.retry.timeout bounds the total time spent connecting on startup. "0s" means no deadline.
+	// Default value is "0s".
+	"db.retry.timeout": "0s",
+
+	// db.slow_query.threshold is the duration above which a query is logged as slow and kept for the
+	// slow query report. "0s" disables both. Default value is "1s".
+	"db.slow_query.threshold": "1s",
+
+	// db.slow_query.buffer_size is the number of most recent slow queries kept for the report.
+	// Default value is 1000.
+	"db.slow_query.buffer_size": 1000,
+
+	// db.slow_query.report_interval is how often the slow query patterns that took the most time are
+	// logged, as one structured entry. "0s" disables the periodic report; admins can still fetch it from
+	// /api/v1/admin/db/slow-queries. Default value is "15m".
+	"db.slow_query.report_interval": "15m",
+
+	// db.slow_query.report_top is the number of query patterns listed in a report. Default value is 10.
+	"db.slow_query.report_top": 10,
+
+	// db.replicas lists postgres:// connection strings of read replicas. Reads outside transactions go to
+	// them in turn, using the db.pool and db.schema settings of the primary. Default value is empty.
+	"db.replicas": []string{},
+
 	// jwt.secret is the secret key used to sign and verify JSON Web Tokens (JWT).
 	// Default value is "secret".
 	"jwt.secret": "secret",
 
+	// jwt.keys maps key IDs to signing secrets, e.g. "jwt.keys.k2": "...", and jwt.current_kid names the
+	// one new tokens are signed with. Tokens signed by any listed key, or by jwt.secret, stay valid, so keys
+	// can be rotated without signing everyone out. Default value is no keys, signing with jwt.secret.
+	"jwt.current_kid": "",
+
 	// jwt.access_token_exp sets the duration for which an access token remains valid.
 	// Default value is "900s" (15 minutes).
 	"jwt.access_token_exp": "900s",
@@ -113,14 +218,237 @@ var defaultConfigs = map[string]interface{}{
 	// Default value is "604800s" (7 days).
 	"jwt.refresh_token_exp": "604800s",
 
+	// jwt.verification_token_exp sets how long the link in an account verification email stays valid.
+	// Must be positive and at most 30 days. Default value is "48h".
+	"jwt.verification_token_exp": "48h",
+
+	// jwt.password_reset_token_exp sets how long a password reset link stays valid.
+	// Must be positive and at most 24 hours. Default value is "30m".
+	"jwt.password_reset_token_exp": "30m",
+
+	// jwt.invite_token_exp sets how long the link in an invitation email sent by an admin stays valid.
+	// Must be positive and at most 30 days. Default value is "168h" (7 days).
+	"jwt.invite_token_exp": "168h",
+
+	// jwt.impersonation_token_exp sets how long an administrator can impersonate a user before having to start again.
+	// Must be positive and at most 1 hour. Default value is "10m".
+	"jwt.impersonation_token_exp": "10m",
+
+	// security.breached_password_check rejects new passwords found in the HaveIBeenPwned breach corpus.
+	// Only a 5-character hash prefix is sent. Default value is false.
+	"security.breached_password_check": false,
+
+	// security.breached_password_timeout bounds the breach lookup; when it times out the password is accepted.
+	// Default value is "2s" (2 seconds).
+	"security.breached_password_timeout": "2s",
+
+	// security.csrf_protection enables double-submit CSRF protection for cookie-authenticated requests:
+	// POST, PUT, PATCH and DELETE must send an X-CSRF-Token header matching the csrf_token cookie.
+	// Default value is true.
+	"security.csrf_protection": true,
+
+	// security.reauth_max_age is how recently the user must have signed in to delete their account
+	// without confirming their password. Default value is "5m" (5 minutes).
+	"security.reauth_max_age": "5m",
+
+	// security.password_history is how many previous passwords, besides the current one, are remembered
+	// and refused when the password is changed. Default value is 0, which only refuses the current password.
+	"security.password_history": 0,
+
+	// security.username_login lets users pick a username at sign-up and sign in with either their
+	// username or their email. Default value is false, which keeps sign-in by email only.
+	"security.username_login": false,
+
+	// security.password_algorithm hashes new passwords with "bcrypt" or "argon2id". Existing hashes of the other
+	// algorithm still verify and are rehashed when their user signs in. Default value is "bcrypt".
+	"security.password_algorithm": "bcrypt",
+
+	// security.headers.* are the security headers added to every response; an empty value, false or "0s"
+	// leaves a header out, e.g. for local development. Strict-Transport-Security is only sent in production
+	// or over TLS, with the max age of security.headers.hsts_max_age. Default values are nosniff, DENY,
+	// "strict-origin-when-cross-origin", a policy that lets responses load nothing, and HSTS for 1 year
+	// including subdomains.
+	"security.headers.content_type_options":    true,
+	"security.headers.frame_options":           "DENY",
+	"security.headers.referrer_policy":         "strict-origin-when-cross-origin",
+	"security.headers.content_security_policy": "default-src 'none'; frame-ancestors 'none'",
+	"security.headers.hsts_max_age":            "8760h",
+	"security.headers.hsts_include_subdomains": true,
+
+	// ratelimit.enabled limits how many requests each client IP may make per window.
+	// Responses carry X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset headers.
+	// Default value is true.
+	"ratelimit.enabled": true,
+
+	// ratelimit.requests is the number of requests allowed per window; further requests get 429.
+	// Default value is 100.
+	"ratelimit.requests": 100,
+
+	// ratelimit.window is the length of the rate limit window.
+	// Default value is "1m" (1 minute).
+	"ratelimit.window": "1m",
+
+	// tracing.enabled records an OpenTelemetry span for every request, database query, queued email and
+	// webhook delivery, continuing the caller's traceparent header, adds the trace ID to the logs and
+	// forwards it to webhook receivers. When disabled, no spans are recorded. Default value is false.
+	"tracing.enabled": false,
+
+	// tracing.endpoint is the host and port of the OTLP/HTTP collector spans are exported to.
+	// Default value is "localhost:4318".
+	"tracing.endpoint": "localhost:4318",
+
+	// tracing.insecure exports spans over plain HTTP rather than HTTPS. Default value is false.
+	"tracing.insecure": false,
+
+	// tracing.service_name names the application in the exported spans.
+	// Default value is "go-backend-template".
+	"tracing.service_name": "go-backend-template",
+
+	// tracing.sample_ratio is the fraction of new traces that are recorded, from 0 to 1. Traces continued
+	// from a caller follow the caller's sampling decision. Default value is 1.
+	"tracing.sample_ratio": 1.0,
+
+	// cors.enabled answers cross-origin requests from browsers on the origins in cors.allowed_origins.
+	// Requests from other origins get no CORS headers. Default value is false.
+	"cors.enabled": false,
+
+	// cors.allowed_origins lists exact origins ("https://app.example.com"), subdomain wildcards
+	// ("https://*.example.com", which doesn't match example.com itself) or "*" for any origin.
+	// Default value is "http://localhost:3000", the frontend used in development.
+	"cors.allowed_origins": []string{"http://localhost:3000"},
+
+	// cors.allowed_methods and cors.allowed_headers are what preflight requests may ask for;
+	// cors.exposed_headers are the response headers scripts may read.
+	"cors.allowed_methods": []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+	"cors.allowed_headers": []string{"Authorization", "Content-Type", "X-API-Key", "X-CSRF-Token", "Idempotency-Key"},
+	"cors.exposed_headers": []string{"X-CSRF-Token", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset"},
+
+	// cors.allow_credentials lets browsers send the session cookies cross-origin. The requesting origin is
+	// then echoed back, and "*" is refused in cors.allowed_origins. Default value is true.
+	"cors.allow_credentials": true,
+
+	// cors.max_age is how long browsers may cache a preflight response, in whole seconds; "0s" sends no
+	// Access-Control-Max-Age. Browsers cap it, e.g. Chrome at 2 hours. Default value is "10m" (10 minutes).
+	"cors.max_age": "10m",
+
+	// metrics.enabled counts authentication outcomes and serves them at /metrics in the Prometheus
+	// text format. Default value is false.
+	"metrics.enabled": false,
+
+	// auth.allow_signup lets anyone create an account with POST /api/v1/auth/sign-up or by signing in
+	// through OAuth for the first time. When false, sign-up answers 403 and only invited users, who accept
+	// their invitation through /api/v1/auth/accept-invite, get an account. Default value is true.
+	"auth.allow_signup": true,
+
+	// auth.invite_only keeps sign-up open only to invited people: sign-up requests must carry the
+	// invite_token of an invitation to the same email. OAuth only signs in existing accounts.
+	// Default value is false.
+	"auth.invite_only": false,
+
+	// auth.password_reset_limit caps how many password reset emails one account is sent per
+	// auth.password_reset_window, however many IPs the requests come from. Requests over the cap are
+	// answered like any other but send nothing. Zero disables the cap. Default value is 3.
+	"auth.password_reset_limit": 3,
+
+	// auth.password_reset_window is the period auth.password_reset_limit applies to. Default value is "1h".
+	"auth.password_reset_window": "1h",
+
+	// seed.admin_email is the email of the admin user created by the seed command (cmd/seed).
+	// Default value is "admin@example.com".
+	"seed.admin_email": "admin@example.com",
+
+	// seed.admin_password is the password of the seeded admin user. Change it for any shared environment.
+	// Default value is "Admin@12345".
+	"seed.admin_password": "Admin@12345",
+
+	// seed.admin_first_name and seed.admin_last_name name the seeded admin user.
+	"seed.admin_first_name": "Admin",
+	"seed.admin_last_name":  "User",
+
+	// seed.sample_password is the password shared by the seeded sample users.
+	// Default value is "Sample@12345".
+	"seed.sample_password": "Sample@12345",
+
+	// webhook.timeout bounds each HTTP request made to a webhook subscriber.
+	// Default value is "10s" (10 seconds).
+	"webhook.timeout": "10s",
+
+	// webhook.max_attempts is the number of times a delivery is attempted before giving up.
+	// Default value is 5.
+	"webhook.max_attempts": 5,
+
+	// webhook.retry_base_delay is the delay after the first failed delivery; it doubles on every retry.
+	// Default value is "1s".
+	"webhook.retry_base_delay": "1s",
+
+	// webhook.retry_max_delay caps the delay between delivery attempts.
+	// Default value is "1m".
+	"webhook.retry_max_delay": "1m",
+
+	// cookie.secure is deliberately absent: when not set it follows server.production,
+	// so the access_token cookie is HTTPS-only in production and still works over plain HTTP locally.
+
+	// cookie.domain sets the Domain attribute of the access_token cookie, e.g. ".example.com"
+	// to share it across subdomains. Default value is "" (the host that set it).
+	"cookie.domain": "",
+
+	// cookie.path sets the Path attribute of the access_token cookie.
+	// Default value is "/".
+	"cookie.path": "/",
+
+	// cookie.same_site sets the SameSite attribute of the access_token cookie: "lax", "strict" or "none".
+	// "none" requires cookie.secure. Default value is "lax".
+	"cookie.same_site": "lax",
+
 	// logging.level determines the verbosity of the logging output.
 	// Default value is -1
 	"logging.level": -1,
 
-	// logging.encoding defines the format of the log output.
+	// logging.encoding defines the format of the log output, "console" or "json", whatever server.production is.
 	// Default value is "console".
 	"logging.encoding": "console",
 
+	// logging.to_file is deliberately absent: when not set it follows server.production,
+	// so logs also go to a file, in addition to stdout, in production only.
+
+	// logging.disable_caller leaves the calling file and line out of log entries.
+	// Default value is false.
+	"logging.disable_caller": false,
+
+	// logging.stacktrace_level is deliberately absent: when not set, stacktraces are attached from
+	// warn (1) up in development and from error (2) up in production.
+
+	// logging.disable_stacktrace leaves stacktraces out at every level.
+	// Default value is false.
+	"logging.disable_stacktrace": false,
+
+	// logging.development_encoder switches to zap's development encoder settings, which capitalise
+	// levels and colour them in console output. Default value is false.
+	"logging.development_encoder": false,
+
+	// logging.directory is where the log file is written when logging.to_file is on.
+	// Default value is "./logs".
+	"logging.directory": "./logs",
+
+	// logging.max_size is the size in megabytes at which the log file is rotated. 0 disables rotation.
+	// Default value is 100.
+	"logging.max_size": 100,
+
+	// logging.max_backups is the number of rotated log files kept. 0 keeps them all.
+	// Default value is 7.
+	"logging.max_backups": 7,
+
+	// logging.max_age is how long rotated log files are kept. "0s" keeps them forever.
+	// Default value is "720h" (30 days).
+	"logging.max_age": "720h",
+
+	// logging.sampling.initial and logging.sampling.thereafter rate-limit repeated log entries in production:
+	// each second, the first "initial" entries with the same level and message are logged, then every
+	// "thereafter"-th one. Sampling is never applied in development. Set initial to 0 to disable it.
+	// Default values are 100 and 100.
+	"logging.sampling.initial":    100,
+	"logging.sampling.thereafter": 100,
+
 	// aws.region specifies the AWS region for cloud resources.
 	// Default value is "eu-west-2".
 	"aws.region": "eu-west-2",
@@ -129,10 +457,79 @@ var defaultConfigs = map[string]interface{}{
 	// Valid values are "smtp" or "ses"
 	"mail.provider": "smtp",
 
+	// mail.primary and mail.secondary choose the providers used when mail.provider is "failover":
+	// every email is sent through the primary, and through the secondary if the primary fails.
+	"mail.primary":   "ses",
+	"mail.secondary": "smtp",
+
 	// mail.from_email address that will be used when sending emails.
 	// This should be a valid email address.
 	"mail.from_email": "example@gmail.com",
 
+	// mail.from_name is the display name shown next to mail.from_email, e.g. "Example Team".
+	// Default value is "" (the bare address).
+	"mail.from_name": "",
+
+	// mail.verification_url_template is the link in account verification emails, with {token} replaced by the
+	// token, e.g. "https://app.example.com/verify?token={token}" for a frontend that then calls
+	// /api/v1/auth/verify-email. Default value is "" (link straight to that route under server.domain).
+	"mail.verification_url_template": "",
+
+	// mail.dry_run renders emails and writes them to a temporary directory instead of sending them.
+	// Useful when developing templates. Default value is false.
+	"mail.dry_run": false,
+
+	// mail.send_rate caps bulk sends to this many messages per second to stay under the provider's sending rate.
+	// Default value is 14, the standard SES production rate. Set to 0 to disable throttling.
+	"mail.send_rate": 14,
+
+	// mail.sns_topic_arn is the SNS topic SES bounce and complaint notifications are published to.
+	// The feedback webhook rejects messages from any other topic, and every message while it is empty,
+	// so it must be set for bounces and complaints to be processed. Default value is empty.
+	"mail.sns_topic_arn": "",
+
+	// mail.send_timeout bounds a single send to the email provider. "0s" disables the timeout.
+	// Default value is "30s" (30 seconds).
+	"mail.send_timeout": "30s",
+
+	// mail.startup_check calls SES on startup to confirm the credentials and region work when SES is configured.
+	// Disable it in tests and other environments without AWS access. Default value is true.
+	"mail.startup_check": true,
+
+	// mail.health_check makes /readyz verify the email provider as well as the database: SES is asked whether
+	// sending is enabled and the SMTP server is sent NOOP. It adds an external call to every probe.
+	// Default value is false.
+	"mail.health_check": false,
+
+	// mail.queue.workers is the number of background workers emails are sent from. On shutdown the
+	// queue is drained within server.graceful_shutdown. Set to 0 to send emails on the request instead.
+	// mail.queue.size is the number of emails that can wait for a worker.
+	// Default values are 4 and 1000.
+	"mail.queue.workers": 4,
+	"mail.queue.size":    1000,
+
+	// mail.queue.max_attempts is how many times a queued email is tried before it is given up on, and
+	// mail.queue.retry_delay the wait before the first retry, doubled after each further failure.
+	// Default values are 3 and "5s" (5 seconds).
+	"mail.queue.max_attempts": 3,
+	"mail.queue.retry_delay":  "5s",
+
+	// mail.queue.dead_letter saves queued emails that fail their last attempt to the failed_emails table,
+	// from which administrators can queue them again. Default value is true.
+	"mail.queue.dead_letter": true,
+
+	// mail.unsubscribe.url is the public address of /api/v1/email/unsubscribe, e.g.
+	// "https://api.example.com/api/v1/email/unsubscribe". When set, marketing emails carry List-Unsubscribe
+	// and List-Unsubscribe-Post headers pointing at it with a ?token= naming the recipient's account and the
+	// email category, so mail clients can offer one-click unsubscribe. mail.unsubscribe.token_expiry is how
+	// long such a link keeps working. Default values are "" (no headers) and "8760h" (one year).
+	"mail.unsubscribe.url":          "",
+	"mail.unsubscribe.token_expiry": "8760h",
+
+	// mail.unsubscribe.page_url is the page browsers are redirected to after confirming an unsubscribe link,
+	// with ?unsubscribed=<category> or ?error=<reason> appended. Default value is "" (show a plain page with the outcome).
+	"mail.unsubscribe.page_url": "",
+
 	// mail.smtp.server address used for sending emails.
 	// In this case, it is set to Gmail's SMTP server.
 	"mail.smtp.server": "smtp.gmail.com",
@@ -147,4 +544,8 @@ var defaultConfigs = map[string]interface{}{
 	// mail.smtp.password for authenticating with the SMTP server.
 	// This should be kept secret and secure.
 	"mail.smtp.password": "password",
+
+	// mail.smtp.min_tls_version is the lowest TLS version accepted when the connection to the SMTP
+	// server is upgraded with STARTTLS, either "1.2" or "1.3". Default value is "1.2".
+	"mail.smtp.min_tls_version": "1.2",
 }
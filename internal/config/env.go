@@ -0,0 +1,65 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// envPrefix is the prefix of the environment variables that override settings.
+const envPrefix = "MYAPP_"
+
+// envKeyMapper maps environment variable names to setting paths. Underscores separate the segments
+// of a path but also appear within segments, as in DB_SLOW_QUERY_THRESHOLD for db.slow_query.threshold,
+// so names are looked up among the paths of the Config struct instead of being split.
+type envKeyMapper struct {
+	// paths maps a lowercased variable name, without the prefix, to the path of its setting.
+	paths map[string]string
+	// maps lists the paths of map settings, whose variables end with a key of the map, as in JWT_KEYS_<KID>.
+	maps []string
+}
+
+// newEnvKeyMapper collects the setting paths from the json tags of Config.
+func newEnvKeyMapper() *envKeyMapper {
+	m := &envKeyMapper{paths: map[string]string{}}
+	m.collect(reflect.TypeOf(Config{}), "")
+	return m
+}
+
+// collect records the paths of the fields of t, a struct whose own path is prefix.
+func (m *envKeyMapper) collect(t reflect.Type, prefix string) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+
+		switch field.Type.Kind() {
+		case reflect.Struct:
+			m.collect(field.Type, path)
+		case reflect.Map:
+			m.maps = append(m.maps, path)
+		default:
+			m.paths[strings.ReplaceAll(path, ".", "_")] = path
+		}
+	}
+}
+
+// transform returns the setting path for an environment variable, or "" when the variable doesn't
+// name a setting, so that koanf skips it.
+func (m *envKeyMapper) transform(name string) string {
+	name = strings.ToLower(strings.TrimPrefix(name, envPrefix))
+	if path, ok := m.paths[name]; ok {
+		return path
+	}
+	for _, path := range m.maps {
+		if key, ok := strings.CutPrefix(name, strings.ReplaceAll(path, ".", "_")+"_"); ok && key != "" {
+			return path + "." + key
+		}
+	}
+	return ""
+}
@@ -0,0 +1,36 @@
+package config
+
+import "testing"
+
+func TestEnvKeyMapperTransform(t *testing.T) {
+	mapper := newEnvKeyMapper()
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "MYAPP_SERVER_PORT", want: "server.port"},
+		{name: "MYAPP_DB_PORT", want: "db.port"},
+		{name: "MYAPP_MAIL_SMTP_PORT", want: "mail.smtp.port"},
+		// Underscores within a segment aren't taken for separators.
+		{name: "MYAPP_JWT_ACCESS_TOKEN_EXP", want: "jwt.access_token_exp"},
+		{name: "MYAPP_DB_SLOW_QUERY_THRESHOLD", want: "db.slow_query.threshold"},
+		{name: "MYAPP_SERVER_TRUSTED_PROXIES", want: "server.trusted_proxies"},
+		// Map settings take the rest of the name as the key, underscores and all.
+		{name: "MYAPP_JWT_KEYS_2024", want: "jwt.keys.2024"},
+		{name: "MYAPP_JWT_KEYS_KEY_2024_01", want: "jwt.keys.key_2024_01"},
+		{name: "MYAPP_JWT_KEYS_", want: ""},
+		// Names that don't match a setting are skipped.
+		{name: "MYAPP_SERVER", want: ""},
+		{name: "MYAPP_SERVER_NO_SUCH_SETTING", want: ""},
+		{name: "MYAPP_DB_SLOW", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mapper.transform(tt.name); got != tt.want {
+				t.Errorf("transform(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+// Package cors answers cross-origin requests from the origins on the configured allowlist.
+package cors
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/npushpakumara/go-backend-template/internal/config"
+)
+
+// AllowOriginFunc reports whether a request from origin may be answered with CORS headers.
+type AllowOriginFunc func(origin string) bool
+
+// NewAllowOriginFunc returns an AllowOriginFunc for the patterns of cors.allowed_origins, which LoadConfig has
+// already validated. An exact pattern matches that origin only; "*.example.com" matches any subdomain of
+// example.com, at any depth, but not example.com itself; "*" matches every origin. Patterns without a scheme
+// match both http and https. Hosts are compared case-insensitively and ports must match.
+func NewAllowOriginFunc(patterns []string) AllowOriginFunc {
+	type pattern struct {
+		scheme   string
+		host     string
+		wildcard bool
+	}
+
+	parsed := make([]pattern, 0, len(patterns))
+	for _, p := range patterns {
+		if p == "*" {
+			return func(string) bool { return true }
+		}
+		var pat pattern
+		host := p
+		if scheme, rest, ok := strings.Cut(p, "://"); ok {
+			pat.scheme, host = scheme, rest
+		}
+		if suffix, ok := strings.CutPrefix(host, "*."); ok {
+			pat.wildcard, host = true, suffix
+		}
+		pat.host = strings.ToLower(host)
+		parsed = append(parsed, pat)
+	}
+
+	return func(origin string) bool {
+		u, err := url.Parse(origin)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return false
+		}
+		host := strings.ToLower(u.Host)
+
+		for _, pat := range parsed {
+			if pat.scheme != "" && pat.scheme != u.Scheme {
+				continue
+			}
+			if pat.wildcard {
+				if strings.HasSuffix(host, "."+pat.host) {
+					return true
+				}
+			} else if host == pat.host {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Middleware adds CORS headers to requests from allowed origins and answers their preflight requests,
+// which browsers may cache for cors.max_age. Requests from other origins get no CORS headers, so browsers
+// refuse to hand the response to the calling script. With credentials allowed, the requesting origin is
+// echoed rather than "*", which browsers would reject.
+func Middleware(cfg *config.Config) gin.HandlerFunc {
+	if !cfg.CORS.Enabled {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	cors := cfg.CORS
+	allowOrigin := NewAllowOriginFunc(cors.AllowedOrigins)
+	anyOrigin := !cors.AllowCredentials && slices.Contains(cors.AllowedOrigins, "*")
+	methods := strings.Join(cors.AllowedMethods, ", ")
+	headers := strings.Join(cors.AllowedHeaders, ", ")
+	exposed := strings.Join(cors.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(cors.MaxAge.Seconds()))
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		h := c.Writer.Header()
+		if !anyOrigin {
+			// The response depends on the requesting origin, so caches must keep one copy per origin.
+			h.Add("Vary", "Origin")
+		}
+
+		if !allowOrigin(origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusNoContent)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if anyOrigin {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+		}
+		if cors.AllowCredentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if preflight {
+			h.Set("Access-Control-Allow-Methods", methods)
+			h.Set("Access-Control-Allow-Headers", headers)
+			if cors.MaxAge > 0 {
+				h.Set("Access-Control-Max-Age", maxAge)
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		if exposed != "" {
+			h.Set("Access-Control-Expose-Headers", exposed)
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,206 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/npushpakumara/go-backend-template/internal/config"
+)
+
+func TestAllowOriginFunc(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		origin   string
+		want     bool
+	}{
+		{name: "exact origin", patterns: []string{"https://app.example.com"}, origin: "https://app.example.com", want: true},
+		{name: "exact origin in another case", patterns: []string{"https://app.example.com"}, origin: "https://APP.example.com", want: true},
+		{name: "other scheme", patterns: []string{"https://app.example.com"}, origin: "http://app.example.com", want: false},
+		{name: "other port", patterns: []string{"https://app.example.com"}, origin: "https://app.example.com:8443", want: false},
+		{name: "matching port", patterns: []string{"http://localhost:3000"}, origin: "http://localhost:3000", want: true},
+		{name: "no scheme matches http", patterns: []string{"app.example.com"}, origin: "http://app.example.com", want: true},
+		{name: "no scheme matches https", patterns: []string{"app.example.com"}, origin: "https://app.example.com", want: true},
+		{name: "wildcard subdomain", patterns: []string{"https://*.example.com"}, origin: "https://app.example.com", want: true},
+		{name: "wildcard nested subdomain", patterns: []string{"https://*.example.com"}, origin: "https://a.b.example.com", want: true},
+		{name: "wildcard excludes the domain itself", patterns: []string{"https://*.example.com"}, origin: "https://example.com", want: false},
+		{name: "wildcard excludes lookalike domains", patterns: []string{"https://*.example.com"}, origin: "https://evilexample.com", want: false},
+		{name: "wildcard excludes suffixed domains", patterns: []string{"https://*.example.com"}, origin: "https://app.example.com.evil.com", want: false},
+		{name: "any origin", patterns: []string{"*"}, origin: "https://anywhere.test", want: true},
+		{name: "null origin", patterns: []string{"https://app.example.com"}, origin: "null", want: false},
+		{name: "non-http scheme", patterns: []string{"app.example.com"}, origin: "file://app.example.com", want: false},
+		{name: "empty allowlist", patterns: nil, origin: "https://app.example.com", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewAllowOriginFunc(tt.patterns)(tt.origin); got != tt.want {
+				t.Errorf("allowed(%q) with %v = %v, want %v", tt.origin, tt.patterns, got, tt.want)
+			}
+		})
+	}
+}
+
+// newCORSConfig returns an enabled CORS configuration for the origins.
+func newCORSConfig(credentials bool, origins ...string) *config.Config {
+	cfg := &config.Config{}
+	cfg.CORS = config.CORSConfig{
+		Enabled:          true,
+		AllowedOrigins:   origins,
+		AllowedMethods:   []string{"GET", "POST"},
+		AllowedHeaders:   []string{"Authorization", "Content-Type"},
+		ExposedHeaders:   []string{"X-CSRF-Token"},
+		AllowCredentials: credentials,
+		MaxAge:           10 * time.Minute,
+	}
+	return cfg
+}
+
+// serve sends a request from origin through the middleware to a handler that answers 200,
+// as a preflight request when preflight is set.
+func serve(cfg *config.Config, origin string, preflight bool) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware(cfg))
+	router.Any("/api/v1/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	method := http.MethodGet
+	if preflight {
+		method = http.MethodOptions
+	}
+	req := httptest.NewRequest(method, "/api/v1/users", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	if preflight {
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddlewareWithCredentials(t *testing.T) {
+	cfg := newCORSConfig(true, "https://app.example.com")
+
+	w := serve(cfg, "https://app.example.com", false)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	h := w.Header()
+	if got := h.Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want the requesting origin", got)
+	}
+	if got := h.Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Errorf("Access-Control-Allow-Credentials = %q, want true", got)
+	}
+	if got := h.Get("Access-Control-Expose-Headers"); got != "X-CSRF-Token" {
+		t.Errorf("Access-Control-Expose-Headers = %q, want X-CSRF-Token", got)
+	}
+	if got := h.Get("Vary"); got != "Origin" {
+		t.Errorf("Vary = %q, want Origin", got)
+	}
+}
+
+func TestMiddlewarePreflight(t *testing.T) {
+	cfg := newCORSConfig(true, "https://app.example.com")
+
+	w := serve(cfg, "https://app.example.com", true)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+	h := w.Header()
+	want := map[string]string{
+		"Access-Control-Allow-Origin":      "https://app.example.com",
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Allow-Methods":     "GET, POST",
+		"Access-Control-Allow-Headers":     "Authorization, Content-Type",
+		"Access-Control-Max-Age":           "600",
+	}
+	for name, value := range want {
+		if got := h.Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+}
+
+func TestMiddlewareRefusesOtherOrigins(t *testing.T) {
+	cfg := newCORSConfig(true, "https://app.example.com")
+
+	for _, preflight := range []bool{false, true} {
+		w := serve(cfg, "https://evil.example.net", preflight)
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+			t.Errorf("preflight %v: Access-Control-Allow-Origin = %q, want none", preflight, got)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+			t.Errorf("preflight %v: Access-Control-Allow-Credentials = %q, want none", preflight, got)
+		}
+		if got := w.Header().Get("Vary"); got != "Origin" {
+			t.Errorf("preflight %v: Vary = %q, want Origin", preflight, got)
+		}
+	}
+
+	// The request itself is still served; it's the browser that withholds the response.
+	if w := serve(cfg, "https://evil.example.net", false); w.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if w := serve(cfg, "https://evil.example.net", true); w.Code != http.StatusNoContent {
+		t.Errorf("preflight status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+}
+
+func TestMiddlewareWildcardWithoutCredentials(t *testing.T) {
+	cfg := newCORSConfig(false, "*")
+
+	w := serve(cfg, "https://anywhere.test", false)
+	h := w.Header()
+	if got := h.Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want *", got)
+	}
+	if got := h.Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("Access-Control-Allow-Credentials = %q, want none", got)
+	}
+	// Every origin gets the same answer, so caches may share it.
+	if got := h.Get("Vary"); got != "" {
+		t.Errorf("Vary = %q, want none", got)
+	}
+}
+
+// LoadConfig refuses "*" with credentials, but the middleware never answers "*" to a credentialed
+// request either: browsers would refuse it.
+func TestMiddlewareWildcardWithCredentialsEchoesOrigin(t *testing.T) {
+	cfg := newCORSConfig(true, "*")
+
+	h := serve(cfg, "https://anywhere.test", false).Header()
+	if got := h.Get("Access-Control-Allow-Origin"); got != "https://anywhere.test" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want the requesting origin", got)
+	}
+	if got := h.Get("Vary"); got != "Origin" {
+		t.Errorf("Vary = %q, want Origin", got)
+	}
+}
+
+func TestMiddlewareIgnoresSameOriginRequests(t *testing.T) {
+	cfg := newCORSConfig(true, "https://app.example.com")
+
+	w := serve(cfg, "", false)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	for _, name := range []string{"Access-Control-Allow-Origin", "Access-Control-Allow-Credentials", "Vary"} {
+		if got := w.Header().Get(name); got != "" {
+			t.Errorf("%s = %q, want none", name, got)
+		}
+	}
+}
+
+func TestMiddlewareDisabled(t *testing.T) {
+	cfg := newCORSConfig(true, "https://app.example.com")
+	cfg.CORS.Enabled = false
+
+	if got := serve(cfg, "https://app.example.com", false).Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want none", got)
+	}
+}
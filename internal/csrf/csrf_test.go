@@ -0,0 +1,115 @@
+package csrf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/npushpakumara/go-backend-template/internal/config"
+)
+
+// newCSRFRouter serves a mutating route, an exempt callback and a safe route behind the CSRF middleware.
+func newCSRFRouter(enabled bool) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{}
+	cfg.Security.CSRFProtection = enabled
+
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	router := gin.New()
+	router.Use(Middleware(cfg, "/oauth/:provider/callback"))
+	router.POST("/users/:id", ok)
+	router.PUT("/users/:id", ok)
+	router.PATCH("/users/:id", ok)
+	router.DELETE("/users/:id", ok)
+	router.GET("/users/:id", ok)
+	router.POST("/oauth/:provider/callback", ok)
+	return router
+}
+
+func TestMiddleware(t *testing.T) {
+	const token = "csrf-token"
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		session bool
+		cookie  string
+		header  string
+		want    int
+	}{
+		{name: "matching token", method: http.MethodPost, path: "/users/1", session: true, cookie: token, header: token, want: http.StatusNoContent},
+		{name: "missing header", method: http.MethodPost, path: "/users/1", session: true, cookie: token, want: http.StatusForbidden},
+		{name: "missing cookie", method: http.MethodPost, path: "/users/1", session: true, header: token, want: http.StatusForbidden},
+		{name: "missing both", method: http.MethodPost, path: "/users/1", session: true, want: http.StatusForbidden},
+		{name: "mismatched token", method: http.MethodPost, path: "/users/1", session: true, cookie: token, header: "other-token", want: http.StatusForbidden},
+		{name: "mismatched token on PUT", method: http.MethodPut, path: "/users/1", session: true, cookie: token, header: "other-token", want: http.StatusForbidden},
+		{name: "mismatched token on PATCH", method: http.MethodPatch, path: "/users/1", session: true, cookie: token, header: "other-token", want: http.StatusForbidden},
+		{name: "missing token on DELETE", method: http.MethodDelete, path: "/users/1", session: true, want: http.StatusForbidden},
+		{name: "safe method", method: http.MethodGet, path: "/users/1", session: true, want: http.StatusNoContent},
+		{name: "no session cookie", method: http.MethodPost, path: "/users/1", want: http.StatusNoContent},
+		{name: "exempt route", method: http.MethodPost, path: "/oauth/google/callback", session: true, want: http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.session {
+				req.AddCookie(&http.Cookie{Name: sessionCookie, Value: "session-token"})
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: CookieName, Value: tt.cookie})
+			}
+			if tt.header != "" {
+				req.Header.Set(HeaderName, tt.header)
+			}
+			w := httptest.NewRecorder()
+			newCSRFRouter(true).ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("%s %s status = %d, want %d", tt.method, tt.path, w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestMiddlewareDisabled(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/users/1", nil)
+	req.AddCookie(&http.Cookie{Name: sessionCookie, Value: "session-token"})
+	w := httptest.NewRecorder()
+	newCSRFRouter(false).ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("status = %d with CSRF protection disabled, want %d", w.Code, http.StatusNoContent)
+	}
+}
+
+// A token issued on sign-in, echoed back by the client, is accepted.
+func TestIssue(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	token, err := Issue(c, &config.CookieConfig{Path: "/", SameSite: "lax"})
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	if token == "" || w.Header().Get(HeaderName) != token {
+		t.Fatalf("Issue() header = %q, want the token %q", w.Header().Get(HeaderName), token)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != CookieName || cookies[0].Value != token || cookies[0].HttpOnly {
+		t.Fatalf("Issue() cookies = %+v, want a script-readable %s cookie holding the token", cookies, CookieName)
+	}
+
+	other, err := Issue(c, &config.CookieConfig{Path: "/", SameSite: "lax"})
+	if err != nil || other == token {
+		t.Errorf("Issue() again = %q, %v, want a new token", other, err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/users/1", nil)
+	req.AddCookie(&http.Cookie{Name: sessionCookie, Value: "session-token"})
+	req.AddCookie(cookies[0])
+	req.Header.Set(HeaderName, token)
+	w = httptest.NewRecorder()
+	newCSRFRouter(true).ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("status with the issued token = %d, want %d", w.Code, http.StatusNoContent)
+	}
+}
@@ -0,0 +1,148 @@
+package account
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/internal/csrf"
+	"github.com/npushpakumara/go-backend-template/internal/features/account/dto"
+	"github.com/npushpakumara/go-backend-template/internal/features/auth"
+	"github.com/npushpakumara/go-backend-template/internal/postgres"
+	"github.com/npushpakumara/go-backend-template/internal/rbac"
+	"github.com/npushpakumara/go-backend-template/pkg"
+	apiError "github.com/npushpakumara/go-backend-template/pkg/errors"
+	"github.com/npushpakumara/go-backend-template/pkg/httpx"
+	"github.com/npushpakumara/go-backend-template/pkg/logging"
+)
+
+// Handler handles requests a user makes about their own account.
+type Handler struct {
+	accountService Service
+	cfg            *config.Config
+}
+
+// NewAccountHandler creates a new Handler instance with the provided Service.
+func NewAccountHandler(accountService Service, cfg *config.Config) *Handler {
+	return &Handler{accountService, cfg}
+}
+
+// Router sets up the account routes, which are only available to users signed in with a session.
+// An administrator impersonating the user may update the profile but not delete or export the account.
+func Router(router *gin.Engine, handler *Handler, authMiddleware *jwt.GinJWTMiddleware) {
+	users := router.Group("api/v1/users")
+
+	users.Use(authMiddleware.MiddlewareFunc())
+	{
+		users.PATCH("/me", handler.updateProfile)
+		users.DELETE("/me", rbac.DenyImpersonation(), handler.deleteAccount)
+		users.GET("/me/export", rbac.DenyImpersonation(), handler.exportData)
+	}
+}
+
+// deleteAccount deletes the current user's account. The body may carry the user's password;
+// it can be omitted if the user signed in recently.
+func (ah *Handler) deleteAccount(ctx *gin.Context) {
+	logger := logging.FromContext(ctx)
+	var requestBody dto.DeleteAccountRequestDto
+
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil && !errors.Is(err, io.EOF) {
+		logger.Errorw("account.handler.deleteAccount failed to get request body: v", err)
+		var details []*pkg.ValidationErrDetail
+		if vErrs, ok := err.(validator.ValidationErrors); ok {
+			details = pkg.ValidationErrorDetails(&requestBody, "json", vErrs)
+		}
+		ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "error", Message: "Invalid request body", Errors: details})
+		return
+	}
+
+	user, ok := rbac.CurrentIdentity(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, apiError.ErrorResponse{Status: "error", Message: "Unauthorized"})
+		return
+	}
+
+	var authTime time.Time
+	if signedIn, ok := jwt.ExtractClaims(ctx)[rbac.AuthTimeKey].(float64); ok {
+		authTime = time.Unix(int64(signedIn), 0)
+	}
+
+	if err := ah.accountService.DeleteAccount(ctx, user.ID, &requestBody, authTime); err != nil {
+		switch {
+		case errors.Is(err, apiError.ErrIncorrectPassword):
+			ctx.JSON(http.StatusUnauthorized, apiError.ErrorResponse{Status: "error", Message: "Incorrect password"})
+		case errors.Is(err, apiError.ErrReauthenticationRequired):
+			ctx.JSON(http.StatusUnauthorized, apiError.ErrorResponse{Status: "error", Message: "Confirm your password or sign in again to delete your account"})
+		case errors.Is(err, postgres.ErrRecordNotFound):
+			ctx.JSON(http.StatusNotFound, apiError.ErrorResponse{Status: "error", Message: "User not found"})
+		default:
+			ctx.JSON(http.StatusInternalServerError, apiError.ErrorResponse{Status: "error", Message: "Internal server error"})
+		}
+		return
+	}
+
+	httpx.SetCookie(ctx.Writer, auth.AccessTokenCookie, "", -1, ah.cfg.Cookie.Options())
+	csrf.Clear(ctx, &ah.cfg.Cookie)
+	ctx.JSON(http.StatusOK, apiError.ErrorResponse{Status: "success", Message: "Account deleted"})
+}
+
+// updateProfile applies a partial update to the current user's profile and returns the updated profile.
+func (ah *Handler) updateProfile(ctx *gin.Context) {
+	logger := logging.FromContext(ctx)
+	var requestBody dto.UpdateProfileRequestDto
+
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		logger.Errorw("account.handler.updateProfile failed to get request body: v", err)
+		var details []*pkg.ValidationErrDetail
+		if vErrs, ok := err.(validator.ValidationErrors); ok {
+			details = pkg.ValidationErrorDetails(&requestBody, "json", vErrs)
+		}
+		ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "error", Message: "Invalid request body", Errors: details})
+		return
+	}
+
+	user, ok := rbac.CurrentIdentity(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, apiError.ErrorResponse{Status: "error", Message: "Unauthorized"})
+		return
+	}
+
+	resp, err := ah.accountService.UpdateProfile(ctx, user.ID, &requestBody)
+	if err != nil {
+		if errors.Is(err, postgres.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, apiError.ErrorResponse{Status: "error", Message: "User not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, apiError.ErrorResponse{Status: "error", Message: "Internal server error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// exportData returns the current user's personal data as a downloadable JSON document.
+func (ah *Handler) exportData(ctx *gin.Context) {
+	user, ok := rbac.CurrentIdentity(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, apiError.ErrorResponse{Status: "error", Message: "Unauthorized"})
+		return
+	}
+
+	resp, err := ah.accountService.ExportData(ctx, user.ID)
+	if err != nil {
+		if errors.Is(err, postgres.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, apiError.ErrorResponse{Status: "error", Message: "User not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, apiError.ErrorResponse{Status: "error", Message: "Internal server error"})
+		return
+	}
+
+	ctx.Header("Content-Disposition", `attachment; filename="account-export.json"`)
+	ctx.JSON(http.StatusOK, resp)
+}
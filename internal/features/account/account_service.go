@@ -0,0 +1,181 @@
+package account
+
+import (
+	"context"
+	"time"
+
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/internal/features/account/dto"
+	"github.com/npushpakumara/go-backend-template/internal/features/apikey"
+	"github.com/npushpakumara/go-backend-template/internal/features/auth"
+	"github.com/npushpakumara/go-backend-template/internal/features/email"
+	"github.com/npushpakumara/go-backend-template/internal/features/email/entities"
+	"github.com/npushpakumara/go-backend-template/internal/features/loginhistory"
+	"github.com/npushpakumara/go-backend-template/internal/features/user"
+	userDto "github.com/npushpakumara/go-backend-template/internal/features/user/dto"
+	"github.com/npushpakumara/go-backend-template/internal/postgres"
+	"github.com/npushpakumara/go-backend-template/internal/rbac"
+	"github.com/npushpakumara/go-backend-template/pkg/clock"
+	apiError "github.com/npushpakumara/go-backend-template/pkg/errors"
+	"github.com/npushpakumara/go-backend-template/pkg/logging"
+)
+
+// Service defines the self-service operations a user can perform on their own account.
+type Service interface {
+	// DeleteAccount erases the user's account and personal data. authTime is when the user signed in;
+	// unless it is recent the request must carry the user's password.
+	DeleteAccount(ctx context.Context, userID string, request *dto.DeleteAccountRequestDto, authTime time.Time) error
+
+	// ExportData returns every piece of personal data stored about the user.
+	ExportData(ctx context.Context, userID string) (*dto.AccountExportResponseDto, error)
+
+	// UpdateProfile applies the fields present in the request to the user's profile and returns the updated profile.
+	UpdateProfile(ctx context.Context, userID string, request *dto.UpdateProfileRequestDto) (*dto.ProfileResponseDto, error)
+}
+
+// accountServiceImpl is a concrete implementation of the Service interface.
+type accountServiceImpl struct {
+	userService         user.Service
+	loginHistoryService loginhistory.Service
+	apiKeyService       apikey.Service
+	emailService        email.Service
+	transactionManager  postgres.TransactionManager
+	passwordHasher      auth.PasswordHasher
+	clock               clock.Clock
+	cfg                 *config.Config
+}
+
+// NewAccountService creates a new instance of accountServiceImpl with the provided dependencies.
+func NewAccountService(userService user.Service, loginHistoryService loginhistory.Service, apiKeyService apikey.Service, emailService email.Service, transactionManager postgres.TransactionManager, passwordHasher auth.PasswordHasher, clk clock.Clock, cfg *config.Config) Service {
+	return &accountServiceImpl{userService, loginHistoryService, apiKeyService, emailService, transactionManager, passwordHasher, clk, cfg}
+}
+
+// DeleteAccount re-authenticates the user, then in one transaction anonymizes and soft-deletes the
+// user, removes their login history and revokes their API keys. Revoking the user's sessions
+// invalidates every access token they hold. A confirmation is emailed to the former address
+// once the deletion is committed; failing to send it doesn't undo the deletion.
+func (as *accountServiceImpl) DeleteAccount(c context.Context, userID string, request *dto.DeleteAccountRequestDto, authTime time.Time) error {
+	logger := logging.FromContext(c)
+
+	existing, err := as.loadUser(c, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := as.reauthenticate(existing.Password, request.Password, authTime); err != nil {
+		return err
+	}
+
+	err = as.transactionManager.RunInTransaction(c, func(ctx context.Context) error {
+		if err := as.userService.DeleteUser(ctx, userID); err != nil {
+			return err
+		}
+		if err := as.loginHistoryService.DeleteForUser(ctx, userID); err != nil {
+			return err
+		}
+		return as.apiKeyService.RevokeOwnerAPIKeys(ctx, userID)
+	})
+	if err != nil {
+		return err
+	}
+
+	confirmation, err := email.NewTemplatedEmail("AccountDeleted", existing.Locale, as.cfg.Mail.FromEmail, []string{existing.Email},
+		&entities.AccountDeletedEmailData{Name: existing.FirstName})
+	if err != nil {
+		logger.Errorw("account.service.DeleteAccount failed to parse email template: %v", err)
+		return nil
+	}
+	if err := as.emailService.SendEmail(c, *confirmation); err != nil {
+		logger.Errorw("account.service.DeleteAccount failed to send confirmation email: %v", err)
+	}
+
+	return nil
+}
+
+// loadUser returns the user, reusing the one the JWT middleware loaded for the request when it is the same user.
+func (as *accountServiceImpl) loadUser(ctx context.Context, userID string) (*userDto.UserResponseDto, error) {
+	if user, ok := rbac.CurrentUser(ctx); ok && user.ID == userID {
+		return user, nil
+	}
+	return as.userService.GetUserByID(ctx, userID)
+}
+
+// reauthenticate accepts the request if the password matches, or if no password was given and the
+// user signed in within the configured window. Users without a password can only use the latter.
+func (as *accountServiceImpl) reauthenticate(hashedPassword, password string, authTime time.Time) error {
+	if password != "" {
+		if hashedPassword == "" {
+			return apiError.ErrIncorrectPassword
+		}
+		return as.passwordHasher.Compare(hashedPassword, password)
+	}
+
+	if authTime.IsZero() || as.clock.Now().Sub(authTime) > as.cfg.Security.ReauthMaxAge {
+		return apiError.ErrReauthenticationRequired
+	}
+	return nil
+}
+
+// ExportData gathers the user's profile, linked identities and login history.
+func (as *accountServiceImpl) ExportData(ctx context.Context, userID string) (*dto.AccountExportResponseDto, error) {
+	existing, err := as.loadUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := as.loginHistoryService.ListAll(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	identities := []dto.LinkedIdentityResponseDto{}
+	if existing.Provider != "" {
+		identities = append(identities, dto.LinkedIdentityResponseDto{Provider: existing.Provider, ProviderID: existing.ProviderID})
+	}
+
+	return &dto.AccountExportResponseDto{
+		Profile:          newProfileResponse(existing),
+		LinkedIdentities: identities,
+		LoginHistory:     history,
+		ExportedAt:       as.clock.Now().UTC(),
+	}, nil
+}
+
+// UpdateProfile updates only the fields the request carries, then reads the profile back.
+func (as *accountServiceImpl) UpdateProfile(ctx context.Context, userID string, request *dto.UpdateProfileRequestDto) (*dto.ProfileResponseDto, error) {
+	err := as.userService.UpdateProfile(ctx, userID, userDto.ProfileUpdate{
+		FirstName:   request.FirstName,
+		LastName:    request.LastName,
+		PhoneNumber: request.PhoneNumber,
+		Locale:      request.Locale,
+		Timezone:    request.Timezone,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := as.userService.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := newProfileResponse(updated)
+	return &profile, nil
+}
+
+// newProfileResponse maps a user to the profile returned to its owner, leaving out credentials.
+func newProfileResponse(user *userDto.UserResponseDto) dto.ProfileResponseDto {
+	return dto.ProfileResponseDto{
+		ID:          user.ID,
+		FirstName:   user.FirstName,
+		LastName:    user.LastName,
+		Email:       user.Email,
+		PhoneNumber: user.PhoneNumber,
+		Status:      user.Status,
+		Role:        user.Role,
+		Locale:      user.Locale,
+		Timezone:    user.Timezone,
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+	}
+}
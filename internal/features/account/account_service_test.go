@@ -0,0 +1,163 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/internal/features/account/dto"
+	"github.com/npushpakumara/go-backend-template/internal/features/apikey"
+	"github.com/npushpakumara/go-backend-template/internal/features/auth"
+	"github.com/npushpakumara/go-backend-template/internal/features/email/emailtest"
+	"github.com/npushpakumara/go-backend-template/internal/features/loginhistory"
+	"github.com/npushpakumara/go-backend-template/internal/features/user"
+	userEntity "github.com/npushpakumara/go-backend-template/internal/features/user/entity"
+	"github.com/npushpakumara/go-backend-template/internal/features/user/usertest"
+	"github.com/npushpakumara/go-backend-template/internal/postgres"
+	"github.com/npushpakumara/go-backend-template/internal/postgres/postgrestest"
+	"github.com/npushpakumara/go-backend-template/pkg/clock"
+	apiError "github.com/npushpakumara/go-backend-template/pkg/errors"
+)
+
+// testPassword is the password of the users created by createUser.
+const testPassword = "Correct-Horse-9"
+
+// recordingLoginHistory is a loginhistory.Service that records whose history was deleted. Other methods aren't used.
+type recordingLoginHistory struct {
+	loginhistory.Service
+	deleted []string
+}
+
+func (r *recordingLoginHistory) DeleteForUser(_ context.Context, userID string) error {
+	r.deleted = append(r.deleted, userID)
+	return nil
+}
+
+// recordingAPIKeys is an apikey.Service that records whose keys were revoked. Other methods aren't used.
+type recordingAPIKeys struct {
+	apikey.Service
+	revoked []string
+}
+
+func (r *recordingAPIKeys) RevokeOwnerAPIKeys(_ context.Context, ownerID string) error {
+	r.revoked = append(r.revoked, ownerID)
+	return nil
+}
+
+// testAccount is an account service wired to in-memory dependencies, with handles on each of them.
+type testAccount struct {
+	service Service
+	repo    *usertest.Repository
+	history *recordingLoginHistory
+	apiKeys *recordingAPIKeys
+	emails  *emailtest.MockEmailService
+	clock   *clock.Fake
+	hasher  auth.PasswordHasher
+	cfg     *config.Config
+}
+
+// newTestAccount returns an account service backed by an in-memory user repository and a fake clock.
+// Users who signed in within the last 5 minutes may delete their account without their password.
+func newTestAccount(t *testing.T) *testAccount {
+	t.Helper()
+
+	cfg := &config.Config{}
+	cfg.Security.ReauthMaxAge = 5 * time.Minute
+	cfg.Mail.FromEmail = "no-reply@example.com"
+
+	ta := &testAccount{
+		repo:    usertest.NewRepository(),
+		history: &recordingLoginHistory{},
+		apiKeys: &recordingAPIKeys{},
+		emails:  emailtest.NewMockEmailService(),
+		clock:   clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)),
+		hasher:  auth.NewPasswordHasher(cfg),
+		cfg:     cfg,
+	}
+	users := user.NewUserService(ta.repo, postgrestest.NopTransactionManager{}, cfg)
+	ta.service = NewAccountService(users, ta.history, ta.apiKeys, ta.emails, postgrestest.NopTransactionManager{}, ta.hasher, ta.clock, cfg)
+	return ta
+}
+
+// createUser inserts an active user with the given email. Users without a provider get testPassword as their password.
+func (ta *testAccount) createUser(t *testing.T, email, provider string) *userEntity.User {
+	t.Helper()
+
+	u := &userEntity.User{FirstName: "Ada", Email: email, Status: userEntity.StatusActive, Role: userEntity.RoleUser}
+	if provider != "" {
+		u.Provider, u.ProviderID = provider, "provider-id"
+	} else {
+		hash, err := ta.hasher.Hash(testPassword)
+		if err != nil {
+			t.Fatalf("hash password: %v", err)
+		}
+		u.Password = hash
+	}
+
+	created, err := ta.repo.Insert(context.Background(), u)
+	if err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+	return created
+}
+
+// deleted reports whether the user's account was deleted.
+func (ta *testAccount) deleted(t *testing.T, id string) bool {
+	t.Helper()
+	_, err := ta.repo.FindByID(context.Background(), id)
+	if err != nil && !errors.Is(err, postgres.ErrRecordNotFound) {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	return err != nil
+}
+
+func TestDeleteAccountRequiresReauthentication(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		password string
+		signedIn time.Duration // how long before the request the user signed in; 0 when the token has no auth_time
+		wantErr  error
+		wantGone bool
+	}{
+		{name: "correct password", password: testPassword, signedIn: time.Hour, wantGone: true},
+		{name: "correct password without auth_time", password: testPassword, wantGone: true},
+		{name: "wrong password", password: "Wrong-Horse-9", signedIn: time.Minute, wantErr: apiError.ErrIncorrectPassword},
+		{name: "recent sign-in", signedIn: time.Minute, wantGone: true},
+		{name: "sign-in at the limit", signedIn: 5 * time.Minute, wantGone: true},
+		{name: "stale sign-in", signedIn: 5*time.Minute + time.Second, wantErr: apiError.ErrReauthenticationRequired},
+		{name: "token without auth_time", wantErr: apiError.ErrReauthenticationRequired},
+		{name: "OAuth user with recent sign-in", provider: "google", signedIn: time.Minute, wantGone: true},
+		{name: "OAuth user with stale sign-in", provider: "google", signedIn: time.Hour, wantErr: apiError.ErrReauthenticationRequired},
+		{name: "OAuth user with a password", provider: "google", password: testPassword, signedIn: time.Hour, wantErr: apiError.ErrIncorrectPassword},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ta := newTestAccount(t)
+			u := ta.createUser(t, "ada@example.com", tt.provider)
+
+			var authTime time.Time
+			if tt.signedIn > 0 {
+				authTime = ta.clock.Now().Add(-tt.signedIn)
+			}
+			err := ta.service.DeleteAccount(context.Background(), u.ID.String(), &dto.DeleteAccountRequestDto{Password: tt.password}, authTime)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DeleteAccount() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if got := ta.deleted(t, u.ID.String()); got != tt.wantGone {
+				t.Errorf("user deleted = %v, want %v", got, tt.wantGone)
+			}
+			if tt.wantGone {
+				if len(ta.history.deleted) != 1 || len(ta.apiKeys.revoked) != 1 {
+					t.Errorf("deleted login history of %v and revoked API keys of %v, want both for %s", ta.history.deleted, ta.apiKeys.revoked, u.ID)
+				}
+			} else if len(ta.history.deleted) != 0 || len(ta.apiKeys.revoked) != 0 || len(ta.emails.Sent()) != 0 {
+				t.Errorf("a rejected deletion deleted login history of %v, revoked API keys of %v and sent %d emails, want nothing",
+					ta.history.deleted, ta.apiKeys.revoked, len(ta.emails.Sent()))
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package dto
+
+// DeleteAccountRequestDto confirms an account deletion. Password may be left out if the user signed in recently.
+type DeleteAccountRequestDto struct {
+	Password string `json:"password"`
+}
+
+// UpdateProfileRequestDto is a partial update of the user's profile. Fields left out of the body are not changed,
+// which is why they are pointers. The names and phone number follow the same rules as sign-up.
+// Timezone is an IANA time zone name such as "Europe/London" and Locale a BCP 47 language tag such as "en-GB".
+type UpdateProfileRequestDto struct {
+	FirstName   *string `json:"first_name" binding:"omitnil,min=2,max=100,person_name"`
+	LastName    *string `json:"last_name" binding:"omitnil,min=2,max=100,person_name"`
+	PhoneNumber *string `json:"phone_number" binding:"omitnil,e164,min=12,max=12"`
+	Timezone    *string `json:"timezone" binding:"omitnil,min=1,max=64,ne=Local,timezone"`
+	Locale      *string `json:"locale" binding:"omitnil,bcp47_language_tag,max=35"`
+}
@@ -0,0 +1,36 @@
+package dto
+
+import (
+	"time"
+
+	loginHistoryDto "github.com/npushpakumara/go-backend-template/internal/features/loginhistory/dto"
+)
+
+// ProfileResponseDto is the profile section of an account export.
+type ProfileResponseDto struct {
+	ID          string    `json:"id"`
+	FirstName   string    `json:"first_name"`
+	LastName    string    `json:"last_name"`
+	Email       string    `json:"email"`
+	PhoneNumber string    `json:"phone_number"`
+	Status      string    `json:"status"`
+	Role        string    `json:"role"`
+	Locale      string    `json:"locale"`
+	Timezone    string    `json:"timezone"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// LinkedIdentityResponseDto is an external identity provider account linked to the user.
+type LinkedIdentityResponseDto struct {
+	Provider   string `json:"provider"`
+	ProviderID string `json:"provider_id"`
+}
+
+// AccountExportResponseDto holds every piece of personal data stored about the user.
+type AccountExportResponseDto struct {
+	Profile          ProfileResponseDto                      `json:"profile"`
+	LinkedIdentities []LinkedIdentityResponseDto             `json:"linked_identities"`
+	LoginHistory     []loginHistoryDto.LoginEventResponseDto `json:"login_history"`
+	ExportedAt       time.Time                               `json:"exported_at"`
+}
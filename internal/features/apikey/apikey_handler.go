@@ -0,0 +1,80 @@
+package apikey
+
+import (
+	"errors"
+	"net/http"
+
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/npushpakumara/go-backend-template/internal/features/apikey/dto"
+	userEntity "github.com/npushpakumara/go-backend-template/internal/features/user/entity"
+	"github.com/npushpakumara/go-backend-template/internal/postgres"
+	"github.com/npushpakumara/go-backend-template/internal/rbac"
+	"github.com/npushpakumara/go-backend-template/pkg"
+	apiError "github.com/npushpakumara/go-backend-template/pkg/errors"
+	"github.com/npushpakumara/go-backend-template/pkg/logging"
+)
+
+// Handler handles API key management requests.
+type Handler struct {
+	apiKeyService Service
+}
+
+// NewAPIKeyHandler creates a new Handler instance with the provided Service.
+func NewAPIKeyHandler(apiKeyService Service) *Handler {
+	return &Handler{apiKeyService}
+}
+
+// Router sets up the admin routes for managing API keys.
+// The routes are only available to administrators outside any organization.
+func Router(router *gin.Engine, handler *Handler, authMiddleware *jwt.GinJWTMiddleware) {
+	admin := router.Group("api/v1/admin")
+
+	admin.Use(authMiddleware.MiddlewareFunc(), rbac.RequireRole(userEntity.RoleAdmin), rbac.DenyOrgScoped())
+	{
+		admin.POST("/api-keys", handler.createAPIKey)
+		admin.DELETE("/api-keys/:id", pkg.RequireUUIDParams("id"), handler.revokeAPIKey)
+	}
+}
+
+// createAPIKey issues a new API key owned by the calling admin and returns the plaintext key once.
+func (ah *Handler) createAPIKey(ctx *gin.Context) {
+	logger := logging.FromContext(ctx)
+	var requestBody dto.CreateAPIKeyRequestDto
+
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		logger.Errorw("apikey.handler.createAPIKey failed to get request body: v", err)
+		var details []*pkg.ValidationErrDetail
+		if vErrs, ok := err.(validator.ValidationErrors); ok {
+			details = pkg.ValidationErrorDetails(&requestBody, "json", vErrs)
+		}
+		ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "error", Message: "Invalid request body", Errors: details})
+		return
+	}
+
+	identity, _ := rbac.CurrentIdentity(ctx)
+
+	resp, err := ah.apiKeyService.CreateAPIKey(ctx, identity.ID, &requestBody)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, apiError.ErrorResponse{Status: "error", Message: "Internal server error"})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, resp)
+}
+
+// revokeAPIKey revokes the API key identified by the "id" path parameter.
+func (ah *Handler) revokeAPIKey(ctx *gin.Context) {
+	err := ah.apiKeyService.RevokeAPIKey(ctx, ctx.Param("id"))
+	if err != nil {
+		if errors.Is(err, postgres.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, apiError.ErrorResponse{Status: "error", Message: "API key not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, apiError.ErrorResponse{Status: "error", Message: "Internal server error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, apiError.ErrorResponse{Status: "success", Message: "API key revoked"})
+}
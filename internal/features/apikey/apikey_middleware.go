@@ -0,0 +1,136 @@
+package apikey
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/npushpakumara/go-backend-template/internal/features/apikey/dto"
+	userDto "github.com/npushpakumara/go-backend-template/internal/features/user/dto"
+	userEntity "github.com/npushpakumara/go-backend-template/internal/features/user/entity"
+	"github.com/npushpakumara/go-backend-template/internal/rbac"
+	apiError "github.com/npushpakumara/go-backend-template/pkg/errors"
+	"github.com/npushpakumara/go-backend-template/pkg/logging"
+)
+
+// Context keys under which the authenticated API key and its scopes are stored.
+const (
+	APIKeyContextKey = "api_key"
+	ScopesContextKey = "scopes"
+)
+
+// Scopes an API key can be granted. Every route that accepts API keys requires one of them through
+// RequireScope, so a key can only reach the routes its scopes name.
+const (
+	// ScopeUsersRead allows reading users.
+	ScopeUsersRead = "users:read"
+	// ScopeWhoAmI allows a key to describe itself through GET /api/v1/auth/whoami.
+	ScopeWhoAmI = "whoami"
+)
+
+// Owners loads the users API keys belong to. It is satisfied by user.Service, which this package can't import.
+type Owners interface {
+	GetUserByID(ctx context.Context, userID string) (*userDto.UserResponseDto, error)
+}
+
+// Middleware authenticates requests carrying an API key and falls through to the JWT middleware otherwise.
+type Middleware struct {
+	apiKeyService Service
+	owners        Owners
+	jwtMiddleware *jwt.GinJWTMiddleware
+}
+
+// NewAPIKeyMiddleware creates a new Middleware that checks API keys before the given JWT middleware.
+func NewAPIKeyMiddleware(apiKeyService Service, owners Owners, jwtMiddleware *jwt.GinJWTMiddleware) *Middleware {
+	return &Middleware{apiKeyService, owners, jwtMiddleware}
+}
+
+// MiddlewareFunc returns a Gin handler that accepts either an API key
+// (via the "X-API-Key" header or an "Authorization: Bearer ak_..." header) or a JWT cookie.
+// When a key is presented it must be valid; the request does not fall back to the cookie.
+// Requests made with the key of a user in an organization are scoped to that organization, as a session would be,
+// and keys of suspended or banned users are refused.
+// Routes using it must also use RequireScope, and must not use rbac.RequireRole, which rejects every API key.
+func (m *Middleware) MiddlewareFunc() gin.HandlerFunc {
+	jwtHandler := m.jwtMiddleware.MiddlewareFunc()
+
+	return func(c *gin.Context) {
+		key := extractKey(c)
+		if key == "" {
+			jwtHandler(c)
+			return
+		}
+
+		apiKey, err := m.apiKeyService.Authenticate(c, key)
+		if err != nil {
+			logging.FromContext(c).Warnw("apikey.middleware failed to authenticate api key", "err", err)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, apiError.ErrorResponse{Status: "error", Message: "Invalid API key"})
+			return
+		}
+
+		// The owner is looked up outside any organization; the key alone doesn't say which one it belongs to.
+		owner, err := m.owners.GetUserByID(rbac.WithoutOrg(c), apiKey.OwnerID)
+		if err != nil {
+			logging.FromContext(c).Warnw("apikey.middleware failed to load api key owner", "err", err)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, apiError.ErrorResponse{Status: "error", Message: "Invalid API key"})
+			return
+		}
+		// As with sessions, suspending or banning a user stops their keys from working until the account is reactivated.
+		if owner.Status == userEntity.StatusSuspended || owner.Status == userEntity.StatusBanned {
+			c.AbortWithStatusJSON(http.StatusForbidden, apiError.ErrorResponse{Status: "error", Message: "API key owner's account is not active"})
+			return
+		}
+		if owner.OrgID != "" {
+			rbac.SetOrg(c, owner.OrgID)
+		}
+
+		c.Set(APIKeyContextKey, apiKey)
+		c.Set(ScopesContextKey, apiKey.Scopes)
+		c.Next()
+	}
+}
+
+// RequireScope is a Gin middleware that rejects API-key requests lacking the given scope.
+// Requests authenticated with a user session are not scope-restricted and pass through.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get(APIKeyContextKey); !ok {
+			c.Next()
+			return
+		}
+
+		if !slices.Contains(c.GetStringSlice(ScopesContextKey), scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, apiError.ErrorResponse{Status: "error", Message: "API key is missing the required scope"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// CurrentAPIKey returns the API key that authenticated the request, if any.
+func CurrentAPIKey(c *gin.Context) (*dto.APIKeyResponseDto, bool) {
+	value, ok := c.Get(APIKeyContextKey)
+	if !ok {
+		return nil, false
+	}
+	apiKey, ok := value.(*dto.APIKeyResponseDto)
+	return apiKey, ok
+}
+
+// extractKey reads an API key from the X-API-Key header or a Bearer Authorization header.
+// Bearer values that don't carry the API key prefix are ignored.
+func extractKey(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+
+	auth := c.GetHeader("Authorization")
+	if token, ok := strings.CutPrefix(auth, "Bearer "); ok && strings.HasPrefix(token, keyPrefix) {
+		return token
+	}
+	return ""
+}
@@ -0,0 +1,239 @@
+package apikey
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/npushpakumara/go-backend-template/internal/features/apikey/dto"
+	userDto "github.com/npushpakumara/go-backend-template/internal/features/user/dto"
+	userEntity "github.com/npushpakumara/go-backend-template/internal/features/user/entity"
+	"github.com/npushpakumara/go-backend-template/internal/postgres"
+	"github.com/npushpakumara/go-backend-template/internal/rbac"
+	apiError "github.com/npushpakumara/go-backend-template/pkg/errors"
+)
+
+// fakeKeys is a Service that authenticates the keys in its map and rejects every other key.
+type fakeKeys struct {
+	Service
+	keys map[string]*dto.APIKeyResponseDto
+}
+
+func (f *fakeKeys) Authenticate(_ context.Context, key string) (*dto.APIKeyResponseDto, error) {
+	apiKey, ok := f.keys[key]
+	if !ok {
+		return nil, apiError.ErrInvalidAPIKey
+	}
+	return apiKey, nil
+}
+
+// fakeOwners is an Owners that finds the users in its map and no other.
+type fakeOwners map[string]*userDto.UserResponseDto
+
+func (f fakeOwners) GetUserByID(_ context.Context, userID string) (*userDto.UserResponseDto, error) {
+	owner, ok := f[userID]
+	if !ok {
+		return nil, postgres.ErrRecordNotFound
+	}
+	return owner, nil
+}
+
+// newMiddleware creates a Middleware for the given keys, each owned by the user of the same ID in owners.
+func newMiddleware(t *testing.T, keys map[string]*dto.APIKeyResponseDto, owners fakeOwners) *Middleware {
+	t.Helper()
+
+	jwtMiddleware, err := jwt.New(&jwt.GinJWTMiddleware{Realm: "test", Key: []byte("test-secret")})
+	if err != nil {
+		t.Fatalf("jwt.New() error = %v", err)
+	}
+	return NewAPIKeyMiddleware(&fakeKeys{keys: keys}, owners, jwtMiddleware)
+}
+
+// newScopedRouter serves GET /users behind the API key middleware and the users:read scope.
+// Requests without a key reach the JWT middleware, which rejects them.
+func newScopedRouter(t *testing.T, keys map[string]*dto.APIKeyResponseDto) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	owners := fakeOwners{}
+	for _, apiKey := range keys {
+		owners[apiKey.OwnerID] = &userDto.UserResponseDto{ID: apiKey.OwnerID}
+	}
+	m := newMiddleware(t, keys, owners)
+
+	router := gin.New()
+	router.GET("/users", m.MiddlewareFunc(), RequireScope(ScopeUsersRead), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func TestRequireScope(t *testing.T) {
+	router := newScopedRouter(t, map[string]*dto.APIKeyResponseDto{
+		"ak_reader": {ID: "reader", OwnerID: "owner", Scopes: []string{ScopeUsersRead}},
+		"ak_other":  {ID: "other", OwnerID: "owner", Scopes: []string{ScopeWhoAmI}},
+		"ak_none":   {ID: "none", OwnerID: "owner"},
+	})
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		want   int
+	}{
+		{name: "key with the scope", header: "X-API-Key", value: "ak_reader", want: http.StatusOK},
+		{name: "bearer key with the scope", header: "Authorization", value: "Bearer ak_reader", want: http.StatusOK},
+		{name: "key with another scope", header: "X-API-Key", value: "ak_other", want: http.StatusForbidden},
+		{name: "key without scopes", header: "X-API-Key", value: "ak_none", want: http.StatusForbidden},
+		{name: "unknown key", header: "X-API-Key", value: "ak_unknown", want: http.StatusUnauthorized},
+		{name: "no credentials", want: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/users", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+// Requests authenticated with a user session aren't restricted by scopes.
+func TestRequireScopeIgnoresSessions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.GET("/users", RequireScope(ScopeUsersRead), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+// Keys act within their owner's organization, so a users:read key can't read other tenants' users.
+func TestMiddlewareScopesKeysToTheOwnersOrg(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	m := newMiddleware(t, map[string]*dto.APIKeyResponseDto{
+		"ak_tenant":   {ID: "tenant", OwnerID: "tenant-admin", Scopes: []string{ScopeUsersRead}},
+		"ak_platform": {ID: "platform", OwnerID: "platform-admin", Scopes: []string{ScopeUsersRead}},
+		"ak_orphan":   {ID: "orphan", OwnerID: "deleted-user", Scopes: []string{ScopeUsersRead}},
+	}, fakeOwners{
+		"tenant-admin":   {ID: "tenant-admin", OrgID: "org-1"},
+		"platform-admin": {ID: "platform-admin"},
+	})
+
+	var gotOrg string
+	router := gin.New()
+	router.GET("/users", m.MiddlewareFunc(), RequireScope(ScopeUsersRead), func(c *gin.Context) {
+		gotOrg, _ = rbac.CurrentOrg(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name       string
+		key        string
+		wantStatus int
+		wantOrg    string
+	}{
+		{name: "key of a user in an organization", key: "ak_tenant", wantStatus: http.StatusOK, wantOrg: "org-1"},
+		{name: "key of a user outside any organization", key: "ak_platform", wantStatus: http.StatusOK},
+		{name: "key whose owner no longer exists", key: "ak_orphan", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotOrg = ""
+			req := httptest.NewRequest(http.MethodGet, "/users", nil)
+			req.Header.Set("X-API-Key", tt.key)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if gotOrg != tt.wantOrg {
+				t.Errorf("CurrentOrg() = %q, want %q", gotOrg, tt.wantOrg)
+			}
+		})
+	}
+}
+
+// Suspending or banning a user stops their keys from working, as it does their sessions.
+func TestMiddlewareRejectsKeysOfInactiveOwners(t *testing.T) {
+	tests := []struct {
+		status string
+		want   int
+	}{
+		{status: userEntity.StatusActive, want: http.StatusOK},
+		{status: userEntity.StatusSuspended, want: http.StatusForbidden},
+		{status: userEntity.StatusBanned, want: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			m := newMiddleware(t, map[string]*dto.APIKeyResponseDto{
+				"ak_reader": {ID: "reader", OwnerID: "owner", Scopes: []string{ScopeUsersRead}},
+			}, fakeOwners{"owner": {ID: "owner", Status: tt.status}})
+
+			router := gin.New()
+			router.GET("/users", m.MiddlewareFunc(), RequireScope(ScopeUsersRead), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/users", nil)
+			req.Header.Set("X-API-Key", "ak_reader")
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+// Keys can only be granted the scopes the middleware knows, so a typo can't create a key that reaches nothing.
+func TestCreateAPIKeyRequestScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		scopes  []string
+		wantErr bool
+	}{
+		{name: "no scopes"},
+		{name: "known scopes", scopes: []string{ScopeUsersRead, ScopeWhoAmI}},
+		{name: "unknown scope", scopes: []string{ScopeUsersRead, "users:write"}, wantErr: true},
+		{name: "empty scope", scopes: []string{""}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := dto.CreateAPIKeyRequestDto{Label: "ci", Scopes: tt.scopes}
+
+			err := binding.Validator.ValidateStruct(&request)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateStruct() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+
+	"github.com/npushpakumara/go-backend-template/internal/features/apikey/entity"
+	"github.com/npushpakumara/go-backend-template/internal/postgres"
+	"github.com/npushpakumara/go-backend-template/pkg/logging"
+	"gorm.io/gorm"
+)
+
+// Repository defines the interface for API key data operations.
+type Repository interface {
+	// Insert adds a new API key to the database.
+	Insert(ctx context.Context, key *entity.APIKey) (*entity.APIKey, error)
+
+	// FindByHash retrieves an API key by the hash of its plaintext value.
+	// It returns postgres.ErrRecordNotFound if no key matches.
+	FindByHash(ctx context.Context, hash string) (*entity.APIKey, error)
+
+	// Revoke marks the API key with the given ID as revoked.
+	// It returns postgres.ErrRecordNotFound if no key matches.
+	Revoke(ctx context.Context, id string) error
+
+	// RevokeByOwner marks every API key owned by the given user as revoked.
+	RevokeByOwner(ctx context.Context, ownerID string) error
+}
+
+// apiKeyRepositoryImpl is a concrete implementation of the Repository interface.
+type apiKeyRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository creates a new instance of apiKeyRepositoryImpl with the provided database connection.
+func NewAPIKeyRepository(db *gorm.DB) Repository {
+	return &apiKeyRepositoryImpl{db}
+}
+
+// Insert adds a new API key to the database.
+func (ar *apiKeyRepositoryImpl) Insert(ctx context.Context, key *entity.APIKey) (*entity.APIKey, error) {
+	logger := logging.FromContext(ctx)
+	db := postgres.FromContext(ctx, ar.db)
+
+	logger.Debugw("apikey.db.Insert", "label", key.Label)
+	if err := db.WithContext(ctx).Create(key).Error; err != nil {
+		if pgErr := postgres.IsPgxError(err); errors.Is(pgErr, postgres.ErrKeyDuplicate) {
+			logger.Warn("apikey.db.Insert key already exists")
+			return nil, postgres.ErrKeyDuplicate
+		}
+		logger.Errorw("apikey.db.Insert failed to save: %v", err)
+		return nil, err
+	}
+	return key, nil
+}
+
+// FindByHash retrieves an API key by the hash of its plaintext value.
+func (ar *apiKeyRepositoryImpl) FindByHash(ctx context.Context, hash string) (*entity.APIKey, error) {
+	logger := logging.FromContext(ctx)
+	db := postgres.FromContext(ctx, ar.db)
+
+	var key entity.APIKey
+	if err := db.WithContext(ctx).First(&key, "key_hash = ?", hash).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Warn("apikey.db.FindByHash api key not found")
+			return nil, postgres.ErrRecordNotFound
+		}
+		logger.Errorw("apikey.db.FindByHash failed to find api key: %v", err)
+		return nil, err
+	}
+	return &key, nil
+}
+
+// Revoke marks the API key with the given ID as revoked.
+func (ar *apiKeyRepositoryImpl) Revoke(ctx context.Context, id string) error {
+	logger := logging.FromContext(ctx)
+	db := postgres.FromContext(ctx, ar.db)
+
+	logger.Debugw("apikey.db.Revoke", "id", id)
+
+	result := db.WithContext(ctx).Model(&entity.APIKey{}).Where("id = ?", id).Update("revoked", true)
+	if result.Error != nil {
+		logger.Errorw("apikey.db.Revoke failed to revoke api key: %v", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		logger.Warn("apikey.db.Revoke api key not found")
+		return postgres.ErrRecordNotFound
+	}
+	return nil
+}
+
+// RevokeByOwner marks every API key owned by the given user as revoked.
+// Owners without any keys are not an error.
+func (ar *apiKeyRepositoryImpl) RevokeByOwner(ctx context.Context, ownerID string) error {
+	logger := logging.FromContext(ctx)
+	db := postgres.FromContext(ctx, ar.db)
+
+	logger.Debugw("apikey.db.RevokeByOwner", "owner", ownerID)
+
+	if err := db.WithContext(ctx).Model(&entity.APIKey{}).Where("owner_id = ? AND revoked = ?", ownerID, false).Update("revoked", true).Error; err != nil {
+		logger.Errorw("apikey.db.RevokeByOwner failed to revoke api keys: %v", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/npushpakumara/go-backend-template/internal/features/apikey/dto"
+	"github.com/npushpakumara/go-backend-template/internal/features/apikey/entity"
+	"github.com/npushpakumara/go-backend-template/internal/postgres"
+	apiError "github.com/npushpakumara/go-backend-template/pkg/errors"
+	"github.com/npushpakumara/go-backend-template/pkg/logging"
+)
+
+// keyPrefix is prepended to every generated API key so they are easy to recognise in headers and secret scanners.
+const keyPrefix = "ak_"
+
+// Service defines the methods that the API key service implements.
+type Service interface {
+	// CreateAPIKey issues a new API key owned by the given user.
+	// The plaintext key is only ever returned from this call.
+	CreateAPIKey(ctx context.Context, ownerID string, request *dto.CreateAPIKeyRequestDto) (*dto.CreateAPIKeyResponseDto, error)
+
+	// RevokeAPIKey revokes the API key with the given ID so it can no longer be used.
+	RevokeAPIKey(ctx context.Context, id string) error
+
+	// RevokeOwnerAPIKeys revokes every API key owned by the given user.
+	RevokeOwnerAPIKeys(ctx context.Context, ownerID string) error
+
+	// Authenticate looks up the given plaintext key and returns it if it is valid and not revoked.
+	Authenticate(ctx context.Context, key string) (*dto.APIKeyResponseDto, error)
+}
+
+// apiKeyServiceImpl is a concrete implementation of the Service interface.
+type apiKeyServiceImpl struct {
+	apiKeyRepository Repository
+}
+
+// NewAPIKeyService creates a new instance of apiKeyServiceImpl with the provided Repository.
+func NewAPIKeyService(apiKeyRepository Repository) Service {
+	return &apiKeyServiceImpl{apiKeyRepository}
+}
+
+// CreateAPIKey generates a random key, stores its hash, and returns the plaintext key together with its metadata.
+func (as *apiKeyServiceImpl) CreateAPIKey(ctx context.Context, ownerID string, request *dto.CreateAPIKeyRequestDto) (*dto.CreateAPIKeyResponseDto, error) {
+	logger := logging.FromContext(ctx)
+
+	owner, err := uuid.Parse(ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := generateKey()
+	if err != nil {
+		logger.Errorw("apikey.service.CreateAPIKey failed to generate key", "err", err)
+		return nil, err
+	}
+
+	newKey, err := as.apiKeyRepository.Insert(ctx, &entity.APIKey{
+		Label:   request.Label,
+		KeyHash: hashKey(key),
+		Scopes:  strings.Join(request.Scopes, ","),
+		OwnerID: owner,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.CreateAPIKeyResponseDto{
+		APIKeyResponseDto: *toResponseDto(newKey),
+		Key:               key,
+	}, nil
+}
+
+// RevokeAPIKey revokes the API key with the given ID.
+func (as *apiKeyServiceImpl) RevokeAPIKey(ctx context.Context, id string) error {
+	return as.apiKeyRepository.Revoke(ctx, id)
+}
+
+// RevokeOwnerAPIKeys revokes every API key owned by the given user, e.g. when their account is deleted.
+func (as *apiKeyServiceImpl) RevokeOwnerAPIKeys(ctx context.Context, ownerID string) error {
+	return as.apiKeyRepository.RevokeByOwner(ctx, ownerID)
+}
+
+// Authenticate hashes the provided key and resolves it to a stored, non-revoked API key.
+// Unknown and revoked keys both result in ErrInvalidAPIKey so callers can't tell them apart.
+func (as *apiKeyServiceImpl) Authenticate(ctx context.Context, key string) (*dto.APIKeyResponseDto, error) {
+	logger := logging.FromContext(ctx)
+
+	apiKey, err := as.apiKeyRepository.FindByHash(ctx, hashKey(key))
+	if err != nil {
+		if errors.Is(err, postgres.ErrRecordNotFound) {
+			return nil, apiError.ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+
+	if apiKey.Revoked {
+		logger.Warnw("apikey.service.Authenticate revoked api key used", "id", apiKey.ID)
+		return nil, apiError.ErrInvalidAPIKey
+	}
+
+	return toResponseDto(apiKey), nil
+}
+
+// generateKey returns a new random API key carrying the keyPrefix.
+func generateKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return keyPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashKey returns the hex-encoded SHA-256 hash of the given key.
+// API keys have enough entropy that a fast hash is sufficient and allows indexed lookups.
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// toResponseDto maps an APIKey entity to its response DTO.
+func toResponseDto(key *entity.APIKey) *dto.APIKeyResponseDto {
+	return &dto.APIKeyResponseDto{
+		ID:        key.ID.String(),
+		Label:     key.Label,
+		Scopes:    key.GetScopes(),
+		OwnerID:   key.OwnerID.String(),
+		Revoked:   key.Revoked,
+		CreatedAt: key.CreatedAt,
+	}
+}
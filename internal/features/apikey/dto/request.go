@@ -0,0 +1,9 @@
+package dto
+
+// CreateAPIKeyRequestDto is a Data Transfer Object (DTO) used to capture and validate the data required to issue a new API key.
+// It includes a human-readable label and the list of scopes granted to the key, each one of the scopes
+// in apikey_middleware.go, which this package can't import.
+type CreateAPIKeyRequestDto struct {
+	Label  string   `json:"label" binding:"required,min=2,max=100"`
+	Scopes []string `json:"scopes" binding:"omitempty,dive,oneof=users:read whoami"`
+}
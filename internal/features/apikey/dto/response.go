@@ -0,0 +1,20 @@
+package dto
+
+import "time"
+
+// APIKeyResponseDto represents an API key without its secret value.
+type APIKeyResponseDto struct {
+	ID        string    `json:"id"`
+	Label     string    `json:"label"`
+	Scopes    []string  `json:"scopes"`
+	OwnerID   string    `json:"owner_id"`
+	Revoked   bool      `json:"revoked"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateAPIKeyResponseDto is returned once when a new API key is issued.
+// The plaintext Key is never stored and cannot be retrieved again.
+type CreateAPIKeyResponseDto struct {
+	APIKeyResponseDto
+	Key string `json:"key"`
+}
@@ -0,0 +1,41 @@
+package entity
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// APIKey represents a key issued to a service for machine-to-machine calls.
+// Only the SHA-256 hash of the key is stored; the plaintext is shown once on creation.
+type APIKey struct {
+	*gorm.Model
+	ID      uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Label   string    `gorm:"size:100;not null"`
+	KeyHash string    `gorm:"size:64;uniqueIndex;not null"`
+	Scopes  string    `gorm:"size:255"`
+	OwnerID uuid.UUID `gorm:"type:uuid;not null"`
+	Revoked bool      `gorm:"type:boolean;not null;default:false"`
+}
+
+// TableName overrides the default table name used by GORM for the APIKey model.
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// BeforeCreate is a GORM hook that sets the ID field to a new UUID if it hasn't been set already.
+func (key *APIKey) BeforeCreate(tx *gorm.DB) (err error) {
+	if key.ID == uuid.Nil {
+		key.ID = uuid.New()
+	}
+	return
+}
+
+// GetScopes splits the comma-separated Scopes column into a slice of individual scopes.
+func (key *APIKey) GetScopes() []string {
+	if key.Scopes == "" {
+		return []string{}
+	}
+	return strings.Split(key.Scopes, ",")
+}
@@ -0,0 +1,39 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/npushpakumara/go-backend-template/internal/features/audit/entity"
+	"github.com/npushpakumara/go-backend-template/internal/postgres"
+	"github.com/npushpakumara/go-backend-template/pkg/logging"
+	"gorm.io/gorm"
+)
+
+// Repository defines the interface for audit log data operations.
+type Repository interface {
+	// Insert adds a new audit log entry to the database.
+	Insert(ctx context.Context, log *entity.AuditLog) error
+}
+
+// auditRepositoryImpl is a concrete implementation of the Repository interface.
+type auditRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewAuditRepository creates a new instance of auditRepositoryImpl with the provided database connection.
+func NewAuditRepository(db *gorm.DB) Repository {
+	return &auditRepositoryImpl{db}
+}
+
+// Insert adds a new audit log entry to the database.
+func (ar *auditRepositoryImpl) Insert(ctx context.Context, log *entity.AuditLog) error {
+	logger := logging.FromContext(ctx)
+	db := postgres.FromContext(ctx, ar.db)
+
+	logger.Debugw("audit.db.Insert", "action", log.Action, "target", log.TargetID)
+	if err := db.WithContext(ctx).Create(log).Error; err != nil {
+		logger.Errorw("audit.db.Insert failed to save: %v", err)
+		return err
+	}
+	return nil
+}
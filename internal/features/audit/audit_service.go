@@ -0,0 +1,57 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/npushpakumara/go-backend-template/internal/features/audit/entity"
+	"github.com/npushpakumara/go-backend-template/pkg/logging"
+)
+
+// Entry describes a privileged action to be written to the audit log.
+type Entry struct {
+	ActorID   string
+	Action    string
+	TargetID  string
+	IPAddress string
+	Details   map[string]interface{}
+}
+
+// Service defines the methods that the audit service implements.
+type Service interface {
+	// Record writes the given entry to the audit log.
+	Record(ctx context.Context, entry Entry) error
+}
+
+// auditServiceImpl is a concrete implementation of the Service interface.
+type auditServiceImpl struct {
+	auditRepository Repository
+}
+
+// NewAuditService creates a new instance of auditServiceImpl with the provided Repository.
+func NewAuditService(auditRepository Repository) Service {
+	return &auditServiceImpl{auditRepository}
+}
+
+// Record serialises the entry details to JSON and stores the entry.
+func (as *auditServiceImpl) Record(ctx context.Context, entry Entry) error {
+	logger := logging.FromContext(ctx)
+
+	var details []byte
+	if len(entry.Details) > 0 {
+		var err error
+		details, err = json.Marshal(entry.Details)
+		if err != nil {
+			logger.Errorw("audit.service.Record failed to marshal details", "err", err)
+			return err
+		}
+	}
+
+	return as.auditRepository.Insert(ctx, &entity.AuditLog{
+		ActorID:   entry.ActorID,
+		Action:    entry.Action,
+		TargetID:  entry.TargetID,
+		IPAddress: entry.IPAddress,
+		Details:   string(details),
+	})
+}
@@ -0,0 +1,39 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Actions recorded in the audit log.
+const (
+	ActionUserActivated          = "user.activated"
+	ActionUserSuspended          = "user.suspended"
+	ActionUserReactivated        = "user.reactivated"
+	ActionUserImpersonated       = "user.impersonated"
+	ActionUserImpersonationEnded = "user.impersonation_ended"
+)
+
+// AuditLog records a privileged action performed by an actor against a target.
+type AuditLog struct {
+	*gorm.Model
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	ActorID   string    `gorm:"size:36;not null;index"`
+	Action    string    `gorm:"size:50;not null;index"`
+	TargetID  string    `gorm:"size:36;index"`
+	IPAddress string    `gorm:"size:45"`
+	Details   string    `gorm:"type:text"`
+}
+
+// TableName overrides the default table name used by GORM for the AuditLog model.
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+// BeforeCreate is a GORM hook that sets the ID field to a new UUID if it hasn't been set already.
+func (log *AuditLog) BeforeCreate(tx *gorm.DB) (err error) {
+	if log.ID == uuid.Nil {
+		log.ID = uuid.New()
+	}
+	return
+}
@@ -3,54 +3,121 @@ package auth
 import (
 	"errors"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
 	jwt "github.com/appleboy/gin-jwt/v2"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
+	gojwt "github.com/golang-jwt/jwt/v5"
 	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/internal/csrf"
+	"github.com/npushpakumara/go-backend-template/internal/features/apikey"
+	"github.com/npushpakumara/go-backend-template/internal/features/audit"
+	auditEntity "github.com/npushpakumara/go-backend-template/internal/features/audit/entity"
 	"github.com/npushpakumara/go-backend-template/internal/features/auth/dto"
+	"github.com/npushpakumara/go-backend-template/internal/features/email"
+	userDto "github.com/npushpakumara/go-backend-template/internal/features/user/dto"
+	userEntity "github.com/npushpakumara/go-backend-template/internal/features/user/entity"
+	"github.com/npushpakumara/go-backend-template/internal/idempotency"
 	"github.com/npushpakumara/go-backend-template/internal/postgres"
+	"github.com/npushpakumara/go-backend-template/internal/rbac"
 	"github.com/npushpakumara/go-backend-template/pkg"
 	apiError "github.com/npushpakumara/go-backend-template/pkg/errors"
+	"github.com/npushpakumara/go-backend-template/pkg/httpx"
 	"github.com/npushpakumara/go-backend-template/pkg/logging"
 )
 
+// apiPrefix is the prefix shared by every authentication route.
+const apiPrefix = "/api/v1"
+
+// verifyEmailPath is the route that activates an account. It is shared by the router and the
+// verification email so the link sent to users always points at a registered route.
+const verifyEmailPath = "/auth/verify-email"
+
+// Routes that start or renew a session.
+const (
+	signUpPath       = "/auth/sign-up"
+	signInPath       = "/auth/sign-in"
+	refreshTokenPath = "/auth/refresh-token"
+)
+
+// CSRFExemptRoutes are the routes a client may call before it holds a CSRF token, so they skip
+// the CSRF check even when an access_token cookie is present. A forged refresh only renews the
+// victim's own cookie, and it is how sessions from before CSRF protection obtain a token.
+var CSRFExemptRoutes = []string{apiPrefix + signUpPath, apiPrefix + signInPath, apiPrefix + refreshTokenPath}
+
 // Handler handles authentication-related requests
 type Handler struct {
-	authService Service
-	cfg         *config.Config // Configuration settings for the application
+	authService  Service
+	auditService audit.Service
+	cfg          *config.Config // Configuration settings for the application
 }
 
 // NewAuthHandler creates a new instance of Handler with the given Service
-func NewAuthHandler(authService Service, cfg *config.Config) *Handler {
-	return &Handler{authService, cfg}
+func NewAuthHandler(authService Service, auditService audit.Service, cfg *config.Config) *Handler {
+	return &Handler{authService, auditService, cfg}
 }
 
 // Router sets up the routes for authentication-related API endpoints
 // It groups the routes under "api/v1/auth" and assigns handler functions to the routes
-func Router(router *gin.Engine, handler *Handler, authMiddleware *jwt.GinJWTMiddleware) {
-	v1 := router.Group("api/v1")
+// Sign-up and forgot-password accept an Idempotency-Key header so retried requests don't create duplicate
+// users or send duplicate emails.
+func Router(router *gin.Engine, handler *Handler, authMiddleware *jwt.GinJWTMiddleware, apiKeyMiddleware *apikey.Middleware, idempotencyMiddleware *idempotency.Middleware) {
+	v1 := router.Group(apiPrefix)
 
 	v1.Use()
 	{
 		// User authentication and management
-		v1.POST("/auth/sign-up", handler.signUp)
-		v1.POST("/auth/sign-in", authMiddleware.LoginHandler)
+		v1.POST(signUpPath, idempotencyMiddleware.MiddlewareFunc(), handler.signUp)
+		v1.POST(signInPath, authMiddleware.LoginHandler)
 		v1.POST("/auth/sign-out", authMiddleware.LogoutHandler)
-		v1.POST("/auth/refresh-token", authMiddleware.RefreshHandler)
+		v1.POST(refreshTokenPath, authMiddleware.RefreshHandler)
 
 		// Account verification and email management
-		v1.GET("/auth/verify-email", handler.verifyUser)
+		v1.GET(verifyEmailPath, handler.verifyUser)
 		v1.POST("/auth/resend-verification-email", handler.reSendVerificationEmail)
 
-		// Password management
+		// Password reset for users who forgot their password
+		v1.POST("/auth/forgot-password", idempotencyMiddleware.MiddlewareFunc(), handler.forgotPassword)
 		v1.PUT("/auth/reset-password", handler.resetPassword)
 
+		// Invitations sent by administrators
+		v1.POST("/auth/accept-invite", handler.acceptInvite)
+
 		// OAuth handling
-		v1.GET("/oauth/:provider", OAuthMiddleware())
-		v1.GET("/oauth/:provider/callback", OAuthCallbackMiddleware(authMiddleware, handler.authService.HandleOAuthUser))
+		v1.GET("/auth/providers", handler.providers)
+		v1.GET("/oauth/:provider", OAuthMiddleware(&handler.cfg.Cookie))
+		v1.GET("/oauth/:provider/callback", OAuthCallbackMiddleware(authMiddleware, &handler.cfg.Cookie, handler.cfg.Server.LoginURL, handler.authService.HandleOAuthUser))
+	}
+
+	// Session introspection, only reachable with a valid access token
+	session := v1.Group("/auth")
+	session.Use(authMiddleware.MiddlewareFunc())
+	{
+		session.GET("/me", handler.me)
+		session.GET("/csrf-token", handler.csrfToken)
+		session.POST("/stop-impersonation", handler.stopImpersonation(authMiddleware))
+	}
+
+	// Identity introspection for both user sessions and API keys with the whoami scope
+	v1.GET("/auth/whoami", apiKeyMiddleware.MiddlewareFunc(), apikey.RequireScope(apikey.ScopeWhoAmI), handler.whoami)
+
+	// Password management for the signed-in user, which an impersonating administrator may not take over
+	me := v1.Group("/users/me")
+	me.Use(authMiddleware.MiddlewareFunc(), rbac.DenyImpersonation())
+	{
+		me.POST("/change-password", handler.changePassword(authMiddleware))
 	}
 
+	// User invitations and impersonation, only available to administrators
+	admin := router.Group("api/v1/admin")
+	admin.Use(authMiddleware.MiddlewareFunc(), rbac.RequireRole(userEntity.RoleAdmin))
+	{
+		admin.POST("/users/invite", handler.inviteUsers)
+		admin.POST("/users/:id/impersonate", pkg.RequireUUIDParams("id"), handler.impersonate(authMiddleware))
+	}
 }
 
 // signUpUser handles the user registration request
@@ -70,13 +137,47 @@ func (ah *Handler) signUp(ctx *gin.Context) {
 		return
 	}
 
+	// While sign-up is invite-only, a sign-up carrying an invitation accepts it instead of creating an account.
+	if ah.cfg.Auth.InviteOnly && requestBody.InviteToken != "" {
+		ah.signUpWithInvite(ctx, &requestBody)
+		return
+	}
+
 	// Call the Service to register the user
 	err := ah.authService.RegisterUser(ctx, &requestBody)
+	if errors.Is(err, email.ErrEmailNotDeliverable) {
+		ctx.JSON(http.StatusCreated, dto.SignUpResponseDto{Status: "success",
+			Message: "User has been registered, but we can't send email to this address yet. Please contact support to verify your email"})
+		return
+	}
+	if errors.Is(err, apiError.ErrVerificationEmailNotSent) {
+		ctx.JSON(http.StatusCreated, dto.SignUpResponseDto{Status: "success",
+			Message: "User has been registered, but the confirmation email could not be sent. Please request a new one"})
+		return
+	}
 	if err != nil {
-		if errors.Is(err, postgres.ErrKeyDuplicate) {
+		if errors.Is(err, apiError.ErrSignupClosed) {
+			ctx.JSON(http.StatusForbidden, apiError.ErrorResponse{Status: "error", Message: "Sign-up is closed"})
+			return
+		}
+		if errors.Is(err, apiError.ErrInvitationRequired) {
+			ctx.JSON(http.StatusForbidden, apiError.ErrorResponse{Status: "error", Message: "Sign-up requires an invitation"})
+			return
+		}
+		if errors.Is(err, apiError.ErrEmailAlreadyExists) {
 			ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "error", Message: "User already exist in the system", Errors: nil})
 			return
 		}
+		if errors.Is(err, apiError.ErrUsernameTaken) {
+			ctx.JSON(http.StatusConflict, apiError.ErrorResponse{Status: "error", Message: "Username is already taken",
+				Errors: pkg.NewValidationErrorDetails("username", "is already taken", requestBody.Username)})
+			return
+		}
+		if errors.Is(err, apiError.ErrUsernameNotEnabled) {
+			ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "error", Message: "Invalid request body",
+				Errors: pkg.NewValidationErrorDetails("username", "usernames are not enabled", requestBody.Username)})
+			return
+		}
 
 		ctx.JSON(http.StatusInternalServerError, apiError.ErrorResponse{Status: "error", Message: "Internal srver error", Errors: nil})
 		return
@@ -84,75 +185,207 @@ func (ah *Handler) signUp(ctx *gin.Context) {
 	ctx.JSON(http.StatusCreated, dto.SignUpResponseDto{Status: "success", Message: "User has been registered. Please check email for account confirmation"})
 }
 
+// signUpWithInvite signs up an invited user while sign-up is invite-only. The invitation already proves the email,
+// so the account is active straight away. Usernames can only be chosen when an account is created, so invited
+// users can't pick one, and they join the organization they were invited to rather than creating one.
+func (ah *Handler) signUpWithInvite(ctx *gin.Context, requestBody *dto.SignUpRequestDto) {
+	if requestBody.Username != "" {
+		ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "error", Message: "Invalid request body",
+			Errors: pkg.NewValidationErrorDetails("username", "can't be chosen when signing up with an invitation", requestBody.Username)})
+		return
+	}
+	if requestBody.OrganizationName != "" {
+		ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "error", Message: "Invalid request body",
+			Errors: pkg.NewValidationErrorDetails("organization_name", "can't be chosen when signing up with an invitation", requestBody.OrganizationName)})
+		return
+	}
+
+	_, err := ah.authService.RegisterInvitedUser(ctx, requestBody)
+	if err != nil {
+		if errors.Is(err, apiError.ErrSignupClosed) {
+			ctx.JSON(http.StatusForbidden, apiError.ErrorResponse{Status: "error", Message: "Sign-up is closed"})
+			return
+		}
+		respondAcceptInviteError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, dto.SignUpResponseDto{Status: "success", Message: "User has been registered. You can now sign in"})
+}
+
+// Values of the "error" query parameter added to the frontend redirect when verification fails.
+const (
+	verifyErrMissingToken = "missing_token"
+	verifyErrExpired      = "expired"
+	verifyErrInvalidToken = "invalid_token"
+	verifyErrNotFound     = "not_found"
+	verifyErrForbidden    = "forbidden"
+	verifyErrInternal     = "internal"
+)
+
+// Codes of a successful verification. They are reported as "code" in JSON responses, and an already verified
+// account also adds ?already_verified=true to the frontend redirect, so the frontend can tell the two apart.
+const (
+	verifyActivated       = "activated"
+	verifyAlreadyVerified = "already_verified"
+)
+
+// resendVerificationMessage answers every resend request that gets as far as the lookup, whether the account
+// doesn't exist, is already verified or was sent a new email, so the endpoint can't be used to probe for accounts.
+const resendVerificationMessage = "If the account exists and is not verified yet, a new verification email has been sent"
+
 // verifyUser handles the user verification request
 // It extracts the token from the query parameters and calls the authService to activate the user's account
+// Verification links are opened in a browser, so the outcome is reported by redirecting to the frontend
+// when server.frontend_url is set; API clients asking for application/json still get a JSON response
 func (ah *Handler) verifyUser(ctx *gin.Context) {
 	logger := logging.FromContext(ctx)
 
 	// Get the token from query parameters
-	token, ok := ctx.GetQuery("token")
-	if !ok {
-		logger.Error("auth.handler.VerifyUser failed to get token")
+	var query dto.VerifyEmailRequestDto
+	if _, err := pkg.BindQuery(ctx, &query); err != nil {
+		logger.Errorw("auth.handler.VerifyUser failed to get token: %v", err)
 
-		ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "failed", Message: "Missing or invalid token", Errors: nil})
+		ah.respondVerification(ctx, http.StatusBadRequest, verifyErrMissingToken, "Missing or invalid token")
 		return
 	}
 
 	// Call the Service to activate the account
-	id, err := ah.authService.ActivateAccount(ctx, token)
+	result, err := ah.authService.ActivateAccount(ctx, query.Token)
 	if err != nil {
 		if errors.Is(err, postgres.ErrRecordNotFound) {
-			ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "failed", Message: "User not found", Errors: nil})
+			ah.respondVerification(ctx, http.StatusBadRequest, verifyErrNotFound, "User not found")
+			return
+		}
+		if errors.Is(err, apiError.ErrAccountSuspended) || errors.Is(err, apiError.ErrAccountBanned) {
+			ah.respondVerification(ctx, http.StatusForbidden, verifyErrForbidden, "Account is not allowed to be activated")
+			return
+		}
+		if errors.Is(err, gojwt.ErrTokenExpired) {
+			ah.respondVerification(ctx, http.StatusBadRequest, verifyErrExpired, "Verification link has expired")
 			return
 		}
-		ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "failed", Message: "Missing or invalid token", Errors: nil})
+		ah.respondVerification(ctx, http.StatusBadRequest, verifyErrInvalidToken, "Missing or invalid token")
 		return
 	}
 
-	if id == "" {
+	if result.ID == "" {
 		logger.Error("auth.handler.VerifyUser failed to get user id")
-		ctx.JSON(http.StatusInternalServerError, apiError.ErrorResponse{Status: "failed", Message: "Internal server error"})
+		ah.respondVerification(ctx, http.StatusInternalServerError, verifyErrInternal, "Internal server error")
 		return
 	}
 
-	ctx.JSON(http.StatusOK, dto.SignUpResponseDto{Status: "success", Message: "Account activated"})
+	// Opening the link twice, e.g. by double-clicking it, is not an error.
+	if result.AlreadyActive {
+		ah.respondVerification(ctx, http.StatusOK, verifyAlreadyVerified, "Account already verified")
+		return
+	}
+	ah.respondVerification(ctx, http.StatusOK, verifyActivated, "Account activated")
+}
+
+// respondVerification reports the outcome of an account verification.
+// Browsers are redirected to the frontend with ?verified=true or ?error=<code>; requests that accept
+// application/json, or any request when no frontend URL is configured, get a JSON response with the given status.
+// A status below 400 means the verification succeeded and code is one of its success codes.
+func (ah *Handler) respondVerification(ctx *gin.Context, status int, code, message string) {
+	frontendURL := ah.cfg.Server.FrontendURL
+	wantsJSON := strings.Contains(ctx.GetHeader("Accept"), "application/json")
+	succeeded := status < http.StatusBadRequest
+
+	if frontendURL != "" && !wantsJSON {
+		target, err := url.Parse(frontendURL)
+		if err == nil {
+			query := target.Query()
+			if succeeded {
+				query.Set("verified", "true")
+				if code == verifyAlreadyVerified {
+					query.Set("already_verified", "true")
+				}
+			} else {
+				query.Set("error", code)
+			}
+			target.RawQuery = query.Encode()
+
+			ctx.Redirect(http.StatusFound, target.String())
+			return
+		}
+		logging.FromContext(ctx).Errorw("auth.handler.respondVerification invalid frontend url", "err", err)
+	}
+
+	if succeeded {
+		ctx.JSON(status, dto.VerificationResponseDto{Status: "success", Code: code, Message: message})
+		return
+	}
+	ctx.JSON(status, apiError.ErrorResponse{Status: "failed", Message: message, Errors: nil})
 }
 
 // reSendVerificationEmail handles the request to resend the account verification email to the user.
-// It expects the user's ID to be provided as a query parameter and performs the following steps:
+// It expects the user's ID or email to be provided as a query parameter and resends the email to inactive users.
+// Unknown, already verified and unverified accounts all get the same response, so it doesn't reveal which
+// accounts exist; only the unverified ones are sent an email.
 func (ah *Handler) reSendVerificationEmail(ctx *gin.Context) {
-	userID, ok := ctx.GetQuery("id")
-	if !ok {
-		ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "failed", Message: "Missing user id", Errors: nil})
+	logger := logging.FromContext(ctx)
+	var query dto.ResendVerificationRequestDto
+
+	if details, err := pkg.BindQuery(ctx, &query); err != nil {
+		logger.Errorw("auth.handler.reSendVerificationEmail failed to get query parameters: %v", err)
+		ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "failed", Message: "Invalid query parameters", Errors: details})
 		return
 	}
 
-	user, err := ah.authService.GetUserByID(ctx, userID)
-	if err != nil {
-		if errors.Is(err, postgres.ErrRecordNotFound) {
-			ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "failed", Message: "User not found", Errors: nil})
-			return
-		}
+	var user *userDto.UserResponseDto
+	var err error
+	if query.ID != "" {
+		user, err = ah.authService.GetUserByID(ctx, query.ID)
+	} else {
+		user, err = ah.authService.GetUserByEmail(ctx, query.Email)
+	}
+	if err != nil && !errors.Is(err, postgres.ErrRecordNotFound) {
 		ctx.JSON(http.StatusInternalServerError, apiError.ErrorResponse{Status: "failed", Message: "Internal server error", Errors: nil})
 		return
 	}
 
-	if user.IsActive {
-		ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "failed", Message: "User is already active", Errors: nil})
+	// Suspended and banned accounts can't be activated, so they aren't sent a link either.
+	if err == nil && !user.IsActive && user.Status != userEntity.StatusSuspended && user.Status != userEntity.StatusBanned {
+		if err := ah.authService.SendAccountVerificationEmail(ctx, user); err != nil {
+			logger.Errorw("auth.handler.reSendVerificationEmail failed to send verification email", "user_id", user.ID, "err", err)
+		}
+	}
+
+	ctx.JSON(http.StatusOK, dto.SignUpResponseDto{Status: "success", Message: resendVerificationMessage})
+}
+
+// forgotPasswordMessage is the response to every valid forgot-password request, whether or not an email was sent.
+const forgotPasswordMessage = "If an account with this email can sign in with a password, a password reset email has been sent"
+
+// forgotPassword handles the request of a user who forgot their password to be emailed a reset link.
+// It expects a JSON body containing the user's email. Unknown and registered emails get the same response,
+// so it doesn't reveal which accounts exist.
+func (ah *Handler) forgotPassword(ctx *gin.Context) {
+	logger := logging.FromContext(ctx)
+	var requestBody dto.ForgotPasswordRequestDto
+
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		logger.Errorw("auth.handler.forgotPassword failed to get request body: v", err)
+		var details []*pkg.ValidationErrDetail
+		if vErrs, ok := err.(validator.ValidationErrors); ok {
+			details = pkg.ValidationErrorDetails(&requestBody, "json", vErrs)
+		}
+		ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "error", Message: "Invalid request body", Errors: details})
 		return
 	}
 
-	err = ah.authService.SendAccountVerificationEmail(ctx, user)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, apiError.ErrorResponse{Status: "failed", Message: "Internal server error", Errors: nil})
+	if err := ah.authService.RequestPasswordReset(ctx, requestBody.Email); err != nil {
+		ctx.JSON(http.StatusInternalServerError, apiError.ErrorResponse{Status: "failed", Message: "Internal server error"})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, dto.SignUpResponseDto{Status: "success", Message: "Email has been sent"})
+	ctx.JSON(http.StatusOK, dto.SignUpResponseDto{Status: "success", Message: forgotPasswordMessage})
 }
 
 // resetPassword handles the request to reset a user's password.
-// It expects a JSON body containing the user's current password and the new password.
+// It expects a JSON body containing the token from the password reset email and the new password.
+// Every session of the user is signed out, so they sign in again with the new password.
 func (ah *Handler) resetPassword(ctx *gin.Context) {
 	logger := logging.FromContext(ctx)
 	var requestBody dto.PasswordResetRequestDto
@@ -169,13 +402,410 @@ func (ah *Handler) resetPassword(ctx *gin.Context) {
 
 	err := ah.authService.ResetPassword(ctx, &requestBody)
 	if err != nil {
-		if errors.Is(err, apiError.ErrIncorrectPassword) {
-			ctx.JSON(http.StatusUnauthorized, apiError.ErrorResponse{Status: "error", Message: "Invalid current password"})
+		if errors.Is(err, apiError.ErrPasswordReused) {
+			ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "error", Message: "New password must be different from your current and previous passwords",
+				Errors: pkg.NewValidationErrorDetails("new_password", "must differ from previous passwords", nil)})
+			return
+		}
+		if errors.Is(err, apiError.ErrPasswordBreached) {
+			ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "error", Message: "New password has appeared in a data breach, please choose another",
+				Errors: pkg.NewValidationErrorDetails("new_password", "password found in a data breach", nil)})
+			return
+		}
+		if errors.Is(err, apiError.ErrAccountSuspended) || errors.Is(err, apiError.ErrAccountBanned) || errors.Is(err, apiError.ErrAccountNotActive) {
+			ctx.JSON(http.StatusForbidden, apiError.ErrorResponse{Status: "error", Message: "Account is not allowed to reset its password"})
+			return
+		}
+		if errors.Is(err, postgres.ErrRecordNotFound) || errors.Is(err, apiError.ErrInvalidToken) || errors.Is(err, gojwt.ErrTokenExpired) ||
+			errors.Is(err, gojwt.ErrTokenMalformed) || errors.Is(err, gojwt.ErrTokenSignatureInvalid) {
+			ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "error", Message: "Invalid or expired password reset link"})
 			return
 		}
 		ctx.JSON(http.StatusInternalServerError, apiError.ErrorResponse{Status: "failed", Message: "Internal server error"})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, dto.SignUpResponseDto{Status: "success", Message: "Password updated successfully"})
+	ctx.JSON(http.StatusOK, dto.SignUpResponseDto{Status: "success", Message: "Password has been reset. You can now sign in"})
+}
+
+// changePassword handles a signed-in user's request to change their password.
+// It expects a JSON body containing the user's current password and the new password.
+// Every other session is signed out, while the current client is issued a fresh access token.
+func (ah *Handler) changePassword(authMiddleware *jwt.GinJWTMiddleware) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		logger := logging.FromContext(ctx)
+		var requestBody dto.ChangePasswordRequestDto
+
+		if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+			logger.Errorw("auth.handler.changePassword failed to get request body: v", err)
+			var details []*pkg.ValidationErrDetail
+			if vErrs, ok := err.(validator.ValidationErrors); ok {
+				details = pkg.ValidationErrorDetails(&requestBody, "json", vErrs)
+			}
+			ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "error", Message: "Invalid request body", Errors: details})
+			return
+		}
+
+		user, ok := rbac.CurrentIdentity(ctx)
+		if !ok {
+			ctx.JSON(http.StatusUnauthorized, apiError.ErrorResponse{Status: "error", Message: "Unauthorized"})
+			return
+		}
+
+		err := ah.authService.ChangePassword(ctx, user.ID, &requestBody)
+		if err != nil {
+			if errors.Is(err, apiError.ErrIncorrectPassword) {
+				ctx.JSON(http.StatusUnauthorized, apiError.ErrorResponse{Status: "error", Message: "Invalid current password"})
+				return
+			}
+			if errors.Is(err, apiError.ErrPasswordReused) {
+				ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "error", Message: "New password must be different from the current and recent passwords",
+					Errors: pkg.NewValidationErrorDetails("new_password", "must not match a recently used password", nil)})
+				return
+			}
+			if errors.Is(err, apiError.ErrPasswordBreached) {
+				ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "error", Message: "New password has appeared in a data breach, please choose another",
+					Errors: pkg.NewValidationErrorDetails("new_password", "password found in a data breach", nil)})
+				return
+			}
+			ctx.JSON(http.StatusInternalServerError, apiError.ErrorResponse{Status: "failed", Message: "Internal server error"})
+			return
+		}
+
+		// Changing the password revoked every session, including this one, so keep the caller signed in with a new token.
+		token, expires, err := authMiddleware.TokenGenerator(&userDto.UserResponseDto{ID: user.ID, OrgID: user.OrgID})
+		if err != nil {
+			logger.Errorw("auth.handler.changePassword failed to issue a new access token", "err", err)
+			ctx.JSON(http.StatusOK, dto.SignUpResponseDto{Status: "success", Message: "Password updated successfully, please sign in again"})
+			return
+		}
+		httpx.SetCookie(ctx.Writer, AccessTokenCookie, token, int(time.Until(expires).Seconds()), ah.cfg.Cookie.Options())
+
+		ctx.JSON(http.StatusOK, dto.SignUpResponseDto{Status: "success", Message: "Password updated successfully"})
+	}
+}
+
+// inviteUsers handles an administrator's request to invite a list of people.
+// It always answers 200 with the outcome of each invitation, since some may succeed while others fail.
+func (ah *Handler) inviteUsers(ctx *gin.Context) {
+	logger := logging.FromContext(ctx)
+	var requestBody dto.InviteUsersRequestDto
+
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		logger.Errorw("auth.handler.inviteUsers failed to get request body: v", err)
+		var details []*pkg.ValidationErrDetail
+		if vErrs, ok := err.(validator.ValidationErrors); ok {
+			details = pkg.ValidationErrorDetails(&requestBody, "json", vErrs)
+		}
+		ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "error", Message: "Invalid request body", Errors: details})
+		return
+	}
+
+	resp, err := ah.authService.InviteUsers(ctx, &requestBody)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, apiError.ErrorResponse{Status: "error", Message: "Internal server error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// acceptInvite handles an invited user's request to set their name and password using the token from their invitation.
+func (ah *Handler) acceptInvite(ctx *gin.Context) {
+	logger := logging.FromContext(ctx)
+	var requestBody dto.AcceptInviteRequestDto
+
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		logger.Errorw("auth.handler.acceptInvite failed to get request body: v", err)
+		var details []*pkg.ValidationErrDetail
+		if vErrs, ok := err.(validator.ValidationErrors); ok {
+			details = pkg.ValidationErrorDetails(&requestBody, "json", vErrs)
+		}
+		ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "error", Message: "Invalid request body", Errors: details})
+		return
+	}
+
+	_, err := ah.authService.AcceptInvite(ctx, &requestBody)
+	if err != nil {
+		respondAcceptInviteError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.SignUpResponseDto{Status: "success", Message: "Invitation accepted. You can now sign in"})
+}
+
+// respondAcceptInviteError reports why an invitation couldn't be accepted, through either accept-invite or sign-up.
+func respondAcceptInviteError(ctx *gin.Context, err error) {
+	if errors.Is(err, apiError.ErrInvitationNotPending) {
+		ctx.JSON(http.StatusConflict, apiError.ErrorResponse{Status: "error", Message: "Invitation has already been accepted"})
+		return
+	}
+	if errors.Is(err, apiError.ErrAccountSuspended) || errors.Is(err, apiError.ErrAccountBanned) {
+		ctx.JSON(http.StatusForbidden, apiError.ErrorResponse{Status: "error", Message: "Account is not allowed to be activated"})
+		return
+	}
+	if errors.Is(err, apiError.ErrPasswordBreached) {
+		ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "error", Message: "Password has appeared in a data breach, please choose another",
+			Errors: pkg.NewValidationErrorDetails("password", "password found in a data breach", nil)})
+		return
+	}
+	if errors.Is(err, postgres.ErrRecordNotFound) || errors.Is(err, apiError.ErrInvalidToken) || errors.Is(err, gojwt.ErrTokenExpired) ||
+		errors.Is(err, gojwt.ErrTokenMalformed) || errors.Is(err, gojwt.ErrTokenSignatureInvalid) {
+		ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "error", Message: "Invalid or expired invitation"})
+		return
+	}
+	ctx.JSON(http.StatusInternalServerError, apiError.ErrorResponse{Status: "error", Message: "Internal server error"})
+}
+
+// impersonate handles an administrator's request to act as another user, so that support staff can reproduce the
+// user's issues. The administrator's session is replaced by a short-lived session for the user whose token carries
+// an act claim naming the administrator; POST /api/v1/auth/stop-impersonation returns to the administrator's identity.
+// Other administrators can't be impersonated, and the impersonation is refused unless it is written to the audit log.
+func (ah *Handler) impersonate(authMiddleware *jwt.GinJWTMiddleware) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		logger := logging.FromContext(ctx)
+		userID := ctx.Param("id")
+
+		admin, ok := rbac.CurrentIdentity(ctx)
+		if !ok {
+			ctx.JSON(http.StatusUnauthorized, apiError.ErrorResponse{Status: "error", Message: "Unauthorized"})
+			return
+		}
+
+		user, err := ah.authService.GetUserByID(ctx, userID)
+		if err != nil {
+			if errors.Is(err, postgres.ErrRecordNotFound) {
+				ctx.JSON(http.StatusNotFound, apiError.ErrorResponse{Status: "error", Message: "User not found"})
+				return
+			}
+			ctx.JSON(http.StatusInternalServerError, apiError.ErrorResponse{Status: "error", Message: "Internal server error"})
+			return
+		}
+
+		if user.Role == userEntity.RoleAdmin {
+			ctx.JSON(http.StatusForbidden, apiError.ErrorResponse{Status: "error", Message: "Administrators cannot be impersonated"})
+			return
+		}
+		if user.Status == userEntity.StatusSuspended || user.Status == userEntity.StatusBanned {
+			ctx.JSON(http.StatusConflict, apiError.ErrorResponse{Status: "error", Message: "Suspended or banned users cannot be impersonated"})
+			return
+		}
+
+		token, expires, err := ah.impersonationToken(authMiddleware, &dto.ImpersonationDto{UserID: user.ID, OrgID: user.OrgID, ActorID: admin.ID})
+		if err != nil {
+			logger.Errorw("auth.handler.impersonate failed to issue an access token", "err", err)
+			ctx.JSON(http.StatusInternalServerError, apiError.ErrorResponse{Status: "error", Message: "Internal server error"})
+			return
+		}
+
+		err = ah.auditService.Record(ctx, audit.Entry{
+			ActorID:   admin.ID,
+			Action:    auditEntity.ActionUserImpersonated,
+			TargetID:  user.ID,
+			IPAddress: ctx.ClientIP(),
+			Details:   map[string]interface{}{"expires_at": expires.UTC()},
+		})
+		if err != nil {
+			logger.Errorw("auth.handler.impersonate failed to record audit entry", "err", err)
+			ctx.JSON(http.StatusInternalServerError, apiError.ErrorResponse{Status: "error", Message: "Internal server error"})
+			return
+		}
+
+		httpx.SetCookie(ctx.Writer, AccessTokenCookie, token, int(time.Until(expires).Seconds()), ah.cfg.Cookie.Options())
+		if _, err := csrf.Issue(ctx, &ah.cfg.Cookie); err != nil {
+			logger.Errorw("auth.handler.impersonate failed to issue CSRF token: %v", err)
+		}
+
+		ctx.JSON(http.StatusOK, dto.ImpersonationResponseDto{
+			Status:    "success",
+			Message:   "Impersonating user",
+			UserID:    user.ID,
+			ExpiresAt: expires.UTC(),
+		})
+	}
+}
+
+// impersonationToken signs the access token of an impersonated session. TokenGenerator gives every token
+// the access token lifetime, so the token is signed here with the middleware's key to expire sooner.
+func (ah *Handler) impersonationToken(authMiddleware *jwt.GinJWTMiddleware, data *dto.ImpersonationDto) (string, time.Time, error) {
+	now := time.Now()
+	expires := now.Add(ah.cfg.JWT.ImpersonationTokenExpiry)
+
+	claims := gojwt.MapClaims(authMiddleware.PayloadFunc(data))
+	claims["exp"] = expires.Unix()
+	claims["orig_iat"] = now.Unix()
+
+	token, err := gojwt.NewWithClaims(gojwt.GetSigningMethod(authMiddleware.SigningAlgorithm), claims).SignedString(authMiddleware.Key)
+	return token, expires, err
+}
+
+// stopImpersonation ends an impersonated session and signs the administrator back in as themselves.
+// The administrator is checked again, so one who has since lost the admin role, been suspended or banned,
+// or had their sessions revoked is signed out instead.
+func (ah *Handler) stopImpersonation(authMiddleware *jwt.GinJWTMiddleware) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		logger := logging.FromContext(ctx)
+
+		actorID, ok := rbac.Impersonator(ctx)
+		if !ok {
+			ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "error", Message: "Session is not impersonating a user"})
+			return
+		}
+		user, _ := rbac.CurrentIdentity(ctx)
+
+		// The administrator may belong to no organization, or another one, than the user the session is scoped to.
+		admin, err := ah.authService.GetUserByID(rbac.WithoutOrg(ctx), actorID)
+		if err != nil && !errors.Is(err, postgres.ErrRecordNotFound) {
+			ctx.JSON(http.StatusInternalServerError, apiError.ErrorResponse{Status: "error", Message: "Internal server error"})
+			return
+		}
+		if err != nil || !canResumeAdminSession(ctx, admin) {
+			httpx.SetCookie(ctx.Writer, AccessTokenCookie, "", -1, ah.cfg.Cookie.Options())
+			csrf.Clear(ctx, &ah.cfg.Cookie)
+			ctx.JSON(http.StatusUnauthorized, apiError.ErrorResponse{Status: "error", Message: "Impersonation ended, please sign in again"})
+			return
+		}
+
+		token, expires, err := authMiddleware.TokenGenerator(&userDto.UserResponseDto{ID: admin.ID, OrgID: admin.OrgID})
+		if err != nil {
+			logger.Errorw("auth.handler.stopImpersonation failed to issue an access token", "err", err)
+			ctx.JSON(http.StatusInternalServerError, apiError.ErrorResponse{Status: "error", Message: "Internal server error"})
+			return
+		}
+		httpx.SetCookie(ctx.Writer, AccessTokenCookie, token, int(time.Until(expires).Seconds()), ah.cfg.Cookie.Options())
+		if _, err := csrf.Issue(ctx, &ah.cfg.Cookie); err != nil {
+			logger.Errorw("auth.handler.stopImpersonation failed to issue CSRF token: %v", err)
+		}
+
+		// The administrator is already back in their own session, so a failure to record it is only logged.
+		err = ah.auditService.Record(ctx, audit.Entry{
+			ActorID:   admin.ID,
+			Action:    auditEntity.ActionUserImpersonationEnded,
+			TargetID:  user.ID,
+			IPAddress: ctx.ClientIP(),
+		})
+		if err != nil {
+			logger.Errorw("auth.handler.stopImpersonation failed to record audit entry", "err", err)
+		}
+
+		ctx.JSON(http.StatusOK, apiError.ErrorResponse{Status: "success", Message: "Impersonation ended"})
+	}
+}
+
+// canResumeAdminSession reports whether the administrator behind an impersonated session may be signed back in:
+// they must still be an active administrator whose sessions haven't been revoked since the impersonation started.
+func canResumeAdminSession(ctx *gin.Context, admin *userDto.UserResponseDto) bool {
+	if admin.Role != userEntity.RoleAdmin || admin.Status == userEntity.StatusSuspended || admin.Status == userEntity.StatusBanned {
+		return false
+	}
+	if admin.SessionsRevokedAt != nil {
+		startedAt, ok := jwt.ExtractClaims(ctx)[rbac.AuthTimeKey].(float64)
+		if !ok || int64(startedAt) < admin.SessionsRevokedAt.Unix() {
+			return false
+		}
+	}
+	return true
+}
+
+// me handles the token introspection request
+// It reports the identity and expiry of the access token used to make the request,
+// which helps clients debug session issues without a separate user lookup
+func (ah *Handler) me(ctx *gin.Context) {
+	logger := logging.FromContext(ctx)
+
+	// The JWT middleware has already validated the token and loaded the user behind it
+	user, ok := rbac.CurrentIdentity(ctx)
+	if !ok {
+		logger.Errorw("auth.handler.me no identity found for a validated token")
+		ctx.JSON(http.StatusUnauthorized, apiError.ErrorResponse{Status: "error", Message: "Unauthorized"})
+		return
+	}
+
+	expiresAt, ok := tokenExpiry(ctx)
+	if !ok {
+		logger.Errorw("auth.handler.me token has no expiry claim")
+		ctx.JSON(http.StatusUnauthorized, apiError.ErrorResponse{Status: "error", Message: "Unauthorized"})
+		return
+	}
+
+	impersonatorID, _ := rbac.Impersonator(ctx)
+	ctx.JSON(http.StatusOK, dto.MeResponseDto{
+		ID:             user.ID,
+		Email:          user.Email,
+		Role:           user.Role,
+		OrgID:          user.OrgID,
+		ImpersonatorID: impersonatorID,
+		ExpiresAt:      expiresAt,
+	})
+}
+
+// whoami reports the principal behind the request, so that service clients can introspect their API key
+// the same way users introspect their session. API keys are checked first, as the middleware does.
+func (ah *Handler) whoami(ctx *gin.Context) {
+	logger := logging.FromContext(ctx)
+
+	if apiKey, ok := apikey.CurrentAPIKey(ctx); ok {
+		ctx.JSON(http.StatusOK, dto.WhoAmIResponseDto{
+			Type:    dto.PrincipalAPIKey,
+			ID:      apiKey.ID,
+			Scopes:  apiKey.Scopes,
+			OwnerID: apiKey.OwnerID,
+		})
+		return
+	}
+
+	user, ok := rbac.CurrentIdentity(ctx)
+	if !ok {
+		logger.Errorw("auth.handler.whoami no identity found for an authenticated request")
+		ctx.JSON(http.StatusUnauthorized, apiError.ErrorResponse{Status: "error", Message: "Unauthorized"})
+		return
+	}
+
+	expiresAt, ok := tokenExpiry(ctx)
+	if !ok {
+		logger.Errorw("auth.handler.whoami token has no expiry claim")
+		ctx.JSON(http.StatusUnauthorized, apiError.ErrorResponse{Status: "error", Message: "Unauthorized"})
+		return
+	}
+
+	impersonatorID, _ := rbac.Impersonator(ctx)
+	ctx.JSON(http.StatusOK, dto.WhoAmIResponseDto{
+		Type:           dto.PrincipalUser,
+		ID:             user.ID,
+		Role:           user.Role,
+		ImpersonatorID: impersonatorID,
+		ExpiresAt:      &expiresAt,
+	})
+}
+
+// tokenExpiry returns the expiry of the access token that authenticated the request.
+func tokenExpiry(ctx *gin.Context) (time.Time, bool) {
+	exp, ok := jwt.ExtractClaims(ctx)["exp"].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(exp), 0).UTC(), true
+}
+
+// csrfToken returns the session's CSRF token for clients that can't read the csrf_token cookie,
+// issuing a new one if the session doesn't have one yet.
+func (ah *Handler) csrfToken(ctx *gin.Context) {
+	logger := logging.FromContext(ctx)
+
+	token, err := ctx.Cookie(csrf.CookieName)
+	if err != nil || token == "" {
+		token, err = csrf.Issue(ctx, &ah.cfg.Cookie)
+		if err != nil {
+			logger.Errorw("auth.handler.csrfToken failed to issue CSRF token: %v", err)
+			ctx.JSON(http.StatusInternalServerError, apiError.ErrorResponse{Status: "error", Message: "Internal server error"})
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, dto.CSRFTokenResponseDto{CSRFToken: token})
+}
+
+// providers lists the supported OAuth providers, whether each is enabled and the URL that starts its sign-in flow.
+func (ah *Handler) providers(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, listOAuthProviders(ah.cfg))
 }
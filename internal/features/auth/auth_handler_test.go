@@ -0,0 +1,341 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/internal/features/apikey"
+	apiKeyDto "github.com/npushpakumara/go-backend-template/internal/features/apikey/dto"
+	"github.com/npushpakumara/go-backend-template/internal/features/auth/dto"
+	userDto "github.com/npushpakumara/go-backend-template/internal/features/user/dto"
+	userEntity "github.com/npushpakumara/go-backend-template/internal/features/user/entity"
+	"github.com/npushpakumara/go-backend-template/internal/idempotency"
+	"github.com/npushpakumara/go-backend-template/internal/rbac"
+	"github.com/npushpakumara/go-backend-template/pkg"
+	apiError "github.com/npushpakumara/go-backend-template/pkg/errors"
+)
+
+// recordingResets is a Service that records the emails password resets are requested for.
+type recordingResets struct {
+	Service
+	emails []string
+}
+
+func (r *recordingResets) RequestPasswordReset(_ context.Context, email string) error {
+	r.emails = append(r.emails, email)
+	return nil
+}
+
+// newAuthRouter serves the authentication routes with the given service. The JWT and API key
+// middlewares accept no credentials.
+func newAuthRouter(t *testing.T, service Service) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{}
+	cfg.Server.IdempotencyTTL = time.Hour
+	jwtMiddleware, err := jwt.New(&jwt.GinJWTMiddleware{Realm: "test", Key: []byte("test-secret")})
+	if err != nil {
+		t.Fatalf("jwt.New() error = %v", err)
+	}
+
+	router := gin.New()
+	Router(router, NewAuthHandler(service, nil, cfg), jwtMiddleware, apikey.NewAPIKeyMiddleware(nil, nil, jwtMiddleware), idempotency.NewIdempotencyMiddleware(cfg))
+	return router
+}
+
+// A forgot-password request retried with the same Idempotency-Key sends a single reset email.
+func TestForgotPasswordIsIdempotent(t *testing.T) {
+	service := &recordingResets{}
+	router := newAuthRouter(t, service)
+
+	for i := range 2 {
+		req := httptest.NewRequest(http.MethodPost, apiPrefix+"/auth/forgot-password", strings.NewReader(`{"email":"ada@example.com"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(idempotency.Header, "reset-1")
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("request %d status = %d, want %d", i+1, rec.Code, http.StatusOK)
+		}
+		if replayed := rec.Header().Get(idempotency.ReplayedHeader) != ""; replayed != (i == 1) {
+			t.Errorf("request %d replayed = %v, want %v", i+1, replayed, i == 1)
+		}
+	}
+
+	if len(service.emails) != 1 {
+		t.Errorf("RequestPasswordReset() called %d times, want 1", len(service.emails))
+	}
+}
+
+// newInviteRouter serves the invite handler of the test auth service without the administrator check.
+func newInviteRouter(ta *testAuth) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.POST("/invites", NewAuthHandler(ta.service, nil, ta.cfg).inviteUsers)
+	return router
+}
+
+// postInvites sends the invite request body and decodes the response.
+func postInvites(t *testing.T, router *gin.Engine, body string) (int, dto.InviteUsersResponseDto) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/invites", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	var resp dto.InviteUsersResponseDto
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode response %q: %v", rec.Body.String(), err)
+		}
+	}
+	return rec.Code, resp
+}
+
+// Each invitation in a batch is reported on its own, so one that fails doesn't fail the others.
+func TestInviteUsersReportsEachOutcome(t *testing.T) {
+	ta := newTestAuth(t)
+	ta.createUser(t, "active@example.com", userEntity.StatusActive, "", "")
+	pending, err := ta.repo.Insert(context.Background(), &userEntity.User{Email: "pending@example.com", Status: userEntity.StatusPending, Role: userEntity.RoleUser})
+	if err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+
+	code, resp := postInvites(t, newInviteRouter(ta), `{"invites":[{"email":"new@example.com"},{"email":"active@example.com"},{"email":"pending@example.com"}]}`)
+	if code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", code, http.StatusOK)
+	}
+
+	want := []dto.InviteResultDto{
+		{Email: "new@example.com", Status: dto.InviteStatusInvited},
+		{Email: "active@example.com", Status: dto.InviteStatusFailed, Error: "User is already active"},
+		{Email: "pending@example.com", Status: dto.InviteStatusResent, UserID: pending.ID.String()},
+	}
+	if len(resp.Results) != len(want) {
+		t.Fatalf("results = %+v, want %d results", resp.Results, len(want))
+	}
+	for i, got := range resp.Results {
+		// The new account's ID isn't known in advance.
+		if i == 0 {
+			got.UserID = ""
+		}
+		if got != want[i] {
+			t.Errorf("result %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+	if sent := ta.emails.Sent(); len(sent) != 2 {
+		t.Errorf("sent %d invitation emails, want 2", len(sent))
+	}
+}
+
+// A batch with an invalid invitation is refused as a whole, before anyone is invited.
+func TestInviteUsersRejectsInvalidBatch(t *testing.T) {
+	ta := newTestAuth(t)
+
+	code, _ := postInvites(t, newInviteRouter(ta), `{"invites":[{"email":"new@example.com"},{"email":"not-an-email"}]}`)
+	if code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", code, http.StatusBadRequest)
+	}
+	if users := ta.repo.Users(); len(users) != 0 {
+		t.Errorf("repository has %d users, want none", len(users))
+	}
+	if sent := ta.emails.Sent(); len(sent) != 0 {
+		t.Errorf("sent %d invitation emails, want none", len(sent))
+	}
+}
+
+// signUpBody returns a valid sign-up request body for the email, carrying the invite token when it isn't empty.
+func signUpBody(t *testing.T, address, inviteToken string) string {
+	t.Helper()
+
+	body, err := json.Marshal(dto.SignUpRequestDto{
+		FirstName: "Ada", LastName: "Lovelace", Email: address, Password: "Battery-Staple-7",
+		PhoneNumber: "+14155550100", InviteToken: inviteToken,
+	})
+	if err != nil {
+		t.Fatalf("encode sign-up request: %v", err)
+	}
+	return string(body)
+}
+
+func TestSignUpFollowsSignUpSettings(t *testing.T) {
+	if err := pkg.RegisterValidations(); err != nil {
+		t.Fatalf("RegisterValidations() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		allowSignup bool
+		inviteOnly  bool
+		invited     string
+		email       string
+		want        int
+		wantActive  bool
+	}{
+		{name: "open", allowSignup: true, email: "ada@example.com", want: http.StatusCreated},
+		{name: "closed", email: "ada@example.com", want: http.StatusForbidden},
+		{name: "closed with an invitation", inviteOnly: true, invited: "ada@example.com", email: "ada@example.com", want: http.StatusForbidden},
+		{name: "invite-only without an invitation", allowSignup: true, inviteOnly: true, email: "ada@example.com", want: http.StatusForbidden},
+		{name: "invite-only with an invitation", allowSignup: true, inviteOnly: true, invited: "ada@example.com", email: "ada@example.com", want: http.StatusCreated, wantActive: true},
+		{name: "invite-only with another email's invitation", allowSignup: true, inviteOnly: true, invited: "grace@example.com", email: "ada@example.com", want: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ta := newTestAuth(t)
+			var inviteToken string
+			if tt.invited != "" {
+				_, inviteToken = ta.inviteUser(t, tt.invited)
+			}
+			ta.cfg.Auth.AllowSignup = tt.allowSignup
+			ta.cfg.Auth.InviteOnly = tt.inviteOnly
+
+			router := gin.New()
+			router.POST("/sign-up", NewAuthHandler(ta.service, nil, ta.cfg).signUp)
+			req := httptest.NewRequest(http.MethodPost, "/sign-up", strings.NewReader(signUpBody(t, tt.email, inviteToken)))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+
+			// An invited account exists before the sign-up, but only an accepted invitation activates it.
+			stored, err := ta.repo.FindByEmail(context.Background(), tt.email)
+			wantExists := tt.want == http.StatusCreated || tt.invited == tt.email
+			if exists := err == nil; exists != wantExists {
+				t.Fatalf("account exists = %v, want %v", exists, wantExists)
+			}
+			if err == nil && stored.IsActive() != tt.wantActive {
+				t.Errorf("account status = %s, want active %v", stored.Status, tt.wantActive)
+			}
+		})
+	}
+}
+
+// stubKeys is an apikey.Service that authenticates the keys in its map and rejects every other key.
+type stubKeys struct {
+	apikey.Service
+	keys map[string]*apiKeyDto.APIKeyResponseDto
+}
+
+func (s stubKeys) Authenticate(_ context.Context, key string) (*apiKeyDto.APIKeyResponseDto, error) {
+	apiKey, ok := s.keys[key]
+	if !ok {
+		return nil, apiError.ErrInvalidAPIKey
+	}
+	return apiKey, nil
+}
+
+// stubOwners is an apikey.Owners that finds every user, active.
+type stubOwners struct{}
+
+func (stubOwners) GetUserByID(_ context.Context, userID string) (*userDto.UserResponseDto, error) {
+	return &userDto.UserResponseDto{ID: userID, Status: userEntity.StatusActive}, nil
+}
+
+// newWhoAmIRouter serves whoami behind API keys for the given keys and a JWT middleware that accepts
+// any token it signed as a session of a user with the "user" role. It returns a token for user-1.
+func newWhoAmIRouter(t *testing.T, keys map[string]*apiKeyDto.APIKeyResponseDto) (*gin.Engine, string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	jwtMiddleware, err := jwt.New(&jwt.GinJWTMiddleware{
+		Realm:       "test",
+		Key:         []byte("test-secret"),
+		Timeout:     time.Hour,
+		TokenLookup: "cookie:" + AccessTokenCookie,
+		PayloadFunc: func(data interface{}) jwt.MapClaims {
+			return jwt.MapClaims{rbac.IdentityKey: data.(*userDto.UserResponseDto).ID}
+		},
+		Authorizator: func(_ interface{}, c *gin.Context) bool {
+			rbac.SetIdentity(c, &userDto.UserResponseDto{ID: jwt.ExtractClaims(c)[rbac.IdentityKey].(string), Role: userEntity.RoleUser})
+			return true
+		},
+	})
+	if err != nil {
+		t.Fatalf("jwt.New() error = %v", err)
+	}
+	token, _, err := jwtMiddleware.TokenGenerator(&userDto.UserResponseDto{ID: "user-1"})
+	if err != nil {
+		t.Fatalf("TokenGenerator() error = %v", err)
+	}
+
+	cfg := &config.Config{}
+	cfg.Server.IdempotencyTTL = time.Hour
+	router := gin.New()
+	// whoami doesn't use the service, but Router needs one to bind the OAuth callback to.
+	Router(router, NewAuthHandler(struct{ Service }{}, nil, cfg), jwtMiddleware, apikey.NewAPIKeyMiddleware(stubKeys{keys: keys}, stubOwners{}, jwtMiddleware), idempotency.NewIdempotencyMiddleware(cfg))
+	return router, token
+}
+
+func TestWhoAmI(t *testing.T) {
+	router, session := newWhoAmIRouter(t, map[string]*apiKeyDto.APIKeyResponseDto{
+		"ak_whoami": {ID: "key-1", OwnerID: "user-1", Scopes: []string{apikey.ScopeWhoAmI}},
+		"ak_reader": {ID: "key-2", OwnerID: "user-1", Scopes: []string{apikey.ScopeUsersRead}},
+	})
+
+	tests := []struct {
+		name    string
+		session string
+		apiKey  string
+		want    int
+		wantDto dto.WhoAmIResponseDto
+	}{
+		{name: "session", session: session, want: http.StatusOK, wantDto: dto.WhoAmIResponseDto{Type: dto.PrincipalUser, ID: "user-1", Role: userEntity.RoleUser}},
+		{name: "API key with the whoami scope", apiKey: "ak_whoami", want: http.StatusOK,
+			wantDto: dto.WhoAmIResponseDto{Type: dto.PrincipalAPIKey, ID: "key-1", Scopes: []string{apikey.ScopeWhoAmI}, OwnerID: "user-1"}},
+		{name: "API key without the whoami scope", apiKey: "ak_reader", want: http.StatusForbidden},
+		{name: "unknown API key", apiKey: "ak_unknown", want: http.StatusUnauthorized},
+		{name: "no credentials", want: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, apiPrefix+"/auth/whoami", nil)
+			if tt.session != "" {
+				req.AddCookie(&http.Cookie{Name: AccessTokenCookie, Value: tt.session})
+			}
+			if tt.apiKey != "" {
+				req.Header.Set("X-API-Key", tt.apiKey)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.want != http.StatusOK {
+				return
+			}
+
+			var got dto.WhoAmIResponseDto
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("decode response %q: %v", rec.Body.String(), err)
+			}
+			// Sessions expire with their access token; API keys never do.
+			if hasExpiry := got.ExpiresAt != nil; hasExpiry != (tt.wantDto.Type == dto.PrincipalUser) {
+				t.Errorf("expires_at = %v, want it set only for sessions", got.ExpiresAt)
+			}
+			got.ExpiresAt = nil
+			if !reflect.DeepEqual(got, tt.wantDto) {
+				t.Errorf("whoami = %+v, want %+v", got, tt.wantDto)
+			}
+		})
+	}
+}
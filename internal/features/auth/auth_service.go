@@ -4,7 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"time"
+	"net/url"
+	"strings"
 
 	"github.com/markbates/goth"
 	"github.com/npushpakumara/go-backend-template/internal/config"
@@ -14,7 +15,11 @@ import (
 	"github.com/npushpakumara/go-backend-template/internal/features/email/entities"
 	"github.com/npushpakumara/go-backend-template/internal/features/user"
 	userDto "github.com/npushpakumara/go-backend-template/internal/features/user/dto"
+	userEntity "github.com/npushpakumara/go-backend-template/internal/features/user/entity"
+	"github.com/npushpakumara/go-backend-template/internal/features/webhook"
+	webhookEntity "github.com/npushpakumara/go-backend-template/internal/features/webhook/entity"
 	"github.com/npushpakumara/go-backend-template/internal/postgres"
+	"github.com/npushpakumara/go-backend-template/pkg/clock"
 	apiError "github.com/npushpakumara/go-backend-template/pkg/errors"
 	"github.com/npushpakumara/go-backend-template/pkg/logging"
 )
@@ -24,27 +29,48 @@ type Service interface {
 	// RegisterUser handles the process of registering a new user.
 	// It accepts a SignUpRequestDto containing the user's registration details and performs necessary actions such as
 	// validating the input, storing the user's data, and sending a confirmation email.
+	// When only the email fails, the user is kept and ErrVerificationEmailNotSent is returned.
+	// It fails with ErrSignupClosed when sign-up is disabled and with ErrInvitationRequired when it is invite-only.
 	RegisterUser(ctx context.Context, user *dto.SignUpRequestDto) error
 
-	// LoginUser handles the user login process.
-	// It accepts a SignInRequestDto containing the user's email and password, validates the credentials,
-	// and returns the user's ID if successful. If login fails, it returns an appropriate error.
-	LoginUser(ctx context.Context, request *dto.SignInRequestDto) (string, error)
+	// RegisterInvitedUser signs up an invited user while sign-up is invite-only. The request's invite token must
+	// belong to a pending invitation to the request's email; that account is activated with the request's details.
+	// It returns the user's ID.
+	RegisterInvitedUser(ctx context.Context, user *dto.SignUpRequestDto) (string, error)
 
-	// ResetPassword handles the process of resetting a user's password.
-	// It accepts a PasswordResetRequestDto containing the user's current and new passwords, verifies the current password,
-	// and updates the user's password in the database if validation is successful.
+	// LoginUser handles the user login process.
+	// It accepts a SignInRequestDto containing the user's email or username and password, validates the credentials,
+	// and returns the user if successful. If login fails, it returns an appropriate error.
+	LoginUser(ctx context.Context, request *dto.SignInRequestDto) (*userDto.UserResponseDto, error)
+
+	// ChangePassword changes the password of the signed-in user identified by userID.
+	// It verifies the current password, updates the user's password in the database
+	// and revokes every session issued so far.
+	ChangePassword(ctx context.Context, userID string, request *dto.ChangePasswordRequestDto) error
+
+	// RequestPasswordReset emails a link to choose a new password to the active account registered with the email.
+	// Nothing is sent to unknown emails, to accounts that can't sign in with a password or to accounts that have
+	// been sent too many reset emails lately, and a failure to send is only logged, so the outcome doesn't reveal
+	// which emails are registered.
+	RequestPasswordReset(ctx context.Context, email string) error
+
+	// ResetPassword sets a new password for the user named by the token from a password reset email
+	// and revokes every session issued so far. A token is refused once the password has been reset with it.
 	ResetPassword(ctx context.Context, request *dto.PasswordResetRequestDto) error
 
 	// ActivateAccount handles the activation of a user's account.
 	// It accepts a token string, verifies its validity, and activates the account associated with the token.
-	// It returns the user's ID if activation is successful.
-	ActivateAccount(ctx context.Context, token string) (string, error)
+	// It returns the user's ID, and whether the account was already active, if activation is successful.
+	ActivateAccount(ctx context.Context, token string) (*dto.ActivationResult, error)
 
 	// GetUserByID retrieves a user's details based on their ID.
 	// It returns a UserResponseDto containing the user's information, or an error if the user is not found.
 	GetUserByID(ctx context.Context, id string) (*userDto.UserResponseDto, error)
 
+	// GetUserByEmail retrieves a user's details based on their email.
+	// It returns a UserResponseDto containing the user's information, or an error if the user is not found.
+	GetUserByEmail(ctx context.Context, email string) (*userDto.UserResponseDto, error)
+
 	// SendAccountVerificationEmail sends an account verification email to the user.
 	// It accepts a UserResponseDto containing the user's details, generates a verification token,
 	// and sends the email. Returns an error if the email cannot be sent.
@@ -54,6 +80,15 @@ type Service interface {
 	// It accepts a Goth User object containing the OAuth user's details, processes the user (e.g., linking accounts, creating a new user),
 	// and returns an OAuthResponseDto with the necessary information, or an error if the process fails.
 	HandleOAuthUser(ctx context.Context, gothUser goth.User) (*dto.OAuthResponseDto, error)
+
+	// InviteUsers creates a pending account without a password for each invited email and emails it an invitation link.
+	// Each invitation succeeds or fails on its own; the outcome of every one is reported in the response.
+	// Invited accounts join the organization of the inviting administrator, if they belong to one.
+	InviteUsers(ctx context.Context, request *dto.InviteUsersRequestDto) (*dto.InviteUsersResponseDto, error)
+
+	// AcceptInvite activates an invited account using the token from its invitation link,
+	// setting the name and password chosen by the user. It returns the user's ID.
+	AcceptInvite(ctx context.Context, request *dto.AcceptInviteRequestDto) (string, error)
 }
 
 // authServiceImpl is a concrete implementation of the Service interface.
@@ -61,31 +96,83 @@ type authServiceImpl struct {
 	userService        user.Service  // Service responsible for user operations
 	emailService       email.Service // Service responsible for sending emails
 	transactionManager postgres.TransactionManager
-	cfg                *config.Config // Configuration settings for the application
+	breachChecker      BreachChecker   // Checks new passwords against known data breaches
+	resetThrottle      ResetThrottle   // Caps the password reset emails sent to each account
+	passwordHasher     PasswordHasher  // Hashes new passwords and verifies existing ones
+	webhookService     webhook.Service // Notifies external systems of user events
+	clock              clock.Clock     // Source of the current time for token issuing and expiry
+	metrics            Metrics         // Counts sign-ups, sign-ins and other authentication outcomes
+	keyring            *tokens.Keyring // Signs and verifies the tokens sent in emails
+	cfg                *config.Config  // Configuration settings for the application
 }
 
 // NewAuthService creates a new instance of authServiceImpl with the provided services and configuration.
 // This function returns an Service interface that uses the authServiceImpl implementation.
-func NewAuthService(userService user.Service, emailService email.Service, transactionManager postgres.TransactionManager, cfg *config.Config) Service {
-	return &authServiceImpl{userService, emailService, transactionManager, cfg}
+func NewAuthService(userService user.Service, emailService email.Service, transactionManager postgres.TransactionManager, breachChecker BreachChecker, resetThrottle ResetThrottle, passwordHasher PasswordHasher, webhookService webhook.Service, clk clock.Clock, metrics Metrics, cfg *config.Config) Service {
+	return &authServiceImpl{userService, emailService, transactionManager, breachChecker, resetThrottle, passwordHasher, webhookService, clk, metrics, tokens.NewKeyring(&cfg.JWT), cfg}
 }
 
 // RegisterUser processes the registration of a new user. It converts the provided sign-up request
 // data into a format suitable for the user service, registers the user, and sends a verification email.
-// Returns an error if any step of the process fails.
-func (as *authServiceImpl) RegisterUser(c context.Context, requestBody *dto.SignUpRequestDto) error {
-	logger := logging.FromContext(c)
+// The user is committed before the email is sent, so a mail failure doesn't lose the account; it is
+// reported as ErrVerificationEmailNotSent and the user can ask for the email to be resent. When the provider
+// won't deliver to the address at all, the error also wraps email.ErrEmailNotDeliverable.
+func (as *authServiceImpl) RegisterUser(ctx context.Context, requestBody *dto.SignUpRequestDto) error {
+	logger := logging.FromContext(ctx)
+
+	if !as.cfg.Auth.AllowSignup {
+		return apiError.ErrSignupClosed
+	}
+	if as.cfg.Auth.InviteOnly {
+		return apiError.ErrInvitationRequired
+	}
 
-	ctx, err := as.transactionManager.Begin(c)
+	var newUser *userDto.UserResponseDto
+	err := as.transactionManager.RunInTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		newUser, err = as.registerUser(ctx, requestBody)
+		return err
+	})
 	if err != nil {
 		return err
 	}
+	as.metrics.SignedUp()
+
+	// Notify subscribers only once the user is committed, using the context outside the transaction.
+	as.emitUserEvent(ctx, webhookEntity.EventUserCreated, newUser)
 
-	defer func() {
-		if r := recover(); r != nil || err != nil {
-			as.transactionManager.Rollback(ctx)
+	// Send an account verification email to the newly registered user.
+	if err := as.SendAccountVerificationEmail(ctx, newUser); err != nil {
+		if errors.Is(err, email.ErrEmailNotDeliverable) {
+			// The provider won't send to this address, so resending won't help either.
+			logger.Warnw("auth.service.RegisterUser verification email is not deliverable, the email needs manual verification",
+				"user_id", newUser.ID, "needs_manual_verification", true, "err", err)
+			return fmt.Errorf("%w: %w", apiError.ErrVerificationEmailNotSent, err)
 		}
-	}()
+		logger.Errorw("auth.service.RegisterUser failed to send verification email", "user_id", newUser.ID, "err", err)
+		return fmt.Errorf("%w: %w", apiError.ErrVerificationEmailNotSent, err)
+	}
+
+	return nil
+}
+
+// RegisterInvitedUser accepts the invitation named by the request's token, as AcceptInvite does, with the
+// details from the sign-up form. The invitation proves the email, so no verification email is sent.
+func (as *authServiceImpl) RegisterInvitedUser(ctx context.Context, requestBody *dto.SignUpRequestDto) (string, error) {
+	if !as.cfg.Auth.AllowSignup {
+		return "", apiError.ErrSignupClosed
+	}
+
+	profile := userDto.ProfileUpdate{FirstName: &requestBody.FirstName, LastName: &requestBody.LastName, PhoneNumber: &requestBody.PhoneNumber}
+	if requestBody.Locale != "" {
+		profile.Locale = &requestBody.Locale
+	}
+	return as.acceptInvite(ctx, requestBody.InviteToken, normalizeEmail(requestBody.Email), profile, requestBody.Password)
+}
+
+// registerUser creates the user within the caller's transaction.
+func (as *authServiceImpl) registerUser(ctx context.Context, requestBody *dto.SignUpRequestDto) (*userDto.UserResponseDto, error) {
+	logger := logging.FromContext(ctx)
 
 	// Convert the sign-up request data to the format needed by the user service.
 	userPayload := &userDto.RegisterRequestDto{
@@ -94,56 +181,105 @@ func (as *authServiceImpl) RegisterUser(c context.Context, requestBody *dto.Sign
 		Email:       requestBody.Email,
 		Password:    requestBody.Password,
 		PhoneNumber: requestBody.PhoneNumber,
+		Locale:      requestBody.Locale,
+		Username:    strings.ToLower(requestBody.Username),
+	}
+
+	if userPayload.Username != "" {
+		if err := as.checkUsernameAvailable(ctx, userPayload.Username); err != nil {
+			return nil, err
+		}
 	}
 
-	hashedPassword, err := hashPassword(requestBody.Password)
+	hashedPassword, err := as.passwordHasher.Hash(requestBody.Password)
 	if err != nil {
 		logger.Errorw("auth.service.RegisterUser failed to hash password: ", err)
-		return err
+		return nil, err
 	}
 
 	userPayload.Password = hashedPassword
 
 	// Register the user with the user service.
 	newUser, err := as.userService.CreateUser(ctx, userPayload)
-	if err != nil {
-		return err
+	if err != nil || requestBody.OrganizationName == "" {
+		return newUser, err
 	}
 
-	// Send an account verification email to the newly registered user.
-	if err := as.SendAccountVerificationEmail(ctx, newUser); err != nil {
-		return err
+	// The new user owns the organization they asked for.
+	organization, err := as.userService.CreateOrganization(ctx, requestBody.OrganizationName, newUser.ID)
+	if err != nil {
+		logger.Errorw("auth.service.RegisterUser failed to create organization", "err", err)
+		return nil, err
 	}
+	newUser.OrgID = organization.ID
+	return newUser, nil
+}
 
-	as.transactionManager.Commit(ctx)
+// checkUsernameAvailable refuses a username when username sign-in is disabled or another account already has it.
+// The unique index still guards against two sign-ups racing for the same name.
+func (as *authServiceImpl) checkUsernameAvailable(ctx context.Context, username string) error {
+	if !as.cfg.Security.UsernameLogin {
+		return apiError.ErrUsernameNotEnabled
+	}
 
-	return nil
+	_, err := as.userService.GetUserByUsername(ctx, username)
+	switch {
+	case err == nil:
+		return apiError.ErrUsernameTaken
+	case errors.Is(err, postgres.ErrRecordNotFound):
+		return nil
+	default:
+		return err
+	}
 }
 
 // ActivateAccount activates a user account using the provided token.
-// The token is used to find and update the user's status to active.
+// The token is used to find and update the user's status to active. An account that is already active
+// is left untouched, so following the verification link again succeeds without writing or notifying anyone.
 // Returns an error if token extraction or user update fails.
-func (as *authServiceImpl) ActivateAccount(ctx context.Context, token string) (string, error) {
+func (as *authServiceImpl) ActivateAccount(ctx context.Context, token string) (*dto.ActivationResult, error) {
 	logger := logging.FromContext(ctx)
 
 	// Extract the user ID from the token.
-	id, err := tokens.ExtractSubjectFromToken(as.cfg.JWT.Secret, token)
+	id, err := tokens.ExtractSubjectFromToken(as.clock, as.keyring, token, tokens.PurposeVerification)
 	if err != nil {
 		logger.Errorw("auth.service.ActivateAccount failed to extract id from token", err)
-		return "", err
+		return nil, err
+	}
+
+	user, err := as.userService.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// A verification link must not lift a suspension or ban.
+	if err := checkAccountStatus(user.Status); err != nil {
+		logger.Errorw("auth.service.ActivateAccount refused to activate account", "status", user.Status)
+		return nil, err
 	}
 
-	// Prepare the payload to update the user's status.
-	payload := map[string]interface{}{
-		"is_active": true,
+	if user.IsActive {
+		return &dto.ActivationResult{ID: id, AlreadyActive: true}, nil
 	}
 
-	err = as.userService.UpdateUser(ctx, id, payload)
+	err = as.userService.ActivateUser(ctx, id)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	as.metrics.EmailVerified()
 
-	return id, nil
+	as.emitUserEvent(ctx, webhookEntity.EventUserActivated, user)
+
+	return &dto.ActivationResult{ID: id}, nil
+}
+
+// verificationLink returns the link of an account verification email: mail.verification_url_template
+// with the token filled in when set, otherwise the API's own verification route.
+func (as *authServiceImpl) verificationLink(token string) string {
+	if tmpl := as.cfg.Mail.VerificationURLTemplate; tmpl != "" {
+		return strings.ReplaceAll(tmpl, config.VerificationTokenPlaceholder, url.QueryEscape(token))
+	}
+	return fmt.Sprintf("%s%s%s?token=%s", as.cfg.Server.Domain, apiPrefix, verifyEmailPath, url.QueryEscape(token))
 }
 
 // SendAccountVerificationEmail creates a JWT token for account verification and sends an email to the user.
@@ -153,7 +289,7 @@ func (as *authServiceImpl) SendAccountVerificationEmail(ctx context.Context, req
 	logger := logging.FromContext(ctx)
 
 	// Create a new JWT token for account verification.
-	tokenString, err := tokens.NewJwtToken(requestBody.ID, as.cfg.JWT.Secret, time.Hour*48)
+	tokenString, err := tokens.NewJwtToken(as.clock, requestBody.ID, tokens.PurposeVerification, as.keyring, as.cfg.JWT.VerificationTokenExpiry)
 	if err != nil {
 		logger.Errorw("auth.service.sendAccountVerificationEmail failed to create jwt token: %v", err)
 		return err // Return error if token creation fails.
@@ -161,23 +297,16 @@ func (as *authServiceImpl) SendAccountVerificationEmail(ctx context.Context, req
 
 	mailData := &entities.VerificationEmailData{
 		Name: requestBody.FirstName,
-		Link: fmt.Sprintf("%s/api/v1/auth/verify?token=%s", as.cfg.Server.Domain, tokenString),
+		Link: as.verificationLink(tokenString),
 	}
 
-	mailBody, err := email.ParseTemplate(entities.EmailTemplates["UserVerification"].Template, mailData)
+	// Render the verification email in the user's preferred language.
+	newEmail, err := email.NewTemplatedEmail("UserVerification", requestBody.Locale, as.cfg.Mail.FromEmail, []string{requestBody.Email}, mailData)
 	if err != nil {
 		logger.Errorw("auth.service.sendAccountVerificationEmail failed to parse email template: %v", err)
 		return err
 	}
 
-	// Prepare the email content.
-	newEmail := &entities.Email{
-		To:      []string{requestBody.Email},
-		From:    as.cfg.Mail.FromEmail,
-		Subject: entities.EmailTemplates["UserVerification"].Subject,
-		Data:    mailBody,
-	}
-
 	// Send the verification email using the email service.
 	if err := as.emailService.SendEmail(ctx, *newEmail); err != nil {
 		return err
@@ -188,8 +317,25 @@ func (as *authServiceImpl) SendAccountVerificationEmail(ctx context.Context, req
 
 // HandleOAuthUser handles the process of registering a user via an OAuth provider.
 // It takes in the OAuth user information, creates a user registration payload,
-// and attempts to register the user using the userService.
+// and attempts to register the user using the userService. When the email is already
+// registered, the existing account is only used if it is linked to the same provider identity;
+// see linkOAuthUser. The provider's email is normalized first, since providers may return it in any case.
 func (as *authServiceImpl) HandleOAuthUser(ctx context.Context, gothUser goth.User) (*dto.OAuthResponseDto, error) {
+	gothUser.Email = normalizeEmail(gothUser.Email)
+
+	// While sign-up is closed or invite-only, OAuth only signs in accounts that already exist.
+	if !as.cfg.Auth.AllowSignup || as.cfg.Auth.InviteOnly {
+		resp, err := as.linkOAuthUser(ctx, gothUser)
+		if errors.Is(err, postgres.ErrRecordNotFound) {
+			return nil, apiError.ErrSignupClosed
+		}
+		if err != nil {
+			return nil, err
+		}
+		as.metrics.LoginSucceeded()
+		return oauthResponse(resp), nil
+	}
+
 	userPayload := &userDto.RegisterRequestDto{
 		FirstName:  gothUser.FirstName,
 		LastName:   gothUser.LastName,
@@ -200,24 +346,74 @@ func (as *authServiceImpl) HandleOAuthUser(ctx context.Context, gothUser goth.Us
 
 	resp, err := as.userService.CreateUser(ctx, userPayload)
 	if err != nil {
-		if errors.Is(err, postgres.ErrKeyDuplicate) {
-			resp, err = as.userService.GetUserByEmail(ctx, gothUser.Email)
+		if errors.Is(err, apiError.ErrEmailAlreadyExists) {
+			resp, err = as.linkOAuthUser(ctx, gothUser)
 			if err != nil {
 				return nil, err
 			}
 		} else {
 			return nil, err
 		}
+	} else {
+		as.metrics.SignedUp()
+		as.emitUserEvent(ctx, webhookEntity.EventUserCreated, resp)
 	}
+	as.metrics.LoginSucceeded()
 
+	return oauthResponse(resp), nil
+}
+
+// oauthResponse describes the user an OAuth sign-in resolved to.
+func oauthResponse(user *userDto.UserResponseDto) *dto.OAuthResponseDto {
 	return &dto.OAuthResponseDto{
-		ID:         resp.ID,
-		FirstName:  resp.FirstName,
-		LastName:   resp.LastName,
-		Email:      resp.Email,
-		Provider:   resp.Provider,
-		ProviderID: resp.ProviderID,
-	}, nil
+		ID:         user.ID,
+		FirstName:  user.FirstName,
+		LastName:   user.LastName,
+		Email:      user.Email,
+		Provider:   user.Provider,
+		ProviderID: user.ProviderID,
+		OrgID:      user.OrgID,
+	}
+}
+
+// linkOAuthUser resolves an OAuth sign-in whose email already belongs to an account.
+// An account linked to the same provider identity is returned as is. An account linked to any other
+// identity is never taken over by email; ProviderConflictError names the provider to use instead.
+// A verified password account is linked to the provider and keeps its password, so the user can sign in
+// either way, while an unverified one is refused because whoever registered it may not own the email.
+func (as *authServiceImpl) linkOAuthUser(ctx context.Context, gothUser goth.User) (*userDto.UserResponseDto, error) {
+	logger := logging.FromContext(ctx)
+
+	existing, err := as.userService.GetUserByEmail(ctx, gothUser.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing.ProviderID != "" {
+		if existing.Provider == gothUser.Provider && existing.ProviderID == gothUser.UserID {
+			return existing, nil
+		}
+		logger.Warnw("auth.service.linkOAuthUser email is linked to another identity", "user_id", existing.ID, "provider", gothUser.Provider, "linked_provider", existing.Provider)
+		return nil, &apiError.ProviderConflictError{Provider: existing.Provider}
+	}
+
+	if err := checkAccountStatus(existing.Status); err != nil {
+		return nil, err
+	}
+	if !existing.IsActive {
+		logger.Warnw("auth.service.linkOAuthUser refused to link unverified account", "user_id", existing.ID, "provider", gothUser.Provider)
+		return nil, apiError.ErrEmailRegisteredWithPassword
+	}
+
+	if err := as.userService.LinkProvider(ctx, existing.ID, gothUser.Provider, gothUser.UserID); err != nil {
+		logger.Errorf("auth.service.linkOAuthUser failed to link provider: %v", err)
+		return nil, err
+	}
+	logger.Infow("auth.service.linkOAuthUser linked account to provider", "user_id", existing.ID, "provider", gothUser.Provider)
+
+	existing.Provider = gothUser.Provider
+	existing.ProviderID = gothUser.UserID
+	return existing, nil
 }
 
 // GetUserByID retrieves a user by their ID and returns a UserResponseDto.
@@ -234,68 +430,417 @@ func (as *authServiceImpl) GetUserByID(ctx context.Context, id string) (*userDto
 	return user, nil
 }
 
+// GetUserByEmail retrieves a user by their email and returns a UserResponseDto.
+// It logs any errors that occur during the process.
+func (as *authServiceImpl) GetUserByEmail(ctx context.Context, email string) (*userDto.UserResponseDto, error) {
+	logger := logging.FromContext(ctx)
+
+	user, err := as.userService.GetUserByEmail(ctx, email)
+	if err != nil {
+		logger.Errorf("auth.service.GetUserByEmail failed to get user by email: %v", err)
+		return nil, err
+	}
+
+	return user, nil
+}
+
 // LoginUser attempts to log in a user based on the provided SignInRequestDto.
 // It performs various checks such as validating the email, checking if the account is active, and verifying the password.
-func (as *authServiceImpl) LoginUser(ctx context.Context, requestBody *dto.SignInRequestDto) (string, error) {
+func (as *authServiceImpl) LoginUser(ctx context.Context, requestBody *dto.SignInRequestDto) (*userDto.UserResponseDto, error) {
 	logger := logging.FromContext(ctx)
 
-	resp, err := as.userService.GetUserByEmail(ctx, requestBody.Email)
+	resp, err := as.findUserByIdentifier(ctx, requestBody.LoginIdentifier())
 	if err != nil {
-		logger.Errorf("auth.service.LoginUser failed to get user by email: %v", err)
-		return "", err
+		if errors.Is(err, postgres.ErrRecordNotFound) {
+			as.metrics.LoginFailed(LoginFailureNotFound)
+		}
+		logger.Errorf("auth.service.LoginUser failed to find user: %v", err)
+		return nil, err
 	}
 
-	if resp.ProviderID != "" {
+	// Accounts created through an OAuth provider have no password; linked password accounts keep theirs.
+	if resp.ProviderID != "" && resp.Password == "" {
+		as.metrics.LoginFailed(LoginFailureOAuthLinked)
 		logger.Errorw("auth.service.LoginUser failed to login", "email associate with oauth account")
-		return "", apiError.ErrEmailLinkedToOauth
+		return nil, apiError.ErrEmailLinkedToOauth
+	}
+
+	if err := checkAccountStatus(resp.Status); err != nil {
+		as.metrics.LoginFailed(LoginFailureInactive)
+		logger.Errorw("auth.service.LoginUser account is not allowed to sign in", "status", resp.Status)
+		return nil, err
 	}
 
 	if !resp.IsActive {
+		as.metrics.LoginFailed(LoginFailureInactive)
 		logger.Errorf("auth.service.LoginUser account is not activated")
-		return "", apiError.ErrAccountNotActive
+		return nil, apiError.ErrAccountNotActive
 	}
 
-	if err := checkPassword(resp.Password, requestBody.Password); err != nil {
+	if err := as.passwordHasher.Compare(resp.Password, requestBody.Password); err != nil {
 		if errors.Is(err, apiError.ErrIncorrectPassword) {
+			as.metrics.LoginFailed(LoginFailureWrongPassword)
 			logger.Errorw("auth.service.LoginUser failed to login", "invalid password", err)
-			return "", err
+			return nil, err
 		}
-		return "", err
+		return nil, err
+	}
+
+	if as.passwordHasher.NeedsRehash(resp.Password) {
+		as.rehashPassword(ctx, resp, requestBody.Password)
+	}
+
+	as.metrics.LoginSucceeded()
+	return resp, nil
+}
+
+// rehashPassword replaces the user's password hash with one made by the configured algorithm, now that the
+// password is known. The sign-in has already succeeded, so a failure is only logged and retried next time.
+func (as *authServiceImpl) rehashPassword(ctx context.Context, user *userDto.UserResponseDto, password string) {
+	logger := logging.FromContext(ctx)
+
+	hashedPassword, err := as.passwordHasher.Hash(password)
+	if err == nil {
+		err = as.userService.RehashPassword(ctx, user.ID, user.Password, hashedPassword)
 	}
+	if err != nil {
+		logger.Errorw("auth.service.LoginUser failed to rehash password", "user_id", user.ID, "err", err)
+	}
+}
 
-	return resp.ID, nil
+// findUserByIdentifier looks up the user signing in. With username sign-in enabled, an identifier without "@"
+// is a username, which can never contain one; anything else is an email.
+func (as *authServiceImpl) findUserByIdentifier(ctx context.Context, identifier string) (*userDto.UserResponseDto, error) {
+	if as.cfg.Security.UsernameLogin && !strings.Contains(identifier, "@") {
+		return as.userService.GetUserByUsername(ctx, strings.ToLower(identifier))
+	}
+	return as.userService.GetUserByEmail(ctx, identifier)
 }
 
-// ResetPassword allows a user to reset their password by providing the current and new passwords.
+// ChangePassword allows a signed-in user to change their password by providing the current and new passwords.
 // It first verifies the current password and then updates the user's password in the database.
-func (as *authServiceImpl) ResetPassword(ctx context.Context, request *dto.PasswordResetRequestDto) error {
+// Every session issued until now is revoked; the caller is expected to issue a new one for the current client.
+func (as *authServiceImpl) ChangePassword(ctx context.Context, userID string, request *dto.ChangePasswordRequestDto) error {
 	logger := logging.FromContext(ctx)
 
-	resp, err := as.userService.GetUserByEmail(ctx, request.Email)
+	resp, err := as.userService.GetUserByID(ctx, userID)
 	if err != nil {
-		logger.Errorf("auth.service.ResetPassword failed to get user by email: %v", err)
+		logger.Errorf("auth.service.ChangePassword failed to get user by id: %v", err)
 		return err
 	}
 
-	err = checkPassword(resp.Password, request.CurrentPassword)
+	err = as.passwordHasher.Compare(resp.Password, request.CurrentPassword)
 	if err != nil {
-		logger.Errorf("auth.service.ResetPassword incorrect current password: %v", err)
+		logger.Errorf("auth.service.ChangePassword incorrect current password: %v", err)
 		return apiError.ErrIncorrectPassword
 	}
 
-	hashedPassword, err := hashPassword(request.NewPassword)
+	if err := as.setNewPassword(ctx, resp, request.NewPassword); err != nil {
+		return err
+	}
+	as.metrics.PasswordChanged()
+
+	as.emitUserEvent(ctx, webhookEntity.EventUserPasswordChanged, resp)
+
+	return nil
+}
+
+// RequestPasswordReset sends a password reset email to the account with the email, when it is active and has a password.
+// The link in the email carries a token minted for PasswordResetTokenExpiry. The reset throttle caps the emails per
+// account independently of the requesting IP; when it refuses, or can't be consulted, nothing is sent.
+func (as *authServiceImpl) RequestPasswordReset(ctx context.Context, email string) error {
+	logger := logging.FromContext(ctx)
+
+	user, err := as.userService.GetUserByEmail(ctx, normalizeEmail(email))
+	if errors.Is(err, postgres.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		logger.Errorf("auth.service.RequestPasswordReset failed to get user by email: %v", err)
+		return err
+	}
+
+	// Accounts that can't sign in with a password aren't offered one: unverified accounts must verify their email
+	// first, and suspended or banned ones must not regain access this way.
+	if !user.IsActive || user.Password == "" || checkAccountStatus(user.Status) != nil {
+		logger.Infow("auth.service.RequestPasswordReset skipped account that can't reset its password", "user_id", user.ID, "status", user.Status)
+		return nil
+	}
+
+	allowed, err := as.resetThrottle.Allow(ctx, user.Email)
+	if err != nil {
+		logger.Errorw("auth.service.RequestPasswordReset failed to check the password reset limit", "user_id", user.ID, "err", err)
+		return nil
+	}
+	if !allowed {
+		logger.Warnw("auth.service.RequestPasswordReset too many password reset requests for the account", "user_id", user.ID)
+		return nil
+	}
+
+	if err := as.sendPasswordResetEmail(ctx, user); err != nil {
+		logger.Errorw("auth.service.RequestPasswordReset failed to send password reset email", "user_id", user.ID, "err", err)
+	}
+	return nil
+}
+
+// sendPasswordResetEmail emails the user a link to the password reset page carrying a new reset token.
+func (as *authServiceImpl) sendPasswordResetEmail(ctx context.Context, user *userDto.UserResponseDto) error {
+	tokenString, err := tokens.NewJwtToken(as.clock, user.ID, tokens.PurposePasswordReset, as.keyring, as.cfg.JWT.PasswordResetTokenExpiry)
+	if err != nil {
+		return err
+	}
+
+	mailData := &entities.PasswordResetEmailData{
+		Name: user.FirstName,
+		Link: fmt.Sprintf("%s?token=%s", as.cfg.Server.PasswordResetURL, url.QueryEscape(tokenString)),
+	}
+
+	newEmail, err := email.NewTemplatedEmail("PasswordReset", user.Locale, as.cfg.Mail.FromEmail, []string{user.Email}, mailData)
 	if err != nil {
 		return err
 	}
 
-	payload := map[string]interface{}{
-		"password": hashedPassword,
+	return as.emailService.SendEmail(ctx, *newEmail)
+}
+
+// ResetPassword sets the new password of the user named by the reset token, applying the same rules as ChangePassword.
+// Setting the password revokes the user's sessions, and reset tokens issued before the latest revocation are refused,
+// so each link works once and a link sent before a suspension or password change no longer works.
+func (as *authServiceImpl) ResetPassword(ctx context.Context, request *dto.PasswordResetRequestDto) error {
+	logger := logging.FromContext(ctx)
+
+	id, issuedAt, err := tokens.ExtractPasswordResetToken(as.clock, as.keyring, request.Token)
+	if err != nil {
+		logger.Errorw("auth.service.ResetPassword failed to extract id from token", "err", err)
+		return err
 	}
 
-	err = as.userService.UpdateUser(ctx, resp.ID, payload)
+	resp, err := as.userService.GetUserByID(ctx, id)
 	if err != nil {
+		logger.Errorf("auth.service.ResetPassword failed to get user by id: %v", err)
+		return err
+	}
+
+	if resp.SessionsRevokedAt != nil && issuedAt.Before(*resp.SessionsRevokedAt) {
+		logger.Warnw("auth.service.ResetPassword refused a token issued before the sessions were revoked", "user_id", resp.ID)
+		return apiError.ErrInvalidToken
+	}
+	if err := checkAccountStatus(resp.Status); err != nil {
+		logger.Errorw("auth.service.ResetPassword account is not allowed to reset its password", "status", resp.Status)
+		return err
+	}
+	if !resp.IsActive {
+		return apiError.ErrAccountNotActive
+	}
+
+	if err := as.setNewPassword(ctx, resp, request.NewPassword); err != nil {
 		return err
 	}
+	as.metrics.PasswordChanged()
+
+	as.emitUserEvent(ctx, webhookEntity.EventUserPasswordReset, resp)
 
 	return nil
 }
+
+// setNewPassword replaces the user's password after checking that the new one differs from the current
+// and previous passwords and hasn't appeared in a data breach.
+func (as *authServiceImpl) setNewPassword(ctx context.Context, user *userDto.UserResponseDto, newPassword string) error {
+	logger := logging.FromContext(ctx)
+
+	// The new password must actually change something.
+	if as.passwordHasher.Compare(user.Password, newPassword) == nil {
+		logger.Errorw("auth.service.setNewPassword new password matches the current password")
+		return apiError.ErrPasswordReused
+	}
+
+	history, err := as.userService.GetPasswordHistory(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	for _, previous := range history {
+		if as.passwordHasher.Compare(previous, newPassword) == nil {
+			logger.Errorw("auth.service.setNewPassword new password matches a previous password")
+			return apiError.ErrPasswordReused
+		}
+	}
+
+	if err := checkBreachedPassword(ctx, as.breachChecker, newPassword); err != nil {
+		logger.Errorw("auth.service.setNewPassword new password found in a data breach")
+		return err
+	}
+
+	hashedPassword, err := as.passwordHasher.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+
+	return as.updatePassword(ctx, user.ID, hashedPassword)
+}
+
+// updatePassword replaces the user's password in a transaction, so the password history is updated along with it.
+func (as *authServiceImpl) updatePassword(ctx context.Context, id, hashedPassword string) error {
+	return as.transactionManager.RunInTransaction(ctx, func(ctx context.Context) error {
+		return as.userService.UpdatePassword(ctx, id, hashedPassword)
+	})
+}
+
+// InviteUsers invites every email in the request. A pending invited account is sent a fresh link,
+// while an email that belongs to any other account is reported as failed.
+func (as *authServiceImpl) InviteUsers(ctx context.Context, request *dto.InviteUsersRequestDto) (*dto.InviteUsersResponseDto, error) {
+	results := make([]dto.InviteResultDto, 0, len(request.Invites))
+	for _, invite := range request.Invites {
+		results = append(results, as.inviteUser(ctx, invite))
+	}
+	return &dto.InviteUsersResponseDto{Results: results}, nil
+}
+
+// inviteUser creates or finds the invited account and sends it an invitation email.
+func (as *authServiceImpl) inviteUser(ctx context.Context, invite dto.InviteRequestDto) dto.InviteResultDto {
+	logger := logging.FromContext(ctx)
+	result := dto.InviteResultDto{Email: invite.Email, Status: dto.InviteStatusInvited}
+
+	invited, err := as.userService.CreateUser(ctx, &userDto.RegisterRequestDto{
+		Email:  invite.Email,
+		Role:   invite.Role,
+		Locale: invite.Locale,
+	})
+	switch {
+	case err == nil:
+		as.emitUserEvent(ctx, webhookEntity.EventUserCreated, invited)
+	case errors.Is(err, apiError.ErrEmailAlreadyExists):
+		invited, err = as.userService.GetUserByEmail(ctx, invite.Email)
+		// The email belongs to a user of another organization, which the administrator can't see.
+		if errors.Is(err, postgres.ErrRecordNotFound) {
+			result.Status, result.Error = dto.InviteStatusFailed, "User is already registered"
+			return result
+		}
+		if err != nil {
+			logger.Errorf("auth.service.inviteUser failed to get user by email: %v", err)
+			result.Status, result.Error = dto.InviteStatusFailed, "Internal server error"
+			return result
+		}
+		if !isPendingInvite(invited) {
+			result.Status, result.Error = dto.InviteStatusFailed, "User is already registered"
+			if invited.IsActive {
+				result.Error = "User is already active"
+			}
+			return result
+		}
+		result.Status = dto.InviteStatusResent
+	default:
+		logger.Errorf("auth.service.inviteUser failed to create user: %v", err)
+		result.Status, result.Error = dto.InviteStatusFailed, "Internal server error"
+		return result
+	}
+
+	result.UserID = invited.ID
+	if err := as.sendInvitationEmail(ctx, invited); err != nil {
+		logger.Errorf("auth.service.inviteUser failed to send invitation email: %v", err)
+		result.Status, result.Error = dto.InviteStatusFailed, "Failed to send invitation email"
+	}
+	return result
+}
+
+// sendInvitationEmail emails the user a link to the invitation page carrying a new invite token.
+func (as *authServiceImpl) sendInvitationEmail(ctx context.Context, user *userDto.UserResponseDto) error {
+	tokenString, err := tokens.NewJwtToken(as.clock, user.ID, tokens.PurposeInvite, as.keyring, as.cfg.JWT.InviteTokenExpiry)
+	if err != nil {
+		return err
+	}
+
+	mailData := &entities.InvitationEmailData{
+		Link: fmt.Sprintf("%s?token=%s", as.cfg.Server.InviteURL, url.QueryEscape(tokenString)),
+	}
+
+	newEmail, err := email.NewTemplatedEmail("UserInvitation", user.Locale, as.cfg.Mail.FromEmail, []string{user.Email}, mailData)
+	if err != nil {
+		return err
+	}
+
+	return as.emailService.SendEmail(ctx, *newEmail)
+}
+
+// AcceptInvite sets the invited user's name and password and activates the account.
+// Only tokens minted for invitations are accepted. The link can only be used while the account is
+// still a pending invitation, with no password set, so it works once.
+func (as *authServiceImpl) AcceptInvite(ctx context.Context, request *dto.AcceptInviteRequestDto) (string, error) {
+	profile := userDto.ProfileUpdate{FirstName: &request.FirstName, LastName: &request.LastName}
+	return as.acceptInvite(ctx, request.Token, "", profile, request.Password)
+}
+
+// acceptInvite activates the pending invited account named by the token with the given profile and password.
+// When email is set, the invitation must have been sent to it; a mismatch is reported as an invalid token.
+func (as *authServiceImpl) acceptInvite(ctx context.Context, token, email string, profile userDto.ProfileUpdate, password string) (string, error) {
+	logger := logging.FromContext(ctx)
+
+	id, err := tokens.ExtractSubjectFromToken(as.clock, as.keyring, token, tokens.PurposeInvite)
+	if err != nil {
+		logger.Errorw("auth.service.AcceptInvite failed to extract id from token", "err", err)
+		return "", err
+	}
+
+	user, err := as.userService.GetUserByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	if err := checkAccountStatus(user.Status); err != nil {
+		logger.Errorw("auth.service.AcceptInvite refused to activate account", "status", user.Status)
+		return "", err
+	}
+	if email != "" && email != user.Email {
+		logger.Warnw("auth.service.AcceptInvite invitation was sent to another email", "user_id", user.ID)
+		return "", apiError.ErrInvalidToken
+	}
+
+	if err := checkBreachedPassword(ctx, as.breachChecker, password); err != nil {
+		return "", err
+	}
+
+	hashedPassword, err := as.passwordHasher.Hash(password)
+	if err != nil {
+		return "", err
+	}
+
+	// The update only applies while the account is still a pending invitation, so of two requests accepting
+	// the same invitation at once, the second fails with ErrInvitationNotPending.
+	if err := as.userService.AcceptInvitation(ctx, id, profile, hashedPassword); err != nil {
+		return "", err
+	}
+
+	as.emitUserEvent(ctx, webhookEntity.EventUserActivated, user)
+
+	return id, nil
+}
+
+// normalizeEmail trims and lowercases an email so it matches the stored account regardless of how it was typed or returned.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// isPendingInvite reports whether the account was created by an invitation that hasn't been accepted yet.
+func isPendingInvite(user *userDto.UserResponseDto) bool {
+	return user.Status == userEntity.StatusPending && user.Password == "" && user.ProviderID == ""
+}
+
+// emitUserEvent notifies webhook subscribers of a user event.
+// Webhooks are best effort, so a failure is logged rather than failing the request that caused the event.
+func (as *authServiceImpl) emitUserEvent(ctx context.Context, event string, user *userDto.UserResponseDto) {
+	data := webhook.UserEventData{UserID: user.ID, Email: user.Email}
+	if err := as.webhookService.Emit(ctx, event, data); err != nil {
+		logging.FromContext(ctx).Errorw("auth.service.emitUserEvent failed to emit webhook event", "event", event, "err", err)
+	}
+}
+
+// checkAccountStatus returns a distinct error for account statuses that block the user from signing in.
+func checkAccountStatus(status string) error {
+	switch status {
+	case userEntity.StatusSuspended:
+		return apiError.ErrAccountSuspended
+	case userEntity.StatusBanned:
+		return apiError.ErrAccountBanned
+	default:
+		return nil
+	}
+}
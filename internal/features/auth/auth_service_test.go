@@ -0,0 +1,960 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/markbates/goth"
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/internal/features/auth/dto"
+	"github.com/npushpakumara/go-backend-template/internal/features/auth/tokens"
+	"github.com/npushpakumara/go-backend-template/internal/features/email"
+	"github.com/npushpakumara/go-backend-template/internal/features/email/emailtest"
+	"github.com/npushpakumara/go-backend-template/internal/features/email/entities"
+	"github.com/npushpakumara/go-backend-template/internal/features/user"
+	userEntity "github.com/npushpakumara/go-backend-template/internal/features/user/entity"
+	"github.com/npushpakumara/go-backend-template/internal/features/user/usertest"
+	"github.com/npushpakumara/go-backend-template/internal/features/webhook"
+	webhookEntity "github.com/npushpakumara/go-backend-template/internal/features/webhook/entity"
+	"github.com/npushpakumara/go-backend-template/internal/postgres"
+	"github.com/npushpakumara/go-backend-template/internal/postgres/postgrestest"
+	"github.com/npushpakumara/go-backend-template/pkg/clock"
+	apiError "github.com/npushpakumara/go-backend-template/pkg/errors"
+)
+
+// testPassword satisfies the sign-up password rules and is used for every account created by the tests.
+const testPassword = "Correct-Horse-9"
+
+// TestMain runs the tests from the module root, where the email templates are looked up.
+func TestMain(m *testing.M) {
+	if err := os.Chdir("../../.."); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// testAuth is an auth service wired to in-memory dependencies, with handles on each of them.
+type testAuth struct {
+	service  Service
+	users    user.Service
+	repo     *usertest.Repository
+	emails   *emailtest.MockEmailService
+	webhooks *recordingWebhooks
+	metrics  *recordingMetrics
+	clock    *clock.Fake
+	hasher   PasswordHasher
+	cfg      *config.Config
+}
+
+// newTestAuth returns an auth service backed by an in-memory user repository, a mock email service,
+// a fake clock and webhook and metrics recorders. Sign-up is open.
+func newTestAuth(t *testing.T) *testAuth {
+	t.Helper()
+
+	cfg := &config.Config{}
+	cfg.Server.Domain = "https://api.example.com"
+	cfg.Auth.AllowSignup = true
+	cfg.Auth.PasswordResetLimit = 3
+	cfg.Auth.PasswordResetWindow = time.Hour
+	cfg.JWT.Secret = "test-secret"
+	cfg.JWT.VerificationTokenExpiry = 48 * time.Hour
+	cfg.JWT.InviteTokenExpiry = 72 * time.Hour
+	cfg.JWT.PasswordResetTokenExpiry = 30 * time.Minute
+	cfg.Server.PasswordResetURL = "https://app.example.com/reset-password"
+	cfg.Mail.FromEmail = "no-reply@example.com"
+
+	ta := &testAuth{
+		repo:     usertest.NewRepository(),
+		emails:   emailtest.NewMockEmailService(),
+		webhooks: &recordingWebhooks{},
+		metrics:  &recordingMetrics{},
+		clock:    clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)),
+		hasher:   NewPasswordHasher(cfg),
+		cfg:      cfg,
+	}
+	ta.users = user.NewUserService(ta.repo, postgrestest.NopTransactionManager{}, cfg)
+	ta.service = NewAuthService(ta.users, ta.emails, postgrestest.NopTransactionManager{}, noopBreachChecker{}, NewMemoryResetThrottle(ta.clock, cfg.Auth.PasswordResetLimit, cfg.Auth.PasswordResetWindow), ta.hasher, ta.webhooks, ta.clock, ta.metrics, cfg)
+	return ta
+}
+
+// createUser inserts a user with the given email, status and OAuth identity. Users without a provider
+// get testPassword as their password.
+func (ta *testAuth) createUser(t *testing.T, email, status, provider, providerID string) *userEntity.User {
+	t.Helper()
+
+	u := &userEntity.User{FirstName: "Ada", Email: email, Status: status, Provider: provider, ProviderID: providerID, Role: userEntity.RoleUser}
+	if providerID == "" {
+		hash, err := ta.hasher.Hash(testPassword)
+		if err != nil {
+			t.Fatalf("hash password: %v", err)
+		}
+		u.Password = hash
+	}
+
+	created, err := ta.repo.Insert(context.Background(), u)
+	if err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+	return created
+}
+
+// linkTokenPattern matches the token in the link of an email.
+var linkTokenPattern = regexp.MustCompile(`\?token=([A-Za-z0-9_.-]+)`)
+
+// linkToken returns the token in the link of the email.
+func linkToken(t *testing.T, sent entities.Email) string {
+	t.Helper()
+
+	match := linkTokenPattern.FindStringSubmatch(sent.Data)
+	if match == nil {
+		t.Fatalf("email %q has no link with a token", sent.Subject)
+	}
+	return match[1]
+}
+
+// recordingWebhooks is a webhook.Service that records the type of every emitted event.
+type recordingWebhooks struct {
+	webhook.Service
+
+	mu     sync.Mutex
+	events []string
+}
+
+func (w *recordingWebhooks) Emit(_ context.Context, event string, _ interface{}) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.events = append(w.events, event)
+	return nil
+}
+
+// Events returns the types of the emitted events, oldest first.
+func (w *recordingWebhooks) Events() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string(nil), w.events...)
+}
+
+func TestHandleOAuthUserSameProviderSignsIn(t *testing.T) {
+	ta := newTestAuth(t)
+	existing := ta.createUser(t, "ada@example.com", userEntity.StatusActive, "google", "google-123")
+
+	resp, err := ta.service.HandleOAuthUser(context.Background(), goth.User{Provider: "google", UserID: "google-123", Email: "Ada@Example.com"})
+	if err != nil {
+		t.Fatalf("HandleOAuthUser() error = %v", err)
+	}
+	if resp.ID != existing.ID.String() {
+		t.Errorf("HandleOAuthUser() signed in %s, want %s", resp.ID, existing.ID)
+	}
+}
+
+// While sign-up is closed or invite-only, OAuth signs in existing accounts but creates none.
+func TestHandleOAuthUserWhenSignUpIsRestricted(t *testing.T) {
+	tests := []struct {
+		name        string
+		allowSignup bool
+		inviteOnly  bool
+	}{
+		{name: "closed"},
+		{name: "invite-only", allowSignup: true, inviteOnly: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ta := newTestAuth(t)
+			ta.cfg.Auth.AllowSignup = tt.allowSignup
+			ta.cfg.Auth.InviteOnly = tt.inviteOnly
+			existing := ta.createUser(t, "ada@example.com", userEntity.StatusActive, "google", "google-123")
+
+			resp, err := ta.service.HandleOAuthUser(context.Background(), goth.User{Provider: "google", UserID: "google-123", Email: "ada@example.com"})
+			if err != nil {
+				t.Fatalf("HandleOAuthUser() for an existing account error = %v", err)
+			}
+			if resp.ID != existing.ID.String() {
+				t.Errorf("HandleOAuthUser() signed in %s, want %s", resp.ID, existing.ID)
+			}
+
+			_, err = ta.service.HandleOAuthUser(context.Background(), goth.User{Provider: "google", UserID: "google-456", Email: "grace@example.com"})
+			if !errors.Is(err, apiError.ErrSignupClosed) {
+				t.Fatalf("HandleOAuthUser() for a new account error = %v, want ErrSignupClosed", err)
+			}
+			if users := ta.repo.Users(); len(users) != 1 {
+				t.Errorf("repository has %d users, want only the existing one", len(users))
+			}
+		})
+	}
+}
+
+func TestHandleOAuthUserOtherProviderIsRefused(t *testing.T) {
+	ta := newTestAuth(t)
+	existing := ta.createUser(t, "ada@example.com", userEntity.StatusActive, "google", "google-123")
+
+	_, err := ta.service.HandleOAuthUser(context.Background(), goth.User{Provider: "microsoftonline", UserID: "ms-456", Email: "ada@example.com"})
+
+	var conflict *apiError.ProviderConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("HandleOAuthUser() error = %v, want a ProviderConflictError", err)
+	}
+	if conflict.Provider != "google" {
+		t.Errorf("ProviderConflictError.Provider = %q, want %q", conflict.Provider, "google")
+	}
+
+	stored, err := ta.repo.FindByID(context.Background(), existing.ID.String())
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	if stored.Provider != "google" || stored.ProviderID != "google-123" {
+		t.Errorf("account is linked to %s/%s, want google/google-123", stored.Provider, stored.ProviderID)
+	}
+}
+
+func TestHandleOAuthUserLinksVerifiedPasswordAccount(t *testing.T) {
+	ta := newTestAuth(t)
+	existing := ta.createUser(t, "ada@example.com", userEntity.StatusActive, "", "")
+
+	resp, err := ta.service.HandleOAuthUser(context.Background(), goth.User{Provider: "google", UserID: "google-123", Email: "ada@example.com"})
+	if err != nil {
+		t.Fatalf("HandleOAuthUser() error = %v", err)
+	}
+	if resp.ID != existing.ID.String() || resp.Provider != "google" || resp.ProviderID != "google-123" {
+		t.Errorf("HandleOAuthUser() = %+v, want user %s linked to google/google-123", resp, existing.ID)
+	}
+
+	stored, err := ta.repo.FindByID(context.Background(), existing.ID.String())
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	if stored.Password != existing.Password {
+		t.Error("linking the provider changed the password hash")
+	}
+
+	// The password keeps working after the account is linked.
+	signedIn, err := ta.service.LoginUser(context.Background(), &dto.SignInRequestDto{Email: "ada@example.com", Password: testPassword})
+	if err != nil {
+		t.Fatalf("LoginUser() after linking error = %v", err)
+	}
+	if signedIn.ID != existing.ID.String() {
+		t.Errorf("LoginUser() signed in %s, want %s", signedIn.ID, existing.ID)
+	}
+
+	// And a second provider still can't take the account over.
+	_, err = ta.service.HandleOAuthUser(context.Background(), goth.User{Provider: "microsoftonline", UserID: "ms-456", Email: "ada@example.com"})
+	var conflict *apiError.ProviderConflictError
+	if !errors.As(err, &conflict) || conflict.Provider != "google" {
+		t.Errorf("HandleOAuthUser() with another provider error = %v, want a ProviderConflictError naming google", err)
+	}
+}
+
+func TestHandleOAuthUserRefusesUnverifiedPasswordAccount(t *testing.T) {
+	ta := newTestAuth(t)
+	existing := ta.createUser(t, "ada@example.com", userEntity.StatusPending, "", "")
+
+	_, err := ta.service.HandleOAuthUser(context.Background(), goth.User{Provider: "google", UserID: "google-123", Email: "ada@example.com"})
+	if !errors.Is(err, apiError.ErrEmailRegisteredWithPassword) {
+		t.Fatalf("HandleOAuthUser() error = %v, want ErrEmailRegisteredWithPassword", err)
+	}
+
+	stored, err := ta.repo.FindByID(context.Background(), existing.ID.String())
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	if stored.ProviderID != "" {
+		t.Errorf("unverified account was linked to %s", stored.ProviderID)
+	}
+}
+
+func TestLoginUserRefusesOAuthOnlyAccount(t *testing.T) {
+	ta := newTestAuth(t)
+	ta.createUser(t, "ada@example.com", userEntity.StatusActive, "google", "google-123")
+
+	_, err := ta.service.LoginUser(context.Background(), &dto.SignInRequestDto{Email: "ada@example.com", Password: testPassword})
+	if !errors.Is(err, apiError.ErrEmailLinkedToOauth) {
+		t.Fatalf("LoginUser() error = %v, want ErrEmailLinkedToOauth", err)
+	}
+}
+
+func TestChangePasswordRejectsWrongCurrentPassword(t *testing.T) {
+	ta := newTestAuth(t)
+	existing := ta.createUser(t, "ada@example.com", userEntity.StatusActive, "", "")
+
+	err := ta.service.ChangePassword(context.Background(), existing.ID.String(), &dto.ChangePasswordRequestDto{CurrentPassword: "Wrong-Horse-9", NewPassword: "Battery-Staple-7"})
+	if !errors.Is(err, apiError.ErrIncorrectPassword) {
+		t.Fatalf("ChangePassword() error = %v, want ErrIncorrectPassword", err)
+	}
+
+	stored, err := ta.repo.FindByID(context.Background(), existing.ID.String())
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	if stored.Password != existing.Password {
+		t.Error("ChangePassword() changed the password")
+	}
+	if stored.SessionsRevokedAt != nil {
+		t.Error("ChangePassword() revoked the sessions")
+	}
+	if events := ta.webhooks.Events(); len(events) != 0 {
+		t.Errorf("ChangePassword() emitted %v, want no events", events)
+	}
+}
+
+func TestChangePasswordRevokesSessions(t *testing.T) {
+	ta := newTestAuth(t)
+	existing := ta.createUser(t, "ada@example.com", userEntity.StatusActive, "", "")
+
+	err := ta.service.ChangePassword(context.Background(), existing.ID.String(), &dto.ChangePasswordRequestDto{CurrentPassword: testPassword, NewPassword: "Battery-Staple-7"})
+	if err != nil {
+		t.Fatalf("ChangePassword() error = %v", err)
+	}
+
+	stored, err := ta.repo.FindByID(context.Background(), existing.ID.String())
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	if stored.SessionsRevokedAt == nil {
+		t.Error("ChangePassword() didn't revoke the sessions")
+	}
+	if events := ta.webhooks.Events(); len(events) != 1 || events[0] != webhookEntity.EventUserPasswordChanged {
+		t.Errorf("ChangePassword() emitted %v, want [%s]", events, webhookEntity.EventUserPasswordChanged)
+	}
+
+	if _, err := ta.service.LoginUser(context.Background(), &dto.SignInRequestDto{Email: "ada@example.com", Password: "Battery-Staple-7"}); err != nil {
+		t.Errorf("LoginUser() with the new password error = %v", err)
+	}
+	if _, err := ta.service.LoginUser(context.Background(), &dto.SignInRequestDto{Email: "ada@example.com", Password: testPassword}); !errors.Is(err, apiError.ErrIncorrectPassword) {
+		t.Errorf("LoginUser() with the old password error = %v, want ErrIncorrectPassword", err)
+	}
+}
+
+func TestRequestPasswordResetSendsLink(t *testing.T) {
+	ta := newTestAuth(t)
+	existing := ta.createUser(t, "ada@example.com", userEntity.StatusActive, "", "")
+
+	if err := ta.service.RequestPasswordReset(context.Background(), " Ada@Example.com "); err != nil {
+		t.Fatalf("RequestPasswordReset() error = %v", err)
+	}
+
+	sent := ta.emails.SentTo("ada@example.com")
+	if len(sent) != 1 {
+		t.Fatalf("sent %d emails to ada@example.com, want 1", len(sent))
+	}
+	if sent[0].Template != "PasswordReset" {
+		t.Errorf("email template = %q, want PasswordReset", sent[0].Template)
+	}
+	if !regexp.MustCompile(`https://app\.example\.com/reset-password\?token=`).MatchString(sent[0].Data) {
+		t.Errorf("email doesn't link to the password reset page: %s", sent[0].Data)
+	}
+
+	id, err := tokens.ExtractSubjectFromToken(ta.clock, tokens.NewKeyring(&ta.cfg.JWT), linkToken(t, sent[0]), tokens.PurposePasswordReset)
+	if err != nil {
+		t.Fatalf("reset link token is invalid: %v", err)
+	}
+	if id != existing.ID.String() {
+		t.Errorf("reset link names user %s, want %s", id, existing.ID)
+	}
+}
+
+// Accounts that can't sign in with a password get no email, and the caller can't tell them from registered ones.
+func TestRequestPasswordResetSendsNothing(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     string
+		providerID string
+	}{
+		{name: "unverified", status: userEntity.StatusPending},
+		{name: "suspended", status: userEntity.StatusSuspended},
+		{name: "banned", status: userEntity.StatusBanned},
+		{name: "oauth only", status: userEntity.StatusActive, providerID: "google-123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ta := newTestAuth(t)
+			provider := ""
+			if tt.providerID != "" {
+				provider = "google"
+			}
+			ta.createUser(t, "ada@example.com", tt.status, provider, tt.providerID)
+
+			if err := ta.service.RequestPasswordReset(context.Background(), "ada@example.com"); err != nil {
+				t.Fatalf("RequestPasswordReset() error = %v", err)
+			}
+			if sent := ta.emails.Sent(); len(sent) != 0 {
+				t.Errorf("sent %d emails, want none", len(sent))
+			}
+		})
+	}
+
+	t.Run("unknown email", func(t *testing.T) {
+		ta := newTestAuth(t)
+
+		if err := ta.service.RequestPasswordReset(context.Background(), "nobody@example.com"); err != nil {
+			t.Fatalf("RequestPasswordReset() error = %v", err)
+		}
+		if sent := ta.emails.Sent(); len(sent) != 0 {
+			t.Errorf("sent %d emails, want none", len(sent))
+		}
+	})
+}
+
+// requestResetToken asks for a password reset email for the address and returns the token in its link.
+func (ta *testAuth) requestResetToken(t *testing.T, address string) string {
+	t.Helper()
+
+	if err := ta.service.RequestPasswordReset(context.Background(), address); err != nil {
+		t.Fatalf("RequestPasswordReset() error = %v", err)
+	}
+	sent, ok := ta.emails.LastEmail()
+	if !ok {
+		t.Fatal("no password reset email was sent")
+	}
+	return linkToken(t, sent)
+}
+
+func TestResetPassword(t *testing.T) {
+	ta := newTestAuth(t)
+	existing := ta.createUser(t, "ada@example.com", userEntity.StatusActive, "", "")
+	token := ta.requestResetToken(t, "ada@example.com")
+
+	if err := ta.service.ResetPassword(context.Background(), &dto.PasswordResetRequestDto{Token: token, NewPassword: "Battery-Staple-7"}); err != nil {
+		t.Fatalf("ResetPassword() error = %v", err)
+	}
+
+	stored, err := ta.repo.FindByID(context.Background(), existing.ID.String())
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	if stored.SessionsRevokedAt == nil {
+		t.Error("ResetPassword() didn't revoke the sessions")
+	}
+	if events := ta.webhooks.Events(); len(events) != 1 || events[0] != webhookEntity.EventUserPasswordReset {
+		t.Errorf("ResetPassword() emitted %v, want [%s]", events, webhookEntity.EventUserPasswordReset)
+	}
+	if _, err := ta.service.LoginUser(context.Background(), &dto.SignInRequestDto{Email: "ada@example.com", Password: "Battery-Staple-7"}); err != nil {
+		t.Errorf("LoginUser() with the new password error = %v", err)
+	}
+
+	// The link only works once.
+	err = ta.service.ResetPassword(context.Background(), &dto.PasswordResetRequestDto{Token: token, NewPassword: "Another-Staple-8"})
+	if !errors.Is(err, apiError.ErrInvalidToken) {
+		t.Errorf("ResetPassword() with a used token error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestResetPasswordRejectsReusedPassword(t *testing.T) {
+	ta := newTestAuth(t)
+	existing := ta.createUser(t, "ada@example.com", userEntity.StatusActive, "", "")
+	token := ta.requestResetToken(t, "ada@example.com")
+
+	err := ta.service.ResetPassword(context.Background(), &dto.PasswordResetRequestDto{Token: token, NewPassword: testPassword})
+	if !errors.Is(err, apiError.ErrPasswordReused) {
+		t.Fatalf("ResetPassword() error = %v, want ErrPasswordReused", err)
+	}
+
+	stored, err := ta.repo.FindByID(context.Background(), existing.ID.String())
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	if stored.SessionsRevokedAt != nil {
+		t.Error("ResetPassword() revoked the sessions")
+	}
+}
+
+func TestResetPasswordRejectsExpiredToken(t *testing.T) {
+	ta := newTestAuth(t)
+	ta.createUser(t, "ada@example.com", userEntity.StatusActive, "", "")
+	token := ta.requestResetToken(t, "ada@example.com")
+
+	ta.clock.Advance(ta.cfg.JWT.PasswordResetTokenExpiry + time.Second)
+
+	err := ta.service.ResetPassword(context.Background(), &dto.PasswordResetRequestDto{Token: token, NewPassword: "Battery-Staple-7"})
+	if !errors.Is(err, gojwt.ErrTokenExpired) {
+		t.Fatalf("ResetPassword() error = %v, want ErrTokenExpired", err)
+	}
+}
+
+// A verification link can't be used to reset the password.
+func TestResetPasswordRejectsOtherPurposes(t *testing.T) {
+	ta := newTestAuth(t)
+	existing := ta.createUser(t, "ada@example.com", userEntity.StatusActive, "", "")
+
+	token, err := tokens.NewJwtToken(ta.clock, existing.ID.String(), tokens.PurposeVerification, tokens.NewKeyring(&ta.cfg.JWT), time.Hour)
+	if err != nil {
+		t.Fatalf("NewJwtToken() error = %v", err)
+	}
+
+	err = ta.service.ResetPassword(context.Background(), &dto.PasswordResetRequestDto{Token: token, NewPassword: "Battery-Staple-7"})
+	if !errors.Is(err, apiError.ErrInvalidToken) {
+		t.Fatalf("ResetPassword() error = %v, want ErrInvalidToken", err)
+	}
+}
+
+// Reset emails to one account are capped however the requests arrive, without changing the outcome reported to the caller.
+func TestRequestPasswordResetIsThrottledPerAccount(t *testing.T) {
+	ta := newTestAuth(t)
+	ta.createUser(t, "ada@example.com", userEntity.StatusActive, "", "")
+	ta.createUser(t, "grace@example.com", userEntity.StatusActive, "", "")
+
+	for i := 0; i < ta.cfg.Auth.PasswordResetLimit+2; i++ {
+		if err := ta.service.RequestPasswordReset(context.Background(), "ada@example.com"); err != nil {
+			t.Fatalf("RequestPasswordReset() #%d error = %v", i+1, err)
+		}
+	}
+	if sent := ta.emails.SentTo("ada@example.com"); len(sent) != ta.cfg.Auth.PasswordResetLimit {
+		t.Errorf("sent %d emails to ada@example.com, want %d", len(sent), ta.cfg.Auth.PasswordResetLimit)
+	}
+
+	// Other accounts have their own allowance.
+	if err := ta.service.RequestPasswordReset(context.Background(), "grace@example.com"); err != nil {
+		t.Fatalf("RequestPasswordReset() error = %v", err)
+	}
+	if sent := ta.emails.SentTo("grace@example.com"); len(sent) != 1 {
+		t.Errorf("sent %d emails to grace@example.com, want 1", len(sent))
+	}
+
+	// The allowance comes back once the window has passed.
+	ta.clock.Advance(ta.cfg.Auth.PasswordResetWindow)
+	if err := ta.service.RequestPasswordReset(context.Background(), "ada@example.com"); err != nil {
+		t.Fatalf("RequestPasswordReset() error = %v", err)
+	}
+	if sent := ta.emails.SentTo("ada@example.com"); len(sent) != ta.cfg.Auth.PasswordResetLimit+1 {
+		t.Errorf("sent %d emails to ada@example.com after the window, want %d", len(sent), ta.cfg.Auth.PasswordResetLimit+1)
+	}
+}
+
+// expiresAt returns the exp claim of the token, without verifying it.
+func expiresAt(t *testing.T, token string) time.Time {
+	t.Helper()
+
+	claims := gojwt.MapClaims{}
+	if _, _, err := gojwt.NewParser().ParseUnverified(token, claims); err != nil {
+		t.Fatalf("parse token: %v", err)
+	}
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil {
+		t.Fatalf("token has no exp claim: %v", err)
+	}
+	return exp.Time
+}
+
+// The links in emails expire after the lifetime configured for their flow.
+func TestEmailTokensUseConfiguredLifetime(t *testing.T) {
+	ta := newTestAuth(t)
+	ta.cfg.JWT.VerificationTokenExpiry = 6 * time.Hour
+	ta.cfg.JWT.PasswordResetTokenExpiry = 15 * time.Minute
+	existing := ta.createUser(t, "ada@example.com", userEntity.StatusActive, "", "")
+
+	u, err := ta.users.GetUserByID(context.Background(), existing.ID.String())
+	if err != nil {
+		t.Fatalf("GetUserByID() error = %v", err)
+	}
+	if err := ta.service.SendAccountVerificationEmail(context.Background(), u); err != nil {
+		t.Fatalf("SendAccountVerificationEmail() error = %v", err)
+	}
+	verification, _ := ta.emails.LastEmail()
+	if got, want := expiresAt(t, linkToken(t, verification)), ta.clock.Now().Add(6*time.Hour); !got.Equal(want) {
+		t.Errorf("verification token expires at %s, want %s", got, want)
+	}
+
+	reset := ta.requestResetToken(t, "ada@example.com")
+	if got, want := expiresAt(t, reset), ta.clock.Now().Add(15*time.Minute); !got.Equal(want) {
+		t.Errorf("password reset token expires at %s, want %s", got, want)
+	}
+}
+
+func TestRegisterUserSendsVerificationEmail(t *testing.T) {
+	ta := newTestAuth(t)
+
+	err := ta.service.RegisterUser(context.Background(), &dto.SignUpRequestDto{
+		FirstName:   "Ada",
+		LastName:    "Lovelace",
+		Email:       "ada@example.com",
+		Password:    testPassword,
+		PhoneNumber: "+15555550100",
+	})
+	if err != nil {
+		t.Fatalf("RegisterUser() error = %v", err)
+	}
+
+	users := ta.repo.Users()
+	if len(users) != 1 {
+		t.Fatalf("RegisterUser() created %d users, want 1", len(users))
+	}
+
+	sent := ta.emails.Sent()
+	if len(sent) != 1 {
+		t.Fatalf("sent %d emails, want 1", len(sent))
+	}
+	if len(sent[0].To) != 1 || sent[0].To[0] != "ada@example.com" {
+		t.Errorf("email sent to %v, want [ada@example.com]", sent[0].To)
+	}
+	if sent[0].From != ta.cfg.Mail.FromEmail || sent[0].Template != "UserVerification" {
+		t.Errorf("email from %q with template %q, want %q with UserVerification", sent[0].From, sent[0].Template, ta.cfg.Mail.FromEmail)
+	}
+
+	// The activation link points at the verification route with a token naming the new user.
+	if !regexp.MustCompile(`https://api\.example\.com/api/v1/auth/verify-email\?token=`).MatchString(sent[0].Data) {
+		t.Errorf("email doesn't link to the verification route: %s", sent[0].Data)
+	}
+	id, err := tokens.ExtractSubjectFromToken(ta.clock, tokens.NewKeyring(&ta.cfg.JWT), linkToken(t, sent[0]), tokens.PurposeVerification)
+	if err != nil {
+		t.Fatalf("activation link token is invalid: %v", err)
+	}
+	if id != users[0].ID.String() {
+		t.Errorf("activation link names user %s, want %s", id, users[0].ID)
+	}
+
+	// Following the link activates the account.
+	if _, err := ta.service.ActivateAccount(context.Background(), linkToken(t, sent[0])); err != nil {
+		t.Fatalf("ActivateAccount() error = %v", err)
+	}
+	activated, err := ta.repo.FindByID(context.Background(), id)
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	if activated.Status != userEntity.StatusActive {
+		t.Errorf("status after following the link = %q, want %q", activated.Status, userEntity.StatusActive)
+	}
+}
+
+func TestRegisterUserWithRegisteredEmail(t *testing.T) {
+	ta := newTestAuth(t)
+	ta.createUser(t, "ada@example.com", userEntity.StatusActive, "", "")
+
+	err := ta.service.RegisterUser(context.Background(), &dto.SignUpRequestDto{
+		FirstName:   "Ada",
+		LastName:    "Lovelace",
+		Email:       "ada@example.com",
+		Password:    testPassword,
+		PhoneNumber: "+15555550100",
+	})
+	if !errors.Is(err, apiError.ErrEmailAlreadyExists) {
+		t.Fatalf("RegisterUser() error = %v, want ErrEmailAlreadyExists", err)
+	}
+	if users := ta.repo.Users(); len(users) != 1 {
+		t.Errorf("RegisterUser() left %d users, want only the existing one", len(users))
+	}
+	if sent := ta.emails.Sent(); len(sent) != 0 {
+		t.Errorf("sent %d emails, want none", len(sent))
+	}
+}
+
+func TestRegisterUserKeepsUserWhenEmailFails(t *testing.T) {
+	tests := []struct {
+		name    string
+		sendErr error
+	}{
+		{name: "transient failure", sendErr: errors.New("smtp: connection reset")},
+		{name: "undeliverable address", sendErr: fmt.Errorf("%w: address rejected", email.ErrEmailNotDeliverable)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ta := newTestAuth(t)
+			ta.emails.Err = tt.sendErr
+
+			err := ta.service.RegisterUser(context.Background(), &dto.SignUpRequestDto{
+				FirstName:   "Ada",
+				LastName:    "Lovelace",
+				Email:       "ada@example.com",
+				Password:    testPassword,
+				PhoneNumber: "+15555550100",
+			})
+			if !errors.Is(err, apiError.ErrVerificationEmailNotSent) || !errors.Is(err, tt.sendErr) {
+				t.Fatalf("RegisterUser() error = %v, want ErrVerificationEmailNotSent wrapping %v", err, tt.sendErr)
+			}
+
+			// The account was committed before the email was tried.
+			users := ta.repo.Users()
+			if len(users) != 1 || users[0].Email != "ada@example.com" || users[0].Status != userEntity.StatusPending {
+				t.Fatalf("users after a failed email = %+v, want one pending ada@example.com", users)
+			}
+
+			// Signing up again doesn't help, the user asks for the email to be resent instead.
+			ta.emails.Err = nil
+			registered, err := ta.service.GetUserByEmail(context.Background(), "ada@example.com")
+			if err != nil {
+				t.Fatalf("GetUserByEmail() error = %v", err)
+			}
+			if err := ta.service.SendAccountVerificationEmail(context.Background(), registered); err != nil {
+				t.Fatalf("SendAccountVerificationEmail() error = %v", err)
+			}
+			sent, ok := ta.emails.LastEmail()
+			if !ok || len(sent.To) != 1 || sent.To[0] != "ada@example.com" {
+				t.Fatalf("resent email = %+v, want one to ada@example.com", sent)
+			}
+			if _, err := ta.service.ActivateAccount(context.Background(), linkToken(t, sent)); err != nil {
+				t.Fatalf("ActivateAccount() with the resent link error = %v", err)
+			}
+			activated, err := ta.repo.FindByID(context.Background(), users[0].ID.String())
+			if err != nil {
+				t.Fatalf("FindByID() error = %v", err)
+			}
+			if activated.Status != userEntity.StatusActive {
+				t.Errorf("status after following the resent link = %q, want %q", activated.Status, userEntity.StatusActive)
+			}
+		})
+	}
+}
+
+// changePassword changes the user's password from current to next, failing the test if it's refused.
+func (ta *testAuth) changePassword(t *testing.T, id, current, next string) {
+	t.Helper()
+
+	err := ta.service.ChangePassword(context.Background(), id, &dto.ChangePasswordRequestDto{CurrentPassword: current, NewPassword: next})
+	if err != nil {
+		t.Fatalf("ChangePassword(%q to %q) error = %v", current, next, err)
+	}
+}
+
+func TestChangePasswordRejectsRecentPasswords(t *testing.T) {
+	ta := newTestAuth(t)
+	ta.cfg.Security.PasswordHistory = 2
+	existing := ta.createUser(t, "ada@example.com", userEntity.StatusActive, "", "")
+	id := existing.ID.String()
+
+	ta.changePassword(t, id, testPassword, "Battery-Staple-1")
+	ta.changePassword(t, id, "Battery-Staple-1", "Battery-Staple-2")
+	ta.changePassword(t, id, "Battery-Staple-2", "Battery-Staple-3")
+
+	// The current password and the two before it are refused.
+	for _, reused := range []string{"Battery-Staple-3", "Battery-Staple-2", "Battery-Staple-1"} {
+		err := ta.service.ChangePassword(context.Background(), id, &dto.ChangePasswordRequestDto{CurrentPassword: "Battery-Staple-3", NewPassword: reused})
+		if !errors.Is(err, apiError.ErrPasswordReused) {
+			t.Errorf("ChangePassword() to %q error = %v, want ErrPasswordReused", reused, err)
+		}
+	}
+	if history, _ := ta.repo.FindPasswordHistory(context.Background(), id, 10); len(history) != 2 {
+		t.Errorf("password history has %d entries, want 2", len(history))
+	}
+
+	// The password before those has dropped out of the history and may be used again.
+	ta.changePassword(t, id, "Battery-Staple-3", testPassword)
+}
+
+func TestChangePasswordWithoutHistory(t *testing.T) {
+	ta := newTestAuth(t)
+	existing := ta.createUser(t, "ada@example.com", userEntity.StatusActive, "", "")
+	id := existing.ID.String()
+
+	ta.changePassword(t, id, testPassword, "Battery-Staple-1")
+
+	// Only the current password is refused when no history is kept.
+	err := ta.service.ChangePassword(context.Background(), id, &dto.ChangePasswordRequestDto{CurrentPassword: "Battery-Staple-1", NewPassword: "Battery-Staple-1"})
+	if !errors.Is(err, apiError.ErrPasswordReused) {
+		t.Errorf("ChangePassword() to the current password error = %v, want ErrPasswordReused", err)
+	}
+	ta.changePassword(t, id, "Battery-Staple-1", testPassword)
+	if history, _ := ta.repo.FindPasswordHistory(context.Background(), id, 10); len(history) != 0 {
+		t.Errorf("password history has %d entries, want none", len(history))
+	}
+}
+
+// createUserWithUsername inserts an active password user with the email and username.
+func (ta *testAuth) createUserWithUsername(t *testing.T, email, username string) *userEntity.User {
+	t.Helper()
+
+	hash, err := ta.hasher.Hash(testPassword)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	u := &userEntity.User{FirstName: "Ada", Email: email, Username: &username, Password: hash, Status: userEntity.StatusActive, Role: userEntity.RoleUser}
+	created, err := ta.repo.Insert(context.Background(), u)
+	if err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+	return created
+}
+
+func TestLoginUserByUsernameOrEmail(t *testing.T) {
+	ta := newTestAuth(t)
+	ta.cfg.Security.UsernameLogin = true
+	existing := ta.createUserWithUsername(t, "ada@example.com", "ada_l")
+
+	for _, identifier := range []string{"ada_l", "Ada_L", "ada@example.com"} {
+		resp, err := ta.service.LoginUser(context.Background(), &dto.SignInRequestDto{Identifier: identifier, Password: testPassword})
+		if err != nil {
+			t.Errorf("LoginUser() as %q error = %v", identifier, err)
+			continue
+		}
+		if resp.ID != existing.ID.String() {
+			t.Errorf("LoginUser() as %q signed in user %s, want %s", identifier, resp.ID, existing.ID)
+		}
+	}
+
+	// Older clients still send the email field.
+	if _, err := ta.service.LoginUser(context.Background(), &dto.SignInRequestDto{Email: "ada@example.com", Password: testPassword}); err != nil {
+		t.Errorf("LoginUser() with the email field error = %v", err)
+	}
+
+	if _, err := ta.service.LoginUser(context.Background(), &dto.SignInRequestDto{Identifier: "ada_l", Password: "Wrong-Horse-9"}); !errors.Is(err, apiError.ErrIncorrectPassword) {
+		t.Errorf("LoginUser() as the username with a wrong password error = %v, want ErrIncorrectPassword", err)
+	}
+}
+
+func TestLoginUserByUsernameWhenDisabled(t *testing.T) {
+	ta := newTestAuth(t)
+	ta.createUserWithUsername(t, "ada@example.com", "ada_l")
+
+	// Without username sign-in every identifier is an email.
+	if _, err := ta.service.LoginUser(context.Background(), &dto.SignInRequestDto{Identifier: "ada_l", Password: testPassword}); !errors.Is(err, postgres.ErrRecordNotFound) {
+		t.Errorf("LoginUser() as the username error = %v, want ErrRecordNotFound", err)
+	}
+	if _, err := ta.service.LoginUser(context.Background(), &dto.SignInRequestDto{Identifier: "ada@example.com", Password: testPassword}); err != nil {
+		t.Errorf("LoginUser() as the email error = %v", err)
+	}
+}
+
+func TestLoginUserRehashesPassword(t *testing.T) {
+	ta := newTestAuth(t)
+	existing := ta.createUser(t, "ada@example.com", userEntity.StatusActive, "", "")
+
+	// The account was created with bcrypt; the service now hashes with argon2id.
+	ta.cfg.Security.PasswordAlgorithm = AlgorithmArgon2id
+	ta.hasher = NewPasswordHasher(ta.cfg)
+	ta.service = NewAuthService(ta.users, ta.emails, postgrestest.NopTransactionManager{}, noopBreachChecker{}, NewMemoryResetThrottle(ta.clock, ta.cfg.Auth.PasswordResetLimit, ta.cfg.Auth.PasswordResetWindow), ta.hasher, ta.webhooks, ta.clock, ta.metrics, ta.cfg)
+
+	if _, err := ta.service.LoginUser(context.Background(), &dto.SignInRequestDto{Email: "ada@example.com", Password: testPassword}); err != nil {
+		t.Fatalf("LoginUser() with a bcrypt hash error = %v", err)
+	}
+
+	stored, err := ta.repo.FindByID(context.Background(), existing.ID.String())
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	if stored.Password == existing.Password || ta.hasher.NeedsRehash(stored.Password) {
+		t.Fatalf("LoginUser() left hash %q, want an argon2id hash", stored.Password)
+	}
+	if stored.SessionsRevokedAt != nil {
+		t.Error("LoginUser() revoked the sessions while rehashing")
+	}
+
+	// The new hash verifies the same password, and a wrong one is still refused.
+	if _, err := ta.service.LoginUser(context.Background(), &dto.SignInRequestDto{Email: "ada@example.com", Password: testPassword}); err != nil {
+		t.Errorf("LoginUser() with the argon2id hash error = %v", err)
+	}
+	if _, err := ta.service.LoginUser(context.Background(), &dto.SignInRequestDto{Email: "ada@example.com", Password: "Wrong-Horse-9"}); !errors.Is(err, apiError.ErrIncorrectPassword) {
+		t.Errorf("LoginUser() with a wrong password error = %v, want ErrIncorrectPassword", err)
+	}
+}
+
+// inviteUser invites the email and returns the token from the invitation email.
+func (ta *testAuth) inviteUser(t *testing.T, address string) (string, string) {
+	t.Helper()
+
+	resp, err := ta.service.InviteUsers(context.Background(), &dto.InviteUsersRequestDto{Invites: []dto.InviteRequestDto{{Email: address}}})
+	if err != nil {
+		t.Fatalf("InviteUsers() error = %v", err)
+	}
+	if result := resp.Results[0]; result.Status != dto.InviteStatusInvited {
+		t.Fatalf("InviteUsers() result = %+v, want %s", result, dto.InviteStatusInvited)
+	}
+	sent, ok := ta.emails.LastEmail()
+	if !ok {
+		t.Fatal("no invitation email was sent")
+	}
+	return resp.Results[0].UserID, linkToken(t, sent)
+}
+
+func TestAcceptInvite(t *testing.T) {
+	ta := newTestAuth(t)
+	id, token := ta.inviteUser(t, "ada@example.com")
+
+	request := &dto.AcceptInviteRequestDto{Token: token, FirstName: "Ada", LastName: "Lovelace", Password: "Battery-Staple-7"}
+	if _, err := ta.service.AcceptInvite(context.Background(), request); err != nil {
+		t.Fatalf("AcceptInvite() error = %v", err)
+	}
+
+	stored, err := ta.repo.FindByID(context.Background(), id)
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	if stored.Status != userEntity.StatusActive || stored.FirstName != "Ada" || stored.LastName != "Lovelace" {
+		t.Errorf("stored user = %s %s/%s, want Ada Lovelace/%s", stored.FirstName, stored.LastName, stored.Status, userEntity.StatusActive)
+	}
+	if _, err := ta.service.LoginUser(context.Background(), &dto.SignInRequestDto{Email: "ada@example.com", Password: "Battery-Staple-7"}); err != nil {
+		t.Errorf("LoginUser() with the chosen password error = %v", err)
+	}
+
+	// The link only works once.
+	request.Password = "Another-Staple-8"
+	if _, err := ta.service.AcceptInvite(context.Background(), request); !errors.Is(err, apiError.ErrInvitationNotPending) {
+		t.Errorf("AcceptInvite() with a used token error = %v, want ErrInvitationNotPending", err)
+	}
+}
+
+// A verification or password reset link can't be used to accept an invitation.
+func TestAcceptInviteRejectsOtherPurposes(t *testing.T) {
+	for _, purpose := range []string{tokens.PurposeVerification, tokens.PurposePasswordReset} {
+		t.Run(purpose, func(t *testing.T) {
+			ta := newTestAuth(t)
+			id, _ := ta.inviteUser(t, "ada@example.com")
+
+			token, err := tokens.NewJwtToken(ta.clock, id, purpose, tokens.NewKeyring(&ta.cfg.JWT), time.Hour)
+			if err != nil {
+				t.Fatalf("NewJwtToken() error = %v", err)
+			}
+
+			_, err = ta.service.AcceptInvite(context.Background(), &dto.AcceptInviteRequestDto{Token: token, FirstName: "Ada", LastName: "Lovelace", Password: "Battery-Staple-7"})
+			if !errors.Is(err, apiError.ErrInvalidToken) {
+				t.Fatalf("AcceptInvite() error = %v, want ErrInvalidToken", err)
+			}
+
+			stored, err := ta.repo.FindByID(context.Background(), id)
+			if err != nil {
+				t.Fatalf("FindByID() error = %v", err)
+			}
+			if stored.Status != userEntity.StatusPending || stored.Password != "" {
+				t.Errorf("AcceptInvite() changed the account to %s with password %q", stored.Status, stored.Password)
+			}
+		})
+	}
+}
+
+// Of several requests accepting the same invitation at once, only one sets the password.
+func TestAcceptInviteConcurrently(t *testing.T) {
+	ta := newTestAuth(t)
+	_, token := ta.inviteUser(t, "ada@example.com")
+
+	const attempts = 5
+	errs := make(chan error, attempts)
+	var wg sync.WaitGroup
+	for i := range attempts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := ta.service.AcceptInvite(context.Background(), &dto.AcceptInviteRequestDto{
+				Token: token, FirstName: "Ada", LastName: "Lovelace", Password: fmt.Sprintf("Battery-Staple-%d", i),
+			})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	accepted := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			accepted++
+		case !errors.Is(err, apiError.ErrInvitationNotPending):
+			t.Errorf("AcceptInvite() error = %v, want ErrInvitationNotPending", err)
+		}
+	}
+	if accepted != 1 {
+		t.Errorf("AcceptInvite() succeeded %d times, want 1", accepted)
+	}
+	activated := 0
+	for _, event := range ta.webhooks.Events() {
+		if event == webhookEntity.EventUserActivated {
+			activated++
+		}
+	}
+	if activated != 1 {
+		t.Errorf("emitted %d %s events, want 1", activated, webhookEntity.EventUserActivated)
+	}
+}
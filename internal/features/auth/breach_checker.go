@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	apiError "github.com/npushpakumara/go-backend-template/pkg/errors"
+	"github.com/npushpakumara/go-backend-template/pkg/logging"
+)
+
+// pwnedPasswordsURL is the HaveIBeenPwned range API, queried with the first five characters of the SHA-1 hash.
+const pwnedPasswordsURL = "https://api.pwnedpasswords.com/range/"
+
+// BreachChecker reports whether a password appears in a known data breach.
+type BreachChecker interface {
+	IsBreached(ctx context.Context, password string) (bool, error)
+}
+
+// NewBreachChecker returns a BreachChecker backed by HaveIBeenPwned when the check is enabled,
+// and one that never reports a breach otherwise.
+func NewBreachChecker(cfg *config.Config) BreachChecker {
+	if !cfg.Security.BreachedPasswordCheck {
+		return noopBreachChecker{}
+	}
+	return &pwnedPasswordsChecker{
+		client: &http.Client{Timeout: cfg.Security.BreachedPasswordTimeout},
+	}
+}
+
+// noopBreachChecker is used when breached-password checks are disabled.
+type noopBreachChecker struct{}
+
+// IsBreached always reports that the password is not breached.
+func (noopBreachChecker) IsBreached(context.Context, string) (bool, error) {
+	return false, nil
+}
+
+// pwnedPasswordsChecker queries the HaveIBeenPwned range API using k-anonymity:
+// only the first five characters of the password's SHA-1 hash leave the process.
+type pwnedPasswordsChecker struct {
+	client *http.Client
+}
+
+// IsBreached looks up the password's hash suffix in the range returned for its prefix.
+func (c *pwnedPasswordsChecker) IsBreached(ctx context.Context, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pwnedPasswordsURL+prefix, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Add-Padding", "true")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("pwned passwords api returned status %d", resp.StatusCode)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		candidate, count, ok := strings.Cut(scanner.Text(), ":")
+		// Padding entries have a count of zero and never match a real password.
+		if ok && candidate == suffix && strings.TrimSpace(count) != "0" {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+// checkBreachedPassword returns ErrPasswordBreached when the password is known to be breached.
+// The check fails open: if the breach service can't be reached, the password is accepted and the failure logged.
+func checkBreachedPassword(ctx context.Context, checker BreachChecker, password string) error {
+	breached, err := checker.IsBreached(ctx, password)
+	if err != nil {
+		logging.FromContext(ctx).Warnw("auth.service.checkBreachedPassword breach check failed, accepting password", "err", err)
+		return nil
+	}
+	if breached {
+		return apiError.ErrPasswordBreached
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/npushpakumara/go-backend-template/internal/features/auth/dto"
+	userEntity "github.com/npushpakumara/go-backend-template/internal/features/user/entity"
+	"github.com/npushpakumara/go-backend-template/internal/postgres/postgrestest"
+	"github.com/npushpakumara/go-backend-template/pkg"
+	apiError "github.com/npushpakumara/go-backend-template/pkg/errors"
+)
+
+// stubBreachChecker answers every breach check with the same result.
+type stubBreachChecker struct {
+	breached bool
+	err      error
+}
+
+func (s stubBreachChecker) IsBreached(context.Context, string) (bool, error) {
+	return s.breached, s.err
+}
+
+func TestChangePasswordChecksBreaches(t *testing.T) {
+	tests := []struct {
+		name    string
+		checker stubBreachChecker
+		wantErr error
+	}{
+		{name: "clean", checker: stubBreachChecker{}},
+		{name: "breached", checker: stubBreachChecker{breached: true}, wantErr: apiError.ErrPasswordBreached},
+		// The check fails open, so an unreachable breach service doesn't lock users out of changing passwords.
+		{name: "checker unavailable", checker: stubBreachChecker{err: errors.New("connection refused")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ta := newTestAuth(t)
+			ta.service = NewAuthService(ta.users, ta.emails, postgrestest.NopTransactionManager{}, tt.checker, NewMemoryResetThrottle(ta.clock, ta.cfg.Auth.PasswordResetLimit, ta.cfg.Auth.PasswordResetWindow), ta.hasher, ta.webhooks, ta.clock, ta.metrics, ta.cfg)
+			existing := ta.createUser(t, "ada@example.com", userEntity.StatusActive, "", "")
+
+			err := ta.service.ChangePassword(context.Background(), existing.ID.String(), &dto.ChangePasswordRequestDto{CurrentPassword: testPassword, NewPassword: "Battery-Staple-7"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ChangePassword() error = %v, want %v", err, tt.wantErr)
+			}
+
+			stored, err := ta.repo.FindByID(context.Background(), existing.ID.String())
+			if err != nil {
+				t.Fatalf("FindByID() error = %v", err)
+			}
+			if changed := stored.Password != existing.Password; changed != (tt.wantErr == nil) {
+				t.Errorf("password changed = %v, want %v", changed, tt.wantErr == nil)
+			}
+		})
+	}
+}
+
+// Weak passwords are refused when the request is bound, before the service sees them.
+func TestPasswordStrength(t *testing.T) {
+	if err := pkg.RegisterValidations(); err != nil {
+		t.Fatalf("RegisterValidations() error = %v", err)
+	}
+
+	tests := []struct {
+		password string
+		wantErr  bool
+	}{
+		{password: "Battery-Staple-7"},
+		{password: "battery-staple-7", wantErr: true},
+		{password: "BATTERY-STAPLE-7", wantErr: true},
+		{password: "Battery-Staple", wantErr: true},
+		{password: "BatteryStaple7", wantErr: true},
+		{password: "Ba-7", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			request := dto.ChangePasswordRequestDto{CurrentPassword: testPassword, NewPassword: tt.password}
+			err := binding.Validator.ValidateStruct(&request)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateStruct() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
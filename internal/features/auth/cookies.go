@@ -0,0 +1,31 @@
+package auth
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/pkg/httpx"
+)
+
+const (
+	// AccessTokenCookie is the name of the cookie that carries the JWT access token.
+	AccessTokenCookie = "access_token"
+
+	// oauthStateCookie holds the state parameter of an OAuth flow in progress.
+	oauthStateCookie = "oauth_state"
+
+	// oauthStateTTL is how long the user has to complete the provider's sign-in page.
+	oauthStateTTL = 5 * time.Minute
+)
+
+// oauthStateCookieOptions returns the cookie attributes for the OAuth state cookie.
+// The provider redirects back with a cross-site top-level GET, which carries Lax cookies but not
+// Strict ones, so SameSite is pinned to Lax unless None was configured.
+func oauthStateCookieOptions(cfg *config.CookieConfig) httpx.CookieOptions {
+	opts := cfg.Options()
+	if opts.SameSite != http.SameSiteNoneMode {
+		opts.SameSite = http.SameSiteLaxMode
+	}
+	return opts
+}
@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/npushpakumara/go-backend-template/internal/config"
+)
+
+func TestOAuthStateCookieOptions(t *testing.T) {
+	secure := true
+	tests := []struct {
+		sameSite string
+		want     http.SameSite
+	}{
+		{sameSite: "lax", want: http.SameSiteLaxMode},
+		// A Strict state cookie wouldn't come back with the provider's redirect.
+		{sameSite: "strict", want: http.SameSiteLaxMode},
+		{sameSite: "none", want: http.SameSiteNoneMode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sameSite, func(t *testing.T) {
+			cfg := &config.CookieConfig{Secure: &secure, Domain: "example.com", Path: "/", SameSite: tt.sameSite}
+			opts := oauthStateCookieOptions(cfg)
+			if opts.SameSite != tt.want {
+				t.Errorf("oauthStateCookieOptions() SameSite = %v, want %v", opts.SameSite, tt.want)
+			}
+			if !opts.Secure || opts.Domain != "example.com" || opts.Path != "/" || opts.ScriptReadable {
+				t.Errorf("oauthStateCookieOptions() = %+v, want the configured Secure, Domain and Path, HttpOnly", opts)
+			}
+		})
+	}
+}
@@ -1,26 +1,86 @@
 package dto
 
 // SignUpRequestDto is a Data Transfer Object (DTO) used to capture and validate the data required for a new user sign-up.
-// It includes fields for the user's first and last names, email, password, and phone number, all of which are required.
+// It includes fields for the user's first and last names, email, password, and phone number, all of which are required,
+// an optional preferred locale used for emails and an optional username, accepted when username sign-in is enabled.
+// InviteToken is the token of an invitation to the same email, required when sign-up is invite-only.
 type SignUpRequestDto struct {
-	FirstName   string `json:"first_name" binding:"required,min=2,max=100"`
-	LastName    string `json:"last_name" binding:"required,min=2,max=100"`
+	FirstName   string `json:"first_name" binding:"required,min=2,max=100,person_name"`
+	LastName    string `json:"last_name" binding:"required,min=2,max=100,person_name"`
 	Email       string `json:"email" binding:"required,email"`
-	Password    string `json:"password" binding:"required,min=8,max=100"`
+	Password    string `json:"password" binding:"required,min=8,max=100,max_bytes=72,password_strength"`
 	PhoneNumber string `json:"phone_number" binding:"required,e164,min=12,max=12"`
+	Locale      string `json:"locale" binding:"omitempty,bcp47_language_tag,max=35"`
+	Username    string `json:"username" binding:"omitempty,username"`
+	InviteToken string `json:"invite_token" binding:"omitempty,max=2048"`
+	// OrganizationName creates an organization owned by the new user.
+	OrganizationName string `json:"organization_name" binding:"omitempty,min=2,max=100"`
 }
 
 // SignInRequestDto is a Data Transfer Object (DTO) used to capture and validate the data required for user sign-in.
-// It includes the user's email and password, both of which are required.
+// It includes the user's identifier, an email or a username, and password, both of which are required.
+// Email is still accepted in place of Identifier for clients written before usernames existed.
 type SignInRequestDto struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=8,max=100"`
+	Identifier string `json:"identifier" binding:"required_without=Email,max=100"`
+	Email      string `json:"email" binding:"omitempty,email"`
+	Password   string `json:"password" binding:"required,min=8,max=100"`
 }
 
-// PasswordResetRequestDto is a Data Transfer Object (DTO) used to capture and validate the data required for a password reset.
-// It includes the user's email, current password, and new password, all of which are required.
-type PasswordResetRequestDto struct {
-	Email           string `json:"email" binding:"required,email"`
+// LoginIdentifier returns the identifier the user signs in with, falling back to Email.
+func (request *SignInRequestDto) LoginIdentifier() string {
+	if request.Identifier != "" {
+		return request.Identifier
+	}
+	return request.Email
+}
+
+// ChangePasswordRequestDto is a Data Transfer Object (DTO) used to capture and validate a signed-in user's password change.
+// It includes the user's current password and new password, both of which are required.
+type ChangePasswordRequestDto struct {
 	CurrentPassword string `json:"current_password" binding:"required,min=8,max=100"`
-	NewPassword     string `json:"new_password" binding:"required,min=8,max=100"`
+	NewPassword     string `json:"new_password" binding:"required,min=8,max=100,max_bytes=72,password_strength"`
+}
+
+// ForgotPasswordRequestDto captures the email of a user who forgot their password.
+type ForgotPasswordRequestDto struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// PasswordResetRequestDto is a Data Transfer Object (DTO) used to capture and validate a password reset.
+// It includes the token from the password reset email and the new password, both of which are required.
+type PasswordResetRequestDto struct {
+	Token       string `json:"token" binding:"required,max=2048"`
+	NewPassword string `json:"new_password" binding:"required,min=8,max=100,max_bytes=72,password_strength"`
+}
+
+// VerifyEmailRequestDto captures the query parameters of the account verification link.
+type VerifyEmailRequestDto struct {
+	Token string `form:"token" binding:"required,max=2048"`
+}
+
+// ResendVerificationRequestDto captures the query parameters of a request to resend the verification email.
+// The user is identified by ID or, since sign-up doesn't return the ID, by email.
+type ResendVerificationRequestDto struct {
+	ID    string `form:"id" binding:"required_without=Email,omitempty,uuid"`
+	Email string `form:"email" binding:"omitempty,email"`
+}
+
+// InviteUsersRequestDto captures an administrator's request to invite several people at once.
+type InviteUsersRequestDto struct {
+	Invites []InviteRequestDto `json:"invites" binding:"required,min=1,max=100,dive"`
+}
+
+// InviteRequestDto is a single invitation. Role defaults to "user" and Locale to English.
+type InviteRequestDto struct {
+	Email  string `json:"email" binding:"required,email"`
+	Role   string `json:"role" binding:"omitempty,oneof=user admin"`
+	Locale string `json:"locale" binding:"omitempty,bcp47_language_tag,max=35"`
+}
+
+// AcceptInviteRequestDto captures the details an invited user provides to activate their account.
+type AcceptInviteRequestDto struct {
+	Token     string `json:"token" binding:"required,max=2048"`
+	FirstName string `json:"first_name" binding:"required,min=2,max=100,person_name"`
+	LastName  string `json:"last_name" binding:"required,min=2,max=100,person_name"`
+	Password  string `json:"password" binding:"required,min=8,max=100,max_bytes=72,password_strength"`
 }
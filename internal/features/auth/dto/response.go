@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 // SignUpResponseDto is a Data Transfer Object (DTO) used to structure the response for a sign-up or any related action.
 // It includes a status and a message, which provide feedback about the outcome of the operation.
 type SignUpResponseDto struct {
@@ -16,4 +18,102 @@ type OAuthResponseDto struct {
 	Email      string `json:"email"`
 	Provider   string `json:"provider"`
 	ProviderID string `json:"provider_id"`
+	OrgID      string `json:"org_id,omitempty"`
+}
+
+// VerificationResponseDto reports a successful account verification. Code is "activated" when the link
+// activated the account and "already_verified" when the account was already active, e.g. because the link was opened twice.
+type VerificationResponseDto struct {
+	Status  string `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ActivationResult is the outcome of an account activation. AlreadyActive is set when the account
+// had been activated before, in which case nothing was changed.
+type ActivationResult struct {
+	ID            string
+	AlreadyActive bool
+}
+
+// MeResponseDto is a Data Transfer Object (DTO) describing the session behind the current access token.
+// It combines the token's own claims, such as its expiry, with the identity of the user it belongs to.
+// ImpersonatorID is set when an administrator is impersonating the user, and OrgID when the user belongs to an organization.
+type MeResponseDto struct {
+	ID             string    `json:"id"`
+	Email          string    `json:"email"`
+	Role           string    `json:"role"`
+	OrgID          string    `json:"org_id,omitempty"`
+	ImpersonatorID string    `json:"impersonator_id,omitempty"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+// Principal types reported by the whoami endpoint.
+const (
+	PrincipalUser   = "user"
+	PrincipalAPIKey = "api_key"
+)
+
+// WhoAmIResponseDto describes the principal that authenticated the request, whatever the authentication method.
+// Users have a role and the expiry of their access token; API keys have scopes and the ID of the user who issued
+// them, and never expire. ImpersonatorID is set for user sessions started by an administrator impersonating the user.
+type WhoAmIResponseDto struct {
+	Type           string     `json:"type"`
+	ID             string     `json:"id"`
+	Role           string     `json:"role,omitempty"`
+	Scopes         []string   `json:"scopes,omitempty"`
+	OwnerID        string     `json:"owner_id,omitempty"`
+	ImpersonatorID string     `json:"impersonator_id,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+}
+
+// ImpersonationDto is the data an impersonated session's access token is generated from:
+// the user being impersonated and the administrator acting as them.
+type ImpersonationDto struct {
+	UserID  string
+	OrgID   string
+	ActorID string
+}
+
+// ImpersonationResponseDto is returned when an administrator starts impersonating a user.
+// The impersonated session ends at ExpiresAt, or earlier through POST /api/v1/auth/stop-impersonation.
+type ImpersonationResponseDto struct {
+	Status    string    `json:"status"`
+	Message   string    `json:"message"`
+	UserID    string    `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CSRFTokenResponseDto carries the CSRF token that must be sent in the X-CSRF-Token header
+// of state-changing requests made with the access_token cookie.
+type CSRFTokenResponseDto struct {
+	CSRFToken string `json:"csrf_token"`
+}
+
+// OAuthProviderResponseDto describes an OAuth provider and, when it is enabled, the URL that starts its sign-in flow.
+type OAuthProviderResponseDto struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	Enabled     bool   `json:"enabled"`
+	URL         string `json:"url,omitempty"`
+}
+
+// Outcomes of a single invitation.
+const (
+	InviteStatusInvited = "invited"
+	InviteStatusResent  = "resent"
+	InviteStatusFailed  = "failed"
+)
+
+// InviteResultDto reports what happened to one invitation. Error explains a failed invitation.
+type InviteResultDto struct {
+	Email  string `json:"email"`
+	Status string `json:"status"`
+	UserID string `json:"user_id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// InviteUsersResponseDto lists the outcome of every invitation, in request order.
+type InviteUsersResponseDto struct {
+	Results []InviteResultDto `json:"results"`
 }
@@ -1,24 +1,107 @@
 package auth
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"strings"
 
+	"github.com/npushpakumara/go-backend-template/internal/config"
 	apiError "github.com/npushpakumara/go-backend-template/pkg/errors"
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-// hashPassword hashes a given password using bcrypt with the default cost.
-func hashPassword(password string) (string, error) {
-	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+// MaxPasswordBytes is the longest password bcrypt can hash. bcrypt ignores any bytes past it,
+// so longer passwords are rejected rather than silently truncated. Multibyte UTF-8 characters
+// count for more than one byte. The limit applies whatever the algorithm, so switching
+// algorithms never changes which passwords are accepted.
+const MaxPasswordBytes = 72
+
+// Password hashing algorithms that security.password_algorithm can select.
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+// PasswordHasher hashes passwords and verifies them against stored hashes.
+type PasswordHasher interface {
+	// Hash hashes the password. Passwords longer than MaxPasswordBytes are rejected with ErrPasswordTooLong.
+	Hash(password string) (string, error)
+	// Compare returns nil if the password matches the hash and ErrIncorrectPassword if it doesn't.
+	Compare(hashedPassword, password string) error
+	// NeedsRehash reports whether the hash was made with another algorithm or other parameters
+	// than Hash uses now, so it should be replaced the next time the password is known.
+	NeedsRehash(hashedPassword string) bool
+}
+
+// NewPasswordHasher returns the PasswordHasher for security.password_algorithm. New passwords are hashed
+// with that algorithm, while existing hashes are verified with the algorithm named by their prefix,
+// so users keep signing in while their hashes are migrated.
+func NewPasswordHasher(cfg *config.Config) PasswordHasher {
+	hasher := &passwordHasherImpl{
+		bcrypt:   &bcryptHasher{cost: bcrypt.DefaultCost},
+		argon2id: &argon2idHasher{params: defaultArgon2idParams},
+	}
+	hasher.current = hasher.bcrypt
+	if cfg.Security.PasswordAlgorithm == AlgorithmArgon2id {
+		hasher.current = hasher.argon2id
+	}
+	return hasher
+}
+
+// passwordHasherImpl hashes with the configured algorithm and dispatches verification on the hash prefix.
+type passwordHasherImpl struct {
+	current  PasswordHasher
+	bcrypt   PasswordHasher
+	argon2id PasswordHasher
+}
+
+// Hash hashes the password with the configured algorithm.
+func (h *passwordHasherImpl) Hash(password string) (string, error) {
+	if len(password) > MaxPasswordBytes {
+		return "", apiError.ErrPasswordTooLong
+	}
+	return h.current.Hash(password)
+}
+
+// Compare verifies the password with the algorithm the hash was made with. Hashes without the
+// argon2id prefix are bcrypt hashes, which is all that was stored before argon2id was supported.
+// No stored hash can come from a password longer than MaxPasswordBytes, so such input never matches;
+// otherwise bcrypt would accept any string that starts with the 72-byte password.
+func (h *passwordHasherImpl) Compare(hashedPassword, password string) error {
+	if len(password) > MaxPasswordBytes {
+		return apiError.ErrIncorrectPassword
+	}
+	if strings.HasPrefix(hashedPassword, argon2idPrefix) {
+		return h.argon2id.Compare(hashedPassword, password)
+	}
+	return h.bcrypt.Compare(hashedPassword, password)
+}
+
+// NeedsRehash reports whether the hash differs from what the configured algorithm would produce.
+func (h *passwordHasherImpl) NeedsRehash(hashedPassword string) bool {
+	return h.current.NeedsRehash(hashedPassword)
+}
+
+// bcryptHasher hashes passwords with bcrypt, whose hashes carry their own "$2a$"-style prefix.
+type bcryptHasher struct {
+	cost int
+}
+
+// Hash hashes the password with bcrypt at the hasher's cost.
+func (h *bcryptHasher) Hash(password string) (string, error) {
+	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
 	if err != nil {
 		return "", err
 	}
 	return string(hashedBytes), nil
 }
 
-// checkPassword verifies that a hashed password matches the given raw password.
-// Returns nil if the passwords match, otherwise returns an error.
-func checkPassword(hashedPassword, password string) error {
+// Compare verifies the password against a bcrypt hash.
+func (h *bcryptHasher) Compare(hashedPassword, password string) error {
 	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 	if err != nil {
 		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
@@ -28,3 +111,94 @@ func checkPassword(hashedPassword, password string) error {
 	}
 	return nil
 }
+
+// NeedsRehash reports whether the hash isn't a bcrypt hash at the hasher's cost.
+func (h *bcryptHasher) NeedsRehash(hashedPassword string) bool {
+	cost, err := bcrypt.Cost([]byte(hashedPassword))
+	return err != nil || cost != h.cost
+}
+
+// argon2idPrefix starts every argon2id hash, which is stored in the PHC string format:
+// $argon2id$v=19$m=<memory KiB>,t=<iterations>,p=<parallelism>$<salt>$<key>, both base64 without padding.
+const argon2idPrefix = "$argon2id$"
+
+// argon2idParams are the cost parameters of an argon2id hash.
+type argon2idParams struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	saltLength  uint32
+	keyLength   uint32
+}
+
+// defaultArgon2idParams follow the OWASP recommendation of 19 MiB of memory, 2 iterations and 1 degree of parallelism.
+var defaultArgon2idParams = argon2idParams{memory: 19 * 1024, iterations: 2, parallelism: 1, saltLength: 16, keyLength: 32}
+
+// errInvalidArgon2idHash is returned when a hash has the argon2id prefix but can't be parsed.
+var errInvalidArgon2idHash = errors.New("invalid argon2id hash")
+
+// argon2idHasher hashes passwords with argon2id.
+type argon2idHasher struct {
+	params argon2idParams
+}
+
+// Hash hashes the password with argon2id and a random salt.
+func (h *argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.params.saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	p := h.params
+	key := argon2.IDKey([]byte(password), salt, p.iterations, p.memory, p.parallelism, p.keyLength)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version, p.memory, p.iterations, p.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Compare verifies the password against an argon2id hash, using the parameters stored in the hash.
+func (h *argon2idHasher) Compare(hashedPassword, password string) error {
+	p, salt, key, err := parseArgon2idHash(hashedPassword)
+	if err != nil {
+		return err
+	}
+	other := argon2.IDKey([]byte(password), salt, p.iterations, p.memory, p.parallelism, p.keyLength)
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return apiError.ErrIncorrectPassword
+	}
+	return nil
+}
+
+// NeedsRehash reports whether the hash isn't an argon2id hash with the hasher's parameters.
+func (h *argon2idHasher) NeedsRehash(hashedPassword string) bool {
+	p, _, _, err := parseArgon2idHash(hashedPassword)
+	return err != nil || p != h.params
+}
+
+// parseArgon2idHash splits an argon2id hash into its parameters, salt and key.
+func parseArgon2idHash(hashedPassword string) (argon2idParams, []byte, []byte, error) {
+	var p argon2idParams
+	parts := strings.Split(strings.TrimPrefix(hashedPassword, argon2idPrefix), "$")
+	if !strings.HasPrefix(hashedPassword, argon2idPrefix) || len(parts) != 4 {
+		return p, nil, nil, errInvalidArgon2idHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[0], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, errInvalidArgon2idHash
+	}
+	// argon2 panics on zero iterations or parallelism rather than returning an error.
+	if _, err := fmt.Sscanf(parts[1], "m=%d,t=%d,p=%d", &p.memory, &p.iterations, &p.parallelism); err != nil || p.iterations == 0 || p.parallelism == 0 {
+		return p, nil, nil, errInvalidArgon2idHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return p, nil, nil, errInvalidArgon2idHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil || len(key) == 0 {
+		return p, nil, nil, errInvalidArgon2idHash
+	}
+	p.saltLength, p.keyLength = uint32(len(salt)), uint32(len(key))
+	return p, salt, key, nil
+}
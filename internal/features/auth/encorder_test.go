@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	apiError "github.com/npushpakumara/go-backend-template/pkg/errors"
+)
+
+// newHasher returns the PasswordHasher configured for the algorithm.
+func newHasher(algorithm string) PasswordHasher {
+	cfg := &config.Config{}
+	cfg.Security.PasswordAlgorithm = algorithm
+	return NewPasswordHasher(cfg)
+}
+
+func TestPasswordHasherVerifiesEitherAlgorithm(t *testing.T) {
+	withBcrypt := newHasher(AlgorithmBcrypt)
+	withArgon2id := newHasher(AlgorithmArgon2id)
+
+	bcryptHash, err := withBcrypt.Hash(testPassword)
+	if err != nil {
+		t.Fatalf("bcrypt Hash() error = %v", err)
+	}
+	argon2idHash, err := withArgon2id.Hash(testPassword)
+	if err != nil {
+		t.Fatalf("argon2id Hash() error = %v", err)
+	}
+	if !strings.HasPrefix(argon2idHash, argon2idPrefix) {
+		t.Fatalf("argon2id Hash() = %q, want the %q prefix", argon2idHash, argon2idPrefix)
+	}
+
+	hashers := map[string]PasswordHasher{AlgorithmBcrypt: withBcrypt, AlgorithmArgon2id: withArgon2id}
+	hashes := map[string]string{AlgorithmBcrypt: bcryptHash, AlgorithmArgon2id: argon2idHash}
+	for configured, hasher := range hashers {
+		for made, hash := range hashes {
+			if err := hasher.Compare(hash, testPassword); err != nil {
+				t.Errorf("%s hasher Compare() of a %s hash error = %v", configured, made, err)
+			}
+			if err := hasher.Compare(hash, "Wrong-Horse-9"); !errors.Is(err, apiError.ErrIncorrectPassword) {
+				t.Errorf("%s hasher Compare() of a %s hash with a wrong password error = %v, want ErrIncorrectPassword", configured, made, err)
+			}
+			if got, want := hasher.NeedsRehash(hash), configured != made; got != want {
+				t.Errorf("%s hasher NeedsRehash() of a %s hash = %v, want %v", configured, made, got, want)
+			}
+		}
+	}
+}
+
+func TestPasswordHasherNeedsRehashForOtherParameters(t *testing.T) {
+	weaker := &argon2idHasher{params: defaultArgon2idParams}
+	weaker.params.iterations = 1
+	hash, err := weaker.Hash(testPassword)
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	hasher := newHasher(AlgorithmArgon2id)
+	if err := hasher.Compare(hash, testPassword); err != nil {
+		t.Errorf("Compare() of a hash with other parameters error = %v", err)
+	}
+	if !hasher.NeedsRehash(hash) {
+		t.Error("NeedsRehash() of a hash with other parameters = false, want true")
+	}
+}
+
+func TestPasswordHasherRejectsLongPasswords(t *testing.T) {
+	hasher := newHasher(AlgorithmBcrypt)
+	long := strings.Repeat("a", MaxPasswordBytes+1)
+
+	if _, err := hasher.Hash(long); !errors.Is(err, apiError.ErrPasswordTooLong) {
+		t.Errorf("Hash() error = %v, want ErrPasswordTooLong", err)
+	}
+
+	// bcrypt only reads the first 72 bytes, so a longer password must not match the hash of its prefix.
+	hash, err := hasher.Hash(long[:MaxPasswordBytes])
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	if err := hasher.Compare(hash, long); !errors.Is(err, apiError.ErrIncorrectPassword) {
+		t.Errorf("Compare() with a longer password error = %v, want ErrIncorrectPassword", err)
+	}
+}
@@ -0,0 +1,29 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PasswordResetRequest records a password reset email sent to an account, so the number of them sent
+// to one email can be capped across every instance of the API.
+// Counting is served by a composite index on (email, created_at) created during migration,
+// since created_at comes from the embedded gorm.Model and can't carry an index tag.
+type PasswordResetRequest struct {
+	*gorm.Model
+	ID    uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Email string    `gorm:"size:255;not null"`
+}
+
+// TableName overrides the default table name used by GORM for the PasswordResetRequest model.
+func (PasswordResetRequest) TableName() string {
+	return "password_reset_requests"
+}
+
+// BeforeCreate is a GORM hook that sets the ID field to a new UUID if it hasn't been set already.
+func (request *PasswordResetRequest) BeforeCreate(tx *gorm.DB) (err error) {
+	if request.ID == uuid.Nil {
+		request.ID = uuid.New()
+	}
+	return
+}
@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/internal/features/auth/dto"
+	userEntity "github.com/npushpakumara/go-backend-template/internal/features/user/entity"
+	"github.com/npushpakumara/go-backend-template/internal/metrics"
+)
+
+// recordingMetrics is a Metrics sink that records every outcome, with failed logins as "login_failed:<reason>".
+type recordingMetrics struct {
+	mu       sync.Mutex
+	outcomes []string
+}
+
+func (m *recordingMetrics) record(outcome string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.outcomes = append(m.outcomes, outcome)
+}
+
+func (m *recordingMetrics) SignedUp()                 { m.record("signed_up") }
+func (m *recordingMetrics) LoginSucceeded()           { m.record("login_succeeded") }
+func (m *recordingMetrics) LoginFailed(reason string) { m.record("login_failed:" + reason) }
+func (m *recordingMetrics) EmailVerified()            { m.record("email_verified") }
+func (m *recordingMetrics) PasswordChanged()          { m.record("password_changed") }
+
+// Outcomes returns the recorded outcomes, oldest first, and forgets them.
+func (m *recordingMetrics) Outcomes() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	outcomes := m.outcomes
+	m.outcomes = nil
+	return outcomes
+}
+
+// assertOutcomes fails unless exactly the given outcomes were recorded since the last check.
+func assertOutcomes(t *testing.T, m *recordingMetrics, want ...string) {
+	t.Helper()
+	if got := m.Outcomes(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("recorded %v, want %v", got, want)
+	}
+}
+
+func TestMetricsRecordSignUpAndVerification(t *testing.T) {
+	ta := newTestAuth(t)
+
+	err := ta.service.RegisterUser(context.Background(), &dto.SignUpRequestDto{
+		FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com", Password: testPassword, PhoneNumber: "+15555550100",
+	})
+	if err != nil {
+		t.Fatalf("RegisterUser() error = %v", err)
+	}
+	assertOutcomes(t, ta.metrics, "signed_up")
+
+	if _, err := ta.service.ActivateAccount(context.Background(), linkToken(t, ta.emails.Sent()[0])); err != nil {
+		t.Fatalf("ActivateAccount() error = %v", err)
+	}
+	assertOutcomes(t, ta.metrics, "email_verified")
+}
+
+func TestMetricsRecordLoginOutcomes(t *testing.T) {
+	ta := newTestAuth(t)
+	ta.createUser(t, "ada@example.com", userEntity.StatusActive, "", "")
+	ta.createUser(t, "pending@example.com", userEntity.StatusPending, "", "")
+	ta.createUser(t, "suspended@example.com", userEntity.StatusSuspended, "", "")
+	ta.createUser(t, "oauth@example.com", userEntity.StatusActive, "google", "google-123")
+
+	tests := []struct {
+		email    string
+		password string
+		want     string
+	}{
+		{email: "ada@example.com", password: testPassword, want: "login_succeeded"},
+		{email: "ada@example.com", password: "Wrong-Horse-9", want: "login_failed:" + LoginFailureWrongPassword},
+		{email: "nobody@example.com", password: testPassword, want: "login_failed:" + LoginFailureNotFound},
+		{email: "pending@example.com", password: testPassword, want: "login_failed:" + LoginFailureInactive},
+		{email: "suspended@example.com", password: testPassword, want: "login_failed:" + LoginFailureInactive},
+		{email: "oauth@example.com", password: testPassword, want: "login_failed:" + LoginFailureOAuthLinked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want+" "+tt.email, func(t *testing.T) {
+			_, _ = ta.service.LoginUser(context.Background(), &dto.SignInRequestDto{Email: tt.email, Password: tt.password})
+			assertOutcomes(t, ta.metrics, tt.want)
+		})
+	}
+}
+
+func TestMetricsRecordPasswordChanges(t *testing.T) {
+	ta := newTestAuth(t)
+	existing := ta.createUser(t, "ada@example.com", userEntity.StatusActive, "", "")
+
+	err := ta.service.ChangePassword(context.Background(), existing.ID.String(), &dto.ChangePasswordRequestDto{CurrentPassword: "Wrong-Horse-9", NewPassword: "Battery-Staple-7"})
+	if err == nil {
+		t.Fatal("ChangePassword() with a wrong current password succeeded")
+	}
+	assertOutcomes(t, ta.metrics)
+
+	err = ta.service.ChangePassword(context.Background(), existing.ID.String(), &dto.ChangePasswordRequestDto{CurrentPassword: testPassword, NewPassword: "Battery-Staple-7"})
+	if err != nil {
+		t.Fatalf("ChangePassword() error = %v", err)
+	}
+	assertOutcomes(t, ta.metrics, "password_changed")
+
+	ta.createUser(t, "grace@example.com", userEntity.StatusActive, "", "")
+	token := ta.requestResetToken(t, "grace@example.com")
+	if err := ta.service.ResetPassword(context.Background(), &dto.PasswordResetRequestDto{Token: token, NewPassword: "Staple-Battery-8"}); err != nil {
+		t.Fatalf("ResetPassword() error = %v", err)
+	}
+	assertOutcomes(t, ta.metrics, "password_changed")
+}
+
+// The counters are served at /metrics in the Prometheus text format.
+func TestMetricsEndpoint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{}
+	cfg.Metrics.Enabled = true
+	registry := metrics.NewRegistry()
+	m := NewMetrics(cfg, registry)
+	router := gin.New()
+	metrics.Router(cfg, router, registry)
+
+	m.SignedUp()
+	m.LoginFailed(LoginFailureWrongPassword)
+	m.LoginFailed(LoginFailureWrongPassword)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	for _, line := range []string{
+		"auth_signups_total 1",
+		`auth_login_failures_total{reason="wrong_password"} 2`,
+		"auth_logins_total 0",
+	} {
+		if !strings.Contains(rec.Body.String(), line+"\n") {
+			t.Errorf("/metrics is missing %q:\n%s", line, rec.Body.String())
+		}
+	}
+}
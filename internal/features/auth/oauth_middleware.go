@@ -4,22 +4,29 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/base64"
+	stdErrors "errors"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	jwt "github.com/appleboy/gin-jwt/v2"
 	"github.com/gin-gonic/gin"
 	"github.com/markbates/goth"
 	"github.com/markbates/goth/gothic"
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/internal/csrf"
 	"github.com/npushpakumara/go-backend-template/internal/features/auth/dto"
+	userDto "github.com/npushpakumara/go-backend-template/internal/features/user/dto"
 	"github.com/npushpakumara/go-backend-template/pkg/errors"
+	"github.com/npushpakumara/go-backend-template/pkg/httpx"
 	"github.com/npushpakumara/go-backend-template/pkg/logging"
 )
 
 // OAuthMiddleware is a Gin middleware function that handles the initial OAuth request.
 // It sets up the necessary state for the OAuth flow, including setting the provider in the context
 // and generating a state cookie to prevent CSRF attacks.
-func OAuthMiddleware() gin.HandlerFunc {
+func OAuthMiddleware(cookieConfig *config.CookieConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		provider := c.Param("provider")
 		if provider == "" {
@@ -29,14 +36,8 @@ func OAuthMiddleware() gin.HandlerFunc {
 
 		// Generate a random state string for the OAuth flow to prevent CSRF attacks.
 		state := generateStateOauthCookie()
-		// Set the state as a secure, HttpOnly cookie that expires in 5 minutes.
-		http.SetCookie(c.Writer, &http.Cookie{
-			Name:     "oauth_state",
-			Value:    state,
-			Expires:  time.Now().Add(5 * time.Minute),
-			HttpOnly: true,
-			Secure:   true,
-		})
+		// Set the state as an HttpOnly cookie that expires once the user has had time to sign in.
+		httpx.SetCookie(c.Writer, oauthStateCookie, state, int(oauthStateTTL.Seconds()), oauthStateCookieOptions(cookieConfig))
 
 		// Add the state parameter to the URL query string for the OAuth request.
 		q := c.Request.URL.Query()
@@ -49,57 +50,134 @@ func OAuthMiddleware() gin.HandlerFunc {
 	}
 }
 
+// Values of the "error" query parameter added to the login page redirect when an OAuth callback fails.
+const (
+	oauthErrProviderNotConfigured = "provider_not_configured"
+	oauthErrMissingSession        = "missing_session"
+	oauthErrInvalidState          = "invalid_state"
+	oauthErrFailed                = "authentication_failed"
+)
+
+// errMissingOAuthSession is the error gothic returns when the request carries no session started by OAuthMiddleware.
+const errMissingOAuthSession = "could not find a matching session for this request"
+
 // OAuthCallbackMiddleware is a Gin middleware function that handles the callback from the OAuth provider.
 // It completes the OAuth authentication, validates the state, and generates a JWT for the authenticated user.
-func OAuthCallbackMiddleware(authMiddleware *jwt.GinJWTMiddleware, handleUser func(ctx context.Context, user goth.User) (*dto.OAuthResponseDto, error)) gin.HandlerFunc {
+// Callbacks for a provider that isn't configured, or that arrive without the session and state cookie set when
+// the flow began, such as when the callback URL is opened directly, are sent back to loginURL with an error.
+func OAuthCallbackMiddleware(authMiddleware *jwt.GinJWTMiddleware, cookieConfig *config.CookieConfig, loginURL string, handleUser func(ctx context.Context, user goth.User) (*dto.OAuthResponseDto, error)) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Retrieve a logger from the request context for logging purposes.
 		logger := logging.FromContext(c.Request.Context())
 
-		// Complete the OAuth authentication and retrieve the user information from the provider.
-		user, err := gothic.CompleteUserAuth(c.Writer, c.Request)
-		if err != nil {
-			logger.Errorf("auth.middlewares.OAuthCallbackMiddleware failed to authenticate: %v", err.Error())
-			c.JSON(http.StatusUnauthorized, errors.ErrorResponse{Status: "error", Message: "Authentication failed"})
+		provider := c.Param("provider")
+		if _, err := goth.GetProvider(provider); err != nil {
+			logger.Warnw("auth.middlewares.OAuthCallbackMiddleware provider is not configured", "provider", provider)
+			respondOAuthError(c, loginURL, http.StatusNotFound, oauthErrProviderNotConfigured, "Sign-in provider is not configured")
 			return
 		}
 
 		// Retrieve the state cookie from the request.
-		cookie, err := c.Cookie("oauth_state")
+		cookie, err := c.Cookie(oauthStateCookie)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, errors.ErrorResponse{Status: "error", Message: "State cookie not found"})
+			respondOAuthError(c, loginURL, http.StatusBadRequest, oauthErrMissingSession, "Sign-in session not found. Please start signing in again")
 			return
 		}
 
 		// Validate the state parameter from the URL against the state stored in the cookie.
 		state := c.Query("state")
 		if state == "" || state != cookie {
-			c.JSON(http.StatusUnauthorized, errors.ErrorResponse{Status: "error", Message: "Invalid state"})
+			respondOAuthError(c, loginURL, http.StatusUnauthorized, oauthErrInvalidState, "Invalid state")
+			return
+		}
+
+		// gothic reads the provider from the query string.
+		if c.Query("provider") == "" {
+			q := c.Request.URL.Query()
+			q.Set("provider", provider)
+			c.Request.URL.RawQuery = q.Encode()
+		}
+
+		// Complete the OAuth authentication and retrieve the user information from the provider.
+		user, err := gothic.CompleteUserAuth(c.Writer, c.Request)
+		if err != nil {
+			logger.Errorf("auth.middlewares.OAuthCallbackMiddleware failed to authenticate: %v", err.Error())
+			if strings.Contains(err.Error(), errMissingOAuthSession) {
+				respondOAuthError(c, loginURL, http.StatusBadRequest, oauthErrMissingSession, "Sign-in session not found. Please start signing in again")
+				return
+			}
+			respondOAuthError(c, loginURL, http.StatusUnauthorized, oauthErrFailed, "Authentication failed")
 			return
 		}
 
 		// Handle the authenticated user by invoking the provided handler function.
 		result, err := handleUser(c.Request.Context(), user)
-		if err != nil { // Handle any errors that occur during user handling.
+		var conflict *errors.ProviderConflictError
+		switch {
+		case err == nil:
+		case stdErrors.As(err, &conflict):
+			c.JSON(http.StatusConflict, errors.ErrorResponse{
+				Status:  "error",
+				Message: "This email is already linked to another sign-in provider",
+				Errors:  gin.H{"provider": conflict.Provider},
+			})
+			return
+		case stdErrors.Is(err, errors.ErrEmailRegisteredWithPassword):
+			c.JSON(http.StatusConflict, errors.ErrorResponse{Status: "error", Message: "An account with this email already exists. Verify your email and sign in with your password"})
+			return
+		case stdErrors.Is(err, errors.ErrAccountSuspended) || stdErrors.Is(err, errors.ErrAccountBanned):
+			c.JSON(http.StatusForbidden, errors.ErrorResponse{Status: "error", Message: "Account is not allowed to sign in"})
+			return
+		case stdErrors.Is(err, errors.ErrSignupClosed):
+			c.JSON(http.StatusForbidden, errors.ErrorResponse{Status: "error", Message: "Sign-up is closed. Only existing accounts can sign in"})
+			return
+		default: // Handle any other errors that occur during user handling.
 			logger.Error("auth.middlewares.OAuthCallbackMiddleware failed to handle user", "error", err.Error())
 			c.JSON(http.StatusInternalServerError, errors.ErrorResponse{Status: "error", Message: "Internal server error"})
 			return
 		}
 
 		// Generate a JWT token for the authenticated user using the provided JWT middleware.
-		token, expires, err := authMiddleware.TokenGenerator(result.ID)
+		token, expires, err := authMiddleware.TokenGenerator(&userDto.UserResponseDto{ID: result.ID, OrgID: result.OrgID})
 		if err != nil {
 			logger.Error("auth.middlewares.OAuthCallbackMiddleware failed to handle user", "error", err.Error())
 			c.JSON(http.StatusInternalServerError, errors.ErrorResponse{Status: "error", Message: "Internal server error"})
 			return
 		}
 
-		c.SetCookie("access_token", token, int(time.Until(expires).Seconds()), "/", "", false, true)
+		// The state is single-use.
+		httpx.SetCookie(c.Writer, oauthStateCookie, "", -1, oauthStateCookieOptions(cookieConfig))
+		httpx.SetCookie(c.Writer, AccessTokenCookie, token, int(time.Until(expires).Seconds()), cookieConfig.Options())
+		if _, err := csrf.Issue(c, cookieConfig); err != nil {
+			logger.Errorw("auth.middlewares.OAuthCallbackMiddleware failed to issue CSRF token: %v", err)
+		}
 
 		c.JSON(http.StatusOK, dto.SignUpResponseDto{Status: "success", Message: "Successfully signed in"})
 	}
 }
 
+// respondOAuthError reports a failed OAuth callback. Browsers are redirected to the login page with
+// ?error=<errCode>; requests that accept application/json, or any request when no login URL is configured,
+// get a JSON response with the given status.
+func respondOAuthError(c *gin.Context, loginURL string, status int, errCode, message string) {
+	wantsJSON := strings.Contains(c.GetHeader("Accept"), "application/json")
+
+	if loginURL != "" && !wantsJSON {
+		target, err := url.Parse(loginURL)
+		if err == nil {
+			query := target.Query()
+			query.Set("error", errCode)
+			target.RawQuery = query.Encode()
+
+			c.Redirect(http.StatusFound, target.String())
+			return
+		}
+		logging.FromContext(c).Errorw("auth.middlewares.respondOAuthError invalid login url", "err", err)
+	}
+
+	c.JSON(status, errors.ErrorResponse{Status: "error", Message: message})
+}
+
 // generateStateOauthCookie generates a random state string to be used in the OAuth flow.
 // This state string is encoded in base64 and is used to protect against CSRF attacks.
 func generateStateOauthCookie() string {
@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/providers/faux"
+	"github.com/npushpakumara/go-backend-template/internal/config"
+)
+
+// testLoginURL is the frontend login page failed callbacks are sent back to.
+const testLoginURL = "https://app.example.com/login?next=%2Fsettings"
+
+// serveOAuthCallback sends the request to the OAuth callback route, with the faux provider as the only
+// one configured. The callback never gets as far as the user, so it has no JWT middleware or user handler.
+func serveOAuthCallback(t *testing.T, loginURL string, req *http.Request) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	goth.UseProviders(&faux.Provider{})
+	t.Cleanup(goth.ClearProviders)
+
+	router := gin.New()
+	router.GET("/oauth/:provider/callback", OAuthCallbackMiddleware(nil, &config.CookieConfig{Path: "/", SameSite: "lax"}, loginURL, nil))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// callbackRequest returns a callback request for the provider with the given state, carrying stateCookie when it is set.
+func callbackRequest(provider, state, stateCookie string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/oauth/"+provider+"/callback?code=abc&state="+url.QueryEscape(state), nil)
+	if stateCookie != "" {
+		req.AddCookie(&http.Cookie{Name: oauthStateCookie, Value: stateCookie})
+	}
+	return req
+}
+
+func TestOAuthCallbackRedirectsFailuresToLogin(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     *http.Request
+		wantErr string
+	}{
+		{name: "unconfigured provider", req: callbackRequest("unknown", "state", "state"), wantErr: oauthErrProviderNotConfigured},
+		{name: "no state cookie", req: callbackRequest("faux", "state", ""), wantErr: oauthErrMissingSession},
+		// The state cookie is there but the session gothic keeps for the flow is not.
+		{name: "no provider session", req: callbackRequest("faux", "state", "state"), wantErr: oauthErrMissingSession},
+		{name: "mismatched state", req: callbackRequest("faux", "other", "state"), wantErr: oauthErrInvalidState},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveOAuthCallback(t, testLoginURL, tt.req)
+			if w.Code != http.StatusFound {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusFound)
+			}
+
+			location, err := url.Parse(w.Header().Get("Location"))
+			if err != nil {
+				t.Fatalf("Location %q: %v", w.Header().Get("Location"), err)
+			}
+			if location.Scheme != "https" || location.Host != "app.example.com" || location.Path != "/login" {
+				t.Errorf("redirected to %s, want the login page %s", location, testLoginURL)
+			}
+			if got := location.Query().Get("error"); got != tt.wantErr {
+				t.Errorf("error query parameter = %q, want %q", got, tt.wantErr)
+			}
+			if got := location.Query().Get("next"); got != "/settings" {
+				t.Errorf("next query parameter = %q, want the login URL's own /settings", got)
+			}
+		})
+	}
+}
+
+func TestOAuthCallbackRespondsWithJSON(t *testing.T) {
+	tests := []struct {
+		name       string
+		loginURL   string
+		accept     string
+		req        *http.Request
+		wantStatus int
+	}{
+		{name: "unconfigured provider for an API client", loginURL: testLoginURL, accept: "application/json", req: callbackRequest("unknown", "state", "state"), wantStatus: http.StatusNotFound},
+		{name: "no provider session for an API client", loginURL: testLoginURL, accept: "application/json", req: callbackRequest("faux", "state", "state"), wantStatus: http.StatusBadRequest},
+		{name: "unconfigured provider without a login URL", req: callbackRequest("unknown", "state", "state"), wantStatus: http.StatusNotFound},
+		{name: "no state cookie without a login URL", req: callbackRequest("faux", "state", ""), wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.accept != "" {
+				tt.req.Header.Set("Accept", tt.accept)
+			}
+			w := serveOAuthCallback(t, tt.loginURL, tt.req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/json; charset=utf-8" {
+				t.Errorf("Content-Type = %q, want JSON", ct)
+			}
+			if location := w.Header().Get("Location"); location != "" {
+				t.Errorf("redirected to %s, want a JSON response", location)
+			}
+		})
+	}
+}
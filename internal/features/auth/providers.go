@@ -5,26 +5,77 @@ import (
 	"github.com/markbates/goth/providers/google"
 	"github.com/markbates/goth/providers/microsoftonline"
 	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/internal/features/auth/dto"
 )
 
+// oauthProvider describes an OAuth provider the application knows how to use.
+type oauthProvider struct {
+	// name is the Goth provider name, used in the /oauth/:provider routes.
+	name        string
+	displayName string
+	settings    config.ProviderConfig
+	// defaultScopes are requested when the settings configure no scope.
+	defaultScopes []string
+	build         func(p config.ProviderConfig, scopes []string) goth.Provider
+}
+
+// scopes returns the configured scopes, or the provider's default scopes when none are configured.
+func (p oauthProvider) scopes() []string {
+	if scopes := p.settings.GetScopes(); len(scopes) > 0 {
+		return scopes
+	}
+	return p.defaultScopes
+}
+
+// oauthProviders lists every supported OAuth provider with its settings.
+func oauthProviders(cfg *config.Config) []oauthProvider {
+	return []oauthProvider{
+		{
+			name:          "google",
+			displayName:   "Google",
+			settings:      cfg.OAuth.Google,
+			defaultScopes: []string{"openid", "email", "profile"},
+			build: func(p config.ProviderConfig, scopes []string) goth.Provider {
+				return google.New(p.ClientID, p.ClientSecret, p.RedirectURL, scopes...)
+			},
+		},
+		{
+			name:          "microsoftonline",
+			displayName:   "Microsoft",
+			settings:      cfg.OAuth.Microsoft,
+			defaultScopes: []string{"openid", "User.Read"},
+			build: func(p config.ProviderConfig, scopes []string) goth.Provider {
+				return microsoftonline.New(p.ClientID, p.ClientSecret, p.RedirectURL, scopes...)
+			},
+		},
+	}
+}
+
 // NewOAuthProviders initializes and registers the OAuth providers using the Goth library.
-// It accepts a configuration object that contains the necessary credentials and settings for each OAuth provider.
+// Providers without a client ID and secret are skipped, so their routes answer as unknown providers.
 func NewOAuthProviders(cfg *config.Config) {
+	var providers []goth.Provider
+	for _, p := range oauthProviders(cfg) {
+		if p.settings.Enabled() {
+			providers = append(providers, p.build(p.settings, p.scopes()))
+		}
+	}
+
 	// goth.UseProviders registers the OAuth providers that Goth will use for authentication.
-	// In this case, we are setting up Google and Microsoft as the providers.
+	goth.UseProviders(providers...)
+}
 
-	goth.UseProviders(
-		google.New(
-			cfg.OAuth.Google.ClientID,
-			cfg.OAuth.Google.ClientSecret,
-			cfg.OAuth.Google.RedirectURL,
-			cfg.OAuth.Google.GetScopes()...,
-		),
-		microsoftonline.New(
-			cfg.OAuth.Microsoft.ClientID,
-			cfg.OAuth.Microsoft.ClientSecret,
-			cfg.OAuth.Microsoft.RedirectURL,
-			cfg.OAuth.Microsoft.GetScopes()...,
-		),
-	)
+// listOAuthProviders describes every supported provider for frontends deciding which sign-in buttons to show.
+// Only enabled providers have a sign-in URL.
+func listOAuthProviders(cfg *config.Config) []dto.OAuthProviderResponseDto {
+	providers := oauthProviders(cfg)
+	resp := make([]dto.OAuthProviderResponseDto, 0, len(providers))
+	for _, p := range providers {
+		item := dto.OAuthProviderResponseDto{Name: p.name, DisplayName: p.displayName, Enabled: p.settings.Enabled()}
+		if item.Enabled {
+			item.URL = apiPrefix + "/oauth/" + p.name
+		}
+		resp = append(resp, item)
+	}
+	return resp
 }
@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	authEntity "github.com/npushpakumara/go-backend-template/internal/features/auth/entity"
+	"github.com/npushpakumara/go-backend-template/internal/postgres"
+	"github.com/npushpakumara/go-backend-template/internal/ratelimit"
+	"github.com/npushpakumara/go-backend-template/pkg/clock"
+	"gorm.io/gorm"
+)
+
+// ResetThrottle caps how many password reset emails one account is sent, whichever IPs the requests come from.
+type ResetThrottle interface {
+	// Allow counts a password reset email to the address and reports whether it is within the limit.
+	// Refused requests aren't counted, so the owner is sent emails again once the window has passed.
+	Allow(ctx context.Context, email string) (bool, error)
+}
+
+// NewResetThrottle returns a ResetThrottle that counts requests in Postgres, so the limit holds across every
+// instance of the API, or one that allows every request when auth.password_reset_limit is zero.
+func NewResetThrottle(db *gorm.DB, clk clock.Clock, cfg *config.Config) ResetThrottle {
+	if cfg.Auth.PasswordResetLimit == 0 {
+		return noopResetThrottle{}
+	}
+	return &postgresResetThrottle{db: db, clock: clk, limit: cfg.Auth.PasswordResetLimit, window: cfg.Auth.PasswordResetWindow}
+}
+
+// NewMemoryResetThrottle returns a ResetThrottle allowing limit emails per window for each address.
+// Counts live in process memory, so it only suits tests and deployments running a single instance.
+func NewMemoryResetThrottle(clk clock.Clock, limit int, window time.Duration) ResetThrottle {
+	return &memoryResetThrottle{limiter: ratelimit.NewLimiter(clk, limit, window)}
+}
+
+// noopResetThrottle is used when the password reset limit is disabled.
+type noopResetThrottle struct{}
+
+// Allow always allows the email.
+func (noopResetThrottle) Allow(context.Context, string) (bool, error) {
+	return true, nil
+}
+
+// memoryResetThrottle counts the emails sent to each address with a fixed-window limiter.
+type memoryResetThrottle struct {
+	limiter *ratelimit.Limiter
+}
+
+// Allow takes a request from the address's bucket.
+func (t *memoryResetThrottle) Allow(_ context.Context, email string) (bool, error) {
+	return t.limiter.Take(email).Allowed, nil
+}
+
+// postgresResetThrottle keeps a row per email sent in the password_reset_requests table.
+type postgresResetThrottle struct {
+	db     *gorm.DB
+	clock  clock.Clock
+	limit  int
+	window time.Duration
+}
+
+// Allow records the email unless the address has already been sent limit of them within the window.
+// Rows that have left the window are deleted along the way, so the table only holds recent requests.
+func (t *postgresResetThrottle) Allow(ctx context.Context, email string) (bool, error) {
+	now := t.clock.Now()
+	allowed := false
+
+	err := postgres.FromContext(ctx, t.db).WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Serialize the requests for one address, so two instances can't both take its last slot.
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", email).Error; err != nil {
+			return err
+		}
+
+		err := tx.Unscoped().Where("email = ? AND created_at <= ?", email, now.Add(-t.window)).Delete(&authEntity.PasswordResetRequest{}).Error
+		if err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&authEntity.PasswordResetRequest{}).Where("email = ?", email).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= int64(t.limit) {
+			return nil
+		}
+
+		allowed = true
+		return tx.Create(&authEntity.PasswordResetRequest{Model: &gorm.Model{CreatedAt: now}, Email: email}).Error
+	})
+	if err != nil {
+		return false, err
+	}
+	return allowed, nil
+}
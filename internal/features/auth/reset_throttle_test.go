@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/internal/postgres/postgrestest"
+	"github.com/npushpakumara/go-backend-template/pkg/clock"
+)
+
+func TestPostgresResetThrottle(t *testing.T) {
+	db := postgrestest.NewDB(t)
+	clk := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+
+	cfg := &config.Config{}
+	cfg.Auth.PasswordResetLimit = 2
+	cfg.Auth.PasswordResetWindow = time.Hour
+	throttle := NewResetThrottle(db, clk, cfg)
+
+	allow := func(email string) bool {
+		t.Helper()
+		allowed, err := throttle.Allow(context.Background(), email)
+		if err != nil {
+			t.Fatalf("Allow(%q) error = %v", email, err)
+		}
+		return allowed
+	}
+
+	if !allow("ada@example.com") || !allow("ada@example.com") {
+		t.Fatal("Allow() refused a request within the limit")
+	}
+	if allow("ada@example.com") {
+		t.Error("Allow() allowed a request over the limit")
+	}
+	if !allow("grace@example.com") {
+		t.Error("Allow() refused another address")
+	}
+
+	clk.Advance(time.Hour)
+	if !allow("ada@example.com") {
+		t.Error("Allow() refused a request after the window passed")
+	}
+}
+
+func TestNewResetThrottleDisabled(t *testing.T) {
+	throttle := NewResetThrottle(nil, clock.New(), &config.Config{})
+
+	for i := 0; i < 10; i++ {
+		if allowed, err := throttle.Allow(context.Background(), "ada@example.com"); !allowed || err != nil {
+			t.Fatalf("Allow() = %v, %v, want true, nil", allowed, err)
+		}
+	}
+}
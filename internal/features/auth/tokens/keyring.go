@@ -0,0 +1,56 @@
+package tokens
+
+import (
+	"errors"
+
+	"github.com/npushpakumara/go-backend-template/internal/config"
+)
+
+// ErrUnknownKeyID is returned when a token names a key that isn't in the keyring, usually because it has been retired.
+var ErrUnknownKeyID = errors.New("unknown jwt key id")
+
+// Keyring holds the secrets tokens are signed and verified with. New tokens are signed with the current key;
+// any key in the ring verifies them, which lets the signing key be rotated without invalidating issued tokens.
+type Keyring struct {
+	currentKID string
+	keys       map[string][]byte
+}
+
+// NewKeyring builds the keyring from jwt.keys and jwt.current_kid. jwt.secret is kept under the empty key ID,
+// so tokens issued before key IDs were introduced keep working, and it signs new tokens when no current key is set.
+func NewKeyring(cfg *config.JWTConfig) *Keyring {
+	keys := make(map[string][]byte, len(cfg.Keys)+1)
+	if cfg.Secret != "" {
+		keys[""] = []byte(cfg.Secret)
+	}
+	for kid, secret := range cfg.Keys {
+		keys[kid] = []byte(secret)
+	}
+	return &Keyring{currentKID: cfg.CurrentKID, keys: keys}
+}
+
+// Current returns the key new tokens are signed with and its ID, which is empty for jwt.secret.
+func (k *Keyring) Current() (string, []byte) {
+	return k.currentKID, k.keys[k.currentKID]
+}
+
+// Key returns the key that verifies a token. A token naming a key ID is verified with that key only.
+// A token without one is checked against every key by verifies, since some issuers, such as the session
+// middleware, can't set a kid header; when none matches, the current key is returned so verification fails as usual.
+func (k *Keyring) Key(kid string, verifies func(key []byte) bool) ([]byte, error) {
+	if kid != "" {
+		key, ok := k.keys[kid]
+		if !ok {
+			return nil, ErrUnknownKeyID
+		}
+		return key, nil
+	}
+
+	for _, key := range k.keys {
+		if verifies(key) {
+			return key, nil
+		}
+	}
+	_, key := k.Current()
+	return key, nil
+}
@@ -0,0 +1,114 @@
+package tokens
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/pkg/clock"
+)
+
+// tokenKID returns the kid header of the token, which is empty when it has none.
+func tokenKID(t *testing.T, token string) string {
+	t.Helper()
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	if err != nil {
+		t.Fatalf("ParseUnverified() error = %v", err)
+	}
+	kid, _ := parsed.Header["kid"].(string)
+	return kid
+}
+
+func TestKeyringRotation(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+
+	// Tokens issued before key IDs were introduced are signed with jwt.secret and have no kid.
+	legacy := NewKeyring(&config.JWTConfig{Secret: "legacy-secret"})
+	legacyToken, err := NewJwtToken(clk, "user-1", PurposeVerification, legacy, time.Hour)
+	if err != nil {
+		t.Fatalf("NewJwtToken() error = %v", err)
+	}
+	if kid := tokenKID(t, legacyToken); kid != "" {
+		t.Errorf("token signed with jwt.secret has kid %q, want none", kid)
+	}
+
+	first := NewKeyring(&config.JWTConfig{Secret: "legacy-secret", Keys: map[string]string{"2024-01": "first-secret"}, CurrentKID: "2024-01"})
+	firstToken, err := NewJwtToken(clk, "user-1", PurposeVerification, first, time.Hour)
+	if err != nil {
+		t.Fatalf("NewJwtToken() error = %v", err)
+	}
+	if kid := tokenKID(t, firstToken); kid != "2024-01" {
+		t.Errorf("token kid = %q, want %q", kid, "2024-01")
+	}
+
+	// Rotating to a new key signs new tokens with it, while tokens signed with the older keys still verify.
+	rotated := NewKeyring(&config.JWTConfig{
+		Secret:     "legacy-secret",
+		Keys:       map[string]string{"2024-01": "first-secret", "2024-06": "second-secret"},
+		CurrentKID: "2024-06",
+	})
+	rotatedToken, err := NewJwtToken(clk, "user-1", PurposeVerification, rotated, time.Hour)
+	if err != nil {
+		t.Fatalf("NewJwtToken() error = %v", err)
+	}
+	if kid := tokenKID(t, rotatedToken); kid != "2024-06" {
+		t.Errorf("token kid after rotation = %q, want %q", kid, "2024-06")
+	}
+	for name, token := range map[string]string{"legacy": legacyToken, "first": firstToken, "rotated": rotatedToken} {
+		if _, err := ExtractSubjectFromToken(clk, rotated, token, PurposeVerification); err != nil {
+			t.Errorf("ExtractSubjectFromToken() of the %s token error = %v", name, err)
+		}
+	}
+
+	// The new key isn't known to instances that haven't been rotated yet.
+	if _, err := ExtractSubjectFromToken(clk, first, rotatedToken, PurposeVerification); !errors.Is(err, ErrUnknownKeyID) {
+		t.Errorf("ExtractSubjectFromToken() before rotation error = %v, want ErrUnknownKeyID", err)
+	}
+
+	// Retiring a key rejects the tokens signed with it.
+	retired := NewKeyring(&config.JWTConfig{Keys: map[string]string{"2024-06": "second-secret"}, CurrentKID: "2024-06"})
+	if _, err := ExtractSubjectFromToken(clk, retired, firstToken, PurposeVerification); !errors.Is(err, ErrUnknownKeyID) {
+		t.Errorf("ExtractSubjectFromToken() with a retired kid error = %v, want ErrUnknownKeyID", err)
+	}
+	if _, err := ExtractSubjectFromToken(clk, retired, legacyToken, PurposeVerification); !errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+		t.Errorf("ExtractSubjectFromToken() after retiring jwt.secret error = %v, want ErrTokenSignatureInvalid", err)
+	}
+	if _, err := ExtractSubjectFromToken(clk, retired, rotatedToken, PurposeVerification); err != nil {
+		t.Errorf("ExtractSubjectFromToken() with the current key error = %v", err)
+	}
+}
+
+func TestKeyringKey(t *testing.T) {
+	keyring := NewKeyring(&config.JWTConfig{Secret: "legacy-secret", Keys: map[string]string{"a": "secret-a", "b": "secret-b"}, CurrentKID: "a"})
+	verifiesWith := func(secret string) func([]byte) bool {
+		return func(key []byte) bool { return string(key) == secret }
+	}
+
+	tests := []struct {
+		name     string
+		kid      string
+		verifies func([]byte) bool
+		want     string
+		wantErr  error
+	}{
+		{name: "named key", kid: "b", verifies: verifiesWith("secret-a"), want: "secret-b"},
+		{name: "unknown kid", kid: "c", verifies: verifiesWith("secret-a"), wantErr: ErrUnknownKeyID},
+		{name: "no kid matching a key", verifies: verifiesWith("secret-b"), want: "secret-b"},
+		{name: "no kid matching jwt.secret", verifies: verifiesWith("legacy-secret"), want: "legacy-secret"},
+		{name: "no kid matching no key", verifies: verifiesWith("other"), want: "secret-a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := keyring.Key(tt.kid, tt.verifies)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Key() error = %v, want %v", err, tt.wantErr)
+			}
+			if string(key) != tt.want {
+				t.Errorf("Key() = %q, want %q", key, tt.want)
+			}
+		})
+	}
+}
@@ -2,58 +2,161 @@ package tokens
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/npushpakumara/go-backend-template/pkg/clock"
 	"github.com/npushpakumara/go-backend-template/pkg/errors"
 )
 
-// NewJwtToken creates a new JWT token with the given user ID, secret key, and expiration duration.
-// It sets the issuer to "example.com", the subject to the provided user ID, and includes both issued and expiration dates in the token claims.
-// The token is signed using the HS256 algorithm and the provided secret key.
+// Purposes name the single flow a token was minted for. A token is only accepted by the flow
+// whose purpose it carries, so for example a verification link can't be replayed to accept an invitation.
+const (
+	PurposeVerification  = "verification"
+	PurposeInvite        = "invite"
+	PurposeUnsubscribe   = "unsubscribe"
+	PurposePasswordReset = "password_reset"
+)
+
+// purposeClaims are the claims of the tokens sent in emails.
+type purposeClaims struct {
+	Purpose string `json:"purpose"`
+	jwt.RegisteredClaims
+}
+
+// NewJwtToken creates a new JWT token with the given user ID, purpose, keyring, and expiration duration.
+// It sets the issuer to "example.com", the subject to the provided user ID, the purpose claim, and includes both issued and expiration dates in the token claims.
+// The token is signed using the HS256 algorithm and the keyring's current key, whose ID is set in the kid header.
+// The issued and expiration dates are taken from the given clock.
 // Returns the signed token string and an error if any occurred during signing.
-func NewJwtToken(id, secret string, exp time.Duration) (string, error) {
-	claims := &jwt.RegisteredClaims{
-		Issuer:    "example.com",
-		Subject:   id,
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(exp)),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
+func NewJwtToken(clk clock.Clock, id, purpose string, keyring *Keyring, exp time.Duration) (string, error) {
+	return sign(keyring, newPurposeClaims(clk, id, purpose, exp))
+}
+
+// NewUnsubscribeToken creates the token of an unsubscribe link, which names the user and the email
+// category they stop receiving. It is minted for PurposeUnsubscribe and signed like NewJwtToken.
+func NewUnsubscribeToken(clk clock.Clock, userID, category string, keyring *Keyring, exp time.Duration) (string, error) {
+	return sign(keyring, &unsubscribeClaims{
+		Category:      category,
+		purposeClaims: *newPurposeClaims(clk, userID, PurposeUnsubscribe, exp),
+	})
+}
+
+// unsubscribeClaims are the claims of the tokens in unsubscribe links.
+type unsubscribeClaims struct {
+	Category string `json:"category"`
+	purposeClaims
+}
+
+// newPurposeClaims returns the claims of a token for the subject, minted for purpose and expiring after exp.
+func newPurposeClaims(clk clock.Clock, subject, purpose string, exp time.Duration) *purposeClaims {
+	now := clk.Now()
+	return &purposeClaims{
+		Purpose: purpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "example.com",
+			Subject:   subject,
+			ExpiresAt: jwt.NewNumericDate(now.Add(exp)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
 	}
+}
+
+// sign signs the claims with the keyring's current key, naming it in the kid header.
+func sign(keyring *Keyring, claims jwt.Claims) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedToken, err := token.SignedString([]byte(secret))
+	kid, key := keyring.Current()
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signedToken, err := token.SignedString(key)
 	if err != nil {
 		return "", err
 	}
 	return signedToken, nil
 }
 
-// ExtractSubjectFromToken parses the JWT token using the provided secret key to verify its validity.
-// It ensures the token is signed with the HMAC signing method and extracts the "sub" (subject) claim from the token's claims.
-// Returns the subject as a string and an error if the token is invalid or if any other error occurs during parsing.
-func ExtractSubjectFromToken(secret, tokenString string) (string, error) {
+// ExtractSubjectFromToken parses the JWT token, verifying it with the keyring key named by its kid header.
+// It ensures the token is signed with the HMAC signing method, that its purpose claim equals expectedPurpose,
+// and extracts the "sub" (subject) claim from the token's claims. Expiry is checked against the given clock.
+// Returns the subject as a string and an error if the token is invalid, was minted for another purpose,
+// or if any other error occurs during parsing.
+func ExtractSubjectFromToken(clk clock.Clock, keyring *Keyring, tokenString, expectedPurpose string) (string, error) {
+	claims, err := parse(clk, keyring, tokenString, expectedPurpose)
+	if err != nil {
+		return "", err
+	}
+
+	// Extract the "sub" (subject) claim from the claims
+	subject, ok := claims["sub"].(string)
+	if !ok {
+		return "", errors.ErrInvalidToken
+	}
+
+	return subject, nil
+}
+
+// ExtractUnsubscribeToken parses a token created by NewUnsubscribeToken, checked like ExtractSubjectFromToken,
+// and returns the user ID and email category it names.
+func ExtractUnsubscribeToken(clk clock.Clock, keyring *Keyring, tokenString string) (userID, category string, err error) {
+	claims, err := parse(clk, keyring, tokenString, PurposeUnsubscribe)
+	if err != nil {
+		return "", "", err
+	}
+
+	userID, _ = claims["sub"].(string)
+	category, _ = claims["category"].(string)
+	if userID == "" || category == "" {
+		return "", "", errors.ErrInvalidToken
+	}
+	return userID, category, nil
+}
+
+// ExtractPasswordResetToken parses a token minted by NewJwtToken for PurposePasswordReset, checked like
+// ExtractSubjectFromToken, and returns the user ID and when the token was issued.
+func ExtractPasswordResetToken(clk clock.Clock, keyring *Keyring, tokenString string) (userID string, issuedAt time.Time, err error) {
+	claims, err := parse(clk, keyring, tokenString, PurposePasswordReset)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	userID, _ = claims["sub"].(string)
+	iat, err := claims.GetIssuedAt()
+	if userID == "" || err != nil || iat == nil {
+		return "", time.Time{}, errors.ErrInvalidToken
+	}
+	return userID, iat.Time, nil
+}
+
+// parse verifies the token's signature, expiry and purpose and returns its claims.
+func parse(clk clock.Clock, keyring *Keyring, tokenString, expectedPurpose string) (jwt.MapClaims, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		// Ensure the token is signed with the expected signing method
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return []byte(secret), nil
-	})
+		kid, _ := token.Header["kid"].(string)
+		signingString := token.Raw[:strings.LastIndex(token.Raw, ".")]
+		return keyring.Key(kid, func(key []byte) bool {
+			return token.Method.Verify(signingString, token.Signature, key) == nil
+		})
+	}, jwt.WithTimeFunc(clk.Now))
 
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	// Assert the token claims to jwt.MapClaims type
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok || !token.Valid {
-		return "", errors.ErrInvalidToken
+		return nil, errors.ErrInvalidToken
 	}
 
-	// Extract the "sub" (subject) claim from the claims
-	subject, ok := claims["sub"].(string)
-	if !ok {
-		return "", errors.ErrInvalidToken
+	// Reject tokens minted for another flow, and tokens issued before purposes existed
+	if purpose, _ := claims["purpose"].(string); purpose != expectedPurpose {
+		return nil, errors.ErrInvalidToken
 	}
 
-	return subject, nil
+	return claims, nil
 }
@@ -0,0 +1,163 @@
+package tokens
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/pkg/clock"
+	apiError "github.com/npushpakumara/go-backend-template/pkg/errors"
+)
+
+// newTestKeyring returns a keyring signing with a single unnamed secret.
+func newTestKeyring() *Keyring {
+	return NewKeyring(&config.JWTConfig{Secret: "test-secret"})
+}
+
+func TestExtractSubjectFromTokenRejectsExpiredToken(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	keyring := newTestKeyring()
+
+	token, err := NewJwtToken(clk, "user-1", PurposeVerification, keyring, time.Hour)
+	if err != nil {
+		t.Fatalf("NewJwtToken() error = %v", err)
+	}
+
+	clk.Advance(time.Hour - time.Second)
+	subject, err := ExtractSubjectFromToken(clk, keyring, token, PurposeVerification)
+	if err != nil {
+		t.Fatalf("ExtractSubjectFromToken() before expiry error = %v", err)
+	}
+	if subject != "user-1" {
+		t.Errorf("ExtractSubjectFromToken() = %q, want %q", subject, "user-1")
+	}
+
+	clk.Advance(2 * time.Second)
+	if _, err := ExtractSubjectFromToken(clk, keyring, token, PurposeVerification); !errors.Is(err, jwt.ErrTokenExpired) {
+		t.Fatalf("ExtractSubjectFromToken() after expiry error = %v, want ErrTokenExpired", err)
+	}
+}
+
+// Tokens are checked against the clock they are given, not the system time.
+func TestExtractSubjectFromTokenUsesClock(t *testing.T) {
+	issued := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	keyring := newTestKeyring()
+
+	token, err := NewJwtToken(issued, "user-1", PurposeVerification, keyring, time.Hour)
+	if err != nil {
+		t.Fatalf("NewJwtToken() error = %v", err)
+	}
+
+	// The token expired long before the real current time.
+	if _, err := ExtractSubjectFromToken(clock.New(), keyring, token, PurposeVerification); !errors.Is(err, jwt.ErrTokenExpired) {
+		t.Errorf("ExtractSubjectFromToken() with the system clock error = %v, want ErrTokenExpired", err)
+	}
+	if _, err := ExtractSubjectFromToken(issued, keyring, token, PurposeVerification); err != nil {
+		t.Errorf("ExtractSubjectFromToken() with the issuing clock error = %v", err)
+	}
+}
+
+func TestExtractPasswordResetTokenRejectsExpiredToken(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	keyring := newTestKeyring()
+
+	token, err := NewJwtToken(clk, "user-1", PurposePasswordReset, keyring, 30*time.Minute)
+	if err != nil {
+		t.Fatalf("NewJwtToken() error = %v", err)
+	}
+
+	userID, issuedAt, err := ExtractPasswordResetToken(clk, keyring, token)
+	if err != nil {
+		t.Fatalf("ExtractPasswordResetToken() error = %v", err)
+	}
+	if userID != "user-1" || !issuedAt.Equal(clk.Now()) {
+		t.Errorf("ExtractPasswordResetToken() = %q, %s, want %q, %s", userID, issuedAt, "user-1", clk.Now())
+	}
+
+	clk.Advance(31 * time.Minute)
+	if _, _, err := ExtractPasswordResetToken(clk, keyring, token); !errors.Is(err, jwt.ErrTokenExpired) {
+		t.Fatalf("ExtractPasswordResetToken() after expiry error = %v, want ErrTokenExpired", err)
+	}
+}
+
+func TestExtractUnsubscribeToken(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	keyring := newTestKeyring()
+
+	token, err := NewUnsubscribeToken(clk, "user-1", "marketing", keyring, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("NewUnsubscribeToken() error = %v", err)
+	}
+
+	userID, category, err := ExtractUnsubscribeToken(clk, keyring, token)
+	if err != nil {
+		t.Fatalf("ExtractUnsubscribeToken() error = %v", err)
+	}
+	if userID != "user-1" || category != "marketing" {
+		t.Errorf("ExtractUnsubscribeToken() = %q, %q, want %q, %q", userID, category, "user-1", "marketing")
+	}
+
+	clk.Advance(24*time.Hour + time.Second)
+	if _, _, err := ExtractUnsubscribeToken(clk, keyring, token); !errors.Is(err, jwt.ErrTokenExpired) {
+		t.Errorf("ExtractUnsubscribeToken() after expiry error = %v, want ErrTokenExpired", err)
+	}
+}
+
+func TestExtractUnsubscribeTokenRejectsTamperedToken(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	keyring := newTestKeyring()
+
+	token, err := NewUnsubscribeToken(clk, "user-1", "marketing", keyring, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("NewUnsubscribeToken() error = %v", err)
+	}
+	other, err := NewUnsubscribeToken(clk, "user-2", "marketing", keyring, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("NewUnsubscribeToken() error = %v", err)
+	}
+	forged, err := NewUnsubscribeToken(clk, "user-1", "marketing", NewKeyring(&config.JWTConfig{Secret: "other-secret"}), 24*time.Hour)
+	if err != nil {
+		t.Fatalf("NewUnsubscribeToken() error = %v", err)
+	}
+
+	// Swapping in another user's claims keeps a signature that no longer matches them.
+	parts := strings.Split(token, ".")
+	swapped := parts[0] + "." + strings.Split(other, ".")[1] + "." + parts[2]
+
+	for name, tampered := range map[string]string{"swapped claims": swapped, "signed with another secret": forged} {
+		if _, _, err := ExtractUnsubscribeToken(clk, keyring, tampered); !errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+			t.Errorf("ExtractUnsubscribeToken() of a token with %s error = %v, want ErrTokenSignatureInvalid", name, err)
+		}
+	}
+}
+
+func TestUnsubscribeTokensAreBoundToTheirPurpose(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	keyring := newTestKeyring()
+
+	// A token minted for another flow names a user but no category, and must not unsubscribe them.
+	for _, purpose := range []string{PurposeVerification, PurposePasswordReset} {
+		token, err := NewJwtToken(clk, "user-1", purpose, keyring, time.Hour)
+		if err != nil {
+			t.Fatalf("NewJwtToken() error = %v", err)
+		}
+		if _, _, err := ExtractUnsubscribeToken(clk, keyring, token); !errors.Is(err, apiError.ErrInvalidToken) {
+			t.Errorf("ExtractUnsubscribeToken() of a %s token error = %v, want ErrInvalidToken", purpose, err)
+		}
+	}
+
+	// An unsubscribe link, which is sent in every marketing email, can't be used to verify an account or reset its password.
+	token, err := NewUnsubscribeToken(clk, "user-1", "marketing", keyring, time.Hour)
+	if err != nil {
+		t.Fatalf("NewUnsubscribeToken() error = %v", err)
+	}
+	if _, err := ExtractSubjectFromToken(clk, keyring, token, PurposeVerification); !errors.Is(err, apiError.ErrInvalidToken) {
+		t.Errorf("ExtractSubjectFromToken() of an unsubscribe token error = %v, want ErrInvalidToken", err)
+	}
+	if _, _, err := ExtractPasswordResetToken(clk, keyring, token); !errors.Is(err, apiError.ErrInvalidToken) {
+		t.Errorf("ExtractPasswordResetToken() of an unsubscribe token error = %v, want ErrInvalidToken", err)
+	}
+}
@@ -0,0 +1,74 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/npushpakumara/go-backend-template/internal/features/email/entities"
+)
+
+// ErrRecipientSuppressed is reported for bulk recipients that were skipped because they are on the suppression list.
+var ErrRecipientSuppressed = errors.New("recipient is on the suppression list")
+
+// throttle spaces out sends so that no more than rate messages are sent per second.
+// A zero rate disables throttling.
+type throttle struct {
+	interval time.Duration
+	next     time.Time
+}
+
+// newThrottle creates a throttle allowing rate messages per second.
+func newThrottle(rate float64) *throttle {
+	if rate <= 0 {
+		return &throttle{}
+	}
+	return &throttle{interval: time.Duration(float64(time.Second) / rate)}
+}
+
+// Wait blocks until n more messages may be sent, or returns the context's error if it is done first.
+func (t *throttle) Wait(ctx context.Context, n int) error {
+	if t.interval == 0 {
+		return nil
+	}
+
+	if wait := time.Until(t.next); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	t.next = time.Now().Add(time.Duration(n) * t.interval)
+	return nil
+}
+
+// sendEach renders and sends the template to each recipient one by one through the given service.
+// It is the bulk strategy for providers without a native bulk API.
+func sendEach(ctx context.Context, s Service, from, templateKey string, recipients []entities.Recipient, t *throttle) ([]entities.BulkResult, error) {
+	if _, ok := entities.EmailTemplates[templateKey]; !ok {
+		return nil, fmt.Errorf("unknown email template %q", templateKey)
+	}
+
+	results := make([]entities.BulkResult, 0, len(recipients))
+
+	for _, recipient := range recipients {
+		if err := t.Wait(ctx, 1); err != nil {
+			return results, err
+		}
+
+		result := entities.BulkResult{Email: recipient.Email}
+		email, err := NewTemplatedEmail(templateKey, recipient.Locale, from, []string{recipient.Email}, recipient.Data)
+		if err == nil {
+			err = s.SendEmail(ctx, *email)
+		}
+		result.Err = err
+		results = append(results, result)
+	}
+
+	return results, nil
+}
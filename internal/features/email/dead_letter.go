@@ -0,0 +1,60 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/npushpakumara/go-backend-template/internal/features/email/entities"
+	"github.com/npushpakumara/go-backend-template/internal/postgres"
+	"github.com/npushpakumara/go-backend-template/pkg/logging"
+)
+
+// DeadLetterService recovers the emails the email queue gave up on.
+type DeadLetterService interface {
+	// Retry queues the failed email with the given ID again and marks it deleted in the dead-letter table.
+	// It returns postgres.ErrRecordNotFound if no failed email matches, including one that was already retried.
+	Retry(ctx context.Context, id string) error
+}
+
+// deadLetterServiceImpl is a concrete implementation of the DeadLetterService interface.
+type deadLetterServiceImpl struct {
+	failedEmails       FailedEmailRepository
+	emailService       Service
+	transactionManager postgres.TransactionManager
+}
+
+// NewDeadLetterService creates a new instance of deadLetterServiceImpl.
+func NewDeadLetterService(failedEmails FailedEmailRepository, emailService Service, transactionManager postgres.TransactionManager) DeadLetterService {
+	return &deadLetterServiceImpl{failedEmails, emailService, transactionManager}
+}
+
+// Retry locks the failed email while it is queued and deleted, so concurrent retries queue it only once.
+// If it can't be queued, the transaction is rolled back and the failed email is kept.
+func (ds *deadLetterServiceImpl) Retry(ctx context.Context, id string) error {
+	logger := logging.FromContext(ctx)
+
+	return ds.transactionManager.RunInTransaction(ctx, func(ctx context.Context) error {
+		failed, err := ds.failedEmails.FindByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		var email entities.Email
+		if err := json.Unmarshal([]byte(failed.Data), &email); err != nil {
+			logger.Errorw("email.deadletter.Retry failed to decode failed email", "id", id, "err", err)
+			return err
+		}
+
+		if err := ds.failedEmails.Delete(ctx, id); err != nil {
+			return err
+		}
+
+		// The queue's workers send with their own context, so the transaction doesn't reach them.
+		if err := ds.emailService.SendEmail(ctx, email); err != nil {
+			logger.Errorw("email.deadletter.Retry failed to queue email", "id", id, "err", err)
+			return err
+		}
+		logger.Infow("email.deadletter.Retry queued failed email again", "id", id, "to", email.To, "previous_attempts", failed.Attempts)
+		return nil
+	})
+}
@@ -0,0 +1,66 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/internal/features/email/entities"
+	"github.com/npushpakumara/go-backend-template/pkg/logging"
+)
+
+// unsafeFileChars matches characters that shouldn't appear in a generated file name.
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// dryRunEmailServiceImpl is an implementation of Service that never sends anything.
+// Each email is written as an HTML file to a temporary directory so templates can be inspected locally.
+type dryRunEmailServiceImpl struct {
+	dir  string
+	from string
+}
+
+// NewDryRunEmailService creates a Service that writes emails to a directory under the OS temp dir instead of sending them.
+func NewDryRunEmailService(cfg *config.Config) Service {
+	return &dryRunEmailServiceImpl{
+		dir:  filepath.Join(os.TempDir(), "emails"),
+		from: cfg.Mail.FromEmail,
+	}
+}
+
+// SendEmail writes the rendered email body to a file and logs where it was written.
+func (s *dryRunEmailServiceImpl) SendEmail(ctx context.Context, email entities.Email) error {
+	logger := logging.FromContext(ctx)
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		logger.Errorw("email.service.SendEmail failed to create dry-run directory", "dir", s.dir, "err", err)
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s.html", time.Now().Format("20060102-150405.000000"), unsafeFileChars.ReplaceAllString(email.Subject, "_"))
+	path := filepath.Join(s.dir, name)
+
+	if err := os.WriteFile(path, []byte(email.Data), 0o644); err != nil {
+		logger.Errorw("email.service.SendEmail failed to write dry-run email", "path", path, "err", err)
+		return err
+	}
+
+	// Write the plain-text alternative next to it, as it would be sent.
+	textPath := strings.TrimSuffix(path, ".html") + ".txt"
+	if err := os.WriteFile(textPath, []byte(plainTextBody(email)), 0o644); err != nil {
+		logger.Errorw("email.service.SendEmail failed to write dry-run email", "path", textPath, "err", err)
+		return err
+	}
+
+	logger.Infow("email.service.SendEmail dry run, email not sent", "to", email.To, "subject", email.Subject, "path", path)
+	return nil
+}
+
+// SendBulk writes one file per recipient without any throttling.
+func (s *dryRunEmailServiceImpl) SendBulk(ctx context.Context, templateKey string, recipients []entities.Recipient) ([]entities.BulkResult, error) {
+	return sendEach(ctx, s, s.from, templateKey, recipients, newThrottle(0))
+}
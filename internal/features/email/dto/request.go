@@ -0,0 +1,14 @@
+package dto
+
+// PreviewEmailRequestDto is a Data Transfer Object (DTO) used to render an email template with sample data.
+// Template is the key of a registered email template, e.g. "UserVerification".
+type PreviewEmailRequestDto struct {
+	Template string                 `json:"template" binding:"required"`
+	Locale   string                 `json:"locale" binding:"omitempty,bcp47_language_tag"`
+	Data     map[string]interface{} `json:"data"`
+}
+
+// SendTestEmailRequestDto is a Data Transfer Object (DTO) used to send a test email through the configured provider.
+type SendTestEmailRequestDto struct {
+	To string `json:"to" binding:"required,email"`
+}
@@ -0,0 +1,8 @@
+package dto
+
+// SendTestEmailResponseDto reports that the configured provider accepted a test email.
+type SendTestEmailResponseDto struct {
+	Status   string `json:"status"`
+	Message  string `json:"message"`
+	Provider string `json:"provider"`
+}
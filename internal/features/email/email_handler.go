@@ -0,0 +1,335 @@
+package email
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/internal/features/email/dto"
+	"github.com/npushpakumara/go-backend-template/internal/features/email/entities"
+	userEntity "github.com/npushpakumara/go-backend-template/internal/features/user/entity"
+	"github.com/npushpakumara/go-backend-template/internal/postgres"
+	"github.com/npushpakumara/go-backend-template/internal/ratelimit"
+	"github.com/npushpakumara/go-backend-template/internal/rbac"
+	"github.com/npushpakumara/go-backend-template/pkg"
+	"github.com/npushpakumara/go-backend-template/pkg/clock"
+	apiError "github.com/npushpakumara/go-backend-template/pkg/errors"
+	"github.com/npushpakumara/go-backend-template/pkg/logging"
+)
+
+// Test emails are limited per administrator, since each one is a real send through the provider.
+const (
+	testEmailLimit  = 5
+	testEmailWindow = time.Minute
+)
+
+// unsubscribePath is the unsubscribe endpoint, which mail.unsubscribe.url points at.
+const unsubscribePath = "/api/v1/email/unsubscribe"
+
+// CSRFExemptRoutes are the routes authenticated by a token of their own rather than the session cookie, so a
+// recipient who is also signed in can post to them without a CSRF token.
+var CSRFExemptRoutes = []string{unsubscribePath}
+
+// Handler handles email-related admin requests.
+type Handler struct {
+	emailService      Service
+	feedbackService   FeedbackService
+	deadLetterService DeadLetterService
+	clock             clock.Clock
+	cfg               *config.Config
+}
+
+// NewEmailHandler creates a new Handler instance with the provided email services.
+func NewEmailHandler(emailService Service, feedbackService FeedbackService, deadLetterService DeadLetterService, clk clock.Clock, cfg *config.Config) *Handler {
+	return &Handler{emailService, feedbackService, deadLetterService, clk, cfg}
+}
+
+// Router sets up the routes for working with emails.
+// The admin routes are only available to administrators outside any organization,
+// while the SES webhook is public and authenticated by the SNS message signature,
+// and the unsubscribe endpoint is public and authenticated by the signed token in its link; only its POST
+// unsubscribes, and it is exempt from CSRF checks through CSRFExemptRoutes.
+func Router(router *gin.Engine, handler *Handler, authMiddleware *jwt.GinJWTMiddleware) {
+	v1 := router.Group("api/v1")
+	{
+		v1.POST("/webhooks/ses", handler.sesWebhook)
+		v1.GET("/email/unsubscribe", handler.unsubscribeFromLink)
+		v1.POST("/email/unsubscribe", handler.unsubscribe)
+	}
+
+	admin := router.Group("api/v1/admin")
+
+	admin.Use(authMiddleware.MiddlewareFunc(), rbac.RequireRole(userEntity.RoleAdmin), rbac.DenyOrgScoped())
+	{
+		admin.POST("/email/preview", handler.previewEmail)
+		admin.POST("/email/test", ratelimit.Limit(ratelimit.NewLimiter(handler.clock, testEmailLimit, testEmailWindow), handler.clock, adminKey), handler.sendTestEmail)
+		admin.POST("/email/retry/:id", pkg.RequireUUIDParams("id"), handler.retryFailedEmail)
+	}
+}
+
+// previewEmail renders the requested template with the supplied sample data and returns the HTML.
+// Nothing is sent, which makes it handy for iterating on templates.
+func (eh *Handler) previewEmail(ctx *gin.Context) {
+	logger := logging.FromContext(ctx)
+	var requestBody dto.PreviewEmailRequestDto
+
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		logger.Errorw("email.handler.previewEmail failed to get request body: v", err)
+		var details []*pkg.ValidationErrDetail
+		if vErrs, ok := err.(validator.ValidationErrors); ok {
+			details = pkg.ValidationErrorDetails(&requestBody, "json", vErrs)
+		}
+		ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "error", Message: "Invalid request body", Errors: details})
+		return
+	}
+
+	if _, ok := entities.EmailTemplates[requestBody.Template]; !ok {
+		ctx.JSON(http.StatusNotFound, apiError.ErrorResponse{Status: "error", Message: "Email template not found"})
+		return
+	}
+
+	email, err := NewTemplatedEmail(requestBody.Template, requestBody.Locale, "", nil, requestBody.Data)
+	if err != nil {
+		logger.Errorw("email.handler.previewEmail failed to render template", "template", requestBody.Template, "err", err)
+		ctx.JSON(http.StatusUnprocessableEntity, apiError.ErrorResponse{Status: "error", Message: "Failed to render email template"})
+		return
+	}
+
+	ctx.Header("X-Email-Subject", email.Subject)
+	ctx.Data(http.StatusOK, "text/html; charset=utf-8", []byte(email.Data))
+}
+
+// sendTestEmail sends a short email to the given address through the configured provider,
+// so operators can check delivery end to end. A provider failure is reported with its error.
+func (eh *Handler) sendTestEmail(ctx *gin.Context) {
+	logger := logging.FromContext(ctx)
+	var requestBody dto.SendTestEmailRequestDto
+
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		logger.Errorw("email.handler.sendTestEmail failed to get request body: v", err)
+		var details []*pkg.ValidationErrDetail
+		if vErrs, ok := err.(validator.ValidationErrors); ok {
+			details = pkg.ValidationErrorDetails(&requestBody, "json", vErrs)
+		}
+		ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "error", Message: "Invalid request body", Errors: details})
+		return
+	}
+
+	provider := eh.cfg.Mail.Provider
+	if eh.cfg.Mail.DryRun {
+		provider = "dry_run"
+	}
+
+	email := entities.Email{
+		From:    eh.cfg.Mail.FromEmail,
+		To:      []string{requestBody.To},
+		Subject: "Test email",
+		Data:    "<p>This is a test email sent to check the email provider configuration.</p>",
+	}
+	// Send right away rather than queueing, so a provider failure is reported to the caller.
+	if err := eh.emailService.SendEmail(SendNow(ctx), email); err != nil {
+		logger.Errorw("email.handler.sendTestEmail failed to send test email", "provider", provider, "err", err)
+		ctx.JSON(http.StatusBadGateway, apiError.ErrorResponse{
+			Status:  "error",
+			Message: "Failed to send test email",
+			Errors:  gin.H{"provider": provider, "error": err.Error()},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.SendTestEmailResponseDto{Status: "success", Message: "Test email accepted by the provider", Provider: provider})
+}
+
+// retryFailedEmail queues the failed email identified by the "id" path parameter again.
+func (eh *Handler) retryFailedEmail(ctx *gin.Context) {
+	err := eh.deadLetterService.Retry(ctx, ctx.Param("id"))
+	if err != nil {
+		if errors.Is(err, postgres.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, apiError.ErrorResponse{Status: "error", Message: "Failed email not found"})
+			return
+		}
+		if errors.Is(err, ErrQueueClosed) {
+			ctx.JSON(http.StatusServiceUnavailable, apiError.ErrorResponse{Status: "error", Message: "Email queue is shutting down"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, apiError.ErrorResponse{Status: "error", Message: "Internal server error"})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, apiError.ErrorResponse{Status: "success", Message: "Email queued again"})
+}
+
+// adminKey buckets rate limits by the signed-in administrator.
+func adminKey(ctx *gin.Context) string {
+	if identity, ok := rbac.CurrentIdentity(ctx); ok {
+		return identity.ID
+	}
+	return ctx.ClientIP()
+}
+
+// sesWebhook receives SES bounce and complaint notifications from Amazon SNS.
+// SNS posts the envelope as JSON with a text/plain content type, so the body is decoded manually.
+func (eh *Handler) sesWebhook(ctx *gin.Context) {
+	logger := logging.FromContext(ctx)
+
+	body, err := io.ReadAll(io.LimitReader(ctx.Request.Body, 256<<10))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "error", Message: "Invalid request body"})
+		return
+	}
+
+	msg, err := ParseSNSMessage(body)
+	if err != nil {
+		logger.Errorw("email.handler.sesWebhook failed to parse sns message", "err", err)
+		ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "error", Message: "Invalid request body"})
+		return
+	}
+
+	if err := eh.feedbackService.HandleSNSMessage(ctx, msg); err != nil {
+		if errors.Is(err, ErrInvalidSNSSignature) {
+			ctx.JSON(http.StatusForbidden, apiError.ErrorResponse{Status: "error", Message: "Invalid message signature"})
+			return
+		}
+		if errors.Is(err, ErrSNSTopicNotConfigured) {
+			ctx.JSON(http.StatusServiceUnavailable, apiError.ErrorResponse{Status: "error", Message: "Email feedback is not configured"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, apiError.ErrorResponse{Status: "error", Message: "Internal server error"})
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+// Reasons an unsubscribe link is refused, passed to the unsubscribe page as ?error=<reason>.
+const (
+	unsubscribeErrMissingToken = "missing_token"
+	unsubscribeErrInvalidToken = "invalid_token"
+	unsubscribeErrInternal     = "internal"
+)
+
+// unsubscribePage shows browsers the outcome of the confirmation form when mail.unsubscribe.page_url isn't set.
+const unsubscribePage = `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Email preferences</title></head>
+<body><p>%s</p></body></html>
+`
+
+// unsubscribeConfirmPage is shown when a recipient opens an unsubscribe link. Mail scanners and link previews
+// open links too, so opening one changes nothing; the form posts back to the link, token included, since it
+// has no action.
+const unsubscribeConfirmPage = `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Email preferences</title></head>
+<body><form method="post"><p>Unsubscribe from these emails?</p><button type="submit">Unsubscribe</button></form></body></html>
+`
+
+// missingUnsubscribeToken is the outcome of an unsubscribe link without its token.
+var missingUnsubscribeToken = unsubscribeOutcome{status: http.StatusBadRequest, errCode: unsubscribeErrMissingToken, message: "Missing unsubscribe token"}
+
+// unsubscribeOutcome is the result of following an unsubscribe link. An empty errCode means it succeeded.
+type unsubscribeOutcome struct {
+	status   int
+	errCode  string
+	message  string
+	category string
+}
+
+// writeJSON responds with the outcome as JSON.
+func (outcome unsubscribeOutcome) writeJSON(ctx *gin.Context) {
+	if outcome.errCode != "" {
+		ctx.JSON(outcome.status, apiError.ErrorResponse{Status: "error", Message: outcome.message})
+		return
+	}
+	ctx.JSON(outcome.status, apiError.ErrorResponse{Status: "success", Message: outcome.message})
+}
+
+// unsubscribe turns off the notification preference named by the link's token. Mail clients post here for
+// RFC 8058 one-click unsubscribe, with a List-Unsubscribe=One-Click form body, and get JSON like API clients do.
+// The form on the confirmation page is redirected to mail.unsubscribe.page_url with ?unsubscribed=<category>
+// or ?error=<reason>, or shown the outcome when it isn't set.
+func (eh *Handler) unsubscribe(ctx *gin.Context) {
+	outcome := eh.runUnsubscribe(ctx)
+	if !fromConfirmPage(ctx) {
+		outcome.writeJSON(ctx)
+		return
+	}
+	eh.writeUnsubscribePage(ctx, outcome)
+}
+
+// unsubscribeFromLink shows a recipient who opened the unsubscribe link a page asking them to confirm.
+// It changes nothing itself; requests that accept application/json are told to POST instead.
+func (eh *Handler) unsubscribeFromLink(ctx *gin.Context) {
+	if strings.Contains(ctx.GetHeader("Accept"), "application/json") {
+		ctx.Header("Allow", http.MethodPost)
+		ctx.JSON(http.StatusMethodNotAllowed, apiError.ErrorResponse{Status: "error", Message: "Unsubscribe with a POST request"})
+		return
+	}
+	if ctx.Query("token") == "" {
+		eh.writeUnsubscribePage(ctx, missingUnsubscribeToken)
+		return
+	}
+
+	ctx.Data(http.StatusOK, "text/html; charset=utf-8", []byte(unsubscribeConfirmPage))
+}
+
+// fromConfirmPage reports whether an unsubscribe POST was sent by the confirmation page's form, rather than
+// by a mail client's one-click unsubscribe or an API client.
+func fromConfirmPage(ctx *gin.Context) bool {
+	if ctx.ContentType() != "application/x-www-form-urlencoded" || strings.Contains(ctx.GetHeader("Accept"), "application/json") {
+		return false
+	}
+	return ctx.PostForm("List-Unsubscribe") != "One-Click"
+}
+
+// writeUnsubscribePage redirects the browser to mail.unsubscribe.page_url with the outcome, or shows it when
+// the page isn't set.
+func (eh *Handler) writeUnsubscribePage(ctx *gin.Context, outcome unsubscribeOutcome) {
+	if pageURL := eh.cfg.Mail.Unsubscribe.PageURL; pageURL != "" {
+		target, err := url.Parse(pageURL)
+		if err == nil {
+			query := target.Query()
+			if outcome.errCode == "" {
+				query.Set("unsubscribed", outcome.category)
+			} else {
+				query.Set("error", outcome.errCode)
+			}
+			target.RawQuery = query.Encode()
+
+			// 303 makes the browser load the page with GET after the form's POST.
+			ctx.Redirect(http.StatusSeeOther, target.String())
+			return
+		}
+		logging.FromContext(ctx).Errorw("email.handler.writeUnsubscribePage invalid unsubscribe page url", "err", err)
+	}
+
+	ctx.Data(outcome.status, "text/html; charset=utf-8", []byte(fmt.Sprintf(unsubscribePage, html.EscapeString(outcome.message))))
+}
+
+// runUnsubscribe turns off the notification preference named by the token in the query string.
+func (eh *Handler) runUnsubscribe(ctx *gin.Context) unsubscribeOutcome {
+	logger := logging.FromContext(ctx)
+
+	token := ctx.Query("token")
+	if token == "" {
+		return missingUnsubscribeToken
+	}
+
+	category, err := eh.feedbackService.Unsubscribe(ctx, token)
+	if err != nil {
+		if errors.Is(err, apiError.ErrInvalidToken) {
+			return unsubscribeOutcome{status: http.StatusBadRequest, errCode: unsubscribeErrInvalidToken, message: "Invalid or expired unsubscribe link"}
+		}
+		logger.Errorw("email.handler.runUnsubscribe failed to unsubscribe", "err", err)
+		return unsubscribeOutcome{status: http.StatusInternalServerError, errCode: unsubscribeErrInternal, message: "Internal server error"}
+	}
+
+	return unsubscribeOutcome{status: http.StatusOK, message: fmt.Sprintf("You have been unsubscribed from %s emails", category), category: category}
+}
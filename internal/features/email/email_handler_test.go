@@ -0,0 +1,155 @@
+package email
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	apiError "github.com/npushpakumara/go-backend-template/pkg/errors"
+)
+
+// recordingUnsubscriber is a FeedbackService that accepts the token "valid" for the marketing category
+// and records every token it is asked to unsubscribe.
+type recordingUnsubscriber struct {
+	FeedbackService
+	tokens []string
+}
+
+func (r *recordingUnsubscriber) Unsubscribe(_ context.Context, token string) (string, error) {
+	r.tokens = append(r.tokens, token)
+	if token != "valid" {
+		return "", apiError.ErrInvalidToken
+	}
+	return "marketing", nil
+}
+
+// newUnsubscribeRouter serves the unsubscribe routes with the given mail.unsubscribe.page_url.
+func newUnsubscribeRouter(t *testing.T, pageURL string) (*gin.Engine, *recordingUnsubscriber) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{}
+	cfg.Mail.Unsubscribe.PageURL = pageURL
+	feedback := &recordingUnsubscriber{}
+	handler := NewEmailHandler(nil, feedback, nil, nil, cfg)
+
+	router := gin.New()
+	router.GET(unsubscribePath, handler.unsubscribeFromLink)
+	router.POST(unsubscribePath, handler.unsubscribe)
+	return router, feedback
+}
+
+// Opening the link only asks for confirmation, since scanners and link previews open links too.
+func TestUnsubscribeLinkAsksForConfirmation(t *testing.T) {
+	router, feedback := newUnsubscribeRouter(t, "")
+
+	tests := []struct {
+		name     string
+		target   string
+		accept   string
+		want     int
+		wantBody string
+	}{
+		{name: "link with a token", target: unsubscribePath + "?token=valid", want: http.StatusOK, wantBody: `<form method="post">`},
+		{name: "link without a token", target: unsubscribePath, want: http.StatusBadRequest, wantBody: "Missing unsubscribe token"},
+		{name: "json client", target: unsubscribePath + "?token=valid", accept: "application/json", want: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body = %q, want it to contain %q", rec.Body.String(), tt.wantBody)
+			}
+		})
+	}
+
+	if len(feedback.tokens) != 0 {
+		t.Errorf("Unsubscribe() called with %v, want no calls", feedback.tokens)
+	}
+}
+
+func TestUnsubscribePost(t *testing.T) {
+	tests := []struct {
+		name         string
+		pageURL      string
+		token        string
+		body         string
+		wantStatus   int
+		wantBody     string
+		wantLocation string
+	}{
+		{
+			name:       "one-click unsubscribe",
+			token:      "valid",
+			body:       "List-Unsubscribe=One-Click",
+			wantStatus: http.StatusOK,
+			wantBody:   `"status":"success"`,
+		},
+		{
+			name:       "one-click unsubscribe with an invalid token",
+			token:      "forged",
+			body:       "List-Unsubscribe=One-Click",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `"status":"error"`,
+		},
+		{
+			name:       "confirmation form",
+			token:      "valid",
+			wantStatus: http.StatusOK,
+			wantBody:   "You have been unsubscribed from marketing emails",
+		},
+		{
+			name:         "confirmation form with a page url",
+			pageURL:      "https://app.example.com/unsubscribed",
+			token:        "valid",
+			wantStatus:   http.StatusSeeOther,
+			wantLocation: "https://app.example.com/unsubscribed?unsubscribed=marketing",
+		},
+		{
+			name:         "confirmation form with an invalid token",
+			pageURL:      "https://app.example.com/unsubscribed",
+			token:        "forged",
+			wantStatus:   http.StatusSeeOther,
+			wantLocation: "https://app.example.com/unsubscribed?error=invalid_token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, feedback := newUnsubscribeRouter(t, tt.pageURL)
+			req := httptest.NewRequest(http.MethodPost, unsubscribePath+"?token="+tt.token, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body = %q, want it to contain %q", rec.Body.String(), tt.wantBody)
+			}
+			if got := rec.Header().Get("Location"); got != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", got, tt.wantLocation)
+			}
+			if len(feedback.tokens) != 1 || feedback.tokens[0] != tt.token {
+				t.Errorf("Unsubscribe() called with %v, want [%s]", feedback.tokens, tt.token)
+			}
+		})
+	}
+}
@@ -2,34 +2,107 @@ package email
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	awsclient "github.com/npushpakumara/go-backend-template/internal/aws_client"
 	"github.com/npushpakumara/go-backend-template/internal/config"
 	"github.com/npushpakumara/go-backend-template/internal/features/email/entities"
+	"github.com/npushpakumara/go-backend-template/internal/features/user"
+	"github.com/npushpakumara/go-backend-template/pkg/clock"
+	"go.uber.org/fx"
 )
 
 // Service defines an interface for sending emails.
 // It provides a method to send an email with a given context and email details.
 type Service interface {
 	SendEmail(c context.Context, email entities.Email) error
+
+	// SendBulk sends the template registered under templateKey to every recipient, rendered with
+	// that recipient's data. A failure for one recipient doesn't stop the others; per-recipient
+	// outcomes are reported in the returned results. The error is only set when the whole send
+	// could not proceed, e.g. an unknown template or a cancelled context.
+	SendBulk(c context.Context, templateKey string, recipients []entities.Recipient) ([]entities.BulkResult, error)
 }
 
 // Provider defines the available email providers.
 type Provider string
 
 const (
-	providerSES  Provider = "ses"
-	providerSMTP Provider = "smtp"
+	providerSES      Provider = "ses"
+	providerSMTP     Provider = "smtp"
+	providerFailover Provider = "failover"
 )
 
+// ErrUnknownEmailProvider is returned when mail.provider doesn't name a supported provider.
+var ErrUnknownEmailProvider = errors.New("unknown email provider")
+
 // NewEmailService creates a new email service based on the given provider.
-func NewEmailService(cfg *config.Config, awsClient *awsclient.AWSClient) Service {
-	switch Provider(cfg.Mail.Provider) {
+// When dry run is enabled, emails are written to disk instead of being sent through any provider.
+// The failover provider sends through mail.primary and falls back to mail.secondary when that fails.
+// Every service is wrapped so that addresses on the suppression list are never mailed.
+// Marketing emails are only sent to users who haven't opted out of them, and carry List-Unsubscribe
+// headers when mail.unsubscribe.url is set.
+// An unset or unknown provider is reported as an error so the application fails at startup
+// rather than on the first email it tries to send.
+// When mail.queue.workers is set, emails are sent by background workers that are drained on shutdown,
+// within the application's stop timeout. With mail.queue.dead_letter, emails that fail their last
+// attempt are saved to failedEmails so they can be queued again.
+func NewEmailService(lc fx.Lifecycle, cfg *config.Config, clk clock.Clock, awsClient *awsclient.AWSClient, suppressions SuppressionRepository, failedEmails FailedEmailRepository, users user.Service) (Service, error) {
+	service, err := newBaseEmailService(cfg, awsClient)
+	if err != nil {
+		return nil, err
+	}
+	service = NewMarketingEmailService(service, users, clk, cfg)
+	service = NewSuppressionEmailService(service, suppressions)
+
+	if cfg.Mail.Queue.Workers <= 0 {
+		return service, nil
+	}
+	var deadLetters FailedEmailRepository
+	if cfg.Mail.Queue.DeadLetter {
+		deadLetters = failedEmails
+	}
+	queue := newQueuedEmailService(service, &cfg.Mail.Queue, deadLetters)
+	lc.Append(fx.Hook{OnStop: queue.stop})
+	return queue, nil
+}
+
+// newBaseEmailService creates the service that hands emails to the configured provider.
+func newBaseEmailService(cfg *config.Config, awsClient *awsclient.AWSClient) (Service, error) {
+	if cfg.Mail.DryRun {
+		return NewDryRunEmailService(cfg), nil
+	}
+
+	if Provider(cfg.Mail.Provider) != providerFailover {
+		return newProvider(Provider(cfg.Mail.Provider), "mail.provider", cfg, awsClient)
+	}
+
+	if cfg.Mail.Primary == cfg.Mail.Secondary {
+		return nil, fmt.Errorf("%w: mail.primary and mail.secondary must differ, both are %q",
+			ErrUnknownEmailProvider, cfg.Mail.Primary)
+	}
+	primary, err := newProvider(Provider(cfg.Mail.Primary), "mail.primary", cfg, awsClient)
+	if err != nil {
+		return nil, err
+	}
+	secondary, err := newProvider(Provider(cfg.Mail.Secondary), "mail.secondary", cfg, awsClient)
+	if err != nil {
+		return nil, err
+	}
+	return NewFailoverEmailService(primary, secondary), nil
+}
+
+// newProvider creates the service for a single concrete provider. setting names the config key
+// the provider came from, so a bad value is reported against the right setting.
+func newProvider(provider Provider, setting string, cfg *config.Config, awsClient *awsclient.AWSClient) (Service, error) {
+	switch provider {
 	case providerSES:
-		return NewSESEmailService(awsClient)
+		return NewSESEmailService(awsClient.GetSESClient(), cfg), nil
 	case providerSMTP:
-		return NewSMTPEmailService(cfg)
+		return NewSMTPEmailService(cfg), nil
 	default:
-		return nil
+		return nil, fmt.Errorf("%w %q: %s must be one of %q, %q (or enable mail.dry_run)",
+			ErrUnknownEmailProvider, provider, setting, providerSES, providerSMTP)
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,48 @@
+package email
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/npushpakumara/go-backend-template/internal/config"
+)
+
+// An unknown provider is refused at startup and reported against the setting it came from.
+func TestNewBaseEmailServiceRejectsUnknownProviders(t *testing.T) {
+	tests := []struct {
+		name        string
+		mail        config.MailConfig
+		wantSetting string
+	}{
+		{name: "unknown provider", mail: config.MailConfig{Provider: "sendgrid"}, wantSetting: "mail.provider"},
+		{name: "no provider", mail: config.MailConfig{}, wantSetting: "mail.provider"},
+		{name: "unknown primary", mail: config.MailConfig{Provider: "failover", Primary: "sendgrid", Secondary: "smtp"}, wantSetting: "mail.primary"},
+		{name: "unknown secondary", mail: config.MailConfig{Provider: "failover", Primary: "smtp", Secondary: "sendgrid"}, wantSetting: "mail.secondary"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, err := newBaseEmailService(&config.Config{Mail: tt.mail}, nil)
+			if !errors.Is(err, ErrUnknownEmailProvider) {
+				t.Fatalf("newBaseEmailService() error = %v, want ErrUnknownEmailProvider", err)
+			}
+			if !strings.Contains(err.Error(), tt.wantSetting) {
+				t.Errorf("newBaseEmailService() error = %q, want it to name %s", err, tt.wantSetting)
+			}
+			if service != nil {
+				t.Errorf("newBaseEmailService() = %T, want nil", service)
+			}
+		})
+	}
+}
+
+func TestNewProviderRejectsUnknownName(t *testing.T) {
+	service, err := newProvider("sendgrid", "mail.provider", &config.Config{}, nil)
+	if !errors.Is(err, ErrUnknownEmailProvider) {
+		t.Fatalf("newProvider() error = %v, want ErrUnknownEmailProvider", err)
+	}
+	if service != nil {
+		t.Errorf("newProvider() = %T, want nil", service)
+	}
+}
@@ -0,0 +1,111 @@
+// Package emailtest provides an in-memory email.Service for tests.
+package emailtest
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/npushpakumara/go-backend-template/internal/features/email"
+	"github.com/npushpakumara/go-backend-template/internal/features/email/entities"
+)
+
+// BulkSend records a single call to SendBulk.
+type BulkSend struct {
+	TemplateKey string
+	Recipients  []entities.Recipient
+}
+
+// MockEmailService is an email.Service that records every email instead of sending it.
+// Set Err to make every send fail with that error. It is safe for concurrent use.
+type MockEmailService struct {
+	mu   sync.Mutex
+	sent []entities.Email
+	bulk []BulkSend
+	Err  error
+}
+
+// NewMockEmailService returns an empty MockEmailService.
+func NewMockEmailService() *MockEmailService {
+	return &MockEmailService{}
+}
+
+// Compile-time check that MockEmailService implements email.Service.
+var _ email.Service = (*MockEmailService)(nil)
+
+// SendEmail records the email, or returns Err when it is set.
+func (m *MockEmailService) SendEmail(_ context.Context, email entities.Email) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.Err != nil {
+		return m.Err
+	}
+	m.sent = append(m.sent, email)
+	return nil
+}
+
+// SendBulk records the call and reports every recipient as sent, or returns Err when it is set.
+func (m *MockEmailService) SendBulk(_ context.Context, templateKey string, recipients []entities.Recipient) ([]entities.BulkResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	m.bulk = append(m.bulk, BulkSend{TemplateKey: templateKey, Recipients: slices.Clone(recipients)})
+
+	results := make([]entities.BulkResult, len(recipients))
+	for i, r := range recipients {
+		results[i] = entities.BulkResult{Email: r.Email}
+	}
+	return results, nil
+}
+
+// Sent returns every email recorded by SendEmail, oldest first.
+func (m *MockEmailService) Sent() []entities.Email {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.sent)
+}
+
+// LastEmail returns the most recently recorded email and false if none was sent.
+func (m *MockEmailService) LastEmail() (entities.Email, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.sent) == 0 {
+		return entities.Email{}, false
+	}
+	return m.sent[len(m.sent)-1], true
+}
+
+// SentTo returns the recorded emails addressed to addr, compared case-insensitively.
+func (m *MockEmailService) SentTo(addr string) []entities.Email {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var emails []entities.Email
+	for _, e := range m.sent {
+		if slices.ContainsFunc(e.To, func(to string) bool { return strings.EqualFold(to, addr) }) {
+			emails = append(emails, e)
+		}
+	}
+	return emails
+}
+
+// BulkSends returns every call recorded by SendBulk, oldest first.
+func (m *MockEmailService) BulkSends() []BulkSend {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.bulk)
+}
+
+// Reset forgets every recorded email.
+func (m *MockEmailService) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = nil
+	m.bulk = nil
+}
@@ -1,11 +1,43 @@
 package entities
 
+// DefaultLocale is the locale used when no translation exists for the requested one.
+const DefaultLocale = "en"
+
+// Categories of email. Marketing emails get List-Unsubscribe headers and are not sent to addresses
+// that unsubscribed; anything else is transactional and is still sent to them.
+const (
+	CategoryTransactional = "transactional"
+	CategoryMarketing     = "marketing"
+)
+
 // Email represents the structure of an email message.
+// Data is the HTML body. TextData is the plain-text alternative; when empty it is derived from Data.
+// Headers are added to the message as is; they can't replace From, To, Subject or the MIME headers.
+// An empty Category is transactional. Template is the key of the template the email was rendered from, if any.
 type Email struct {
-	From    string
-	To      []string
-	Subject string
-	Data    string
+	From     string
+	To       []string
+	Subject  string
+	Data     string
+	TextData string
+	Headers  map[string]string
+	Category string
+	Template string
+}
+
+// Recipient is a single destination of a bulk send, with the data used to render its copy of the template.
+type Recipient struct {
+	Email  string
+	Locale string
+	Data   map[string]interface{}
+}
+
+// BulkResult reports the outcome of a bulk send for one recipient.
+// Err is nil when the email was accepted by the provider.
+type BulkResult struct {
+	Email     string
+	MessageID string
+	Err       error
 }
 
 // VerificationEmailData is a struct that holds the dynamic data needed to populate a verification email template.
@@ -15,18 +47,65 @@ type VerificationEmailData struct {
 	Link string
 }
 
+// AccountDeletedEmailData holds the dynamic data of the email confirming an account deletion.
+type AccountDeletedEmailData struct {
+	Name string
+}
+
+// InvitationEmailData holds the dynamic data of the email inviting someone to create their account.
+type InvitationEmailData struct {
+	Link string
+}
+
+// PasswordResetEmailData holds the dynamic data of the email linking a user to the page where they choose a new password.
+type PasswordResetEmailData struct {
+	Name string
+	Link string
+}
+
+// EmailTemplate describes a localized email: its subject per locale and the base name of its template files.
+// Template files are named "<Template>.<locale>.html", e.g. "account-verification.es.html".
+// Category is copied to the emails rendered from the template; empty means transactional.
+type EmailTemplate struct {
+	Subjects map[string]string
+	Template string
+	Category string
+}
+
+// Subject returns the subject for the given locale, falling back to the DefaultLocale.
+func (t EmailTemplate) Subject(locale string) string {
+	if subject, ok := t.Subjects[locale]; ok {
+		return subject
+	}
+	return t.Subjects[DefaultLocale]
+}
+
 // EmailTemplates is a map that stores predefined email templates with their subjects and template names.
 // Each template is identified by a unique key, such as "UserVerification" or "PasswordReset".
-var EmailTemplates = map[string]struct {
-	Subject  string
-	Template string
-}{
+var EmailTemplates = map[string]EmailTemplate{
 	"UserVerification": {
-		Subject:  "User Activation Email",
-		Template: "account-verification.html",
+		Subjects: map[string]string{
+			"en": "User Activation Email",
+			"es": "Correo de activación de cuenta",
+		},
+		Template: "account-verification",
 	},
 	"PasswordReset": {
-		Subject:  "Password Reset Request",
-		Template: "password-reset.html",
+		Subjects: map[string]string{
+			"en": "Password Reset Request",
+		},
+		Template: "password-reset",
 	},
-}
\ No newline at end of file
+	"UserInvitation": {
+		Subjects: map[string]string{
+			"en": "You have been invited",
+		},
+		Template: "user-invitation",
+	},
+	"AccountDeleted": {
+		Subjects: map[string]string{
+			"en": "Your account has been deleted",
+		},
+		Template: "account-deleted",
+	},
+}
@@ -0,0 +1,32 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// FailedEmail is a queued email that still failed after its last attempt, kept so it can be inspected
+// and queued again. Data is the whole Email as JSON, including its rendered body and headers.
+type FailedEmail struct {
+	*gorm.Model
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Recipient string    `gorm:"size:255;not null"`
+	Subject   string    `gorm:"size:255"`
+	Template  string    `gorm:"size:100"`
+	Data      string    `gorm:"type:jsonb;not null"`
+	LastError string    `gorm:"type:text"`
+	Attempts  int       `gorm:"not null"`
+}
+
+// TableName overrides the default table name used by GORM for the FailedEmail model.
+func (FailedEmail) TableName() string {
+	return "failed_emails"
+}
+
+// BeforeCreate is a GORM hook that sets the ID field to a new UUID if it hasn't been set already.
+func (f *FailedEmail) BeforeCreate(tx *gorm.DB) (err error) {
+	if f.ID == uuid.Nil {
+		f.ID = uuid.New()
+	}
+	return
+}
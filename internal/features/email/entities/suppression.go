@@ -0,0 +1,35 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Reasons an address can be added to the suppression list.
+const (
+	SuppressionReasonBounce    = "bounce"
+	SuppressionReasonComplaint = "complaint"
+)
+
+// Suppression is an email address that must no longer receive mail,
+// typically because it hard-bounced or its owner marked our mail as spam.
+type Suppression struct {
+	*gorm.Model
+	ID     uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Email  string    `gorm:"size:100;uniqueIndex;not null"`
+	Reason string    `gorm:"size:20;not null"`
+	Detail string    `gorm:"size:255"`
+}
+
+// TableName overrides the default table name used by GORM for the Suppression model.
+func (Suppression) TableName() string {
+	return "suppression_list"
+}
+
+// BeforeCreate is a GORM hook that sets the ID field to a new UUID if it hasn't been set already.
+func (s *Suppression) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return
+}
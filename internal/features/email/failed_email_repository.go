@@ -0,0 +1,77 @@
+package email
+
+import (
+	"context"
+	"errors"
+
+	"github.com/npushpakumara/go-backend-template/internal/features/email/entities"
+	"github.com/npushpakumara/go-backend-template/internal/postgres"
+	"github.com/npushpakumara/go-backend-template/pkg/logging"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FailedEmailRepository defines the interface for the dead-letter table of emails the queue gave up on.
+type FailedEmailRepository interface {
+	// Insert adds an email that failed its last attempt.
+	Insert(ctx context.Context, failed *entities.FailedEmail) error
+
+	// FindByIDForUpdate retrieves a failed email and locks it until the surrounding transaction ends.
+	// It returns postgres.ErrRecordNotFound if no failed email matches.
+	FindByIDForUpdate(ctx context.Context, id string) (*entities.FailedEmail, error)
+
+	// Delete soft-deletes a failed email once it has been queued again, so it stays in the table for reference.
+	Delete(ctx context.Context, id string) error
+}
+
+// failedEmailRepositoryImpl is a concrete implementation of the FailedEmailRepository interface.
+type failedEmailRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewFailedEmailRepository creates a new instance of failedEmailRepositoryImpl with the provided database connection.
+func NewFailedEmailRepository(db *gorm.DB) FailedEmailRepository {
+	return &failedEmailRepositoryImpl{db}
+}
+
+// Insert adds an email that failed its last attempt.
+func (fr *failedEmailRepositoryImpl) Insert(ctx context.Context, failed *entities.FailedEmail) error {
+	logger := logging.FromContext(ctx)
+	db := postgres.FromContext(ctx, fr.db)
+
+	logger.Debugw("email.db.Insert failed email", "recipient", failed.Recipient, "subject", failed.Subject)
+	if err := db.WithContext(ctx).Create(failed).Error; err != nil {
+		logger.Errorw("email.db.Insert failed to save failed email: %v", err)
+		return err
+	}
+	return nil
+}
+
+// FindByIDForUpdate retrieves a failed email by its ID, locking its row.
+func (fr *failedEmailRepositoryImpl) FindByIDForUpdate(ctx context.Context, id string) (*entities.FailedEmail, error) {
+	logger := logging.FromContext(ctx)
+	db := postgres.FromContext(ctx, fr.db)
+
+	var failed entities.FailedEmail
+	if err := db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&failed, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Warn("email.db.FindByIDForUpdate failed email not found")
+			return nil, postgres.ErrRecordNotFound
+		}
+		logger.Errorw("email.db.FindByIDForUpdate failed to find failed email: %v", err)
+		return nil, err
+	}
+	return &failed, nil
+}
+
+// Delete soft-deletes the failed email with the given ID.
+func (fr *failedEmailRepositoryImpl) Delete(ctx context.Context, id string) error {
+	logger := logging.FromContext(ctx)
+	db := postgres.FromContext(ctx, fr.db)
+
+	if err := db.WithContext(ctx).Where("id = ?", id).Delete(&entities.FailedEmail{}).Error; err != nil {
+		logger.Errorw("email.db.Delete failed to delete failed email: %v", err)
+		return err
+	}
+	return nil
+}
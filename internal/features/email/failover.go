@@ -0,0 +1,87 @@
+package email
+
+import (
+	"context"
+	"errors"
+
+	"github.com/npushpakumara/go-backend-template/internal/features/email/entities"
+	"github.com/npushpakumara/go-backend-template/pkg/logging"
+)
+
+// failoverEmailServiceImpl sends through a primary service and retries with a secondary one when the primary fails.
+type failoverEmailServiceImpl struct {
+	primary   Service
+	secondary Service
+}
+
+// NewFailoverEmailService wraps two services so that a send succeeds if either of them accepts it.
+func NewFailoverEmailService(primary, secondary Service) Service {
+	return &failoverEmailServiceImpl{primary, secondary}
+}
+
+// SendEmail sends through the primary service and, if that fails, through the secondary one.
+// A cancelled context is returned as is rather than failing over. A primary that timed out has already
+// abandoned its send, so failing over after a timeout can't deliver the email twice.
+func (f *failoverEmailServiceImpl) SendEmail(ctx context.Context, email entities.Email) error {
+	logger := logging.FromContext(ctx)
+
+	err := f.primary.SendEmail(ctx, email)
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+
+	logger.Warnw("email.service.SendEmail primary provider failed, failing over to secondary", "failover", true, "err", err)
+
+	if secondaryErr := f.secondary.SendEmail(ctx, email); secondaryErr != nil {
+		logger.Errorw("email.service.SendEmail secondary provider failed", "failover", true, "err", secondaryErr)
+		return errors.Join(err, secondaryErr)
+	}
+	return nil
+}
+
+// SendBulk sends through the primary service and retries the recipients it failed to reach with the secondary one.
+// Suppressed recipients are never retried. If the primary can't send at all, every recipient goes to the secondary.
+func (f *failoverEmailServiceImpl) SendBulk(ctx context.Context, templateKey string, recipients []entities.Recipient) ([]entities.BulkResult, error) {
+	logger := logging.FromContext(ctx)
+
+	results, err := f.primary.SendBulk(ctx, templateKey, recipients)
+	if ctx.Err() != nil {
+		return results, ctx.Err()
+	}
+	if err != nil {
+		logger.Warnw("email.service.SendBulk primary provider failed, failing over to secondary", "failover", true, "err", err)
+		return f.secondary.SendBulk(ctx, templateKey, recipients)
+	}
+
+	// Index the recipients so failed results can be matched back to their data.
+	byEmail := make(map[string]entities.Recipient, len(recipients))
+	for _, r := range recipients {
+		byEmail[r.Email] = r
+	}
+
+	var retry []entities.Recipient
+	kept := results[:0]
+	for _, result := range results {
+		if result.Err != nil && !errors.Is(result.Err, ErrRecipientSuppressed) {
+			retry = append(retry, byEmail[result.Email])
+			continue
+		}
+		kept = append(kept, result)
+	}
+
+	if len(retry) == 0 {
+		return results, nil
+	}
+
+	logger.Warnw("email.service.SendBulk retrying failed recipients with secondary provider", "failover", true, "recipients", len(retry))
+
+	retried, err := f.secondary.SendBulk(ctx, templateKey, retry)
+	if err != nil {
+		logger.Errorw("email.service.SendBulk secondary provider failed", "failover", true, "err", err)
+		for _, r := range retry {
+			kept = append(kept, entities.BulkResult{Email: r.Email, Err: err})
+		}
+		return kept, nil
+	}
+	return append(kept, retried...), nil
+}
@@ -0,0 +1,135 @@
+package email_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/npushpakumara/go-backend-template/internal/features/email"
+	"github.com/npushpakumara/go-backend-template/internal/features/email/emailtest"
+	"github.com/npushpakumara/go-backend-template/internal/features/email/entities"
+)
+
+var welcome = entities.Email{
+	From:    "no-reply@example.com",
+	To:      []string{"ada@example.com"},
+	Subject: "Welcome",
+	Data:    "<p>Hello</p>",
+}
+
+func TestFailoverSendsWithPrimary(t *testing.T) {
+	primary, secondary := emailtest.NewMockEmailService(), emailtest.NewMockEmailService()
+	service := email.NewFailoverEmailService(primary, secondary)
+
+	if err := service.SendEmail(context.Background(), welcome); err != nil {
+		t.Fatalf("SendEmail() error = %v", err)
+	}
+	if got := len(primary.Sent()); got != 1 {
+		t.Errorf("primary sent %d emails, want 1", got)
+	}
+	if got := len(secondary.Sent()); got != 0 {
+		t.Errorf("secondary sent %d emails, want none", got)
+	}
+}
+
+func TestFailoverFallsBackToSecondary(t *testing.T) {
+	primary, secondary := emailtest.NewMockEmailService(), emailtest.NewMockEmailService()
+	primary.Err = errors.New("primary down")
+	service := email.NewFailoverEmailService(primary, secondary)
+
+	if err := service.SendEmail(context.Background(), welcome); err != nil {
+		t.Fatalf("SendEmail() error = %v", err)
+	}
+	sent := secondary.Sent()
+	if len(sent) != 1 || sent[0].Subject != welcome.Subject {
+		t.Errorf("secondary sent %v, want the email", sent)
+	}
+}
+
+func TestFailoverReportsBothFailures(t *testing.T) {
+	primary, secondary := emailtest.NewMockEmailService(), emailtest.NewMockEmailService()
+	errPrimary, errSecondary := errors.New("primary down"), errors.New("secondary down")
+	primary.Err, secondary.Err = errPrimary, errSecondary
+	service := email.NewFailoverEmailService(primary, secondary)
+
+	err := service.SendEmail(context.Background(), welcome)
+	if !errors.Is(err, errPrimary) || !errors.Is(err, errSecondary) {
+		t.Errorf("SendEmail() error = %v, want both providers' errors", err)
+	}
+}
+
+func TestFailoverStopsWhenContextIsDone(t *testing.T) {
+	primary, secondary := emailtest.NewMockEmailService(), emailtest.NewMockEmailService()
+	primary.Err = context.Canceled
+	service := email.NewFailoverEmailService(primary, secondary)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := service.SendEmail(ctx, welcome); !errors.Is(err, context.Canceled) {
+		t.Errorf("SendEmail() error = %v, want %v", err, context.Canceled)
+	}
+	if got := len(secondary.Sent()); got != 0 {
+		t.Errorf("secondary sent %d emails after the context was canceled, want none", got)
+	}
+}
+
+// partialBulkService is an email.Service whose SendBulk fails for the recipients in failed.
+type partialBulkService struct {
+	emailtest.MockEmailService
+	failed map[string]error
+}
+
+func (s *partialBulkService) SendBulk(_ context.Context, _ string, recipients []entities.Recipient) ([]entities.BulkResult, error) {
+	results := make([]entities.BulkResult, len(recipients))
+	for i, r := range recipients {
+		results[i] = entities.BulkResult{Email: r.Email, Err: s.failed[r.Email]}
+	}
+	return results, nil
+}
+
+func TestFailoverBulkRetriesFailedRecipients(t *testing.T) {
+	primary := &partialBulkService{failed: map[string]error{
+		"grace@example.com": errors.New("throttled"),
+		"alan@example.com":  email.ErrRecipientSuppressed,
+	}}
+	secondary := emailtest.NewMockEmailService()
+	service := email.NewFailoverEmailService(primary, secondary)
+
+	recipients := []entities.Recipient{{Email: "ada@example.com"}, {Email: "grace@example.com"}, {Email: "alan@example.com"}}
+	results, err := service.SendBulk(context.Background(), "Newsletter", recipients)
+	if err != nil {
+		t.Fatalf("SendBulk() error = %v", err)
+	}
+
+	// Only the recipient that failed for another reason than suppression is retried.
+	bulk := secondary.BulkSends()
+	if len(bulk) != 1 || len(bulk[0].Recipients) != 1 || bulk[0].Recipients[0].Email != "grace@example.com" {
+		t.Fatalf("secondary SendBulk() calls = %+v, want one for grace@example.com", bulk)
+	}
+
+	got := map[string]error{}
+	for _, result := range results {
+		got[result.Email] = result.Err
+	}
+	if len(got) != 3 || got["ada@example.com"] != nil || got["grace@example.com"] != nil || !errors.Is(got["alan@example.com"], email.ErrRecipientSuppressed) {
+		t.Errorf("SendBulk() results = %v, want ada and grace sent and alan suppressed", got)
+	}
+}
+
+func TestFailoverBulkFallsBackToSecondary(t *testing.T) {
+	primary, secondary := emailtest.NewMockEmailService(), emailtest.NewMockEmailService()
+	primary.Err = errors.New("primary down")
+	service := email.NewFailoverEmailService(primary, secondary)
+
+	recipients := []entities.Recipient{{Email: "ada@example.com"}, {Email: "grace@example.com"}}
+	results, err := service.SendBulk(context.Background(), "Newsletter", recipients)
+	if err != nil {
+		t.Fatalf("SendBulk() error = %v", err)
+	}
+	if len(results) != 2 {
+		t.Errorf("SendBulk() returned %d results, want 2", len(results))
+	}
+	if bulk := secondary.BulkSends(); len(bulk) != 1 || len(bulk[0].Recipients) != 2 {
+		t.Errorf("secondary SendBulk() calls = %+v, want one for both recipients", bulk)
+	}
+}
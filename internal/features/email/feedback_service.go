@@ -0,0 +1,159 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/internal/features/auth/tokens"
+	"github.com/npushpakumara/go-backend-template/internal/features/email/entities"
+	"github.com/npushpakumara/go-backend-template/internal/features/user"
+	"github.com/npushpakumara/go-backend-template/internal/postgres"
+	"github.com/npushpakumara/go-backend-template/pkg/clock"
+	apiError "github.com/npushpakumara/go-backend-template/pkg/errors"
+	"github.com/npushpakumara/go-backend-template/pkg/logging"
+)
+
+// FeedbackService processes recipient feedback: SES bounce and complaint notifications delivered through SNS,
+// and unsubscribe requests from the links in marketing emails.
+type FeedbackService interface {
+	// HandleSNSMessage verifies the message, confirms pending subscriptions,
+	// and adds bounced or complaining recipients to the suppression list.
+	// It returns ErrSNSTopicNotConfigured when mail.sns_topic_arn is unset and ErrInvalidSNSSignature
+	// when the message comes from another topic or its signature doesn't verify.
+	HandleSNSMessage(ctx context.Context, msg *SNSMessage) error
+
+	// Unsubscribe turns off the notification preference named by an unsubscribe token and returns its category.
+	// It returns ErrInvalidToken when the token is malformed, tampered with, expired or was minted for another purpose.
+	Unsubscribe(ctx context.Context, token string) (string, error)
+}
+
+// feedbackServiceImpl is a concrete implementation of the FeedbackService interface.
+type feedbackServiceImpl struct {
+	suppressions SuppressionRepository
+	users        user.Service
+	verifier     *snsVerifier
+	topicARN     string
+	keyring      *tokens.Keyring
+	clock        clock.Clock
+}
+
+// NewFeedbackService creates a new instance of feedbackServiceImpl.
+// Only messages from the configured topic are accepted; with no topic configured, every message is rejected.
+func NewFeedbackService(suppressions SuppressionRepository, users user.Service, clk clock.Clock, cfg *config.Config) FeedbackService {
+	return &feedbackServiceImpl{
+		suppressions: suppressions,
+		users:        users,
+		verifier:     newSNSVerifier(),
+		topicARN:     cfg.Mail.SNSTopicARN,
+		keyring:      tokens.NewKeyring(&cfg.JWT),
+		clock:        clk,
+	}
+}
+
+// HandleSNSMessage verifies and processes a single SNS message. Messages from any topic but the configured
+// one are rejected before their signature is checked, so subscriptions to other topics are never confirmed.
+func (fs *feedbackServiceImpl) HandleSNSMessage(ctx context.Context, msg *SNSMessage) error {
+	logger := logging.FromContext(ctx)
+
+	// Anyone can subscribe the public webhook to a topic of their own, so without a topic to compare
+	// against, neither subscriptions nor notifications can be trusted.
+	if fs.topicARN == "" {
+		logger.Errorw("email.feedback.HandleSNSMessage rejecting message, mail.sns_topic_arn is not set", "topic", msg.TopicArn)
+		return ErrSNSTopicNotConfigured
+	}
+	if msg.TopicArn != fs.topicARN {
+		logger.Warnw("email.feedback.HandleSNSMessage message from unexpected topic", "topic", msg.TopicArn)
+		return ErrInvalidSNSSignature
+	}
+
+	if err := fs.verifier.Verify(ctx, msg); err != nil {
+		logger.Warnw("email.feedback.HandleSNSMessage failed to verify message", "err", err)
+		return ErrInvalidSNSSignature
+	}
+
+	switch msg.Type {
+	case snsTypeSubscriptionConfirmation:
+		logger.Infow("email.feedback.HandleSNSMessage confirming subscription", "topic", msg.TopicArn)
+		return fs.verifier.ConfirmSubscription(ctx, msg)
+	case snsTypeNotification:
+		return fs.handleNotification(ctx, msg.Message)
+	default:
+		logger.Infow("email.feedback.HandleSNSMessage ignoring message", "type", msg.Type)
+		return nil
+	}
+}
+
+// handleNotification suppresses recipients of permanent bounces and complaints.
+// Transient bounces are ignored since the address may recover.
+func (fs *feedbackServiceImpl) handleNotification(ctx context.Context, message string) error {
+	logger := logging.FromContext(ctx)
+
+	var notification sesNotification
+	if err := json.Unmarshal([]byte(message), &notification); err != nil {
+		logger.Errorw("email.feedback.handleNotification failed to decode ses notification", "err", err)
+		return err
+	}
+
+	var suppressions []*entities.Suppression
+	switch notification.NotificationType {
+	case "Bounce":
+		if notification.Bounce.BounceType != "Permanent" {
+			return nil
+		}
+		for _, r := range notification.Bounce.BouncedRecipients {
+			suppressions = append(suppressions, &entities.Suppression{
+				Email:  r.EmailAddress,
+				Reason: entities.SuppressionReasonBounce,
+				Detail: truncate(r.DiagnosticCode, 255),
+			})
+		}
+	case "Complaint":
+		for _, r := range notification.Complaint.ComplainedRecipients {
+			suppressions = append(suppressions, &entities.Suppression{
+				Email:  r.EmailAddress,
+				Reason: entities.SuppressionReasonComplaint,
+				Detail: notification.Complaint.ComplaintFeedbackType,
+			})
+		}
+	}
+
+	for _, s := range suppressions {
+		if err := fs.suppressions.Suppress(ctx, s); err != nil {
+			return err
+		}
+		logger.Infow("email.feedback.handleNotification suppressed address", "email", s.Email, "reason", s.Reason)
+	}
+	return nil
+}
+
+// Unsubscribe verifies the token and turns the user's preference for its category off.
+// An account deleted since the email was sent receives nothing anyway, so it is reported as unsubscribed.
+func (fs *feedbackServiceImpl) Unsubscribe(ctx context.Context, token string) (string, error) {
+	logger := logging.FromContext(ctx)
+
+	userID, category, err := tokens.ExtractUnsubscribeToken(fs.clock, fs.keyring, token)
+	if err != nil {
+		logger.Warnw("email.feedback.Unsubscribe invalid token", "err", err)
+		return "", apiError.ErrInvalidToken
+	}
+
+	if err := fs.users.SetNotificationPreference(ctx, userID, category, false); err != nil {
+		if errors.Is(err, postgres.ErrRecordNotFound) {
+			logger.Infow("email.feedback.Unsubscribe account no longer exists", "user_id", userID)
+			return category, nil
+		}
+		return "", err
+	}
+	logger.Infow("email.feedback.Unsubscribe unsubscribed user", "user_id", userID, "category", category)
+	return category, nil
+}
+
+// truncate shortens s to at most n bytes.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,300 @@
+package email
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/internal/features/email/entities"
+	"github.com/npushpakumara/go-backend-template/pkg/clock"
+)
+
+const (
+	testTopicARN   = "arn:aws:sns:us-east-1:123456789012:ses-feedback"
+	testCertURL    = "https://sns.us-east-1.amazonaws.com/SimpleNotificationService-test.pem"
+	testConfirmURL = "https://sns.us-east-1.amazonaws.com/?Action=ConfirmSubscription&Token=abc"
+)
+
+// recordingSuppressions is a SuppressionRepository that records the suppressed addresses.
+type recordingSuppressions struct {
+	mu         sync.Mutex
+	suppressed []entities.Suppression
+}
+
+func (r *recordingSuppressions) Suppress(_ context.Context, suppression *entities.Suppression) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.suppressed = append(r.suppressed, *suppression)
+	return nil
+}
+
+func (r *recordingSuppressions) FindSuppressed(context.Context, []string) ([]string, error) {
+	return nil, nil
+}
+
+// roundTripFunc lets a function serve as the verifier's HTTP transport.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// testFeedback is a feedback service whose verifier trusts key, with handles on what it did.
+type testFeedback struct {
+	service      *feedbackServiceImpl
+	suppressions *recordingSuppressions
+	key          *rsa.PrivateKey
+	visited      []string
+}
+
+// newTestFeedback returns a feedback service accepting messages from topicARN. The certificate of the
+// signing key is cached under testCertURL, and the URLs the service visits are recorded instead of fetched.
+func newTestFeedback(t *testing.T, topicARN string) *testFeedback {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sns.amazonaws.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+
+	cfg := &config.Config{}
+	cfg.Mail.SNSTopicARN = topicARN
+	cfg.JWT.Secret = "test-secret"
+	tf := &testFeedback{suppressions: &recordingSuppressions{}, key: key}
+	tf.service = NewFeedbackService(tf.suppressions, nil, clock.New(), cfg).(*feedbackServiceImpl)
+	tf.service.verifier.certs.Store(testCertURL, cert)
+	tf.service.verifier.client = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		tf.visited = append(tf.visited, req.URL.String())
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+	})}
+	return tf
+}
+
+// sign signs the message with the test key, as SNS does with signature version 2.
+func (tf *testFeedback) sign(t *testing.T, msg *SNSMessage) *SNSMessage {
+	t.Helper()
+
+	msg.SignatureVersion = "2"
+	msg.SigningCertURL = testCertURL
+	sum := sha256.Sum256([]byte(msg.stringToSign()))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, tf.key, crypto.SHA256, sum[:])
+	if err != nil {
+		t.Fatalf("sign message: %v", err)
+	}
+	msg.Signature = base64.StdEncoding.EncodeToString(signature)
+	return msg
+}
+
+// notification returns an SNS notification from the topic carrying the SES notification.
+func notification(topicARN, sesNotification string) *SNSMessage {
+	return &SNSMessage{
+		Type:      snsTypeNotification,
+		MessageID: "message-1",
+		TopicArn:  topicARN,
+		Message:   sesNotification,
+		Timestamp: "2024-01-01T12:00:00.000Z",
+	}
+}
+
+// subscriptionConfirmation returns an SNS subscription confirmation for the topic.
+func subscriptionConfirmation(topicARN string) *SNSMessage {
+	return &SNSMessage{
+		Type:         snsTypeSubscriptionConfirmation,
+		MessageID:    "message-1",
+		Token:        "abc",
+		TopicArn:     topicARN,
+		Message:      "You have chosen to subscribe to the topic.",
+		SubscribeURL: testConfirmURL,
+		Timestamp:    "2024-01-01T12:00:00.000Z",
+	}
+}
+
+const (
+	permanentBounce = `{"notificationType":"Bounce","bounce":{"bounceType":"Permanent","bouncedRecipients":[{"emailAddress":"ada@example.com","diagnosticCode":"smtp; 550 5.1.1 user unknown"}]}}`
+	transientBounce = `{"notificationType":"Bounce","bounce":{"bounceType":"Transient","bouncedRecipients":[{"emailAddress":"ada@example.com"}]}}`
+	complaint       = `{"notificationType":"Complaint","complaint":{"complaintFeedbackType":"abuse","complainedRecipients":[{"emailAddress":"grace@example.com"},{"emailAddress":"alan@example.com"}]}}`
+)
+
+func TestParseSNSMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr bool
+	}{
+		{
+			name: "notification",
+			body: `{"Type":"Notification","MessageId":"m-1","TopicArn":"` + testTopicARN + `","Message":"{}","Timestamp":"2024-01-01T12:00:00.000Z",` +
+				`"SignatureVersion":"2","Signature":"c2ln","SigningCertURL":"` + testCertURL + `"}`,
+		},
+		{
+			name: "subscription confirmation",
+			body: `{"Type":"SubscriptionConfirmation","MessageId":"m-1","Token":"abc","TopicArn":"` + testTopicARN + `","SubscribeURL":"` + testConfirmURL + `",` +
+				`"SignatureVersion":"1","Signature":"c2ln","SigningCertURL":"` + testCertURL + `"}`,
+		},
+		{name: "not JSON", body: `Type=Notification`, wantErr: true},
+		{name: "empty object", body: `{}`, wantErr: true},
+		{name: "no type", body: `{"Signature":"c2ln","SigningCertURL":"` + testCertURL + `"}`, wantErr: true},
+		{name: "no signature", body: `{"Type":"Notification","SigningCertURL":"` + testCertURL + `"}`, wantErr: true},
+		{name: "no certificate URL", body: `{"Type":"Notification","Signature":"c2ln"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := ParseSNSMessage([]byte(tt.body))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseSNSMessage() = %+v, want an error", msg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSNSMessage() error = %v", err)
+			}
+			if msg.TopicArn != testTopicARN || msg.MessageID != "m-1" || msg.Signature != "c2ln" || msg.SigningCertURL != testCertURL {
+				t.Errorf("ParseSNSMessage() = %+v, want the envelope's fields", msg)
+			}
+		})
+	}
+}
+
+func TestHandleSNSMessageRequiresTheConfiguredTopic(t *testing.T) {
+	tests := []struct {
+		name     string
+		topicARN string
+		msg      func(topicARN string) *SNSMessage
+		from     string
+		wantErr  error
+	}{
+		{name: "notification without a configured topic", msg: func(arn string) *SNSMessage { return notification(arn, permanentBounce) },
+			from: testTopicARN, wantErr: ErrSNSTopicNotConfigured},
+		{name: "confirmation without a configured topic", msg: subscriptionConfirmation,
+			from: testTopicARN, wantErr: ErrSNSTopicNotConfigured},
+		{name: "notification from another topic", topicARN: testTopicARN, msg: func(arn string) *SNSMessage { return notification(arn, permanentBounce) },
+			from: "arn:aws:sns:us-east-1:999999999999:attacker", wantErr: ErrInvalidSNSSignature},
+		{name: "confirmation from another topic", topicARN: testTopicARN, msg: subscriptionConfirmation,
+			from: "arn:aws:sns:us-east-1:999999999999:attacker", wantErr: ErrInvalidSNSSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tf := newTestFeedback(t, tt.topicARN)
+			// The message is validly signed: only its topic is wrong.
+			err := tf.service.HandleSNSMessage(context.Background(), tf.sign(t, tt.msg(tt.from)))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("HandleSNSMessage() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(tf.suppressions.suppressed) != 0 {
+				t.Errorf("suppressed %+v, want nothing", tf.suppressions.suppressed)
+			}
+			if len(tf.visited) != 0 {
+				t.Errorf("visited %v, want the subscription left unconfirmed", tf.visited)
+			}
+		})
+	}
+}
+
+func TestHandleSNSMessageConfirmsSubscription(t *testing.T) {
+	tf := newTestFeedback(t, testTopicARN)
+
+	if err := tf.service.HandleSNSMessage(context.Background(), tf.sign(t, subscriptionConfirmation(testTopicARN))); err != nil {
+		t.Fatalf("HandleSNSMessage() error = %v", err)
+	}
+	if len(tf.visited) != 1 || tf.visited[0] != testConfirmURL {
+		t.Errorf("visited %v, want [%s]", tf.visited, testConfirmURL)
+	}
+}
+
+func TestHandleSNSMessageSuppressesRecipients(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    []entities.Suppression
+	}{
+		{
+			name:    "permanent bounce",
+			message: permanentBounce,
+			want:    []entities.Suppression{{Email: "ada@example.com", Reason: entities.SuppressionReasonBounce, Detail: "smtp; 550 5.1.1 user unknown"}},
+		},
+		{name: "transient bounce", message: transientBounce},
+		{
+			name:    "complaint",
+			message: complaint,
+			want: []entities.Suppression{
+				{Email: "grace@example.com", Reason: entities.SuppressionReasonComplaint, Detail: "abuse"},
+				{Email: "alan@example.com", Reason: entities.SuppressionReasonComplaint, Detail: "abuse"},
+			},
+		},
+		{name: "delivery", message: `{"notificationType":"Delivery"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tf := newTestFeedback(t, testTopicARN)
+
+			if err := tf.service.HandleSNSMessage(context.Background(), tf.sign(t, notification(testTopicARN, tt.message))); err != nil {
+				t.Fatalf("HandleSNSMessage() error = %v", err)
+			}
+			got := tf.suppressions.suppressed
+			if len(got) != len(tt.want) {
+				t.Fatalf("suppressed %+v, want %+v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i].Email != tt.want[i].Email || got[i].Reason != tt.want[i].Reason || got[i].Detail != tt.want[i].Detail {
+					t.Errorf("suppression %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestHandleSNSMessageRejectsInvalidSignatures(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(msg *SNSMessage)
+	}{
+		{name: "changed message", tamper: func(msg *SNSMessage) { msg.Message = complaint }},
+		{name: "signature of another key", tamper: func(msg *SNSMessage) {
+			other := newTestFeedback(t, testTopicARN)
+			msg.Signature = other.sign(t, notification(testTopicARN, permanentBounce)).Signature
+		}},
+		{name: "certificate outside AWS", tamper: func(msg *SNSMessage) { msg.SigningCertURL = "https://sns.example.com/cert.pem" }},
+		{name: "unknown signature version", tamper: func(msg *SNSMessage) { msg.SignatureVersion = "3" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tf := newTestFeedback(t, testTopicARN)
+			msg := tf.sign(t, notification(testTopicARN, permanentBounce))
+			tt.tamper(msg)
+
+			if err := tf.service.HandleSNSMessage(context.Background(), msg); !errors.Is(err, ErrInvalidSNSSignature) {
+				t.Fatalf("HandleSNSMessage() error = %v, want %v", err, ErrInvalidSNSSignature)
+			}
+			if len(tf.suppressions.suppressed) != 0 {
+				t.Errorf("suppressed %+v, want nothing", tf.suppressions.suppressed)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+
+	awsclient "github.com/npushpakumara/go-backend-template/internal/aws_client"
+	"github.com/npushpakumara/go-backend-template/internal/config"
+)
+
+// ProviderCheck verifies that the configured email provider can be reached. It backs the email
+// entry of the readiness probe.
+type ProviderCheck struct {
+	cfg       *config.Config
+	awsClient *awsclient.AWSClient
+}
+
+// NewProviderCheck creates a ProviderCheck for the configured provider.
+func NewProviderCheck(cfg *config.Config, awsClient *awsclient.AWSClient) *ProviderCheck {
+	return &ProviderCheck{cfg: cfg, awsClient: awsClient}
+}
+
+// Enabled reports whether the readiness probe should run the check, as set by mail.health_check.
+// Dry-run mode never contacts a provider, so there is nothing to check.
+func (c *ProviderCheck) Enabled() bool {
+	return c.cfg.Mail.HealthCheck && !c.cfg.Mail.DryRun
+}
+
+// Check asks SES whether sending is enabled, or opens a connection to the SMTP server and sends NOOP.
+// A failover pair passes as long as either provider is reachable, since either can deliver.
+func (c *ProviderCheck) Check(ctx context.Context) error {
+	if Provider(c.cfg.Mail.Provider) != providerFailover {
+		return c.checkProvider(ctx, Provider(c.cfg.Mail.Provider))
+	}
+
+	primaryErr := c.checkProvider(ctx, Provider(c.cfg.Mail.Primary))
+	if primaryErr == nil {
+		return nil
+	}
+	secondaryErr := c.checkProvider(ctx, Provider(c.cfg.Mail.Secondary))
+	if secondaryErr == nil {
+		return nil
+	}
+	return errors.Join(primaryErr, secondaryErr)
+}
+
+// checkProvider checks a single concrete provider.
+func (c *ProviderCheck) checkProvider(ctx context.Context, provider Provider) error {
+	switch provider {
+	case providerSES:
+		return c.awsClient.VerifySES(ctx)
+	case providerSMTP:
+		return checkSMTP(ctx, c.cfg.Mail.SMTP.Server, c.cfg.Mail.SMTP.Port)
+	default:
+		return fmt.Errorf("%w %q", ErrUnknownEmailProvider, provider)
+	}
+}
+
+// checkSMTP connects to the SMTP server, reads its greeting and sends NOOP, giving up when ctx is done.
+func checkSMTP(ctx context.Context, host string, port int) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return fmt.Errorf("smtp server is not reachable: %w", err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return fmt.Errorf("smtp server did not greet: %w", err)
+	}
+	defer client.Close()
+
+	if err := client.Noop(); err != nil {
+		return fmt.Errorf("smtp server rejected NOOP: %w", err)
+	}
+	return client.Quit()
+}
@@ -0,0 +1,123 @@
+package email
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"sort"
+	"strings"
+
+	"github.com/npushpakumara/go-backend-template/internal/features/email/entities"
+)
+
+// ErrInvalidEmailHeader is returned when a custom header has a malformed name, contains a line break,
+// or would replace one of the headers the message is built with.
+var ErrInvalidEmailHeader = errors.New("invalid email header")
+
+// reservedHeaders are written by buildMIMEMessage itself and can't be set through Email.Headers.
+var reservedHeaders = map[string]bool{
+	"From":                      true,
+	"To":                        true,
+	"Cc":                        true,
+	"Bcc":                       true,
+	"Subject":                   true,
+	"Mime-Version":              true,
+	"Content-Type":              true,
+	"Content-Transfer-Encoding": true,
+}
+
+// buildMIMEMessage renders the email as a multipart/alternative message carrying a plain-text
+// part followed by the HTML part. Clients show the last part they can display, so HTML-capable
+// clients use the HTML version and text-only clients fall back to the plain text.
+// from is the From header, which may carry a display name unlike the envelope sender.
+// The email's custom headers follow Subject, sorted by name so the output is stable.
+func buildMIMEMessage(email entities.Email, from string) ([]byte, error) {
+	headers, err := formatHeaders(email.Headers)
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=UTF-8", plainTextBody(email)},
+		{"text/html; charset=UTF-8", email.Data},
+	}
+	for _, part := range parts {
+		w, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(email.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", email.Subject))
+	msg.WriteString(headers)
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", writer.Boundary())
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
+
+// formatHeaders renders the custom headers as "Name: value" lines. Values are RFC 2047-encoded when
+// they aren't plain ASCII. Line breaks are refused rather than stripped, since they would let a value
+// inject headers of its own.
+func formatHeaders(headers map[string]string) (string, error) {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		value := headers[name]
+		if !validHeaderName(name) {
+			return "", fmt.Errorf("%w: malformed name %q", ErrInvalidEmailHeader, name)
+		}
+		if reservedHeaders[textproto.CanonicalMIMEHeaderKey(name)] {
+			return "", fmt.Errorf("%w: %s can't be set as a custom header", ErrInvalidEmailHeader, name)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return "", fmt.Errorf("%w: %s contains a line break", ErrInvalidEmailHeader, name)
+		}
+		fmt.Fprintf(&b, "%s: %s\r\n", name, mime.QEncoding.Encode("UTF-8", value))
+	}
+	return b.String(), nil
+}
+
+// validHeaderName reports whether name is a non-empty run of printable ASCII characters other than a colon.
+func validHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if c := name[i]; c <= ' ' || c > '~' || c == ':' {
+			return false
+		}
+	}
+	return true
+}
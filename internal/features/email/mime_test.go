@@ -0,0 +1,221 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/npushpakumara/go-backend-template/internal/features/email/entities"
+)
+
+// parseMessage parses a message built by buildMIMEMessage.
+func parseMessage(t *testing.T, msg []byte) *mail.Message {
+	t.Helper()
+
+	parsed, err := mail.ReadMessage(bytes.NewReader(msg))
+	if err != nil {
+		t.Fatalf("ReadMessage() error = %v", err)
+	}
+	return parsed
+}
+
+func TestBuildMIMEMessageAddsHeaders(t *testing.T) {
+	email := entities.Email{
+		From:    "no-reply@example.com",
+		To:      []string{"ada@example.com"},
+		Subject: "Welcome",
+		Data:    "<p>Hello</p>",
+		Headers: map[string]string{
+			"X-Campaign":       "spring",
+			"List-Unsubscribe": "<https://api.example.com/unsubscribe?token=abc>",
+			"X-Greeting":       "Grüße",
+		},
+	}
+
+	msg, err := buildMIMEMessage(email, "Example <no-reply@example.com>")
+	if err != nil {
+		t.Fatalf("buildMIMEMessage() error = %v", err)
+	}
+
+	parsed := parseMessage(t, msg)
+	for name, want := range email.Headers {
+		got, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get(name))
+		if err != nil {
+			t.Fatalf("DecodeHeader(%s) error = %v", name, err)
+		}
+		if got != want {
+			t.Errorf("header %s = %q, want %q", name, got, want)
+		}
+	}
+	if got := parsed.Header.Get("X-Greeting"); got == "Grüße" {
+		t.Errorf("non-ASCII header value wasn't encoded: %q", got)
+	}
+
+	// Custom headers follow Subject in name order.
+	head := string(msg[:bytes.Index(msg, []byte("MIME-Version"))])
+	if !(strings.Index(head, "Subject:") < strings.Index(head, "List-Unsubscribe:") &&
+		strings.Index(head, "List-Unsubscribe:") < strings.Index(head, "X-Campaign:") &&
+		strings.Index(head, "X-Campaign:") < strings.Index(head, "X-Greeting:")) {
+		t.Errorf("headers aren't in order:\n%s", head)
+	}
+}
+
+func TestBuildMIMEMessageRejectsInvalidHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+	}{
+		{name: "CRLF in a value", headers: map[string]string{"X-Campaign": "spring\r\nBcc: victim@example.com"}},
+		{name: "LF in a value", headers: map[string]string{"X-Campaign": "spring\nBcc: victim@example.com"}},
+		{name: "CR in a value", headers: map[string]string{"X-Campaign": "spring\rBcc: victim@example.com"}},
+		{name: "CRLF in a name", headers: map[string]string{"X-Campaign\r\nBcc": "victim@example.com"}},
+		{name: "colon in a name", headers: map[string]string{"X-Campaign: spring\r\nBcc": "victim@example.com"}},
+		{name: "space in a name", headers: map[string]string{"X Campaign": "spring"}},
+		{name: "empty name", headers: map[string]string{"": "spring"}},
+		{name: "reserved header", headers: map[string]string{"Bcc": "victim@example.com"}},
+		{name: "reserved header in another case", headers: map[string]string{"content-type": "text/plain"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			email := entities.Email{From: "no-reply@example.com", To: []string{"ada@example.com"}, Subject: "Welcome", Headers: tt.headers}
+			if _, err := buildMIMEMessage(email, email.From); !errors.Is(err, ErrInvalidEmailHeader) {
+				t.Errorf("buildMIMEMessage() error = %v, want ErrInvalidEmailHeader", err)
+			}
+		})
+	}
+}
+
+func TestBuildMIMEMessageEncodesSubjectLineBreaks(t *testing.T) {
+	email := entities.Email{
+		From:    "no-reply@example.com",
+		To:      []string{"ada@example.com"},
+		Subject: "Welcome\r\nBcc: victim@example.com",
+		Data:    "<p>Hello</p>",
+	}
+
+	msg, err := buildMIMEMessage(email, email.From)
+	if err != nil {
+		t.Fatalf("buildMIMEMessage() error = %v", err)
+	}
+
+	parsed := parseMessage(t, msg)
+	if bcc := parsed.Header.Get("Bcc"); bcc != "" {
+		t.Errorf("subject injected a Bcc header: %q", bcc)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil {
+		t.Fatalf("DecodeHeader(Subject) error = %v", err)
+	}
+	if subject != email.Subject {
+		t.Errorf("Subject = %q, want %q", subject, email.Subject)
+	}
+}
+
+func TestSendMailRejectsLineBreaksInAddresses(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   []string
+	}{
+		{name: "sender", from: "no-reply@example.com\r\nRCPT TO:<victim@example.com>", to: []string{"ada@example.com"}},
+		{name: "recipient", from: "no-reply@example.com", to: []string{"ada@example.com\r\nRCPT TO:<victim@example.com>"}},
+		{name: "recipient with LF", from: "no-reply@example.com", to: []string{"ada@example.com\nDATA"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The addresses are checked before dialling, so nothing listens on the address.
+			err := sendMail(context.Background(), "127.0.0.1:1", nil, newSMTPTLSConfig("127.0.0.1", 0), tt.from, tt.to, []byte("Subject: hi\r\n\r\nhi"))
+			if err == nil || !strings.Contains(err.Error(), "CR or LF") {
+				t.Errorf("sendMail() error = %v, want a CR or LF error", err)
+			}
+		})
+	}
+}
+
+func TestBuildMIMEMessageHasTextAndHTMLParts(t *testing.T) {
+	tests := []struct {
+		name     string
+		textData string
+		wantText string
+	}{
+		{name: "explicit text", textData: "Hello Ada,\nwelcome aboard.\n", wantText: "Hello Ada,\nwelcome aboard.\n"},
+		{name: "text derived from HTML", wantText: "Hello Ada,\n\nVerify your email (https://app.example.com/verify?token=abc)\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			email := entities.Email{
+				From:     "no-reply@example.com",
+				To:       []string{"ada@example.com"},
+				Subject:  "Welcome",
+				Data:     `<p>Hello Ada,</p><p><a href="https://app.example.com/verify?token=abc">Verify your email</a></p>`,
+				TextData: tt.textData,
+			}
+			msg, err := buildMIMEMessage(email, "no-reply@example.com")
+			if err != nil {
+				t.Fatalf("buildMIMEMessage() error = %v", err)
+			}
+			parsed := parseMessage(t, msg)
+
+			mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+			if err != nil || mediaType != "multipart/alternative" {
+				t.Fatalf("Content-Type = %q, want multipart/alternative", parsed.Header.Get("Content-Type"))
+			}
+
+			// The plain text comes first, so clients that can show HTML prefer it.
+			want := []struct{ contentType, body string }{
+				{"text/plain; charset=UTF-8", tt.wantText},
+				{"text/html; charset=UTF-8", email.Data},
+			}
+			reader := multipart.NewReader(parsed.Body, params["boundary"])
+			for i, w := range want {
+				part, err := reader.NextPart()
+				if err != nil {
+					t.Fatalf("part %d: NextPart() error = %v", i, err)
+				}
+				if got := part.Header.Get("Content-Type"); got != w.contentType {
+					t.Errorf("part %d Content-Type = %q, want %q", i, got, w.contentType)
+				}
+				// NextPart decodes the quoted-printable transfer encoding, which sends line breaks as CRLF.
+				body, err := io.ReadAll(part)
+				if err != nil {
+					t.Fatalf("part %d: read body: %v", i, err)
+				}
+				if string(body) != strings.ReplaceAll(w.body, "\n", "\r\n") {
+					t.Errorf("part %d body = %q, want %q", i, body, w.body)
+				}
+			}
+			if _, err := reader.NextPart(); err != io.EOF {
+				t.Errorf("NextPart() after the HTML part error = %v, want io.EOF", err)
+			}
+		})
+	}
+}
+
+func TestSESSendEmailInputHasTextAndHTMLBodies(t *testing.T) {
+	s := &sesEmailServiceImpl{From: "no-reply@example.com", FromName: "Example"}
+	email := entities.Email{
+		From:    "no-reply@example.com",
+		To:      []string{"ada@example.com"},
+		Subject: "Welcome",
+		Data:    "<p>Hello <b>Ada</b></p>",
+	}
+
+	body := s.newSendEmailInput(email).Message.Body
+	if body.Html == nil || *body.Html.Data != email.Data {
+		t.Errorf("Body.Html = %v, want %q", body.Html, email.Data)
+	}
+	if body.Text == nil || *body.Text.Data != "Hello Ada\n" {
+		t.Errorf("Body.Text = %v, want %q", body.Text, "Hello Ada\n")
+	}
+
+	email.TextData = "Hello, Ada"
+	if text := s.newSendEmailInput(email).Message.Body.Text; text == nil || *text.Data != email.TextData {
+		t.Errorf("Body.Text = %v, want the email's TextData %q", text, email.TextData)
+	}
+}
@@ -0,0 +1,60 @@
+package email
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/npushpakumara/go-backend-template/internal/features/email/entities"
+)
+
+var (
+	// invisibleBlocks matches elements whose content is never shown to the reader.
+	invisibleBlocks = regexp.MustCompile(`(?is)<(head|style|script)\b.*?</(head|style|script)\s*>`)
+	// anchorTags matches links so their target can be kept next to the link text.
+	anchorTags = regexp.MustCompile(`(?is)<a\b[^>]*?href\s*=\s*["']([^"']*)["'][^>]*>(.*?)</a\s*>`)
+	// lineBreakTags matches tags that end a line or block of text.
+	lineBreakTags = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|h[1-6]|li|tr|table)\s*>`)
+	// anyTag matches every remaining tag.
+	anyTag = regexp.MustCompile(`(?s)<[^>]*>`)
+	// spaceRuns matches runs of horizontal whitespace.
+	spaceRuns = regexp.MustCompile(`[ \t\r\f\v]+`)
+	// blankLines matches two or more consecutive empty lines.
+	blankLines = regexp.MustCompile(`\n{3,}`)
+)
+
+// plainTextBody returns the email's plain-text alternative, deriving it from the HTML body when TextData is empty.
+func plainTextBody(email entities.Email) string {
+	if email.TextData != "" {
+		return email.TextData
+	}
+	return htmlToText(email.Data)
+}
+
+// htmlToText converts an HTML email body to readable plain text. It is meant for the simple
+// markup of our templates, not arbitrary HTML: links become "text (url)", block-level tags become
+// line breaks and everything else is stripped.
+func htmlToText(body string) string {
+	text := invisibleBlocks.ReplaceAllString(body, "")
+	text = anchorTags.ReplaceAllStringFunc(text, func(a string) string {
+		m := anchorTags.FindStringSubmatch(a)
+		label := strings.TrimSpace(anyTag.ReplaceAllString(m[2], ""))
+		if label == "" || label == m[1] {
+			return m[1]
+		}
+		if label == strings.TrimPrefix(m[1], "mailto:") {
+			return label
+		}
+		return label + " (" + m[1] + ")"
+	})
+	text = lineBreakTags.ReplaceAllString(text, "\n\n")
+	text = anyTag.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(spaceRuns.ReplaceAllString(line, " "))
+	}
+	text = blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(text) + "\n"
+}
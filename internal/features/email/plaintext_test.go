@@ -0,0 +1,27 @@
+package email
+
+import "testing"
+
+func TestHTMLToText(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{name: "paragraphs", html: "<p>Hello</p><p>World</p>", want: "Hello\n\nWorld\n"},
+		{name: "line breaks", html: "Line one<br>Line two<br/>Line three", want: "Line one\n\nLine two\n\nLine three\n"},
+		{name: "link", html: `<a href="https://example.com/reset">Reset your password</a>`, want: "Reset your password (https://example.com/reset)\n"},
+		{name: "link showing its URL", html: `<a href="https://example.com">https://example.com</a>`, want: "https://example.com\n"},
+		{name: "mailto link", html: `<a href="mailto:help@example.com">help@example.com</a>`, want: "help@example.com\n"},
+		{name: "head, style and script", html: "<html><head><title>T</title><style>p{}</style></head><body><script>x()</script><p>Body</p></body></html>", want: "Body\n"},
+		{name: "entities", html: "<p>Fish &amp; chips &lt;3</p>", want: "Fish & chips <3\n"},
+		{name: "whitespace", html: "<div>\n   Hello\t\t  there   \n</div>\n\n\n\n<div>Bye</div>", want: "Hello there\n\nBye\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := htmlToText(tt.html); got != tt.want {
+				t.Errorf("htmlToText(%q) = %q, want %q", tt.html, got, tt.want)
+			}
+		})
+	}
+}
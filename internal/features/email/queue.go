@@ -0,0 +1,210 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/internal/features/email/entities"
+	"github.com/npushpakumara/go-backend-template/pkg/logging"
+	"github.com/npushpakumara/go-backend-template/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrQueueClosed is returned when an email is sent after shutdown has started.
+var ErrQueueClosed = errors.New("email queue is closed")
+
+// sendNowKey marks contexts whose emails bypass the queue.
+type sendNowKey struct{}
+
+// SendNow returns a context whose emails are sent by the calling goroutine instead of being queued,
+// so the caller gets the provider's error. Use it when the response depends on the outcome of the send.
+func SendNow(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sendNowKey{}, true)
+}
+
+// queuedEmailServiceImpl wraps another Service and sends emails from a pool of background workers,
+// retrying failed sends with a doubling delay. Emails that fail their last attempt are saved to
+// deadLetters, when set. Bulk sends already run in the background of their own request and are
+// passed straight through.
+type queuedEmailServiceImpl struct {
+	next        Service
+	jobs        chan emailJob
+	maxAttempts int
+	retryDelay  time.Duration
+	deadLetters FailedEmailRepository
+
+	// ctx is cancelled when shutdown runs out of time, abandoning in-flight sends and retries;
+	// wg tracks the workers.
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	// mu guards closed against concurrent enqueues; pending counts queued and in-flight emails.
+	mu      sync.RWMutex
+	closed  bool
+	pending atomic.Int64
+}
+
+// emailJob is a queued email together with the context it is sent with, which carries
+// the request ID and trace of the request that queued it.
+type emailJob struct {
+	ctx   context.Context
+	email entities.Email
+}
+
+// newQueuedEmailService starts the configured number of workers in front of next.
+// deadLetters may be nil, in which case emails that run out of attempts are only logged.
+func newQueuedEmailService(next Service, cfg *config.MailQueueConfig, deadLetters FailedEmailRepository) *queuedEmailServiceImpl {
+	ctx, cancel := context.WithCancel(context.Background())
+	q := &queuedEmailServiceImpl{
+		next:        next,
+		jobs:        make(chan emailJob, cfg.Size),
+		maxAttempts: max(cfg.MaxAttempts, 1),
+		retryDelay:  cfg.RetryDelay,
+		deadLetters: deadLetters,
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+	for range cfg.Workers {
+		q.wg.Add(1)
+		go q.work()
+	}
+	return q
+}
+
+// SendEmail queues the email and returns once it is accepted, blocking while the queue is full.
+// Emails sent with a SendNow context are sent immediately instead.
+func (q *queuedEmailServiceImpl) SendEmail(ctx context.Context, email entities.Email) error {
+	if sendNow, _ := ctx.Value(sendNowKey{}).(bool); sendNow {
+		return q.next.SendEmail(ctx, email)
+	}
+
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return ErrQueueClosed
+	}
+
+	q.pending.Add(1)
+	select {
+	case q.jobs <- emailJob{ctx: tracing.Continue(logging.Continue(q.ctx, ctx), ctx), email: email}:
+		return nil
+	case <-ctx.Done():
+		q.pending.Add(-1)
+		return ctx.Err()
+	}
+}
+
+// SendBulk forwards the bulk send to the wrapped service.
+func (q *queuedEmailServiceImpl) SendBulk(ctx context.Context, templateKey string, recipients []entities.Recipient) ([]entities.BulkResult, error) {
+	return q.next.SendBulk(ctx, templateKey, recipients)
+}
+
+// work sends queued emails until the queue is closed and drained.
+func (q *queuedEmailServiceImpl) work() {
+	defer q.wg.Done()
+	for job := range q.jobs {
+		// Shutdown already gave up on and reported these emails.
+		if q.ctx.Err() == nil {
+			q.send(job.ctx, job.email)
+		}
+		q.pending.Add(-1)
+	}
+}
+
+// send delivers a single email, retrying until it succeeds, attempts run out or shutdown gives up.
+// ctx derives from the worker's own context rather than the request's: the request that queued the
+// email may be over by now and its context may carry a finished database transaction. Only the request ID
+// and trace are carried over, so the worker's logs can still be joined with the request's and its span,
+// which covers every attempt, is a child of the request's span.
+func (q *queuedEmailServiceImpl) send(ctx context.Context, email entities.Email) {
+	ctx, span := tracing.Tracer().Start(ctx, "email.send", trace.WithAttributes(attribute.String("email.template", email.Template)))
+	defer span.End()
+	logger := logging.FromContext(ctx)
+
+	delay := q.retryDelay
+	for attempt := 1; ; attempt++ {
+		err := q.next.SendEmail(ctx, email)
+		if err == nil {
+			return
+		}
+
+		// An undeliverable address fails the same way every time, so it isn't retried.
+		if attempt == q.maxAttempts || errors.Is(err, ErrEmailNotDeliverable) {
+			logger.Errorw("email.queue.send giving up", "to", email.To, "subject", email.Subject, "attempts", attempt, "err", err)
+			span.SetStatus(codes.Error, err.Error())
+			q.deadLetter(ctx, email, attempt, err)
+			return
+		}
+
+		logger.Warnw("email.queue.send attempt failed, retrying",
+			"to", email.To, "subject", email.Subject, "attempt", attempt, "next_delay", delay, "err", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// deadLetter saves an email that failed its last attempt so an administrator can queue it again.
+func (q *queuedEmailServiceImpl) deadLetter(ctx context.Context, email entities.Email, attempts int, sendErr error) {
+	if q.deadLetters == nil {
+		return
+	}
+	logger := logging.FromContext(ctx)
+
+	data, err := json.Marshal(email)
+	if err != nil {
+		logger.Errorw("email.queue.deadLetter failed to encode email", "to", email.To, "err", err)
+		return
+	}
+
+	failed := &entities.FailedEmail{
+		Recipient: strings.Join(email.To, ", "),
+		Subject:   email.Subject,
+		Template:  email.Template,
+		Data:      string(data),
+		LastError: sendErr.Error(),
+		Attempts:  attempts,
+	}
+	if err := q.deadLetters.Insert(ctx, failed); err != nil {
+		logger.Errorw("email.queue.deadLetter failed to save failed email, it is lost", "to", email.To, "subject", email.Subject, "err", err)
+		return
+	}
+	logger.Infow("email.queue.deadLetter saved failed email", "id", failed.ID, "to", email.To)
+}
+
+// stop stops accepting emails and waits for the queued ones to be sent or ctx to expire.
+// When ctx expires first, the emails still queued or in flight are logged and abandoned.
+func (q *queuedEmailServiceImpl) stop(ctx context.Context) error {
+	q.mu.Lock()
+	q.closed = true
+	close(q.jobs)
+	q.mu.Unlock()
+	defer q.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		logging.FromContext(ctx).Warnw("email.queue.stop deadline reached, abandoning emails", "remaining", q.pending.Load())
+		return ctx.Err()
+	}
+}
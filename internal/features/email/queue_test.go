@@ -0,0 +1,163 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/internal/features/email/entities"
+	"github.com/npushpakumara/go-backend-template/pkg/logging"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// gatedService is a Service whose sends wait until release is closed or their context is done.
+// It records the subjects of the emails it sent.
+type gatedService struct {
+	release chan struct{}
+	started chan struct{}
+
+	mu   sync.Mutex
+	sent []string
+}
+
+func newGatedService() *gatedService {
+	return &gatedService{release: make(chan struct{}), started: make(chan struct{}, 16)}
+}
+
+func (s *gatedService) SendEmail(ctx context.Context, email entities.Email) error {
+	s.started <- struct{}{}
+	select {
+	case <-s.release:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, email.Subject)
+	return nil
+}
+
+func (s *gatedService) SendBulk(context.Context, string, []entities.Recipient) ([]entities.BulkResult, error) {
+	return nil, nil
+}
+
+func (s *gatedService) Sent() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.sent...)
+}
+
+// failingService is a Service whose sends all fail with err.
+type failingService struct {
+	Service
+	err error
+}
+
+func (s failingService) SendEmail(context.Context, entities.Email) error {
+	return s.err
+}
+
+// queueEmails queues an email with each subject.
+func queueEmails(t *testing.T, q *queuedEmailServiceImpl, subjects ...string) {
+	t.Helper()
+	for _, subject := range subjects {
+		email := testEmail()
+		email.Subject = subject
+		if err := q.SendEmail(context.Background(), email); err != nil {
+			t.Fatalf("SendEmail(%q) error = %v", subject, err)
+		}
+	}
+}
+
+func TestQueueFlushesEmailsOnStop(t *testing.T) {
+	next := newGatedService()
+	q := newQueuedEmailService(next, &config.MailQueueConfig{Workers: 1, Size: 10, MaxAttempts: 1}, nil)
+
+	queueEmails(t, q, "first", "second", "third")
+	// The worker holds the first email, so the others are still queued when shutdown starts.
+	<-next.started
+
+	stopped := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		stopped <- q.stop(ctx)
+	}()
+
+	select {
+	case err := <-stopped:
+		t.Fatalf("stop() returned %v before the queued emails were sent", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(next.release)
+	if err := <-stopped; err != nil {
+		t.Fatalf("stop() error = %v, want nil", err)
+	}
+	if got := next.Sent(); len(got) != 3 || got[0] != "first" || got[1] != "second" || got[2] != "third" {
+		t.Errorf("sent %v, want [first second third]", got)
+	}
+	if pending := q.pending.Load(); pending != 0 {
+		t.Errorf("pending = %d after stop, want 0", pending)
+	}
+
+	if err := q.SendEmail(context.Background(), testEmail()); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("SendEmail() after stop error = %v, want %v", err, ErrQueueClosed)
+	}
+}
+
+func TestQueueStopGivesUpAtDeadline(t *testing.T) {
+	next := newGatedService()
+	q := newQueuedEmailService(next, &config.MailQueueConfig{Workers: 1, Size: 10, MaxAttempts: 1}, nil)
+
+	queueEmails(t, q, "first", "second")
+	<-next.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := q.stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("stop() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// Giving up cancels the in-flight send, and the email still queued is dropped without being tried.
+	q.wg.Wait()
+	if got := next.Sent(); len(got) != 0 {
+		t.Errorf("sent %v after shutdown gave up, want nothing", got)
+	}
+	if started := len(next.started); started != 0 {
+		t.Errorf("%d more sends started after shutdown gave up, want 0", started)
+	}
+}
+
+// The worker's logs carry the ID of the request that queued the email, so they can be joined with the request's.
+func TestQueueLogsWithRequestID(t *testing.T) {
+	q := newQueuedEmailService(failingService{err: errors.New("connection refused")}, &config.MailQueueConfig{Workers: 1, Size: 1, MaxAttempts: 2}, nil)
+	q.retryDelay = time.Millisecond
+	// The worker logs with the logger of the queue's context, so it is observed from before anything is queued.
+	core, logs := observer.New(zapcore.DebugLevel)
+	q.ctx = logging.WithLogger(q.ctx, zap.New(core).Sugar())
+
+	if err := q.SendEmail(logging.WithRequestID(context.Background(), "req-123"), testEmail()); err != nil {
+		t.Fatalf("SendEmail() error = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := q.stop(ctx); err != nil {
+		t.Fatalf("stop() error = %v, want nil", err)
+	}
+
+	for _, message := range []string{"email.queue.send attempt failed, retrying", "email.queue.send giving up"} {
+		entries := logs.FilterMessage(message).All()
+		if len(entries) != 1 {
+			t.Fatalf("logged %q %d times, want once", message, len(entries))
+		}
+		if got := entries[0].ContextMap()["request_id"]; got != "req-123" {
+			t.Errorf("%q request_id = %v, want %q", message, got, "req-123")
+		}
+	}
+}
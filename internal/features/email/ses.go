@@ -2,38 +2,94 @@ package email
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ses"
 	"github.com/aws/aws-sdk-go-v2/service/ses/types"
 	awsclient "github.com/npushpakumara/go-backend-template/internal/aws_client"
+	"github.com/npushpakumara/go-backend-template/internal/config"
 	"github.com/npushpakumara/go-backend-template/internal/features/email/entities"
 	"github.com/npushpakumara/go-backend-template/pkg/logging"
 )
 
+// sesBulkBatchSize is the maximum number of destinations SES accepts in a single SendBulkTemplatedEmail call.
+const sesBulkBatchSize = 50
+
+// ErrEmailNotDeliverable is returned when the provider refuses to send to a recipient at all, such as SES
+// rejecting an unverified address while the account is in the sandbox. Retrying won't help; the address
+// has to be verified with the provider or the recipient reached some other way.
+var ErrEmailNotDeliverable = errors.New("email address is not deliverable")
+
+// sesUnverifiedAddressMessage is part of the MessageRejected error SES returns in the sandbox
+// for a recipient whose address hasn't been verified.
+const sesUnverifiedAddressMessage = "Email address is not verified"
+
 // sesEmailServiceImpl is a concrete implementation of the Service interface.
 // It uses an AWS client to send emails through AWS SES (Simple Email Service).
 type sesEmailServiceImpl struct {
-	AWSClient *awsclient.AWSClient
+	Client      awsclient.SESAPI
+	From        string
+	FromName    string
+	SendRate    float64
+	SendTimeout time.Duration
 }
 
 // NewSESEmailService creates a new instance of emailServiceImpl.
-// It initializes the service with the given AWS client.
+// It initializes the service with the given SES client, which tests may replace with a fake.
 // This function returns an Service interface that wraps the emailServiceImpl.
-func NewSESEmailService(awsClient *awsclient.AWSClient) Service {
+func NewSESEmailService(client awsclient.SESAPI, cfg *config.Config) Service {
 	return &sesEmailServiceImpl{
-		AWSClient: awsClient,
+		Client:      client,
+		From:        cfg.Mail.FromEmail,
+		FromName:    cfg.Mail.FromName,
+		SendRate:    cfg.Mail.SendRate,
+		SendTimeout: cfg.Mail.SendTimeout,
 	}
 }
 
 // SendEmail sends an email using AWS SES with the provided context and email details.
-// It marshals the email data into JSON format and constructs the input for the SES API.
-// If there is an error in marshalling the data or sending the email, it logs the error
+// Emails with custom headers are sent with SendRawEmail, since SendEmail can't carry them;
+// the rest go through SendEmail with a structured message.
+// If there is an error in building the message or sending the email, it logs the error
 // and returns it. Otherwise, it returns nil indicating success.
 func (s *sesEmailServiceImpl) SendEmail(ctx context.Context, email entities.Email) error {
 	logger := logging.FromContext(ctx)
 
-	input := &ses.SendEmailInput{
+	// The SDK honours the context, so the send timeout is all that's needed to bound the call.
+	ctx, cancel := withSendTimeout(ctx, s.SendTimeout)
+	defer cancel()
+
+	var err error
+	if len(email.Headers) > 0 {
+		err = s.sendRaw(ctx, email)
+	} else {
+		_, err = s.Client.SendEmail(ctx, s.newSendEmailInput(email))
+	}
+	if err != nil {
+		logger.Errorw("email.service.SendEmail error while sending email via aws ses: %w", err)
+		return classifySESError(err)
+	}
+	return nil
+}
+
+// classifySESError wraps the error SES returns for a recipient it won't send to with ErrEmailNotDeliverable.
+// Other errors are returned unchanged.
+func classifySESError(err error) error {
+	var rejected *types.MessageRejected
+	if errors.As(err, &rejected) && strings.Contains(rejected.ErrorMessage(), sesUnverifiedAddressMessage) {
+		return fmt.Errorf("%w: %w", ErrEmailNotDeliverable, err)
+	}
+	return err
+}
+
+// newSendEmailInput builds the structured SendEmail request for the email.
+func (s *sesEmailServiceImpl) newSendEmailInput(email entities.Email) *ses.SendEmailInput {
+	return &ses.SendEmailInput{
 		Destination: &types.Destination{
 			ToAddresses: email.To,
 		},
@@ -43,19 +99,103 @@ func (s *sesEmailServiceImpl) SendEmail(ctx context.Context, email entities.Emai
 					Charset: aws.String("UTF-8"),
 					Data:    aws.String(email.Data),
 				},
+				Text: &types.Content{
+					Charset: aws.String("UTF-8"),
+					Data:    aws.String(plainTextBody(email)),
+				},
 			},
 			Subject: &types.Content{
 				Charset: aws.String("UTF-8"),
 				Data:    aws.String(email.Subject),
 			},
 		},
-		Source: aws.String(email.From),
+		Source: aws.String(formatAddress(s.FromName, email.From)),
 	}
+}
 
-	_, err := s.AWSClient.GetSESClient().SendEmail(ctx, input)
+// sendRaw sends the email as the same MIME message the SMTP provider builds, custom headers included.
+func (s *sesEmailServiceImpl) sendRaw(ctx context.Context, email entities.Email) error {
+	from := formatAddress(s.FromName, email.From)
+	msg, err := buildMIMEMessage(email, from)
 	if err != nil {
-		logger.Errorw("email.service.SendEmail error while sending email via aws ses: %w", err)
 		return err
 	}
-	return nil
+
+	_, err = s.Client.SendRawEmail(ctx, &ses.SendRawEmailInput{
+		Destinations: email.To,
+		RawMessage:   &types.RawMessage{Data: msg},
+		Source:       aws.String(from),
+	})
+	return err
+}
+
+// SendBulk sends the template to the recipients with SES SendBulkTemplatedEmail in batches of 50.
+// The SES template must be registered under the same name as the local template (e.g. "account-verification");
+// each recipient's data is passed as its replacement template data. Batches are throttled to the configured send rate.
+// SES templates can't add headers, so bulk sends never carry custom headers.
+func (s *sesEmailServiceImpl) SendBulk(ctx context.Context, templateKey string, recipients []entities.Recipient) ([]entities.BulkResult, error) {
+	logger := logging.FromContext(ctx)
+
+	tmpl, ok := entities.EmailTemplates[templateKey]
+	if !ok {
+		return nil, fmt.Errorf("unknown email template %q", templateKey)
+	}
+
+	t := newThrottle(s.SendRate)
+	results := make([]entities.BulkResult, 0, len(recipients))
+
+	for start := 0; start < len(recipients); start += sesBulkBatchSize {
+		batch := recipients[start:min(start+sesBulkBatchSize, len(recipients))]
+
+		if err := t.Wait(ctx, len(batch)); err != nil {
+			return results, err
+		}
+
+		destinations := make([]types.BulkEmailDestination, len(batch))
+		for i, r := range batch {
+			data, err := json.Marshal(r.Data)
+			if err != nil {
+				return results, err
+			}
+			destinations[i] = types.BulkEmailDestination{
+				Destination:             &types.Destination{ToAddresses: []string{r.Email}},
+				ReplacementTemplateData: aws.String(string(data)),
+			}
+		}
+
+		sendCtx, cancel := withSendTimeout(ctx, s.SendTimeout)
+		output, err := s.Client.SendBulkTemplatedEmail(sendCtx, &ses.SendBulkTemplatedEmailInput{
+			Source:              aws.String(formatAddress(s.FromName, s.From)),
+			Template:            aws.String(tmpl.Template),
+			DefaultTemplateData: aws.String("{}"),
+			Destinations:        destinations,
+		})
+		cancel()
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			// The caller gave up; don't report the remaining batches as provider failures.
+			return results, ctxErr
+		}
+		if err != nil {
+			// The whole batch was rejected; report it against every recipient and carry on with the next one.
+			logger.Errorw("email.service.SendBulk error while sending batch via aws ses", "err", err)
+			for _, r := range batch {
+				results = append(results, entities.BulkResult{Email: r.Email, Err: err})
+			}
+			continue
+		}
+
+		for i, r := range batch {
+			result := entities.BulkResult{Email: r.Email}
+			if i >= len(output.Status) {
+				result.Err = errors.New("missing status in ses response")
+			} else if status := output.Status[i]; status.Status != types.BulkEmailStatusSuccess {
+				result.Err = fmt.Errorf("%s: %s", status.Status, aws.ToString(status.Error))
+			} else {
+				result.MessageID = aws.ToString(status.MessageId)
+			}
+			results = append(results, result)
+		}
+	}
+
+	return results, nil
 }
@@ -2,8 +2,13 @@ package email
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"net"
 	"net/smtp"
+	"strings"
+	"time"
 
 	"github.com/npushpakumara/go-backend-template/internal/config"
 	"github.com/npushpakumara/go-backend-template/internal/features/email/entities"
@@ -13,34 +18,157 @@ import (
 // smtpServiceImpl is an implementation of an email service that uses SMTP to send emails.
 // It stores the SMTP server address and authentication details.
 type smtpServiceImpl struct {
-	Server string
-	Auth   smtp.Auth
+	Server      string
+	Auth        smtp.Auth
+	TLSConfig   *tls.Config
+	From        string
+	FromName    string
+	SendRate    float64
+	SendTimeout time.Duration
 }
 
 // NewSMTPEmailService initializes and returns a new instance of smtpServiceImpl.
 // It sets up the SMTP authentication using the provided configuration and constructs the server address.
 func NewSMTPEmailService(cfg *config.Config) Service {
 	auth := smtp.PlainAuth("", cfg.Mail.SMTP.Username, cfg.Mail.SMTP.Password, cfg.Mail.SMTP.Server)
+	// LoadConfig has already rejected an unknown version.
+	minVersion, _ := cfg.Mail.SMTP.TLSVersion()
 	return &smtpServiceImpl{
-		Server: fmt.Sprintf("%s:%d", cfg.Mail.SMTP.Server, cfg.Mail.SMTP.Port),
-		Auth:   auth,
+		Server:      fmt.Sprintf("%s:%d", cfg.Mail.SMTP.Server, cfg.Mail.SMTP.Port),
+		Auth:        auth,
+		TLSConfig:   newSMTPTLSConfig(cfg.Mail.SMTP.Server, minVersion),
+		From:        cfg.Mail.FromEmail,
+		FromName:    cfg.Mail.FromName,
+		SendRate:    cfg.Mail.SendRate,
+		SendTimeout: cfg.Mail.SendTimeout,
+	}
+}
+
+// smtpCipherSuites are the TLS 1.2 suites offered to the SMTP server: ECDHE key exchange with AEAD ciphers only.
+// TLS 1.3 suites are not configurable and are all considered secure.
+var smtpCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+}
+
+// newSMTPTLSConfig returns the TLS settings used to upgrade connections to host.
+func newSMTPTLSConfig(host string, minVersion uint16) *tls.Config {
+	return &tls.Config{
+		ServerName:   host,
+		MinVersion:   minVersion,
+		CipherSuites: smtpCipherSuites,
 	}
 }
 
 // SendEmail sends an email using the SMTP server specified in smtpServiceImpl.
+// The connection is bound to the context: it is dialled with it, and its reads and writes are aborted as soon
+// as the context is cancelled or the send timeout expires. SendEmail
+// then returns ctx.Err(), and nothing is left running that could still deliver the email, so a caller such
+// as the failover service can retry elsewhere without sending it twice.
 // It logs any errors encountered during the sending process.
 func (s *smtpServiceImpl) SendEmail(ctx context.Context, email entities.Email) error {
 	logger := logging.FromContext(ctx)
 
-	subject := "Subject: " + email.Subject + "\n"
-	contentType := "MIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\n\n"
-	msg := []byte(subject + contentType + email.Data)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ctx, cancel := withSendTimeout(ctx, s.SendTimeout)
+	defer cancel()
 
-	err := smtp.SendMail(s.Server, s.Auth, email.From, email.To, msg)
+	msg, err := buildMIMEMessage(email, formatAddress(s.FromName, email.From))
 	if err != nil {
-		logger.Errorf("email.service.SendEmail error while sending email via Gmail: %w", err)
+		logger.Errorw("email.service.SendEmail failed to build message: %v", err)
 		return err
 	}
 
+	if err := sendMail(ctx, s.Server, s.Auth, s.TLSConfig, email.From, email.To, msg); err != nil {
+		// A send cut short by the context fails on the aborted connection; report why it was aborted instead.
+		if ctx.Err() != nil {
+			logger.Errorw("email.service.SendEmail gave up waiting for the smtp server", "err", ctx.Err())
+			return ctx.Err()
+		}
+		logger.Errorf("email.service.SendEmail error while sending email via Gmail: %w", err)
+		return err
+	}
 	return nil
 }
+
+// sendMail works like smtp.SendMail, which always uses its own TLS settings, but upgrades the connection
+// with tlsConfig. As with smtp.SendMail, the connection is upgraded whenever the server offers STARTTLS,
+// and smtp.PlainAuth refuses to send credentials over an unencrypted connection to anything but localhost.
+// Unlike smtp.SendMail, it stops as soon as ctx is done.
+func sendMail(ctx context.Context, addr string, auth smtp.Auth, tlsConfig *tls.Config, from string, to []string, msg []byte) error {
+	for _, address := range append([]string{from}, to...) {
+		if strings.ContainsAny(address, "\r\n") {
+			return errors.New("smtp: a line must not contain CR or LF")
+		}
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	// Unblock any pending read or write once the context is done or its deadline passes,
+	// which fails the rest of the exchange.
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.SetDeadline(time.Unix(1, 0))
+	})
+	defer stop()
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("smtp server %s failed the TLS handshake, which requires at least %s: %w",
+				addr, tls.VersionName(tlsConfig.MinVersion), err)
+		}
+	}
+	if auth != nil {
+		if ok, _ := c.Extension("AUTH"); ok {
+			if err := c.Auth(auth); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, recipient := range to {
+		if err := c.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// SendBulk sends the template to each recipient in turn, since SMTP has no bulk API.
+// Sends are spaced out according to the configured send rate.
+func (s *smtpServiceImpl) SendBulk(ctx context.Context, templateKey string, recipients []entities.Recipient) ([]entities.BulkResult, error) {
+	return sendEach(ctx, s, s.From, templateKey, recipients, newThrottle(s.SendRate))
+}
@@ -0,0 +1,246 @@
+package email
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/npushpakumara/go-backend-template/internal/features/email/entities"
+)
+
+// stalledSMTPServer accepts connections but never sends the SMTP greeting, so every send waits on it.
+// Accepted connections are delivered on the returned channel.
+func stalledSMTPServer(t *testing.T) (string, <-chan net.Conn) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	conns := make(chan net.Conn, 1)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { conn.Close() })
+			conns <- conn
+		}
+	}()
+	return ln.Addr().String(), conns
+}
+
+func testEmail() entities.Email {
+	return entities.Email{
+		From:    "no-reply@example.com",
+		To:      []string{"ada@example.com"},
+		Subject: "Hello",
+		Data:    "<p>Hello</p>",
+	}
+}
+
+// assertClosed fails unless the client has closed conn.
+func assertClosed(t *testing.T, conn net.Conn) {
+	t.Helper()
+
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := conn.Read(make([]byte, 1)); !errors.Is(err, io.EOF) {
+		t.Errorf("server read error = %v, want %v once the send is abandoned", err, io.EOF)
+	}
+}
+
+func TestSMTPSendEmailWithCanceledContext(t *testing.T) {
+	addr, conns := stalledSMTPServer(t)
+	s := &smtpServiceImpl{Server: addr, From: "no-reply@example.com"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := s.SendEmail(ctx, testEmail()); !errors.Is(err, context.Canceled) {
+		t.Fatalf("SendEmail() error = %v, want %v", err, context.Canceled)
+	}
+
+	select {
+	case <-conns:
+		t.Error("SendEmail() connected to the server with a canceled context")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSMTPSendEmailCanceledMidSend(t *testing.T) {
+	addr, conns := stalledSMTPServer(t)
+	s := &smtpServiceImpl{Server: addr, From: "no-reply@example.com"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	if err := s.SendEmail(ctx, testEmail()); !errors.Is(err, context.Canceled) {
+		t.Fatalf("SendEmail() error = %v, want %v", err, context.Canceled)
+	}
+	// The connection must not outlive SendEmail, or the abandoned send could still deliver the email.
+	assertClosed(t, <-conns)
+}
+
+func TestSMTPSendEmailTimesOut(t *testing.T) {
+	addr, conns := stalledSMTPServer(t)
+	s := &smtpServiceImpl{Server: addr, From: "no-reply@example.com", SendTimeout: 50 * time.Millisecond}
+
+	start := time.Now()
+	if err := s.SendEmail(context.Background(), testEmail()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("SendEmail() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("SendEmail() returned after %v, want about the 50ms send timeout", elapsed)
+	}
+	assertClosed(t, <-conns)
+}
+
+// newTestCertificate returns a self-signed certificate for 127.0.0.1 and a pool that trusts it.
+func newTestCertificate(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "smtp.test"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pool
+}
+
+// startTLSServer runs an SMTP server that offers STARTTLS with the given TLS versions and accepts
+// every email. The DATA of each delivered email is sent on the returned channel.
+func startTLSServer(t *testing.T, cert tls.Certificate, minVersion, maxVersion uint16) (string, <-chan string) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: minVersion, MaxVersion: maxVersion}
+	delivered := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { conn.Close() }()
+		_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+		text := textproto.NewConn(conn)
+		_ = text.PrintfLine("220 smtp.test ESMTP")
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+			verb := strings.ToUpper(strings.Fields(line + " ")[0])
+			switch verb {
+			case "EHLO":
+				_ = text.PrintfLine("250-smtp.test")
+				_ = text.PrintfLine("250 STARTTLS")
+			case "STARTTLS":
+				_ = text.PrintfLine("220 ready to start TLS")
+				tlsConn := tls.Server(conn, tlsConfig)
+				if err := tlsConn.Handshake(); err != nil {
+					return
+				}
+				conn = tlsConn
+				text = textproto.NewConn(conn)
+			case "DATA":
+				_ = text.PrintfLine("354 go ahead")
+				data, err := text.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				delivered <- string(data)
+				_ = text.PrintfLine("250 queued")
+			case "QUIT":
+				_ = text.PrintfLine("221 bye")
+				return
+			default:
+				_ = text.PrintfLine("250 ok")
+			}
+		}
+	}()
+	return ln.Addr().String(), delivered
+}
+
+func TestSMTPSendEmailEnforcesMinimumTLSVersion(t *testing.T) {
+	cert, roots := newTestCertificate(t)
+	tests := []struct {
+		name      string
+		serverMin uint16
+		serverMax uint16
+		clientMin uint16
+		wantErr   string
+	}{
+		{name: "server offers only TLS 1.0", serverMin: tls.VersionTLS10, serverMax: tls.VersionTLS10, clientMin: tls.VersionTLS12, wantErr: "requires at least TLS 1.2"},
+		{name: "server offers only TLS 1.1", serverMin: tls.VersionTLS11, serverMax: tls.VersionTLS11, clientMin: tls.VersionTLS12, wantErr: "requires at least TLS 1.2"},
+		{name: "server offers only TLS 1.2 to a client requiring 1.3", serverMin: tls.VersionTLS12, serverMax: tls.VersionTLS12, clientMin: tls.VersionTLS13, wantErr: "requires at least TLS 1.3"},
+		{name: "server offers TLS 1.2", serverMin: tls.VersionTLS12, serverMax: tls.VersionTLS12, clientMin: tls.VersionTLS12},
+		{name: "server offers TLS 1.3", serverMin: tls.VersionTLS13, serverMax: tls.VersionTLS13, clientMin: tls.VersionTLS12},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, delivered := startTLSServer(t, cert, tt.serverMin, tt.serverMax)
+			tlsConfig := newSMTPTLSConfig("127.0.0.1", tt.clientMin)
+			tlsConfig.RootCAs = roots
+			s := &smtpServiceImpl{Server: addr, TLSConfig: tlsConfig, From: "no-reply@example.com"}
+
+			err := s.SendEmail(context.Background(), testEmail())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("SendEmail() error = %v, want nil", err)
+				}
+				if data := <-delivered; !strings.Contains(data, "Subject: Hello") {
+					t.Errorf("delivered %q, want the email", data)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), "TLS handshake") {
+				t.Fatalf("SendEmail() error = %v, want a failed TLS handshake that %s", err, tt.wantErr)
+			}
+			select {
+			case data := <-delivered:
+				t.Errorf("delivered %q over a connection below the minimum TLS version", data)
+			case <-time.After(50 * time.Millisecond):
+			}
+		})
+	}
+}
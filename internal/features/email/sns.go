@@ -0,0 +1,228 @@
+package email
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SNS message types delivered to the webhook.
+const (
+	snsTypeNotification             = "Notification"
+	snsTypeSubscriptionConfirmation = "SubscriptionConfirmation"
+	snsTypeUnsubscribeConfirmation  = "UnsubscribeConfirmation"
+)
+
+// snsHostPattern matches the hosts AWS serves SNS signing certificates and subscription URLs from.
+var snsHostPattern = regexp.MustCompile(`^sns\.[a-z0-9-]+\.amazonaws\.com(\.cn)?$`)
+
+// ErrInvalidSNSSignature is returned when an SNS message fails signature verification.
+var ErrInvalidSNSSignature = errors.New("invalid sns message signature")
+
+// ErrSNSTopicNotConfigured is returned for every SNS message while mail.sns_topic_arn is unset.
+var ErrSNSTopicNotConfigured = errors.New("sns topic is not configured")
+
+// SNSMessage is the envelope Amazon SNS posts to HTTP(S) subscribers.
+type SNSMessage struct {
+	Type             string `json:"Type"`
+	MessageID        string `json:"MessageId"`
+	Token            string `json:"Token"`
+	TopicArn         string `json:"TopicArn"`
+	Subject          string `json:"Subject"`
+	Message          string `json:"Message"`
+	Timestamp        string `json:"Timestamp"`
+	SignatureVersion string `json:"SignatureVersion"`
+	Signature        string `json:"Signature"`
+	SigningCertURL   string `json:"SigningCertURL"`
+	SubscribeURL     string `json:"SubscribeURL"`
+}
+
+// sesNotification is the subset of an SES feedback notification needed to maintain the suppression list.
+type sesNotification struct {
+	NotificationType string `json:"notificationType"`
+	Bounce           struct {
+		BounceType        string `json:"bounceType"`
+		BouncedRecipients []struct {
+			EmailAddress   string `json:"emailAddress"`
+			DiagnosticCode string `json:"diagnosticCode"`
+		} `json:"bouncedRecipients"`
+	} `json:"bounce"`
+	Complaint struct {
+		ComplaintFeedbackType string `json:"complaintFeedbackType"`
+		ComplainedRecipients  []struct {
+			EmailAddress string `json:"emailAddress"`
+		} `json:"complainedRecipients"`
+	} `json:"complaint"`
+}
+
+// ParseSNSMessage decodes an SNS envelope from the request body.
+func ParseSNSMessage(body []byte) (*SNSMessage, error) {
+	var msg SNSMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, err
+	}
+	if msg.Type == "" || msg.Signature == "" || msg.SigningCertURL == "" {
+		return nil, errors.New("malformed sns message")
+	}
+	return &msg, nil
+}
+
+// snsVerifier verifies SNS message signatures, caching signing certificates by URL.
+type snsVerifier struct {
+	client *http.Client
+	certs  sync.Map
+}
+
+// newSNSVerifier creates an snsVerifier that fetches certificates with a short timeout.
+func newSNSVerifier() *snsVerifier {
+	return &snsVerifier{client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Verify checks the message signature against the AWS certificate it references.
+func (v *snsVerifier) Verify(ctx context.Context, msg *SNSMessage) error {
+	if err := validateSNSURL(msg.SigningCertURL); err != nil {
+		return err
+	}
+
+	cert, err := v.certificate(ctx, msg.SigningCertURL)
+	if err != nil {
+		return err
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(msg.Signature)
+	if err != nil {
+		return ErrInvalidSNSSignature
+	}
+
+	pub, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return ErrInvalidSNSSignature
+	}
+
+	payload := []byte(msg.stringToSign())
+	switch msg.SignatureVersion {
+	case "1":
+		sum := sha1.Sum(payload)
+		err = rsa.VerifyPKCS1v15(pub, crypto.SHA1, sum[:], signature)
+	case "2":
+		sum := sha256.Sum256(payload)
+		err = rsa.VerifyPKCS1v15(pub, crypto.SHA256, sum[:], signature)
+	default:
+		return ErrInvalidSNSSignature
+	}
+	if err != nil {
+		return ErrInvalidSNSSignature
+	}
+	return nil
+}
+
+// ConfirmSubscription visits the SubscribeURL so SNS starts delivering notifications.
+func (v *snsVerifier) ConfirmSubscription(ctx context.Context, msg *SNSMessage) error {
+	if err := validateSNSURL(msg.SubscribeURL); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, msg.SubscribeURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("sns subscription confirmation failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// certificate returns the parsed certificate at the given URL, fetching it on first use.
+func (v *snsVerifier) certificate(ctx context.Context, certURL string) (*x509.Certificate, error) {
+	if cert, ok := v.certs.Load(certURL); ok {
+		return cert.(*x509.Certificate), nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, certURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(body)
+	if block == nil {
+		return nil, errors.New("sns signing certificate is not valid PEM")
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	v.certs.Store(certURL, cert)
+	return cert, nil
+}
+
+// stringToSign builds the canonical string SNS signs for the message type.
+func (m *SNSMessage) stringToSign() string {
+	var b strings.Builder
+	write := func(key, value string) {
+		b.WriteString(key)
+		b.WriteString("\n")
+		b.WriteString(value)
+		b.WriteString("\n")
+	}
+
+	write("Message", m.Message)
+	write("MessageId", m.MessageID)
+	if m.Type == snsTypeNotification {
+		if m.Subject != "" {
+			write("Subject", m.Subject)
+		}
+	} else {
+		write("SubscribeURL", m.SubscribeURL)
+	}
+	write("Timestamp", m.Timestamp)
+	if m.Type != snsTypeNotification {
+		write("Token", m.Token)
+	}
+	write("TopicArn", m.TopicArn)
+	write("Type", m.Type)
+	return b.String()
+}
+
+// validateSNSURL ensures a URL from an SNS message points at an AWS SNS endpoint over HTTPS.
+func validateSNSURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || !snsHostPattern.MatchString(u.Hostname()) {
+		return fmt.Errorf("untrusted sns url %q", raw)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package email
+
+import (
+	"context"
+
+	awsclient "github.com/npushpakumara/go-backend-template/internal/aws_client"
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/pkg/logging"
+	"go.uber.org/fx"
+)
+
+// VerifyProviderOnStart registers a startup hook that checks SES is usable when it is configured,
+// so bad credentials or a wrong region stop the application at boot with a clear error instead of
+// failing the first email. When SES is only one side of a failover pair a failed check is logged
+// as a warning, since the other provider can still deliver. The check is skipped in dry-run mode
+// and when mail.startup_check is disabled.
+func VerifyProviderOnStart(lc fx.Lifecycle, cfg *config.Config, awsClient *awsclient.AWSClient) {
+	if cfg.Mail.DryRun || !cfg.Mail.StartupCheck {
+		return
+	}
+
+	sole := Provider(cfg.Mail.Provider) == providerSES
+	inFailover := Provider(cfg.Mail.Provider) == providerFailover &&
+		(Provider(cfg.Mail.Primary) == providerSES || Provider(cfg.Mail.Secondary) == providerSES)
+	if !sole && !inFailover {
+		return
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			err := awsClient.VerifySES(ctx)
+			if err == nil {
+				return nil
+			}
+			if inFailover {
+				logging.FromContext(ctx).Warnw("email.VerifyProviderOnStart SES check failed, relying on failover", "err", err)
+				return nil
+			}
+			return err
+		},
+	})
+}
@@ -0,0 +1,76 @@
+package email
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/npushpakumara/go-backend-template/internal/features/email/entities"
+	"github.com/npushpakumara/go-backend-template/pkg/logging"
+)
+
+// suppressionEmailServiceImpl wraps another Service and drops recipients that are on the suppression list.
+type suppressionEmailServiceImpl struct {
+	next         Service
+	suppressions SuppressionRepository
+}
+
+// NewSuppressionEmailService wraps the given Service so that suppressed addresses are never mailed.
+func NewSuppressionEmailService(next Service, suppressions SuppressionRepository) Service {
+	return &suppressionEmailServiceImpl{next, suppressions}
+}
+
+// SendEmail removes suppressed recipients and forwards the email to the wrapped service.
+// If every recipient is suppressed, the email is skipped and nil is returned.
+func (s *suppressionEmailServiceImpl) SendEmail(ctx context.Context, email entities.Email) error {
+	logger := logging.FromContext(ctx)
+
+	suppressed, err := s.suppressions.FindSuppressed(ctx, email.To)
+	if err != nil {
+		return err
+	}
+
+	if len(suppressed) > 0 {
+		logger.Warnw("email.service.SendEmail skipping suppressed recipients", "recipients", suppressed)
+		email.To = slices.DeleteFunc(slices.Clone(email.To), func(to string) bool {
+			return slices.Contains(suppressed, strings.ToLower(to))
+		})
+	}
+
+	if len(email.To) == 0 {
+		return nil
+	}
+
+	return s.next.SendEmail(ctx, email)
+}
+
+// SendBulk removes suppressed recipients, reporting them with ErrRecipientSuppressed,
+// and forwards the rest to the wrapped service.
+func (s *suppressionEmailServiceImpl) SendBulk(ctx context.Context, templateKey string, recipients []entities.Recipient) ([]entities.BulkResult, error) {
+	addresses := make([]string, len(recipients))
+	for i, r := range recipients {
+		addresses[i] = r.Email
+	}
+
+	suppressed, err := s.suppressions.FindSuppressed(ctx, addresses)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []entities.BulkResult
+	allowed := make([]entities.Recipient, 0, len(recipients))
+	for _, r := range recipients {
+		if slices.Contains(suppressed, strings.ToLower(r.Email)) {
+			results = append(results, entities.BulkResult{Email: r.Email, Err: ErrRecipientSuppressed})
+			continue
+		}
+		allowed = append(allowed, r)
+	}
+
+	if len(allowed) == 0 {
+		return results, nil
+	}
+
+	sent, err := s.next.SendBulk(ctx, templateKey, allowed)
+	return append(results, sent...), err
+}
@@ -0,0 +1,65 @@
+package email
+
+import (
+	"context"
+	"strings"
+
+	"github.com/npushpakumara/go-backend-template/internal/features/email/entities"
+	"github.com/npushpakumara/go-backend-template/internal/postgres"
+	"github.com/npushpakumara/go-backend-template/pkg/logging"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SuppressionRepository defines the interface for suppression list data operations.
+type SuppressionRepository interface {
+	// Suppress adds the given address to the suppression list.
+	// Addresses that are already suppressed are left unchanged.
+	Suppress(ctx context.Context, suppression *entities.Suppression) error
+
+	// FindSuppressed returns the subset of the given addresses that are on the suppression list.
+	FindSuppressed(ctx context.Context, emails []string) ([]string, error)
+}
+
+// suppressionRepositoryImpl is a concrete implementation of the SuppressionRepository interface.
+type suppressionRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewSuppressionRepository creates a new instance of suppressionRepositoryImpl with the provided database connection.
+func NewSuppressionRepository(db *gorm.DB) SuppressionRepository {
+	return &suppressionRepositoryImpl{db}
+}
+
+// Suppress adds the given address to the suppression list, ignoring duplicates.
+func (sr *suppressionRepositoryImpl) Suppress(ctx context.Context, suppression *entities.Suppression) error {
+	logger := logging.FromContext(ctx)
+	db := postgres.FromContext(ctx, sr.db)
+
+	suppression.Email = strings.ToLower(suppression.Email)
+
+	logger.Debugw("email.db.Suppress", "email", suppression.Email, "reason", suppression.Reason)
+	if err := db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(suppression).Error; err != nil {
+		logger.Errorw("email.db.Suppress failed to save: %v", err)
+		return err
+	}
+	return nil
+}
+
+// FindSuppressed returns the subset of the given addresses that are on the suppression list.
+func (sr *suppressionRepositoryImpl) FindSuppressed(ctx context.Context, emails []string) ([]string, error) {
+	logger := logging.FromContext(ctx)
+	db := postgres.FromContext(ctx, sr.db)
+
+	lowered := make([]string, len(emails))
+	for i, email := range emails {
+		lowered[i] = strings.ToLower(email)
+	}
+
+	var suppressed []string
+	if err := db.WithContext(ctx).Model(&entities.Suppression{}).Where("email IN ?", lowered).Pluck("email", &suppressed).Error; err != nil {
+		logger.Errorw("email.db.FindSuppressed failed to query suppression list: %v", err)
+		return nil, err
+	}
+	return suppressed, nil
+}
@@ -0,0 +1,137 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"net/url"
+	"time"
+
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/internal/features/auth/tokens"
+	"github.com/npushpakumara/go-backend-template/internal/features/email/entities"
+	"github.com/npushpakumara/go-backend-template/internal/features/user"
+	"github.com/npushpakumara/go-backend-template/internal/postgres"
+	"github.com/npushpakumara/go-backend-template/pkg/clock"
+	"github.com/npushpakumara/go-backend-template/pkg/logging"
+)
+
+// Headers that let mail clients unsubscribe a recipient from marketing emails with a single click (RFC 8058).
+const (
+	headerListUnsubscribe     = "List-Unsubscribe"
+	headerListUnsubscribePost = "List-Unsubscribe-Post"
+	listUnsubscribeOneClick   = "List-Unsubscribe=One-Click"
+)
+
+// marketingEmailServiceImpl wraps another Service and sends marketing emails only to users who haven't
+// opted out of them, adding List-Unsubscribe headers when an unsubscribe URL is configured.
+type marketingEmailServiceImpl struct {
+	next        Service
+	users       user.Service
+	url         string
+	tokenExpiry time.Duration
+	keyring     *tokens.Keyring
+	clock       clock.Clock
+	from        string
+	sendRate    float64
+}
+
+// NewMarketingEmailService wraps the given Service so that marketing emails respect the recipients'
+// notification preferences and, when mail.unsubscribe.url is set, link to it with a token signed for
+// the recipient's account. Transactional emails are forwarded unchanged.
+func NewMarketingEmailService(next Service, users user.Service, clk clock.Clock, cfg *config.Config) Service {
+	return &marketingEmailServiceImpl{
+		next:        next,
+		users:       users,
+		url:         cfg.Mail.Unsubscribe.URL,
+		tokenExpiry: cfg.Mail.Unsubscribe.TokenExpiry,
+		keyring:     tokens.NewKeyring(&cfg.JWT),
+		clock:       clk,
+		from:        cfg.Mail.FromEmail,
+		sendRate:    cfg.Mail.SendRate,
+	}
+}
+
+// SendEmail forwards transactional emails. The unsubscribe link names a single account, so a marketing email
+// is sent as one copy per recipient; the errors of the copies that failed are joined.
+func (s *marketingEmailServiceImpl) SendEmail(ctx context.Context, email entities.Email) error {
+	if email.Category != entities.CategoryMarketing {
+		return s.next.SendEmail(ctx, email)
+	}
+
+	var errs []error
+	for _, to := range email.To {
+		if err := s.sendCopy(ctx, email, to); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sendCopy sends the email to a single recipient, with that recipient's unsubscribe link.
+// Recipients who opted out of the category are skipped. Addresses without an account have no
+// preference to change, so they are sent the email without the headers.
+func (s *marketingEmailServiceImpl) sendCopy(ctx context.Context, email entities.Email, to string) error {
+	logger := logging.FromContext(ctx)
+	email.To = []string{to}
+
+	recipient, err := s.users.GetUserByEmail(ctx, to)
+	if errors.Is(err, postgres.ErrRecordNotFound) {
+		logger.Warnw("email.service.SendEmail marketing email to an address without an account has no unsubscribe link", "recipient", to)
+		return s.next.SendEmail(ctx, email)
+	}
+	if err != nil {
+		return err
+	}
+
+	enabled, err := s.users.NotificationsEnabled(ctx, recipient.ID, email.Category)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		logger.Infow("email.service.SendEmail skipping recipient who unsubscribed", "recipient", to, "category", email.Category)
+		return nil
+	}
+
+	if s.url == "" {
+		return s.next.SendEmail(ctx, email)
+	}
+
+	link, err := s.link(recipient.ID, email.Category)
+	if err != nil {
+		return err
+	}
+	email.Headers = maps.Clone(email.Headers)
+	if email.Headers == nil {
+		email.Headers = make(map[string]string, 2)
+	}
+	email.Headers[headerListUnsubscribe] = "<" + link + ">"
+	email.Headers[headerListUnsubscribePost] = listUnsubscribeOneClick
+	return s.next.SendEmail(ctx, email)
+}
+
+// SendBulk sends marketing templates one recipient at a time so each copy is checked against the recipient's
+// preferences and carries its own link, since provider bulk APIs can't add headers. Other templates are forwarded unchanged.
+func (s *marketingEmailServiceImpl) SendBulk(ctx context.Context, templateKey string, recipients []entities.Recipient) ([]entities.BulkResult, error) {
+	if entities.EmailTemplates[templateKey].Category != entities.CategoryMarketing {
+		return s.next.SendBulk(ctx, templateKey, recipients)
+	}
+	return sendEach(ctx, s, s.from, templateKey, recipients, newThrottle(s.sendRate))
+}
+
+// link returns the unsubscribe URL for the user and category, with its token in the query string.
+func (s *marketingEmailServiceImpl) link(userID, category string) (string, error) {
+	token, err := tokens.NewUnsubscribeToken(s.clock, userID, category, s.keyring, s.tokenExpiry)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(s.url)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	query.Set("token", token)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
@@ -2,14 +2,31 @@ package email
 
 import (
 	"bytes"
+	"context"
 	"fmt"
-	"text/template"
+	"html/template"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/npushpakumara/go-backend-template/internal/features/email/entities"
 )
 
-// ParseTemplate parses a template string and applies the provided data to it, returning the resulting string.
-// If there is an error during the parsing or execution of the template, it returns an empty string and the error.
-func ParseTemplate(templateString string, data interface{}) (string, error) {
-	tmpl, err := template.ParseFiles(fmt.Sprintf("internal/features/email/templates/%s", templateString))
+// templateDir is the directory that holds the email template files.
+const templateDir = "internal/features/email/templates"
+
+// localePattern restricts locales to BCP-47 style tags so they can be safely used in file names.
+var localePattern = regexp.MustCompile(`^[A-Za-z]{2,3}([-_][A-Za-z0-9]{2,8})*$`)
+
+// ParseTemplate resolves the template file for the given template name and locale, applies the provided data to it,
+// and returns the resulting string. If there is an error during the parsing or execution of the template,
+// it returns an empty string and the error. Templates are HTML, so the data is escaped for the context it
+// appears in, which keeps user-supplied values such as names from injecting markup.
+func ParseTemplate(templateName, locale string, data interface{}) (string, error) {
+	tmpl, err := template.ParseFiles(resolveTemplateFile(templateName, locale))
 	if err != nil {
 		return "", err
 	}
@@ -21,3 +38,70 @@ func ParseTemplate(templateString string, data interface{}) (string, error) {
 
 	return buf.String(), nil
 }
+
+// NewTemplatedEmail renders the email template registered under templateKey for the given locale
+// and returns an Email with the localized subject, ready to be passed to Service.SendEmail.
+func NewTemplatedEmail(templateKey, locale, from string, to []string, data interface{}) (*entities.Email, error) {
+	tmpl, ok := entities.EmailTemplates[templateKey]
+	if !ok {
+		return nil, fmt.Errorf("unknown email template %q", templateKey)
+	}
+
+	body, err := ParseTemplate(tmpl.Template, locale, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.Email{
+		To:       to,
+		From:     from,
+		Subject:  tmpl.Subject(baseLanguage(locale)),
+		Data:     body,
+		Category: tmpl.Category,
+		Template: templateKey,
+	}, nil
+}
+
+// resolveTemplateFile returns the most specific existing template file for the locale.
+// It tries the full locale ("es-MX"), then its base language ("es"), and finally the DefaultLocale.
+func resolveTemplateFile(templateName, locale string) string {
+	candidates := []string{entities.DefaultLocale}
+	if localePattern.MatchString(locale) {
+		candidates = []string{locale, baseLanguage(locale), entities.DefaultLocale}
+	}
+
+	for _, candidate := range candidates {
+		path := filepath.Join(templateDir, fmt.Sprintf("%s.%s.html", templateName, candidate))
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return filepath.Join(templateDir, fmt.Sprintf("%s.%s.html", templateName, entities.DefaultLocale))
+}
+
+// baseLanguage strips any region or script subtag from a locale, e.g. "es-MX" becomes "es".
+func baseLanguage(locale string) string {
+	if i := strings.IndexAny(locale, "-_"); i > 0 {
+		return strings.ToLower(locale[:i])
+	}
+	return strings.ToLower(locale)
+}
+
+// withSendTimeout bounds a single provider call by the configured send timeout.
+// A zero timeout leaves the caller's context unchanged.
+func withSendTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// formatAddress returns the address with the display name, e.g. "Example Team <no-reply@example.com>".
+// net/mail quotes names containing special characters such as commas and RFC 2047-encodes non-ASCII names.
+// Without a name the bare address is returned.
+func formatAddress(name, address string) string {
+	if name == "" {
+		return address
+	}
+	return (&mail.Address{Name: name, Address: address}).String()
+}
@@ -0,0 +1,56 @@
+package email
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestMain runs the tests from the module root, where the email templates are looked up.
+func TestMain(m *testing.M) {
+	if err := os.Chdir("../../.."); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// Names come from users and links carry query strings, so both are escaped for the HTML they appear in
+// rather than rendered as markup.
+func TestNewTemplatedEmailEscapesData(t *testing.T) {
+	data := map[string]string{
+		"Name": `<script>alert("hi")</script>`,
+		"Link": "https://app.example.com/verify?token=abc&next=/home",
+	}
+	escapedName := "&lt;script&gt;alert(&#34;hi&#34;)&lt;/script&gt;"
+	escapedLink := `href="https://app.example.com/verify?token=abc&amp;next=/home"`
+
+	tests := []struct {
+		name     string
+		template string
+		locale   string
+		want     []string
+	}{
+		{name: "verification", template: "UserVerification", locale: "en", want: []string{escapedName, escapedLink}},
+		{name: "verification in spanish", template: "UserVerification", locale: "es", want: []string{escapedName, escapedLink}},
+		{name: "invitation", template: "UserInvitation", locale: "en", want: []string{escapedLink}},
+		{name: "account deleted", template: "AccountDeleted", locale: "en", want: []string{escapedName}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			email, err := NewTemplatedEmail(tt.template, tt.locale, "noreply@example.com", []string{"ada@example.com"}, data)
+			if err != nil {
+				t.Fatalf("NewTemplatedEmail() error = %v", err)
+			}
+
+			if strings.Contains(email.Data, "<script>") {
+				t.Errorf("body contains an unescaped <script> tag:\n%s", email.Data)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(email.Data, want) {
+					t.Errorf("body doesn't contain %q:\n%s", want, email.Data)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,8 @@
+package dto
+
+// ListLoginHistoryRequestDto captures the query parameters of the login history endpoint.
+// Before is the next_cursor returned by the previous page; it is empty for the first page.
+type ListLoginHistoryRequestDto struct {
+	Before string `form:"before" binding:"omitempty,max=200"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
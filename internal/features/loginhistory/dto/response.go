@@ -0,0 +1,19 @@
+package dto
+
+import "time"
+
+// LoginEventResponseDto represents a single sign-in in the user's login history.
+type LoginEventResponseDto struct {
+	ID        string    `json:"id"`
+	Method    string    `json:"method"`
+	IPAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// LoginHistoryResponseDto is a page of login events, newest first.
+// NextCursor is empty when there are no older events.
+type LoginHistoryResponseDto struct {
+	Items      []LoginEventResponseDto `json:"items"`
+	NextCursor string                  `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,36 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Sign-in methods recorded with a login event.
+const (
+	MethodPassword = "password"
+)
+
+// LoginEvent records a successful sign-in by a user.
+// The history query is served by a composite index on (user_id, created_at, id) created during migration,
+// since created_at comes from the embedded gorm.Model and can't carry an index tag.
+type LoginEvent struct {
+	*gorm.Model
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null"`
+	Method    string    `gorm:"size:20;not null"`
+	IPAddress string    `gorm:"size:45"`
+	UserAgent string    `gorm:"size:255"`
+}
+
+// TableName overrides the default table name used by GORM for the LoginEvent model.
+func (LoginEvent) TableName() string {
+	return "login_events"
+}
+
+// BeforeCreate is a GORM hook that sets the ID field to a new UUID if it hasn't been set already.
+func (event *LoginEvent) BeforeCreate(tx *gorm.DB) (err error) {
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	return
+}
@@ -0,0 +1,70 @@
+package loginhistory
+
+import (
+	"errors"
+	"net/http"
+
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/npushpakumara/go-backend-template/internal/features/loginhistory/dto"
+	"github.com/npushpakumara/go-backend-template/internal/rbac"
+	"github.com/npushpakumara/go-backend-template/pkg"
+	"github.com/npushpakumara/go-backend-template/pkg/cursor"
+	apiError "github.com/npushpakumara/go-backend-template/pkg/errors"
+	"github.com/npushpakumara/go-backend-template/pkg/logging"
+)
+
+// Handler handles login history requests.
+type Handler struct {
+	loginHistoryService Service
+}
+
+// NewLoginHistoryHandler creates a new Handler instance with the provided Service.
+func NewLoginHistoryHandler(loginHistoryService Service) *Handler {
+	return &Handler{loginHistoryService}
+}
+
+// Router sets up the login history routes, which are only available to authenticated users.
+func Router(router *gin.Engine, handler *Handler, authMiddleware *jwt.GinJWTMiddleware) {
+	users := router.Group("api/v1/users")
+
+	users.Use(authMiddleware.MiddlewareFunc())
+	{
+		users.GET("/me/login-history", handler.listLoginHistory)
+	}
+}
+
+// listLoginHistory returns a page of the current user's sign-ins, newest first.
+// Pass the returned next_cursor as ?before= to fetch the next page.
+func (lh *Handler) listLoginHistory(ctx *gin.Context) {
+	logger := logging.FromContext(ctx)
+	var query dto.ListLoginHistoryRequestDto
+
+	if details, err := pkg.BindQuery(ctx, &query); err != nil {
+		logger.Errorw("loginhistory.handler.listLoginHistory failed to get query parameters: v", err)
+		ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "error", Message: "Invalid query parameters", Errors: details})
+		return
+	}
+
+	user, ok := rbac.CurrentIdentity(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, apiError.ErrorResponse{Status: "error", Message: "Unauthorized"})
+		return
+	}
+
+	resp, err := lh.loginHistoryService.List(ctx, user.ID, &query)
+	if err != nil {
+		if errors.Is(err, cursor.ErrInvalidCursor) {
+			ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{
+				Status:  "error",
+				Message: "Invalid query parameters",
+				Errors:  pkg.NewValidationErrorDetails("before", "must be a next_cursor returned by a previous page", query.Before),
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, apiError.ErrorResponse{Status: "error", Message: "Internal server error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,80 @@
+package loginhistory
+
+import (
+	"context"
+
+	"github.com/npushpakumara/go-backend-template/internal/features/loginhistory/entity"
+	"github.com/npushpakumara/go-backend-template/internal/postgres"
+	"github.com/npushpakumara/go-backend-template/pkg/cursor"
+	"github.com/npushpakumara/go-backend-template/pkg/logging"
+	"gorm.io/gorm"
+)
+
+// Repository defines the interface for login history data operations.
+type Repository interface {
+	// Insert adds a new login event to the database.
+	Insert(ctx context.Context, event *entity.LoginEvent) error
+
+	// FindByUser returns up to limit login events for the user, newest first.
+	// When before is set, only events strictly older than that position are returned.
+	FindByUser(ctx context.Context, userID string, before *cursor.Cursor, limit int) ([]entity.LoginEvent, error)
+
+	// DeleteByUser permanently removes every login event of the user.
+	DeleteByUser(ctx context.Context, userID string) error
+}
+
+// loginHistoryRepositoryImpl is a concrete implementation of the Repository interface.
+type loginHistoryRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewLoginHistoryRepository creates a new instance of loginHistoryRepositoryImpl with the provided database connection.
+func NewLoginHistoryRepository(db *gorm.DB) Repository {
+	return &loginHistoryRepositoryImpl{db}
+}
+
+// Insert adds a new login event to the database.
+func (lr *loginHistoryRepositoryImpl) Insert(ctx context.Context, event *entity.LoginEvent) error {
+	logger := logging.FromContext(ctx)
+	db := postgres.FromContext(ctx, lr.db)
+
+	logger.Debugw("loginhistory.db.Insert", "user", event.UserID)
+	if err := db.WithContext(ctx).Create(event).Error; err != nil {
+		logger.Errorw("loginhistory.db.Insert failed to save: %v", err)
+		return err
+	}
+	return nil
+}
+
+// FindByUser returns a page of the user's login events using keyset pagination on (created_at, id),
+// which matches the order of the idx_login_events_user_cursor index.
+func (lr *loginHistoryRepositoryImpl) FindByUser(ctx context.Context, userID string, before *cursor.Cursor, limit int) ([]entity.LoginEvent, error) {
+	logger := logging.FromContext(ctx)
+	db := postgres.FromContext(ctx, lr.db)
+
+	query := db.WithContext(ctx).Where("user_id = ?", userID)
+	if before != nil {
+		query = query.Where("(created_at, id) < (?, ?)", before.Time, before.ID)
+	}
+
+	var events []entity.LoginEvent
+	if err := query.Order("created_at DESC, id DESC").Limit(limit).Find(&events).Error; err != nil {
+		logger.Errorw("loginhistory.db.FindByUser failed to find login events: %v", err)
+		return nil, err
+	}
+	return events, nil
+}
+
+// DeleteByUser permanently removes the user's login events. Unscoped skips soft deletion,
+// since the IP addresses and user agents are personal data.
+func (lr *loginHistoryRepositoryImpl) DeleteByUser(ctx context.Context, userID string) error {
+	logger := logging.FromContext(ctx)
+	db := postgres.FromContext(ctx, lr.db)
+
+	logger.Debugw("loginhistory.db.DeleteByUser", "user", userID)
+	if err := db.WithContext(ctx).Unscoped().Where("user_id = ?", userID).Delete(&entity.LoginEvent{}).Error; err != nil {
+		logger.Errorw("loginhistory.db.DeleteByUser failed to delete login events: %v", err)
+		return err
+	}
+	return nil
+}
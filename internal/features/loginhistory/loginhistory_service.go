@@ -0,0 +1,150 @@
+package loginhistory
+
+import (
+	"context"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/npushpakumara/go-backend-template/internal/features/loginhistory/dto"
+	"github.com/npushpakumara/go-backend-template/internal/features/loginhistory/entity"
+	"github.com/npushpakumara/go-backend-template/pkg/cursor"
+)
+
+// Page sizes for listing login events. maxPageSize matches the limit accepted by the API.
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// Event describes a sign-in to be added to a user's login history.
+type Event struct {
+	UserID    string
+	Method    string
+	IPAddress string
+	UserAgent string
+}
+
+// Service defines the methods that the login history service implements.
+type Service interface {
+	// Record adds a sign-in to the user's login history.
+	Record(ctx context.Context, event Event) error
+
+	// List returns a page of the user's login history, newest first.
+	List(ctx context.Context, userID string, request *dto.ListLoginHistoryRequestDto) (*dto.LoginHistoryResponseDto, error)
+
+	// ListAll returns the user's entire login history, newest first, e.g. for a data export.
+	ListAll(ctx context.Context, userID string) ([]dto.LoginEventResponseDto, error)
+
+	// DeleteForUser permanently removes the user's login history.
+	DeleteForUser(ctx context.Context, userID string) error
+}
+
+// loginHistoryServiceImpl is a concrete implementation of the Service interface.
+type loginHistoryServiceImpl struct {
+	loginHistoryRepository Repository
+}
+
+// NewLoginHistoryService creates a new instance of loginHistoryServiceImpl with the provided Repository.
+func NewLoginHistoryService(loginHistoryRepository Repository) Service {
+	return &loginHistoryServiceImpl{loginHistoryRepository}
+}
+
+// Record stores the sign-in as a login event.
+func (ls *loginHistoryServiceImpl) Record(ctx context.Context, event Event) error {
+	userID, err := uuid.Parse(event.UserID)
+	if err != nil {
+		return err
+	}
+
+	return ls.loginHistoryRepository.Insert(ctx, &entity.LoginEvent{
+		UserID:    userID,
+		Method:    event.Method,
+		IPAddress: event.IPAddress,
+		UserAgent: truncate(event.UserAgent, 255),
+	})
+}
+
+// List decodes the cursor, fetches one event more than requested to learn whether another page
+// exists, and returns the cursor of the last event on this page as next_cursor.
+func (ls *loginHistoryServiceImpl) List(ctx context.Context, userID string, request *dto.ListLoginHistoryRequestDto) (*dto.LoginHistoryResponseDto, error) {
+	limit := request.Limit
+	if limit == 0 {
+		limit = defaultPageSize
+	}
+
+	var before *cursor.Cursor
+	if request.Before != "" {
+		c, err := cursor.Decode(request.Before)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := uuid.Parse(c.ID); err != nil {
+			return nil, cursor.ErrInvalidCursor
+		}
+		before = &c
+	}
+
+	events, err := ls.loginHistoryRepository.FindByUser(ctx, userID, before, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.LoginHistoryResponseDto{Items: make([]dto.LoginEventResponseDto, 0, min(len(events), limit))}
+	if len(events) > limit {
+		events = events[:limit]
+		last := events[len(events)-1]
+		resp.NextCursor = cursor.Encode(cursor.Cursor{Time: last.CreatedAt, ID: last.ID.String()})
+	}
+
+	for _, event := range events {
+		resp.Items = append(resp.Items, toLoginEventResponse(event))
+	}
+	return resp, nil
+}
+
+// ListAll pages through the user's whole login history.
+func (ls *loginHistoryServiceImpl) ListAll(ctx context.Context, userID string) ([]dto.LoginEventResponseDto, error) {
+	items := []dto.LoginEventResponseDto{}
+	var before *cursor.Cursor
+	for {
+		events, err := ls.loginHistoryRepository.FindByUser(ctx, userID, before, maxPageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range events {
+			items = append(items, toLoginEventResponse(event))
+		}
+		if len(events) < maxPageSize {
+			return items, nil
+		}
+		last := events[len(events)-1]
+		before = &cursor.Cursor{Time: last.CreatedAt, ID: last.ID.String()}
+	}
+}
+
+// DeleteForUser permanently removes the user's login history.
+func (ls *loginHistoryServiceImpl) DeleteForUser(ctx context.Context, userID string) error {
+	return ls.loginHistoryRepository.DeleteByUser(ctx, userID)
+}
+
+// toLoginEventResponse maps a login event entity to its response representation.
+func toLoginEventResponse(event entity.LoginEvent) dto.LoginEventResponseDto {
+	return dto.LoginEventResponseDto{
+		ID:        event.ID.String(),
+		Method:    event.Method,
+		IPAddress: event.IPAddress,
+		UserAgent: event.UserAgent,
+		CreatedAt: event.CreatedAt,
+	}
+}
+
+// truncate shortens s to at most n bytes without splitting a multi-byte character.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
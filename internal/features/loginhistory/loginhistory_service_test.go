@@ -0,0 +1,167 @@
+package loginhistory
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/npushpakumara/go-backend-template/internal/features/loginhistory/dto"
+	"github.com/npushpakumara/go-backend-template/internal/features/loginhistory/entity"
+	"github.com/npushpakumara/go-backend-template/pkg/cursor"
+	"gorm.io/gorm"
+)
+
+// memoryRepository is a Repository that keeps the login events in memory and pages them like Postgres,
+// on (created_at, id) descending.
+type memoryRepository struct {
+	mu     sync.Mutex
+	events []entity.LoginEvent
+}
+
+func (r *memoryRepository) Insert(_ context.Context, event *entity.LoginEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	r.events = append(r.events, *event)
+	return nil
+}
+
+func (r *memoryRepository) FindByUser(_ context.Context, userID string, before *cursor.Cursor, limit int) ([]entity.LoginEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var events []entity.LoginEvent
+	for _, event := range r.events {
+		if event.UserID.String() != userID {
+			continue
+		}
+		if before != nil && compareKey(event.CreatedAt, event.ID.String(), before.Time, before.ID) >= 0 {
+			continue
+		}
+		events = append(events, event)
+	}
+	slices.SortFunc(events, func(a, b entity.LoginEvent) int {
+		return compareKey(b.CreatedAt, b.ID.String(), a.CreatedAt, a.ID.String())
+	})
+	return events[:min(limit, len(events))], nil
+}
+
+func (r *memoryRepository) DeleteByUser(_ context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = slices.DeleteFunc(r.events, func(event entity.LoginEvent) bool { return event.UserID.String() == userID })
+	return nil
+}
+
+// compareKey compares two (created_at, id) keys as the row comparison in FindByUser does.
+func compareKey(aTime time.Time, aID string, bTime time.Time, bID string) int {
+	if c := aTime.Compare(bTime); c != 0 {
+		return c
+	}
+	return strings.Compare(aID, bID)
+}
+
+// insertEvents adds count sign-ins of the user, a minute apart, with every third one sharing
+// its predecessor's time so that pages must break ties on the ID.
+func insertEvents(t *testing.T, repo Repository, userID uuid.UUID, count int) {
+	t.Helper()
+
+	at := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < count; i++ {
+		if i%3 != 2 {
+			at = at.Add(time.Minute)
+		}
+		event := &entity.LoginEvent{Model: &gorm.Model{CreatedAt: at}, UserID: userID, Method: entity.MethodPassword}
+		if err := repo.Insert(context.Background(), event); err != nil {
+			t.Fatalf("Insert() error = %v", err)
+		}
+	}
+}
+
+func TestListPagesAreOrderedAndDisjoint(t *testing.T) {
+	repo := &memoryRepository{}
+	service := NewLoginHistoryService(repo)
+	userID := uuid.New()
+	insertEvents(t, repo, userID, 23)
+	insertEvents(t, repo, uuid.New(), 5)
+
+	var (
+		items []dto.LoginEventResponseDto
+		pages int
+		seen  = map[string]bool{}
+	)
+	request := &dto.ListLoginHistoryRequestDto{Limit: 5}
+	for {
+		page, err := service.List(context.Background(), userID.String(), request)
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		pages++
+		if len(page.Items) > request.Limit {
+			t.Fatalf("page %d has %d items, want at most %d", pages, len(page.Items), request.Limit)
+		}
+		for _, item := range page.Items {
+			if seen[item.ID] {
+				t.Errorf("event %s appears on more than one page", item.ID)
+			}
+			seen[item.ID] = true
+		}
+		items = append(items, page.Items...)
+		if page.NextCursor == "" {
+			break
+		}
+		request.Before = page.NextCursor
+	}
+
+	if pages != 5 || len(items) != 23 {
+		t.Errorf("listed %d events on %d pages, want 23 on 5", len(items), pages)
+	}
+	for i := 1; i < len(items); i++ {
+		if compareKey(items[i-1].CreatedAt, items[i-1].ID, items[i].CreatedAt, items[i].ID) <= 0 {
+			t.Errorf("event %d (%s, %s) isn't older than event %d (%s, %s)",
+				i, items[i].CreatedAt, items[i].ID, i-1, items[i-1].CreatedAt, items[i-1].ID)
+		}
+	}
+
+	// ListAll returns the same events in the same order.
+	all, err := service.ListAll(context.Background(), userID.String())
+	if err != nil {
+		t.Fatalf("ListAll() error = %v", err)
+	}
+	if !slices.EqualFunc(all, items, func(a, b dto.LoginEventResponseDto) bool { return a.ID == b.ID }) {
+		t.Error("ListAll() differs from the listed pages")
+	}
+}
+
+func TestListLastPageHasNoCursor(t *testing.T) {
+	repo := &memoryRepository{}
+	service := NewLoginHistoryService(repo)
+	userID := uuid.New()
+	insertEvents(t, repo, userID, 5)
+
+	page, err := service.List(context.Background(), userID.String(), &dto.ListLoginHistoryRequestDto{Limit: 5})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(page.Items) != 5 || page.NextCursor != "" {
+		t.Errorf("List() = %d items with cursor %q, want 5 and no cursor", len(page.Items), page.NextCursor)
+	}
+}
+
+func TestListRejectsInvalidCursor(t *testing.T) {
+	service := NewLoginHistoryService(&memoryRepository{})
+
+	for _, before := range []string{"not-a-cursor", cursor.Encode(cursor.Cursor{Time: time.Now(), ID: "not-a-uuid"})} {
+		_, err := service.List(context.Background(), uuid.New().String(), &dto.ListLoginHistoryRequestDto{Before: before})
+		if !errors.Is(err, cursor.ErrInvalidCursor) {
+			t.Errorf("List(before=%q) error = %v, want ErrInvalidCursor", before, err)
+		}
+	}
+}
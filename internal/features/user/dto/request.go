@@ -11,4 +11,44 @@ type RegisterRequestDto struct {
 	PhoneNumber string
 	Provider    string
 	ProviderID  string
+	Locale      string
+	// Username is optional and must already be lowercase.
+	Username string
+	// Role defaults to the regular user role when empty.
+	Role string
+}
+
+// ProfileUpdate lists the profile fields a user can change about themselves.
+// Nil fields are left untouched.
+type ProfileUpdate struct {
+	FirstName   *string
+	LastName    *string
+	PhoneNumber *string
+	Locale      *string
+	Timezone    *string
+}
+
+// UpdateStatusRequestDto is a data transfer object used by administrators
+// to suspend a user's account or reactivate a suspended one.
+type UpdateStatusRequestDto struct {
+	Status string `json:"status" binding:"required,oneof=active suspended"`
+}
+
+// ActivateUsersRequestDto captures an administrator's request to activate several users at once,
+// named by ID, by email or both. IDs aren't checked here, so a malformed one is reported in its own result
+// rather than failing the whole request.
+type ActivateUsersRequestDto struct {
+	IDs    []string `json:"ids" binding:"required_without=Emails,max=100"`
+	Emails []string `json:"emails" binding:"required_without=IDs,max=100,dive,max=320"`
+}
+
+// ListUsersRequestDto captures the query parameters accepted by the admin user list.
+// Search matches first name, last name or email; results are paged with Page starting at 1.
+type ListUsersRequestDto struct {
+	Search  string `form:"search" binding:"omitempty,max=100"`
+	Status  string `form:"status" binding:"omitempty,oneof=pending active suspended banned"`
+	SortBy  string `form:"sort_by" binding:"omitempty,oneof=created_at email"`
+	SortDir string `form:"sort_dir" binding:"omitempty,oneof=asc desc"`
+	Page    int    `form:"page" binding:"omitempty,min=1"`
+	Size    int    `form:"size" binding:"omitempty,min=1,max=100"`
 }
@@ -9,11 +9,75 @@ type UserResponseDto struct {
 	FirstName   string
 	LastName    string
 	Email       string
+	Username    string
 	Password    string
 	PhoneNumber string
 	IsActive    bool
+	Status      string
 	Provider    string
 	ProviderID  string
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	Role        string
+	Locale      string
+	Timezone    string
+	// OrgID is the user's organization, empty for users outside any organization.
+	OrgID     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	SessionsRevokedAt *time.Time
+}
+
+// UserSummaryResponseDto is the public representation of a user returned by the admin user list.
+// Unlike UserResponseDto it never carries the password hash.
+type UserSummaryResponseDto struct {
+	ID        string    `json:"id"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+	Email     string    `json:"email"`
+	Status    string    `json:"status"`
+	Role      string    `json:"role"`
+	Provider  string    `json:"provider,omitempty"`
+	OrgID     string    `json:"org_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OrganizationResponseDto represents an organization shared with other features.
+type OrganizationResponseDto struct {
+	ID        string
+	Name      string
+	OwnerID   string
+	CreatedAt time.Time
+}
+
+// UserListResponseDto is a page of users together with the total number of users matching the filter.
+type UserListResponseDto struct {
+	Items []UserSummaryResponseDto `json:"items"`
+	Total int64                    `json:"total"`
+	Page  int                      `json:"page"`
+	Size  int                      `json:"size"`
+}
+
+// Outcomes of activating a single user.
+const (
+	ActivationStatusActivated     = "activated"
+	ActivationStatusAlreadyActive = "already_active"
+	ActivationStatusNotFound      = "not_found"
+	ActivationStatusInvalidID     = "invalid_id"
+	ActivationStatusSkipped       = "skipped"
+)
+
+// ActivationResultDto reports what happened to one user of a batch activation. ID or Email echoes
+// how the user was named in the request, and UserID is set whenever the user was found.
+// Error explains why the user was skipped.
+type ActivationResultDto struct {
+	ID     string `json:"id,omitempty"`
+	Email  string `json:"email,omitempty"`
+	Status string `json:"status"`
+	UserID string `json:"user_id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ActivateUsersResponseDto lists the outcome for every ID and then every email, in request order.
+type ActivateUsersResponseDto struct {
+	Results []ActivationResultDto `json:"results"`
 }
@@ -0,0 +1,29 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// NotificationPreference records whether a user receives the emails of a category, such as marketing.
+// Users without a preference for a category receive its emails.
+type NotificationPreference struct {
+	*gorm.Model
+	ID       uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_notification_preferences_user_category"`
+	Category string    `gorm:"size:30;not null;uniqueIndex:idx_notification_preferences_user_category"`
+	Enabled  bool      `gorm:"not null"`
+}
+
+// TableName overrides the default table name used by GORM for the NotificationPreference model.
+func (NotificationPreference) TableName() string {
+	return "notification_preferences"
+}
+
+// BeforeCreate is a GORM hook that sets the ID field to a new UUID if it hasn't been set already.
+func (preference *NotificationPreference) BeforeCreate(tx *gorm.DB) (err error) {
+	if preference.ID == uuid.Nil {
+		preference.ID = uuid.New()
+	}
+	return
+}
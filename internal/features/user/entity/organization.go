@@ -0,0 +1,29 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Organization groups the users of one customer. Requests made by a member of an organization
+// only see the users of that organization.
+type Organization struct {
+	*gorm.Model
+	ID   uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Name string    `gorm:"size:100;not null"`
+	// OwnerID is the user who created the organization.
+	OwnerID uuid.UUID `gorm:"type:uuid;not null;index"`
+}
+
+// TableName overrides the default table name used by GORM for the Organization model.
+func (Organization) TableName() string {
+	return "organizations"
+}
+
+// BeforeCreate is a GORM hook that sets the ID field to a new UUID if it hasn't been set already.
+func (organization *Organization) BeforeCreate(tx *gorm.DB) (err error) {
+	if organization.ID == uuid.Nil {
+		organization.ID = uuid.New()
+	}
+	return
+}
@@ -0,0 +1,28 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PasswordHistory records a password hash a user has replaced, so it can't be chosen again
+// while it is among the user's most recent passwords.
+type PasswordHistory struct {
+	*gorm.Model
+	ID       uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID   uuid.UUID `gorm:"type:uuid;not null;index"`
+	Password string    `gorm:"size:255;not null"`
+}
+
+// TableName overrides the default table name used by GORM for the PasswordHistory model.
+func (PasswordHistory) TableName() string {
+	return "password_history"
+}
+
+// BeforeCreate is a GORM hook that sets the ID field to a new UUID if it hasn't been set already.
+func (history *PasswordHistory) BeforeCreate(tx *gorm.DB) (err error) {
+	if history.ID == uuid.Nil {
+		history.ID = uuid.New()
+	}
+	return
+}
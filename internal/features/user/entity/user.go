@@ -1,28 +1,67 @@
 package entity
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// Roles that can be assigned to a user.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// DefaultLocale is the preferred language assigned to users who haven't chosen one.
+const DefaultLocale = "en"
+
+// DefaultTimezone is the IANA time zone assigned to users who haven't chosen one.
+const DefaultTimezone = "UTC"
+
+// Statuses a user account can be in.
+// New password-based accounts start as pending until their email is verified.
+const (
+	StatusPending   = "pending"
+	StatusActive    = "active"
+	StatusSuspended = "suspended"
+	StatusBanned    = "banned"
+)
+
 // User represents a user in the system.
 // The struct fields are annotated with GORM tags to specify database constraints.
 type User struct {
 	*gorm.Model
-	ID          uuid.UUID `gorm:"type:uuid;primaryKey"`
-	FirstName   string    `gorm:"size:100;not null"`
-	LastName    string    `gorm:"size:100"`
-	Email       string    `gorm:"size:100;unique;not null"`
-	Password    string    `gorm:"size:255"`
-	PhoneNumber string    `gorm:"size:20"`
-	IsActive    bool      `gorm:"type:boolean"`
-	Provider    string    `gorm:"size:20"`
-	ProviderID  string    `gorm:"size:100"`
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	FirstName string    `gorm:"size:100;not null"`
+	LastName  string    `gorm:"size:100"`
+	Email     string    `gorm:"size:100;unique;not null"`
+	// Username is an optional sign-in name, stored in lowercase. NULL for users without one.
+	Username    *string `gorm:"size:30;uniqueIndex"`
+	Password    string  `gorm:"size:255"`
+	PhoneNumber string  `gorm:"size:20"`
+	Status      string  `gorm:"size:20;not null;default:pending;index"`
+	Provider    string  `gorm:"size:20"`
+	ProviderID  string  `gorm:"size:100"`
+	Role        string  `gorm:"size:20;not null;default:user"`
+	Locale      string  `gorm:"size:35;not null;default:en"`
+	Timezone    string  `gorm:"size:64;not null;default:UTC"`
+	// SessionsRevokedAt invalidates every access token issued before it.
+	SessionsRevokedAt *time.Time
+	// OrgID is the organization the user belongs to. NULL for users outside any organization.
+	OrgID        *uuid.UUID    `gorm:"type:uuid;index"`
+	Organization *Organization `gorm:"foreignKey:OrgID;constraint:OnDelete:SET NULL"`
 }
 
 // TableName overrides the default table name used by GORM for the User model.
 func (User) TableName() string {
-	return "auc.users"
+	return "users"
+}
+
+// IsActive reports whether the user's account is active.
+// It is kept as a helper for code written against the former is_active column.
+func (user *User) IsActive() bool {
+	return user.Status == StatusActive
 }
 
 // BeforeCreate is a GORM hook that is triggered before a new record is created in the database.
@@ -0,0 +1,9 @@
+package user
+
+import "context"
+
+// UpdateColumns runs the unexported update of the Postgres repository, so the external tests can check
+// the columns it refuses.
+func UpdateColumns(ctx context.Context, repo Repository, id string, updates map[string]interface{}) error {
+	return repo.(*userRepositoryImpl).update(ctx, id, updates)
+}
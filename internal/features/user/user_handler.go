@@ -1,39 +1,213 @@
 package user
 
 import (
+	"errors"
 	"net/http"
 
 	jwt "github.com/appleboy/gin-jwt/v2"
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
 	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/internal/features/apikey"
+	"github.com/npushpakumara/go-backend-template/internal/features/audit"
+	auditEntity "github.com/npushpakumara/go-backend-template/internal/features/audit/entity"
+	"github.com/npushpakumara/go-backend-template/internal/features/user/dto"
+	"github.com/npushpakumara/go-backend-template/internal/features/user/entity"
+	"github.com/npushpakumara/go-backend-template/internal/postgres"
+	"github.com/npushpakumara/go-backend-template/internal/rbac"
+	"github.com/npushpakumara/go-backend-template/pkg"
+	apiError "github.com/npushpakumara/go-backend-template/pkg/errors"
+	"github.com/npushpakumara/go-backend-template/pkg/logging"
 )
 
 // Handler struct represents the HTTP handler for user-related operations.
 // It contains a reference to the userService which handles the business logic.
 type Handler struct {
-	userService Service
+	userService  Service
+	auditService audit.Service
 }
 
 // NewUserHandler creates a new Handler instance with the provided userService.
 // It returns a pointer to the Handler struct.
-func NewUserHandler(userService Service) *Handler {
-	return &Handler{userService}
+func NewUserHandler(userService Service, auditService audit.Service) *Handler {
+	return &Handler{userService, auditService}
 }
 
 // Router sets up the routes for the user-related API endpoints.
 // It takes in the application configuration, the Gin router instance, the handler for user operations,
-// and the authentication middleware to secure the endpoints.
-func Router(configs *config.Config, router *gin.Engine, handler *Handler, authMiddleware *jwt.GinJWTMiddleware) {
+// and the authentication middlewares to secure the endpoints. The user list accepts either a user session
+// or an API key with the users:read scope, while the admin routes need an administrator's session.
+func Router(configs *config.Config, router *gin.Engine, handler *Handler, authMiddleware *jwt.GinJWTMiddleware, apiKeyMiddleware *apikey.Middleware) {
 	v1 := router.Group("api/v1")
 
-	v1.Use(authMiddleware.MiddlewareFunc())
+	v1.Use(apiKeyMiddleware.MiddlewareFunc(), apikey.RequireScope(apikey.ScopeUsersRead))
 	{
 		v1.GET("/users", handler.getAllUsers)
 	}
 
+	admin := router.Group("api/v1/admin")
+
+	admin.Use(authMiddleware.MiddlewareFunc(), rbac.RequireRole(entity.RoleAdmin))
+	{
+		admin.GET("/users", handler.listUsers)
+		admin.POST("/users/activate", handler.activateUsers)
+		admin.PATCH("/users/:id/status", pkg.RequireUUIDParams("id"), handler.updateStatus)
+		admin.POST("/users/:id/suspend", pkg.RequireUUIDParams("id"), handler.suspendUser)
+		admin.POST("/users/:id/reactivate", pkg.RequireUUIDParams("id"), handler.reactivateUser)
+	}
 }
 
 // getAllUsers is a handler method for the Handler struct.
 func (uh *Handler) getAllUsers(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, "ok")
 }
+
+// listUsers handles an administrator's request for a filtered, sorted page of users.
+func (uh *Handler) listUsers(ctx *gin.Context) {
+	logger := logging.FromContext(ctx)
+	var request dto.ListUsersRequestDto
+
+	if details, err := pkg.BindQuery(ctx, &request); err != nil {
+		logger.Errorw("user.handler.listUsers failed to get query parameters: v", err)
+		ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "error", Message: "Invalid query parameters", Errors: details})
+		return
+	}
+
+	resp, err := uh.userService.ListUsers(ctx, &request)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, apiError.ErrorResponse{Status: "error", Message: "Internal server error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// updateStatus handles an administrator's request to change a user's account status. It is the same as
+// suspendUser or reactivateUser, depending on the status asked for, and is written to the audit log likewise.
+func (uh *Handler) updateStatus(ctx *gin.Context) {
+	logger := logging.FromContext(ctx)
+	var requestBody dto.UpdateStatusRequestDto
+
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		logger.Errorw("user.handler.updateStatus failed to get request body: v", err)
+		var details []*pkg.ValidationErrDetail
+		if vErrs, ok := err.(validator.ValidationErrors); ok {
+			details = pkg.ValidationErrorDetails(&requestBody, "json", vErrs)
+		}
+		ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "error", Message: "Invalid request body", Errors: details})
+		return
+	}
+
+	userID := ctx.Param("id")
+
+	err := uh.userService.UpdateStatus(ctx, userID, requestBody.Status)
+	if err != nil {
+		respondWithStatusError(ctx, err)
+		return
+	}
+
+	action := auditEntity.ActionUserReactivated
+	if requestBody.Status == entity.StatusSuspended {
+		action = auditEntity.ActionUserSuspended
+	}
+	uh.recordAudit(ctx, action, userID)
+	ctx.JSON(http.StatusOK, apiError.ErrorResponse{Status: "success", Message: "User status updated"})
+}
+
+// suspendUser handles an administrator's request to suspend a user.
+// Suspension revokes the user's existing sessions and is written to the audit log.
+// Only active users can be suspended; any other status is reported as a conflict.
+func (uh *Handler) suspendUser(ctx *gin.Context) {
+	userID := ctx.Param("id")
+
+	err := uh.userService.SuspendUser(ctx, userID)
+	if err != nil {
+		respondWithStatusError(ctx, err)
+		return
+	}
+
+	uh.recordAudit(ctx, auditEntity.ActionUserSuspended, userID)
+	ctx.JSON(http.StatusOK, apiError.ErrorResponse{Status: "success", Message: "User suspended"})
+}
+
+// reactivateUser handles an administrator's request to lift a user's suspension.
+func (uh *Handler) reactivateUser(ctx *gin.Context) {
+	userID := ctx.Param("id")
+
+	err := uh.userService.ReactivateUser(ctx, userID)
+	if err != nil {
+		respondWithStatusError(ctx, err)
+		return
+	}
+
+	uh.recordAudit(ctx, auditEntity.ActionUserReactivated, userID)
+	ctx.JSON(http.StatusOK, apiError.ErrorResponse{Status: "success", Message: "User reactivated"})
+}
+
+// respondWithStatusError maps the errors of suspending or reactivating a user to an HTTP response.
+// Transitions the user's current status doesn't allow are reported as a conflict.
+func respondWithStatusError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, postgres.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, apiError.ErrorResponse{Status: "error", Message: "User not found"})
+	case errors.Is(err, apiError.ErrAccountBanned):
+		ctx.JSON(http.StatusConflict, apiError.ErrorResponse{Status: "error", Message: "User is banned"})
+	case errors.Is(err, apiError.ErrAccountSuspended):
+		ctx.JSON(http.StatusConflict, apiError.ErrorResponse{Status: "error", Message: "User is already suspended"})
+	case errors.Is(err, apiError.ErrAccountNotActive):
+		ctx.JSON(http.StatusConflict, apiError.ErrorResponse{Status: "error", Message: "User is not active"})
+	case errors.Is(err, apiError.ErrUserNotSuspended):
+		ctx.JSON(http.StatusConflict, apiError.ErrorResponse{Status: "error", Message: "User is not suspended"})
+	case errors.Is(err, apiError.ErrStatusChangeNotAllowed):
+		ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "error", Message: "Status change not allowed"})
+	default:
+		ctx.JSON(http.StatusInternalServerError, apiError.ErrorResponse{Status: "error", Message: "Internal server error"})
+	}
+}
+
+// activateUsers handles an administrator's request to activate a list of users without email verification,
+// such as users imported from another system. It always answers 200 with the outcome for each user,
+// since some may be activated while others are skipped. Every activation is written to the audit log.
+func (uh *Handler) activateUsers(ctx *gin.Context) {
+	logger := logging.FromContext(ctx)
+	var requestBody dto.ActivateUsersRequestDto
+
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		logger.Errorw("user.handler.activateUsers failed to get request body: v", err)
+		var details []*pkg.ValidationErrDetail
+		if vErrs, ok := err.(validator.ValidationErrors); ok {
+			details = pkg.ValidationErrorDetails(&requestBody, "json", vErrs)
+		}
+		ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "error", Message: "Invalid request body", Errors: details})
+		return
+	}
+
+	resp, err := uh.userService.ActivateUsers(ctx, &requestBody)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, apiError.ErrorResponse{Status: "error", Message: "Internal server error"})
+		return
+	}
+
+	for _, result := range resp.Results {
+		if result.Status == dto.ActivationStatusActivated {
+			uh.recordAudit(ctx, auditEntity.ActionUserActivated, result.UserID)
+		}
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// recordAudit writes an audit entry for an action the calling administrator performed on the target user.
+// Failing to write the entry is logged but does not fail the request, since the action has already been applied.
+func (uh *Handler) recordAudit(ctx *gin.Context, action, targetID string) {
+	identity, _ := rbac.CurrentIdentity(ctx)
+
+	err := uh.auditService.Record(ctx, audit.Entry{
+		ActorID:   identity.ID,
+		Action:    action,
+		TargetID:  targetID,
+		IPAddress: ctx.ClientIP(),
+	})
+	if err != nil {
+		logging.FromContext(ctx).Errorw("user.handler.recordAudit failed to record audit entry", "action", action, "err", err)
+	}
+}
@@ -3,33 +3,146 @@ package user
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/npushpakumara/go-backend-template/internal/features/user/dto"
 	"github.com/npushpakumara/go-backend-template/internal/features/user/entity"
 	"github.com/npushpakumara/go-backend-template/internal/postgres"
+	"github.com/npushpakumara/go-backend-template/internal/rbac"
 	"github.com/npushpakumara/go-backend-template/pkg/logging"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
 // Repository defines the interface for user-related data operations.
+// When the context is scoped to an organization (see rbac.SetOrg), lookups, updates and deletes only
+// match the organization's users, and inserted users join it. An unscoped context matches the users of
+// every organization: that of background work, sign-in, and requests of users outside any organization,
+// which makes an administrator without an organization a platform administrator.
 type Repository interface {
 	// Insert adds a new user to the database.
-	// It returns the inserted user and an error if something goes wrong.
+	// It returns the inserted user and an error if something goes wrong, which is a *postgres.DuplicateKeyError
+	// naming the violated index when the email or username is already used.
 	Insert(ctx context.Context, user *entity.User) (*entity.User, error)
 
 	// FindByEmail retrieves a user by their email address.
 	// It returns the user if found or an error if something goes wrong or the user does not exist.
 	FindByEmail(ctx context.Context, email string) (*entity.User, error)
 
+	// FindByUsername retrieves a user by their username.
+	// It returns the user if found or an error if something goes wrong or the user does not exist.
+	FindByUsername(ctx context.Context, username string) (*entity.User, error)
+
 	// FindByID retrieves a user by their unique identifier (ID).
 	// It returns the user if found or an error if something goes wrong or the user does not exist.
 	FindByID(ctx context.Context, id string) (*entity.User, error)
 
-	// Update modifies the details of an existing user identified by ID.
-	// It takes a map of field names and values to update and returns an error if the update fails.
-	Update(ctx context.Context, id string, updates map[string]interface{}) error
+	// FindByIDs retrieves the users with the given IDs in a single query, keyed by ID.
+	// IDs that don't match a user are absent from the map rather than reported as an error.
+	FindByIDs(ctx context.Context, ids []string) (map[string]*entity.User, error)
+
+	// SetStatus moves the user's account into the given status.
+	SetStatus(ctx context.Context, id string, status string) error
+
+	// Suspend moves the user's account to suspended and revokes every session issued before revokedAt.
+	Suspend(ctx context.Context, id string, revokedAt time.Time) error
+
+	// SetPassword stores the password hash and revokes every session issued before revokedAt.
+	SetPassword(ctx context.Context, id string, hash string, revokedAt time.Time) error
+
+	// UpdateProfile writes the profile fields set in the update, leaving nil ones untouched.
+	// An update without any field is a no-op.
+	UpdateProfile(ctx context.Context, id string, update dto.ProfileUpdate) error
+
+	// LinkProvider records the OAuth provider the user signs in with and their ID there.
+	LinkProvider(ctx context.Context, id string, provider string, providerID string) error
+
+	// ErasePersonalData replaces the user's name, email, password, phone number and provider ID with
+	// placeholders, storing email as the new address, and revokes every session issued before revokedAt.
+	ErasePersonalData(ctx context.Context, id string, email string, revokedAt time.Time) error
+
+	// SetOrganization makes the user a member of the organization.
+	SetOrganization(ctx context.Context, id string, orgID uuid.UUID) error
+
+	// ActivateInvitation writes the profile fields set in the update and the password hash and activates the
+	// account, only if it is still a pending invitation: pending, with neither a password nor an OAuth identity.
+	// It reports whether it did, so an invitation accepted twice at once is only accepted once.
+	ActivateInvitation(ctx context.Context, id string, profile dto.ProfileUpdate, hash string) (bool, error)
+
+	// ReplacePasswordHash stores newHash as the user's password only if the stored hash is still oldHash,
+	// and reports whether it did, so a hash computed from a password that has since changed is dropped.
+	ReplacePasswordHash(ctx context.Context, id string, oldHash string, newHash string) (bool, error)
+
+	// FindAll returns one page of users matching the filter and the total number of matching users.
+	FindAll(ctx context.Context, filter ListFilter) ([]entity.User, int64, error)
+
+	// Delete soft-deletes the user identified by ID so it is no longer returned by any lookup.
+	Delete(ctx context.Context, id string) error
+
+	// InsertPasswordHistory records a password hash the user has replaced.
+	InsertPasswordHistory(ctx context.Context, history *entity.PasswordHistory) error
+
+	// FindPasswordHistory returns up to limit of the user's replaced password hashes, most recent first.
+	FindPasswordHistory(ctx context.Context, userID string, limit int) ([]entity.PasswordHistory, error)
+
+	// PrunePasswordHistory permanently deletes all but the keep most recent replaced password hashes of the user.
+	PrunePasswordHistory(ctx context.Context, userID string, keep int) error
+
+	// SaveNotificationPreference creates or replaces the user's preference for the category.
+	SaveNotificationPreference(ctx context.Context, preference *entity.NotificationPreference) error
+
+	// FindNotificationPreference returns the user's preference for the category,
+	// or ErrRecordNotFound when the user hasn't set one.
+	FindNotificationPreference(ctx context.Context, userID string, category string) (*entity.NotificationPreference, error)
+
+	// InsertOrganization adds a new organization to the database.
+	InsertOrganization(ctx context.Context, organization *entity.Organization) error
+}
+
+// ListFilter narrows, orders and pages the users returned by FindAll.
+// SortBy must be one of the keys of sortableColumns; anything else falls back to created_at.
+type ListFilter struct {
+	Search  string
+	Status  string
+	SortBy  string
+	SortDir string
+	Page    int
+	Size    int
+}
+
+// sortableColumns maps the sort keys accepted from clients to database columns.
+// Only these columns may be used in ORDER BY, so client input never reaches the query as SQL.
+var sortableColumns = map[string]string{
+	"created_at": "created_at",
+	"email":      "email",
 }
 
+// ErrColumnNotUpdatable is returned when an update names a column outside updatableColumns.
+var ErrColumnNotUpdatable = errors.New("user column can't be updated")
+
+// updatableColumns are the only columns update may write, so a misspelt key in one of the typed update
+// methods fails loudly instead of silently updating nothing or the wrong column. org_id isn't one of them:
+// only SetOrganization moves a user into an organization.
+var updatableColumns = map[string]struct{}{
+	"first_name":          {},
+	"last_name":           {},
+	"email":               {},
+	"password":            {},
+	"phone_number":        {},
+	"status":              {},
+	"provider":            {},
+	"provider_id":         {},
+	"locale":              {},
+	"timezone":            {},
+	"sessions_revoked_at": {},
+}
+
+// likeEscaper escapes the LIKE wildcards in a search term so they match literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 // userRepositoryImpl is a concrete implementation of the Repository interface.
 type userRepositoryImpl struct {
 	db *gorm.DB
@@ -40,17 +153,35 @@ func NewUserRepository(db *gorm.DB) Repository {
 	return &userRepositoryImpl{db}
 }
 
+// scoped returns the database handle for ctx, limited to the users of the organization ctx is scoped to, if any.
+func (us *userRepositoryImpl) scoped(ctx context.Context) *gorm.DB {
+	db := postgres.FromContext(ctx, us.db).WithContext(ctx)
+	if orgID, ok := rbac.CurrentOrg(ctx); ok {
+		db = db.Where("users.org_id = ?", orgID)
+	}
+	return db
+}
+
 // Insert adds a new user to the database.
 // It logs the operation and handles potential errors, including checking for duplicate entries.
+// A user without an organization joins the one ctx is scoped to.
 func (us *userRepositoryImpl) Insert(ctx context.Context, user *entity.User) (*entity.User, error) {
 	logger := logging.FromContext(ctx)
 	db := postgres.FromContext(ctx, us.db)
 
+	if orgID, ok := rbac.CurrentOrg(ctx); ok && user.OrgID == nil {
+		parsed, err := uuid.Parse(orgID)
+		if err != nil {
+			return nil, err
+		}
+		user.OrgID = &parsed
+	}
+
 	logger.Debugw("user.db.Insert", "user", user)
 	if err := db.WithContext(ctx).Create(user).Error; err != nil {
 		if pgErr := postgres.IsPgxError(err); errors.Is(pgErr, postgres.ErrKeyDuplicate) {
 			logger.Warn("user.db.Insert user already exists")
-			return nil, postgres.ErrKeyDuplicate
+			return nil, postgres.NewDuplicateKeyError(err)
 		}
 		logger.Errorw("user.db.Insert failed to save: %v", err)
 		return nil, err
@@ -62,12 +193,11 @@ func (us *userRepositoryImpl) Insert(ctx context.Context, user *entity.User) (*e
 // It logs the search operation and handles errors, including the case where the user is not found.
 func (us *userRepositoryImpl) FindByEmail(ctx context.Context, email string) (*entity.User, error) {
 	logger := logging.FromContext(ctx)
-	db := postgres.FromContext(ctx, us.db)
 
 	logger.Debugw("user.db.FindByEmail", "email", email)
 
 	var user entity.User
-	if err := db.WithContext(ctx).First(&user, "email = ?", email).Error; err != nil {
+	if err := us.scoped(ctx).First(&user, "email = ?", email).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			logger.Warn("user.db.FindByEmail user not found")
 			return nil, postgres.ErrRecordNotFound
@@ -78,16 +208,34 @@ func (us *userRepositoryImpl) FindByEmail(ctx context.Context, email string) (*e
 	return &user, nil
 }
 
+// FindByUsername searches for a user based on their username.
+// It logs the search operation and handles errors, including the case where the user is not found.
+func (us *userRepositoryImpl) FindByUsername(ctx context.Context, username string) (*entity.User, error) {
+	logger := logging.FromContext(ctx)
+
+	logger.Debugw("user.db.FindByUsername", "username", username)
+
+	var user entity.User
+	if err := us.scoped(ctx).First(&user, "username = ?", username).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Warn("user.db.FindByUsername user not found")
+			return nil, postgres.ErrRecordNotFound
+		}
+		logger.Errorw("user.db.FindByUsername failed to find user: %v", err)
+		return nil, err
+	}
+	return &user, nil
+}
+
 // FindByID retrieves a user based on their ID.
 // It logs the search operation and handles errors, including the case where the user is not found.
 func (us *userRepositoryImpl) FindByID(ctx context.Context, id string) (*entity.User, error) {
 	logger := logging.FromContext(ctx)
-	db := postgres.FromContext(ctx, us.db)
 
 	logger.Debugw("user.db.FindByID", "id", id)
 
 	var user entity.User
-	if err := db.WithContext(ctx).First(&user, "id = ?", id).Error; err != nil {
+	if err := us.scoped(ctx).First(&user, "id = ?", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			logger.Warn("user.db.FindByID user not found")
 			return nil, postgres.ErrRecordNotFound
@@ -98,23 +246,349 @@ func (us *userRepositoryImpl) FindByID(ctx context.Context, id string) (*entity.
 	return &user, nil
 }
 
-// Update modifies an existing user's details based on their ID.
+// FindByIDs retrieves the users with the given IDs with one WHERE id IN query.
+// An empty list returns an empty map without querying the database.
+func (us *userRepositoryImpl) FindByIDs(ctx context.Context, ids []string) (map[string]*entity.User, error) {
+	logger := logging.FromContext(ctx)
+
+	logger.Debugw("user.db.FindByIDs", "ids", ids)
+
+	found := make(map[string]*entity.User, len(ids))
+	if len(ids) == 0 {
+		return found, nil
+	}
+
+	var users []entity.User
+	if err := us.scoped(ctx).Where("id IN ?", ids).Find(&users).Error; err != nil {
+		logger.Errorw("user.db.FindByIDs failed to find users: %v", err)
+		return nil, err
+	}
+
+	for i := range users {
+		found[users[i].ID.String()] = &users[i]
+	}
+	return found, nil
+}
+
+// SetStatus writes the status column.
+func (us *userRepositoryImpl) SetStatus(ctx context.Context, id string, status string) error {
+	return us.update(ctx, id, map[string]interface{}{"status": status})
+}
+
+// Suspend writes the status and sessions_revoked_at columns together.
+func (us *userRepositoryImpl) Suspend(ctx context.Context, id string, revokedAt time.Time) error {
+	return us.update(ctx, id, map[string]interface{}{
+		"status":              entity.StatusSuspended,
+		"sessions_revoked_at": revokedAt,
+	})
+}
+
+// SetPassword writes the password and sessions_revoked_at columns together.
+func (us *userRepositoryImpl) SetPassword(ctx context.Context, id string, hash string, revokedAt time.Time) error {
+	return us.update(ctx, id, map[string]interface{}{
+		"password":            hash,
+		"sessions_revoked_at": revokedAt,
+	})
+}
+
+// UpdateProfile writes the columns of the fields set in the update.
+func (us *userRepositoryImpl) UpdateProfile(ctx context.Context, id string, update dto.ProfileUpdate) error {
+	updates := profileColumns(update)
+	if len(updates) == 0 {
+		return nil
+	}
+	return us.update(ctx, id, updates)
+}
+
+// profileColumns maps the fields set in the update to the columns they are stored in.
+func profileColumns(update dto.ProfileUpdate) map[string]interface{} {
+	updates := map[string]interface{}{}
+	if update.FirstName != nil {
+		updates["first_name"] = *update.FirstName
+	}
+	if update.LastName != nil {
+		updates["last_name"] = *update.LastName
+	}
+	if update.PhoneNumber != nil {
+		updates["phone_number"] = *update.PhoneNumber
+	}
+	if update.Locale != nil {
+		updates["locale"] = *update.Locale
+	}
+	if update.Timezone != nil {
+		updates["timezone"] = *update.Timezone
+	}
+	return updates
+}
+
+// LinkProvider writes the provider and provider_id columns. The password hash is left alone.
+func (us *userRepositoryImpl) LinkProvider(ctx context.Context, id string, provider string, providerID string) error {
+	return us.update(ctx, id, map[string]interface{}{
+		"provider":    provider,
+		"provider_id": providerID,
+	})
+}
+
+// ErasePersonalData overwrites every column holding personal data in a single update.
+func (us *userRepositoryImpl) ErasePersonalData(ctx context.Context, id string, email string, revokedAt time.Time) error {
+	return us.update(ctx, id, map[string]interface{}{
+		"first_name":          "Deleted",
+		"last_name":           "",
+		"email":               email,
+		"password":            "",
+		"phone_number":        "",
+		"provider_id":         "",
+		"sessions_revoked_at": revokedAt,
+	})
+}
+
+// SetOrganization writes the org_id column, which update refuses, so no other method can move
+// a user between organizations.
+func (us *userRepositoryImpl) SetOrganization(ctx context.Context, id string, orgID uuid.UUID) error {
+	logger := logging.FromContext(ctx)
+
+	logger.Debugw("user.db.SetOrganization", "id", id, "org_id", orgID)
+
+	result := us.scoped(ctx).Model(&entity.User{}).Where("id = ?", id).Update("org_id", orgID)
+	if result.Error != nil {
+		logger.Errorw("user.db.SetOrganization failed to update user: %v", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		logger.Warn("user.db.SetOrganization user not found")
+		return postgres.ErrRecordNotFound
+	}
+	return nil
+}
+
+// update modifies an existing user's details based on their ID.
 // It logs the update operation and handles errors, including the case where the user is not found.
-func (us *userRepositoryImpl) Update(ctx context.Context, id string, updates map[string]interface{}) error {
+// GORM doesn't report ErrRecordNotFound for an update that matches no rows, so that case is
+// detected from the number of affected rows.
+// Only the columns in updatableColumns may be written; any other key fails with ErrColumnNotUpdatable.
+func (us *userRepositoryImpl) update(ctx context.Context, id string, updates map[string]interface{}) error {
 	logger := logging.FromContext(ctx)
-	db := postgres.FromContext(ctx, us.db)
 
-	logger.Debugw("user.db.Update", id, updates)
+	logger.Debugw("user.db.update", id, updates)
+
+	for column := range updates {
+		if _, ok := updatableColumns[column]; !ok {
+			logger.Errorw("user.db.update refused to update column", "column", column)
+			return fmt.Errorf("%w: %q", ErrColumnNotUpdatable, column)
+		}
+	}
 
 	var user entity.User
-	if err := db.WithContext(ctx).Model(&user).Clauses(clause.Returning{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+	result := us.scoped(ctx).Model(&user).Clauses(clause.Returning{}).Where("id = ?", id).Updates(updates)
+	if result.Error != nil {
+		logger.Errorw("user.db.update failed to update user: %v", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		logger.Warn("user.db.update user not found")
+		return postgres.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// ReplacePasswordHash updates the password column where both the ID and the current hash match.
+func (us *userRepositoryImpl) ReplacePasswordHash(ctx context.Context, id string, oldHash string, newHash string) (bool, error) {
+	logger := logging.FromContext(ctx)
+
+	logger.Debugw("user.db.ReplacePasswordHash", "id", id)
+
+	result := us.scoped(ctx).Model(&entity.User{}).Where("id = ? AND password = ?", id, oldHash).Update("password", newHash)
+	if result.Error != nil {
+		logger.Errorw("user.db.ReplacePasswordHash failed to update password: %v", result.Error)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ActivateInvitation updates the user where the ID matches and the account is still a pending invitation,
+// so checking the invitation and accepting it can't race with another acceptance.
+func (us *userRepositoryImpl) ActivateInvitation(ctx context.Context, id string, profile dto.ProfileUpdate, hash string) (bool, error) {
+	logger := logging.FromContext(ctx)
+
+	logger.Debugw("user.db.ActivateInvitation", "id", id)
+
+	updates := profileColumns(profile)
+	updates["password"] = hash
+	updates["status"] = entity.StatusActive
+
+	result := us.scoped(ctx).Model(&entity.User{}).
+		Where("id = ? AND status = ? AND COALESCE(password, '') = '' AND COALESCE(provider_id, '') = ''", id, entity.StatusPending).
+		Updates(updates)
+	if result.Error != nil {
+		logger.Errorw("user.db.ActivateInvitation failed to update user: %v", result.Error)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// FindAll returns one page of users matching the filter, ordered as requested, and the total number of matches.
+func (us *userRepositoryImpl) FindAll(ctx context.Context, filter ListFilter) ([]entity.User, int64, error) {
+	logger := logging.FromContext(ctx)
+
+	logger.Debugw("user.db.FindAll", "filter", filter)
+
+	query := us.scoped(ctx).Model(&entity.User{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Search != "" {
+		term := "%" + likeEscaper.Replace(filter.Search) + "%"
+		query = query.Where("first_name ILIKE ? OR last_name ILIKE ? OR email ILIKE ?", term, term, term)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		logger.Errorw("user.db.FindAll failed to count users: %v", err)
+		return nil, 0, err
+	}
+
+	column, ok := sortableColumns[filter.SortBy]
+	if !ok {
+		column = sortableColumns["created_at"]
+	}
+
+	var users []entity.User
+	err := query.
+		Order(clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: filter.SortDir != "asc"}).
+		// Break ties on the primary key so pages are stable.
+		Order("id").
+		Offset((filter.Page - 1) * filter.Size).
+		Limit(filter.Size).
+		Find(&users).Error
+	if err != nil {
+		logger.Errorw("user.db.FindAll failed to find users: %v", err)
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// Delete soft-deletes the user with the given ID.
+func (us *userRepositoryImpl) Delete(ctx context.Context, id string) error {
+	logger := logging.FromContext(ctx)
+
+	logger.Debugw("user.db.Delete", "id", id)
+
+	result := us.scoped(ctx).Where("id = ?", id).Delete(&entity.User{})
+	if result.Error != nil {
+		logger.Errorw("user.db.Delete failed to delete user: %v", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		logger.Warn("user.db.Delete user not found")
+		return postgres.ErrRecordNotFound
+	}
+	return nil
+}
+
+// InsertPasswordHistory records a password hash the user has replaced.
+func (us *userRepositoryImpl) InsertPasswordHistory(ctx context.Context, history *entity.PasswordHistory) error {
+	logger := logging.FromContext(ctx)
+	db := postgres.FromContext(ctx, us.db)
+
+	logger.Debugw("user.db.InsertPasswordHistory", "user_id", history.UserID)
+
+	if err := db.WithContext(ctx).Create(history).Error; err != nil {
+		logger.Errorw("user.db.InsertPasswordHistory failed to save: %v", err)
+		return err
+	}
+	return nil
+}
+
+// FindPasswordHistory returns up to limit of the user's replaced password hashes, most recent first.
+func (us *userRepositoryImpl) FindPasswordHistory(ctx context.Context, userID string, limit int) ([]entity.PasswordHistory, error) {
+	logger := logging.FromContext(ctx)
+	db := postgres.FromContext(ctx, us.db)
+
+	logger.Debugw("user.db.FindPasswordHistory", "user_id", userID, "limit", limit)
+
+	var history []entity.PasswordHistory
+	err := db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").Order("id DESC").
+		Limit(limit).
+		Find(&history).Error
+	if err != nil {
+		logger.Errorw("user.db.FindPasswordHistory failed to find password history: %v", err)
+		return nil, err
+	}
+	return history, nil
+}
+
+// PrunePasswordHistory permanently deletes all but the keep most recent replaced password hashes of the user.
+// The rows are hard-deleted, since keeping old hashes around is what the limit is meant to prevent.
+func (us *userRepositoryImpl) PrunePasswordHistory(ctx context.Context, userID string, keep int) error {
+	logger := logging.FromContext(ctx)
+	db := postgres.FromContext(ctx, us.db)
+
+	logger.Debugw("user.db.PrunePasswordHistory", "user_id", userID, "keep", keep)
+
+	recent := db.WithContext(ctx).Unscoped().Model(&entity.PasswordHistory{}).
+		Select("id").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").Order("id DESC").
+		Limit(keep)
+	err := db.WithContext(ctx).Unscoped().
+		Where("user_id = ? AND id NOT IN (?)", userID, recent).
+		Delete(&entity.PasswordHistory{}).Error
+	if err != nil {
+		logger.Errorw("user.db.PrunePasswordHistory failed to prune password history: %v", err)
+		return err
+	}
+	return nil
+}
+
+// SaveNotificationPreference inserts the preference, or updates Enabled when the user already has one for the category.
+func (us *userRepositoryImpl) SaveNotificationPreference(ctx context.Context, preference *entity.NotificationPreference) error {
+	logger := logging.FromContext(ctx)
+	db := postgres.FromContext(ctx, us.db)
+
+	logger.Debugw("user.db.SaveNotificationPreference", "user_id", preference.UserID, "category", preference.Category, "enabled", preference.Enabled)
+
+	err := db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "category"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+	}).Create(preference).Error
+	if err != nil {
+		logger.Errorw("user.db.SaveNotificationPreference failed to save: %v", err)
+		return err
+	}
+	return nil
+}
+
+// FindNotificationPreference returns the user's preference for the category.
+func (us *userRepositoryImpl) FindNotificationPreference(ctx context.Context, userID string, category string) (*entity.NotificationPreference, error) {
+	logger := logging.FromContext(ctx)
+	db := postgres.FromContext(ctx, us.db)
+
+	logger.Debugw("user.db.FindNotificationPreference", "user_id", userID, "category", category)
+
+	var preference entity.NotificationPreference
+	if err := db.WithContext(ctx).First(&preference, "user_id = ? AND category = ?", userID, category).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			logger.Warn("user.db.Update user not found")
-			return postgres.ErrRecordNotFound
+			return nil, postgres.ErrRecordNotFound
 		}
-		logger.Errorw("user.db.Update failed to update user: %v", err)
-		return err
+		logger.Errorw("user.db.FindNotificationPreference failed to find preference: %v", err)
+		return nil, err
 	}
+	return &preference, nil
+}
 
+// InsertOrganization adds a new organization to the database.
+func (us *userRepositoryImpl) InsertOrganization(ctx context.Context, organization *entity.Organization) error {
+	logger := logging.FromContext(ctx)
+	db := postgres.FromContext(ctx, us.db)
+
+	logger.Debugw("user.db.InsertOrganization", "name", organization.Name, "owner_id", organization.OwnerID)
+
+	if err := db.WithContext(ctx).Create(organization).Error; err != nil {
+		logger.Errorw("user.db.InsertOrganization failed to save: %v", err)
+		return err
+	}
 	return nil
 }
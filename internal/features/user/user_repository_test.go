@@ -0,0 +1,519 @@
+package user_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/npushpakumara/go-backend-template/internal/features/user"
+	"github.com/npushpakumara/go-backend-template/internal/features/user/dto"
+	"github.com/npushpakumara/go-backend-template/internal/features/user/entity"
+	"github.com/npushpakumara/go-backend-template/internal/postgres"
+	"github.com/npushpakumara/go-backend-template/internal/postgres/postgrestest"
+	"github.com/npushpakumara/go-backend-template/internal/rbac"
+	gormPostgres "gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// newTestRepository returns a user repository backed by a fresh Postgres schema.
+// Tests using it are skipped when TEST_DATABASE_URL is unset.
+func newTestRepository(t *testing.T) user.Repository {
+	t.Helper()
+	return user.NewUserRepository(postgrestest.NewDB(t))
+}
+
+// insertTestUser inserts an active user with the email and, when not empty, the username.
+func insertTestUser(t *testing.T, repo user.Repository, email, username string) *entity.User {
+	t.Helper()
+
+	u := &entity.User{FirstName: "Ada", Email: email, Status: entity.StatusActive, Role: entity.RoleUser}
+	if username != "" {
+		u.Username = &username
+	}
+	inserted, err := repo.Insert(context.Background(), u)
+	if err != nil {
+		t.Fatalf("Insert(%s) error = %v", email, err)
+	}
+	return inserted
+}
+
+func TestRepositoryInsertDuplicate(t *testing.T) {
+	repo := newTestRepository(t)
+	insertTestUser(t, repo, "ada@example.com", "ada")
+
+	tests := []struct {
+		name       string
+		user       *entity.User
+		constraint string
+	}{
+		{name: "email", user: &entity.User{FirstName: "Ada", Email: "ada@example.com"}, constraint: "uni_users_email"},
+		{name: "username", user: &entity.User{FirstName: "Ada", Email: "other@example.com", Username: ptr("ada")}, constraint: "idx_users_username"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := repo.Insert(context.Background(), tt.user)
+			if !errors.Is(err, postgres.ErrKeyDuplicate) {
+				t.Fatalf("Insert() error = %v, want ErrKeyDuplicate", err)
+			}
+
+			var duplicate *postgres.DuplicateKeyError
+			if !errors.As(err, &duplicate) || duplicate.Constraint != tt.constraint {
+				t.Errorf("Insert() error = %v, want a DuplicateKeyError naming %s", err, tt.constraint)
+			}
+		})
+	}
+}
+
+func TestRepositoryFindNotFound(t *testing.T) {
+	repo := newTestRepository(t)
+	deleted := insertTestUser(t, repo, "deleted@example.com", "")
+	if err := repo.Delete(context.Background(), deleted.ID.String()); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	if _, err := repo.FindByEmail(context.Background(), "nobody@example.com"); !errors.Is(err, postgres.ErrRecordNotFound) {
+		t.Errorf("FindByEmail() of an unknown email error = %v, want ErrRecordNotFound", err)
+	}
+	if _, err := repo.FindByEmail(context.Background(), "deleted@example.com"); !errors.Is(err, postgres.ErrRecordNotFound) {
+		t.Errorf("FindByEmail() of a deleted user error = %v, want ErrRecordNotFound", err)
+	}
+	if _, err := repo.FindByID(context.Background(), deleted.ID.String()); !errors.Is(err, postgres.ErrRecordNotFound) {
+		t.Errorf("FindByID() of a deleted user error = %v, want ErrRecordNotFound", err)
+	}
+}
+
+func TestRepositoryUpdate(t *testing.T) {
+	repo := newTestRepository(t)
+	existing := insertTestUser(t, repo, "ada@example.com", "")
+
+	if err := repo.UpdateProfile(context.Background(), existing.ID.String(), dto.ProfileUpdate{FirstName: ptr("Augusta")}); err != nil {
+		t.Fatalf("UpdateProfile() error = %v", err)
+	}
+	if err := repo.Suspend(context.Background(), existing.ID.String(), time.Now()); err != nil {
+		t.Fatalf("Suspend() error = %v", err)
+	}
+
+	stored, err := repo.FindByID(context.Background(), existing.ID.String())
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	if stored.FirstName != "Augusta" || stored.Status != entity.StatusSuspended {
+		t.Errorf("stored user = %s/%s, want Augusta/%s", stored.FirstName, stored.Status, entity.StatusSuspended)
+	}
+	if stored.Email != "ada@example.com" {
+		t.Errorf("UpdateProfile() changed the email to %q", stored.Email)
+	}
+}
+
+// An update matching no rows is reported as not found, even though it returns the updated rows.
+func TestRepositoryUpdateNotFound(t *testing.T) {
+	repo := newTestRepository(t)
+	deleted := insertTestUser(t, repo, "deleted@example.com", "")
+	if err := repo.Delete(context.Background(), deleted.ID.String()); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	ids := map[string]string{
+		"unknown": "6f1c9a8e-4c1b-4d2e-9a55-0c5d2b7e1f00",
+		"deleted": deleted.ID.String(),
+	}
+	for name, id := range ids {
+		t.Run(name, func(t *testing.T) {
+			err := repo.UpdateProfile(context.Background(), id, dto.ProfileUpdate{FirstName: ptr("Augusta")})
+			if !errors.Is(err, postgres.ErrRecordNotFound) {
+				t.Errorf("UpdateProfile() error = %v, want ErrRecordNotFound", err)
+			}
+		})
+	}
+}
+
+// Columns are checked before the database is reached, so this test needs no database.
+func TestRepositoryUpdateRejectsUnknownColumn(t *testing.T) {
+	repo := user.NewUserRepository(nil)
+
+	tests := []struct {
+		name    string
+		updates map[string]interface{}
+	}{
+		{name: "role", updates: map[string]interface{}{"role": entity.RoleAdmin}},
+		{name: "id", updates: map[string]interface{}{"id": "6f1c9a8e-4c1b-4d2e-9a55-0c5d2b7e1f01"}},
+		{name: "deleted_at", updates: map[string]interface{}{"deleted_at": nil}},
+		{name: "misspelt column", updates: map[string]interface{}{"pasword": "hash"}},
+		{name: "field name instead of column", updates: map[string]interface{}{"FirstName": "Augusta"}},
+		{name: "removed column", updates: map[string]interface{}{"is_active": true}},
+		{name: "org_id", updates: map[string]interface{}{"org_id": "6f1c9a8e-4c1b-4d2e-9a55-0c5d2b7e1f02"}},
+		{name: "allowed column with an unknown one", updates: map[string]interface{}{"first_name": "Augusta", "role": entity.RoleAdmin}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := user.UpdateColumns(context.Background(), repo, "6f1c9a8e-4c1b-4d2e-9a55-0c5d2b7e1f00", tt.updates)
+			if !errors.Is(err, user.ErrColumnNotUpdatable) {
+				t.Fatalf("update() error = %v, want ErrColumnNotUpdatable", err)
+			}
+		})
+	}
+}
+
+// orgContext returns the context of a request scoped to the organization, as the JWT middleware scopes
+// the requests of an organization's members.
+func orgContext(orgID uuid.UUID) context.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/api/v1/users", nil)
+	rbac.SetOrg(c, orgID.String())
+	return c.Request.Context()
+}
+
+// insertTestOrganization inserts an organization and a member of it with the email.
+func insertTestOrganization(t *testing.T, repo user.Repository, name, email string) (*entity.Organization, *entity.User) {
+	t.Helper()
+
+	organization := &entity.Organization{Name: name, OwnerID: uuid.New()}
+	if err := repo.InsertOrganization(context.Background(), organization); err != nil {
+		t.Fatalf("InsertOrganization(%s) error = %v", name, err)
+	}
+	member, err := repo.Insert(orgContext(organization.ID), &entity.User{FirstName: "Ada", Email: email, Status: entity.StatusActive, Role: entity.RoleAdmin})
+	if err != nil {
+		t.Fatalf("Insert(%s) error = %v", email, err)
+	}
+	return organization, member
+}
+
+func TestRepositoryScopesToOrganization(t *testing.T) {
+	repo := newTestRepository(t)
+	acme, acmeAdmin := insertTestOrganization(t, repo, "Acme", "admin@acme.example.com")
+	_, globexAdmin := insertTestOrganization(t, repo, "Globex", "admin@globex.example.com")
+	insertTestUser(t, repo, "loner@example.com", "")
+
+	ctx := orgContext(acme.ID)
+
+	users, total, err := repo.FindAll(ctx, user.ListFilter{Page: 1, Size: 10})
+	if err != nil {
+		t.Fatalf("FindAll() error = %v", err)
+	}
+	if total != 1 || len(users) != 1 || users[0].ID != acmeAdmin.ID {
+		t.Errorf("FindAll() = %d users of %d, want only %s", len(users), total, acmeAdmin.Email)
+	}
+
+	if _, err := repo.FindByID(ctx, globexAdmin.ID.String()); !errors.Is(err, postgres.ErrRecordNotFound) {
+		t.Errorf("FindByID() of another organization's user error = %v, want ErrRecordNotFound", err)
+	}
+	if _, err := repo.FindByEmail(ctx, globexAdmin.Email); !errors.Is(err, postgres.ErrRecordNotFound) {
+		t.Errorf("FindByEmail() of another organization's user error = %v, want ErrRecordNotFound", err)
+	}
+	err = repo.UpdateProfile(ctx, globexAdmin.ID.String(), dto.ProfileUpdate{FirstName: ptr("Mallory")})
+	if !errors.Is(err, postgres.ErrRecordNotFound) {
+		t.Errorf("UpdateProfile() of another organization's user error = %v, want ErrRecordNotFound", err)
+	}
+	if err := repo.Delete(ctx, globexAdmin.ID.String()); !errors.Is(err, postgres.ErrRecordNotFound) {
+		t.Errorf("Delete() of another organization's user error = %v, want ErrRecordNotFound", err)
+	}
+
+	// Requests outside any organization, such as those of a platform administrator, see every user.
+	if _, total, err := repo.FindAll(context.Background(), user.ListFilter{Page: 1, Size: 10}); err != nil || total != 3 {
+		t.Errorf("unscoped FindAll() = %d users, %v, want 3", total, err)
+	}
+}
+
+// newDryRunRepository returns a user repository that runs no queries. The SQL of each query is
+// appended to the returned slice with its values inlined, so tests can check it without a database.
+func newDryRunRepository(t *testing.T) (user.Repository, *[]string) {
+	t.Helper()
+
+	db, err := gorm.Open(gormPostgres.New(gormPostgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	statements := &[]string{}
+	capture := func(tx *gorm.DB) {
+		*statements = append(*statements, tx.Dialector.Explain(tx.Statement.SQL.String(), tx.Statement.Vars...))
+		// A dry run keeps the built SQL, which would be reused by the next query on the same statement,
+		// as FindAll's select follows its count. Running a query clears it.
+		tx.Statement.SQL.Reset()
+		tx.Statement.Vars = nil
+	}
+	if err := db.Callback().Query().After("gorm:query").Register("test:capture", capture); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := db.Callback().Update().After("gorm:update").Register("test:capture", capture); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	return user.NewUserRepository(db), statements
+}
+
+// The organization filter is checked in the generated SQL, so this test needs no database.
+func TestRepositoryFindAllFiltersByOrganization(t *testing.T) {
+	repo, statements := newDryRunRepository(t)
+	orgID := uuid.New()
+
+	if _, _, err := repo.FindAll(orgContext(orgID), user.ListFilter{Page: 1, Size: 10}); err != nil {
+		t.Fatalf("FindAll() error = %v", err)
+	}
+	if len(*statements) != 2 {
+		t.Fatalf("FindAll() ran %d queries, want a count and a select", len(*statements))
+	}
+	for _, statement := range *statements {
+		if !strings.Contains(statement, "users.org_id = '"+orgID.String()+"'") {
+			t.Errorf("query %q isn't limited to the organization", statement)
+		}
+	}
+}
+
+func TestRepositoryFindAllSearchesAndSorts(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := context.Background()
+	for _, u := range []*entity.User{
+		{FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com", Status: entity.StatusActive},
+		{FirstName: "Grace", LastName: "Hopper", Email: "grace@example.com", Status: entity.StatusActive},
+		{FirstName: "Alan", LastName: "Turing", Email: "alan@lovelace.example.com", Status: entity.StatusSuspended},
+		{FirstName: "Edsger", LastName: "Dijkstra", Email: "edsger_d@example.com", Status: entity.StatusActive},
+	} {
+		u.Role = entity.RoleUser
+		if _, err := repo.Insert(ctx, u); err != nil {
+			t.Fatalf("Insert(%s) error = %v", u.Email, err)
+		}
+	}
+
+	emails := func(users []entity.User) []string {
+		var got []string
+		for _, u := range users {
+			got = append(got, u.Email)
+		}
+		return got
+	}
+
+	tests := []struct {
+		name      string
+		filter    user.ListFilter
+		want      []string
+		wantTotal int64
+	}{
+		{
+			name:      "search matches names and emails case-insensitively",
+			filter:    user.ListFilter{Search: "LOVELACE", SortBy: "email", SortDir: "asc", Page: 1, Size: 10},
+			want:      []string{"ada@example.com", "alan@lovelace.example.com"},
+			wantTotal: 2,
+		},
+		{
+			name:      "search treats LIKE wildcards literally",
+			filter:    user.ListFilter{Search: "_d@", SortBy: "email", SortDir: "asc", Page: 1, Size: 10},
+			want:      []string{"edsger_d@example.com"},
+			wantTotal: 1,
+		},
+		{
+			name:      "search combines with the status filter",
+			filter:    user.ListFilter{Search: "lovelace", Status: entity.StatusActive, Page: 1, Size: 10},
+			want:      []string{"ada@example.com"},
+			wantTotal: 1,
+		},
+		{
+			name:      "ascending",
+			filter:    user.ListFilter{SortBy: "email", SortDir: "asc", Page: 1, Size: 10},
+			want:      []string{"ada@example.com", "alan@lovelace.example.com", "edsger_d@example.com", "grace@example.com"},
+			wantTotal: 4,
+		},
+		{
+			name:      "descending",
+			filter:    user.ListFilter{SortBy: "email", SortDir: "desc", Page: 1, Size: 10},
+			want:      []string{"grace@example.com", "edsger_d@example.com", "alan@lovelace.example.com", "ada@example.com"},
+			wantTotal: 4,
+		},
+		{
+			name:      "total counts every match, not just the page",
+			filter:    user.ListFilter{SortBy: "email", SortDir: "asc", Page: 2, Size: 3},
+			want:      []string{"grace@example.com"},
+			wantTotal: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, total, err := repo.FindAll(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("FindAll() error = %v", err)
+			}
+			if got := emails(users); !slices.Equal(got, tt.want) || total != tt.wantTotal {
+				t.Errorf("FindAll() = %v of %d, want %v of %d", got, total, tt.want, tt.wantTotal)
+			}
+		})
+	}
+}
+
+// Only whitelisted columns reach ORDER BY; anything else falls back to the newest users first.
+func TestRepositoryFindAllSortsOnlyByWhitelistedColumns(t *testing.T) {
+	tests := []struct {
+		filter user.ListFilter
+		want   string
+	}{
+		{filter: user.ListFilter{SortBy: "email", SortDir: "asc"}, want: `ORDER BY "email",id`},
+		{filter: user.ListFilter{SortBy: "email", SortDir: "desc"}, want: `ORDER BY "email" DESC,id`},
+		{filter: user.ListFilter{SortBy: "password", SortDir: "asc"}, want: `ORDER BY "created_at",id`},
+		{filter: user.ListFilter{SortBy: "email; DROP TABLE users", SortDir: "asc"}, want: `ORDER BY "created_at",id`},
+		{filter: user.ListFilter{}, want: `ORDER BY "created_at" DESC,id`},
+	}
+	for _, tt := range tests {
+		repo, statements := newDryRunRepository(t)
+		tt.filter.Page, tt.filter.Size = 1, 10
+		if _, _, err := repo.FindAll(context.Background(), tt.filter); err != nil {
+			t.Fatalf("FindAll() error = %v", err)
+		}
+		if got := (*statements)[len(*statements)-1]; !strings.Contains(got, tt.want) {
+			t.Errorf("FindAll(%+v) ran %q, want it to contain %q", tt.filter, got, tt.want)
+		}
+	}
+}
+
+// setColumns matches the columns assigned in the SET clause of an UPDATE statement.
+var setColumns = regexp.MustCompile(`"(\w+)"=`)
+
+// Each typed update writes only its own columns, and updated_at, so none of them can be used to change anything else.
+func TestRepositoryTypedUpdatesWriteOnlyTheirColumns(t *testing.T) {
+	const id = "6f1c9a8e-4c1b-4d2e-9a55-0c5d2b7e1f00"
+	ctx := context.Background()
+	tests := []struct {
+		name   string
+		update func(repo user.Repository) error
+		want   []string
+	}{
+		{
+			name:   "status",
+			update: func(repo user.Repository) error { return repo.SetStatus(ctx, id, entity.StatusActive) },
+			want:   []string{"status"},
+		},
+		{
+			name:   "suspend",
+			update: func(repo user.Repository) error { return repo.Suspend(ctx, id, time.Now()) },
+			want:   []string{"sessions_revoked_at", "status"},
+		},
+		{
+			name:   "password",
+			update: func(repo user.Repository) error { return repo.SetPassword(ctx, id, "new-hash", time.Now()) },
+			want:   []string{"password", "sessions_revoked_at"},
+		},
+		{
+			name: "one profile field",
+			update: func(repo user.Repository) error {
+				return repo.UpdateProfile(ctx, id, dto.ProfileUpdate{FirstName: ptr("Augusta")})
+			},
+			want: []string{"first_name"},
+		},
+		{
+			name: "every profile field",
+			update: func(repo user.Repository) error {
+				return repo.UpdateProfile(ctx, id, dto.ProfileUpdate{
+					FirstName: ptr("Augusta"), LastName: ptr("King"), PhoneNumber: ptr("+14155550100"),
+					Locale: ptr("en-GB"), Timezone: ptr("Europe/London"),
+				})
+			},
+			want: []string{"first_name", "last_name", "locale", "phone_number", "timezone"},
+		},
+		{
+			name:   "link provider",
+			update: func(repo user.Repository) error { return repo.LinkProvider(ctx, id, "google", "g-1") },
+			want:   []string{"provider", "provider_id"},
+		},
+		{
+			name: "erase personal data",
+			update: func(repo user.Repository) error {
+				return repo.ErasePersonalData(ctx, id, "deleted-"+id+"@invalid", time.Now())
+			},
+			want: []string{"email", "first_name", "last_name", "password", "phone_number", "provider_id", "sessions_revoked_at"},
+		},
+		{
+			name: "accept invitation",
+			update: func(repo user.Repository) error {
+				_, err := repo.ActivateInvitation(ctx, id, dto.ProfileUpdate{FirstName: ptr("Ada"), LastName: ptr("Lovelace")}, "new-hash")
+				return err
+			},
+			want: []string{"first_name", "last_name", "password", "status"},
+		},
+		{
+			name:   "organization",
+			update: func(repo user.Repository) error { return repo.SetOrganization(ctx, id, uuid.New()) },
+			want:   []string{"org_id"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, statements := newDryRunRepository(t)
+
+			// A dry run affects no rows, which the repository reports as not found.
+			if err := tt.update(repo); err != nil && !errors.Is(err, postgres.ErrRecordNotFound) {
+				t.Fatalf("update error = %v", err)
+			}
+			if len(*statements) != 1 {
+				t.Fatalf("ran %d statements, want 1: %v", len(*statements), *statements)
+			}
+
+			statement := (*statements)[0]
+			set, _, _ := strings.Cut(statement[strings.Index(statement, " SET ")+1:], " WHERE ")
+			var columns []string
+			for _, match := range setColumns.FindAllStringSubmatch(set, -1) {
+				columns = append(columns, match[1])
+			}
+			want := append(slices.Clone(tt.want), "updated_at")
+			slices.Sort(columns)
+			slices.Sort(want)
+			if !slices.Equal(columns, want) {
+				t.Errorf("updated columns %v, want %v in %q", columns, want, statement)
+			}
+		})
+	}
+}
+
+// Accepting an invitation checks that it is still pending in the update itself, so two acceptances can't both apply.
+func TestRepositoryActivateInvitationOnlyMatchesPendingInvitations(t *testing.T) {
+	repo, statements := newDryRunRepository(t)
+
+	if _, err := repo.ActivateInvitation(context.Background(), "6f1c9a8e-4c1b-4d2e-9a55-0c5d2b7e1f00", dto.ProfileUpdate{}, "new-hash"); err != nil {
+		t.Fatalf("ActivateInvitation() error = %v", err)
+	}
+	if len(*statements) != 1 {
+		t.Fatalf("ran %d statements, want 1: %v", len(*statements), *statements)
+	}
+	_, where, _ := strings.Cut((*statements)[0], " WHERE ")
+	for _, condition := range []string{"status = 'pending'", "COALESCE(password, '') = ''", "COALESCE(provider_id, '') = ''"} {
+		if !strings.Contains(where, condition) {
+			t.Errorf("WHERE %s, want it to contain %s", where, condition)
+		}
+	}
+}
+
+func TestRepositoryActivateInvitation(t *testing.T) {
+	repo := newTestRepository(t)
+	invited, err := repo.Insert(context.Background(), &entity.User{Email: "ada@example.com", Status: entity.StatusPending, Role: entity.RoleUser})
+	if err != nil {
+		t.Fatalf("Insert() error = %v", err)
+	}
+	profile := dto.ProfileUpdate{FirstName: ptr("Ada"), LastName: ptr("Lovelace")}
+
+	activated, err := repo.ActivateInvitation(context.Background(), invited.ID.String(), profile, "first-hash")
+	if err != nil || !activated {
+		t.Fatalf("ActivateInvitation() = %v, %v, want true", activated, err)
+	}
+	// The account is no longer a pending invitation, so a second acceptance changes nothing.
+	activated, err = repo.ActivateInvitation(context.Background(), invited.ID.String(), profile, "second-hash")
+	if err != nil || activated {
+		t.Fatalf("second ActivateInvitation() = %v, %v, want false", activated, err)
+	}
+
+	stored, err := repo.FindByID(context.Background(), invited.ID.String())
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	if stored.Status != entity.StatusActive || stored.Password != "first-hash" || stored.FirstName != "Ada" {
+		t.Errorf("stored user = %s/%s/%s, want Ada/first-hash/%s", stored.FirstName, stored.Password, stored.Status, entity.StatusActive)
+	}
+}
+
+// ptr returns a pointer to s.
+func ptr(s string) *string {
+	return &s
+}
@@ -2,55 +2,146 @@ package user
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
 
+	"github.com/google/uuid"
+	"github.com/npushpakumara/go-backend-template/internal/config"
 	"github.com/npushpakumara/go-backend-template/internal/features/user/dto"
 	"github.com/npushpakumara/go-backend-template/internal/features/user/entity"
 	"github.com/npushpakumara/go-backend-template/internal/postgres"
+	apiError "github.com/npushpakumara/go-backend-template/pkg/errors"
 )
 
 // Service defines the methods that our User Service should implement.
 type Service interface {
+	// CreateUser inserts the user. It fails with ErrEmailAlreadyExists when another account has the email,
+	// and with ErrUsernameTaken when another account has the username.
 	CreateUser(ctx context.Context, user *dto.RegisterRequestDto) (*dto.UserResponseDto, error)
-	UpdateUser(ctx context.Context, userID string, updates map[string]interface{}) error
+	// ActivateUser moves the user's account to active.
+	ActivateUser(ctx context.Context, userID string) error
+	// UpdatePassword stores a new password hash and revokes every session issued so far.
+	// When password history is enabled, the replaced hash is remembered; run it in a transaction
+	// so the history and the password change together.
+	UpdatePassword(ctx context.Context, userID string, hashedPassword string) error
+	// RehashPassword replaces the stored hash of the user's unchanged password with newHash, for instance after
+	// the hashing algorithm changed. Sessions and password history are left alone, and nothing is written
+	// if the stored hash is no longer oldHash.
+	RehashPassword(ctx context.Context, userID string, oldHash string, newHash string) error
+	// GetPasswordHistory returns the hashes of the user's most recently replaced passwords, up to
+	// security.password_history of them. It returns nothing when password history is disabled.
+	GetPasswordHistory(ctx context.Context, userID string) ([]string, error)
+	// UpdateProfile changes the profile fields set in the update and leaves the others untouched.
+	UpdateProfile(ctx context.Context, userID string, update dto.ProfileUpdate) error
+	// AcceptInvitation sets the profile fields set in the update and the password hash of a pending invited account
+	// and activates it, in one update that only applies while the account is still a pending invitation.
+	// Any other account, including one whose invitation was just accepted, fails with ErrInvitationNotPending.
+	AcceptInvitation(ctx context.Context, userID string, profile dto.ProfileUpdate, hashedPassword string) error
+	// LinkProvider links the account to an OAuth identity. A password the account has is kept.
+	LinkProvider(ctx context.Context, userID string, provider string, providerID string) error
 	GetUserByID(ctx context.Context, userID string) (*dto.UserResponseDto, error)
+	// GetUsersByIDs resolves many users with a single query, keyed by the canonical lowercase form of their ID.
+	// Users that don't exist are absent from the map; an ID that isn't a UUID fails with ErrInvalidUserID.
+	GetUsersByIDs(ctx context.Context, userIDs []string) (map[string]dto.UserResponseDto, error)
 	GetUserByEmail(ctx context.Context, email string) (*dto.UserResponseDto, error)
+	// GetUserByUsername finds the user with the given lowercase username.
+	GetUserByUsername(ctx context.Context, username string) (*dto.UserResponseDto, error)
+	// UpdateStatus suspends the account for StatusSuspended and reactivates it for StatusActive, through SuspendUser
+	// and ReactivateUser. Any other status fails with ErrStatusChangeNotAllowed.
+	UpdateStatus(ctx context.Context, userID string, status string) error
+	// SuspendUser suspends an active account and revokes its sessions. Banned accounts fail with ErrAccountBanned,
+	// suspended ones with ErrAccountSuspended and any other inactive account with ErrAccountNotActive.
+	SuspendUser(ctx context.Context, userID string) error
+	// ReactivateUser moves a suspended account back to active. Any other account fails with ErrUserNotSuspended.
+	ReactivateUser(ctx context.Context, userID string) error
+	// ActivateUsers activates every user named in the request, without email verification, in a single transaction.
+	// Users that can't be activated are reported in their results; the error is only set when the transaction fails,
+	// in which case nobody was activated.
+	ActivateUsers(ctx context.Context, request *dto.ActivateUsersRequestDto) (*dto.ActivateUsersResponseDto, error)
+	ListUsers(ctx context.Context, request *dto.ListUsersRequestDto) (*dto.UserListResponseDto, error)
+	DeleteUser(ctx context.Context, userID string) error
+	// SetNotificationPreference turns the emails of a category on or off for the user.
+	// It fails with ErrRecordNotFound when the user doesn't exist.
+	SetNotificationPreference(ctx context.Context, userID string, category string, enabled bool) error
+	// NotificationsEnabled reports whether the user receives the emails of a category, which they do until they opt out.
+	NotificationsEnabled(ctx context.Context, userID string, category string) (bool, error)
+	// CreateOrganization creates an organization owned by the user and moves the user into it.
+	// Run it in a transaction so the organization and the membership are created together.
+	CreateOrganization(ctx context.Context, name string, ownerID string) (*dto.OrganizationResponseDto, error)
 }
 
+// usernameIndex is the unique index GORM creates for User.Username.
+const usernameIndex = "idx_users_username"
+
+// Defaults applied to the admin user list when the request leaves them out.
+const (
+	defaultListPage = 1
+	defaultListSize = 20
+)
+
 // userServiceImpl is the concrete implementation of the Service interface.
 type userServiceImpl struct {
-	userRepository Repository
+	userRepository     Repository
+	transactionManager postgres.TransactionManager
+	cfg                *config.Config
 }
 
 // NewUserService creates a new instance of userServiceImpl with the provided Repository.
 // This function initializes the user service with the repository it will use for data operations.
-func NewUserService(userRepository Repository, transactionManager postgres.TransactionManager) Service {
-	return &userServiceImpl{userRepository}
+func NewUserService(userRepository Repository, transactionManager postgres.TransactionManager, cfg *config.Config) Service {
+	return &userServiceImpl{userRepository, transactionManager, cfg}
 }
 
 // CreateUser handles the registration of a new user.
 // It takes a context and a RegisterRequestDto containing user details,
 // hashes the user's password, and then inserts the user into the repository.
 // If successful, it returns a UserResponseDto with the user's details; otherwise, it returns an error.
+// Duplicates are reported as domain errors wrapping the database error, so callers don't depend on postgres.
 func (us *userServiceImpl) CreateUser(ctx context.Context, user *dto.RegisterRequestDto) (*dto.UserResponseDto, error) {
 
 	requestBody := &entity.User{
-		FirstName:   user.FirstName,
-		LastName:    user.LastName,
+		FirstName:   sanitizeName(user.FirstName),
+		LastName:    sanitizeName(user.LastName),
 		Email:       user.Email,
 		Password:    user.Password,
 		PhoneNumber: user.PhoneNumber,
 		Provider:    user.Provider,
 		ProviderID:  user.ProviderID,
+		Role:        entity.RoleUser,
+		Status:      entity.StatusPending,
+		Locale:      user.Locale,
+		Timezone:    entity.DefaultTimezone,
+	}
+
+	if requestBody.Locale == "" {
+		requestBody.Locale = entity.DefaultLocale
+	}
+	if user.Username != "" {
+		requestBody.Username = &user.Username
+	}
+	if user.Role != "" {
+		requestBody.Role = user.Role
 	}
 
 	// If the user is not an oauth user, then set the password
 	if user.ProviderID != "" {
 		requestBody.Password = ""
-		requestBody.IsActive = true
+		requestBody.Status = entity.StatusActive
 	}
 
 	newUser, err := us.userRepository.Insert(ctx, requestBody)
 	if err != nil {
+		// The email and the username are the only unique columns a new user can clash on.
+		var duplicate *postgres.DuplicateKeyError
+		if errors.As(err, &duplicate) {
+			if duplicate.Constraint == usernameIndex {
+				return nil, fmt.Errorf("%w: %w", apiError.ErrUsernameTaken, err)
+			}
+			return nil, fmt.Errorf("%w: %w", apiError.ErrEmailAlreadyExists, err)
+		}
 		return nil, err
 	}
 
@@ -59,17 +150,234 @@ func (us *userServiceImpl) CreateUser(ctx context.Context, user *dto.RegisterReq
 		FirstName: newUser.FirstName,
 		LastName:  newUser.LastName,
 		Email:     newUser.Email,
+		Username:  user.Username,
+		IsActive:  newUser.IsActive(),
+		Status:    newUser.Status,
+		Role:      newUser.Role,
+		Locale:    newUser.Locale,
+		Timezone:  newUser.Timezone,
 		CreatedAt: newUser.CreatedAt,
 	}, nil
 }
 
-// UpdateUser updates the details of an existing user based on the userId and the updates map.
-func (us *userServiceImpl) UpdateUser(ctx context.Context, userID string, updates map[string]interface{}) error {
+// ActivateUser moves the user's account to active.
+func (us *userServiceImpl) ActivateUser(ctx context.Context, userID string) error {
+	return us.userRepository.SetStatus(ctx, userID, entity.StatusActive)
+}
+
+// UpdatePassword stores the new password hash. Every session issued so far is revoked,
+// so tokens obtained with the old password stop working.
+func (us *userServiceImpl) UpdatePassword(ctx context.Context, userID string, hashedPassword string) error {
+	if keep := us.cfg.Security.PasswordHistory; keep > 0 {
+		if err := us.rememberPassword(ctx, userID, keep); err != nil {
+			return err
+		}
+	}
+
+	return us.userRepository.SetPassword(ctx, userID, hashedPassword, time.Now())
+}
+
+// RehashPassword swaps the hash only if the password wasn't changed since oldHash was read.
+func (us *userServiceImpl) RehashPassword(ctx context.Context, userID string, oldHash string, newHash string) error {
+	_, err := us.userRepository.ReplacePasswordHash(ctx, userID, oldHash, newHash)
+	return err
+}
+
+// rememberPassword adds the user's current password hash to their history and prunes the history
+// to the keep most recent entries. Accounts without a password, such as pending invitations, have nothing to remember.
+func (us *userServiceImpl) rememberPassword(ctx context.Context, userID string, keep int) error {
+	user, err := us.userRepository.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.Password == "" {
+		return nil
+	}
+
+	if err := us.userRepository.InsertPasswordHistory(ctx, &entity.PasswordHistory{UserID: user.ID, Password: user.Password}); err != nil {
+		return err
+	}
+	return us.userRepository.PrunePasswordHistory(ctx, userID, keep)
+}
+
+// GetPasswordHistory returns the hashes of the user's most recently replaced passwords.
+func (us *userServiceImpl) GetPasswordHistory(ctx context.Context, userID string) ([]string, error) {
+	limit := us.cfg.Security.PasswordHistory
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	history, err := us.userRepository.FindPasswordHistory(ctx, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make([]string, len(history))
+	for i, entry := range history {
+		hashes[i] = entry.Password
+	}
+	return hashes, nil
+}
+
+// UpdateProfile updates the fields set in the update. An update without any field is a no-op.
+func (us *userServiceImpl) UpdateProfile(ctx context.Context, userID string, update dto.ProfileUpdate) error {
+	return us.userRepository.UpdateProfile(ctx, userID, sanitizeProfile(update))
+}
+
+// AcceptInvitation activates the invited account with a single conditional update. An invitation accepted
+// by a concurrent request has already left the pending state, so it is reported as no longer pending.
+func (us *userServiceImpl) AcceptInvitation(ctx context.Context, userID string, profile dto.ProfileUpdate, hashedPassword string) error {
+	activated, err := us.userRepository.ActivateInvitation(ctx, userID, sanitizeProfile(profile), hashedPassword)
+	if err != nil {
+		return err
+	}
+	if !activated {
+		return apiError.ErrInvitationNotPending
+	}
+	return nil
+}
+
+// sanitizeProfile returns the update with its names sanitized.
+func sanitizeProfile(update dto.ProfileUpdate) dto.ProfileUpdate {
+	if update.FirstName != nil {
+		firstName := sanitizeName(*update.FirstName)
+		update.FirstName = &firstName
+	}
+	if update.LastName != nil {
+		lastName := sanitizeName(*update.LastName)
+		update.LastName = &lastName
+	}
+	return update
+}
+
+// LinkProvider records the OAuth identity on the account. The password hash is left alone,
+// so the user can keep signing in with their password as well as through the provider.
+func (us *userServiceImpl) LinkProvider(ctx context.Context, userID string, provider string, providerID string) error {
+	return us.userRepository.LinkProvider(ctx, userID, provider, providerID)
+}
+
+// UpdateStatus moves the user's account into the given status. Only the transitions SuspendUser and
+// ReactivateUser allow are made, so a status change always revokes sessions on suspension and never lifts a ban.
+func (us *userServiceImpl) UpdateStatus(ctx context.Context, userID string, status string) error {
+	switch status {
+	case entity.StatusSuspended:
+		return us.SuspendUser(ctx, userID)
+	case entity.StatusActive:
+		return us.ReactivateUser(ctx, userID)
+	default:
+		return apiError.ErrStatusChangeNotAllowed
+	}
+}
+
+// SuspendUser suspends the user's account and revokes every session issued so far,
+// so tokens the user already holds stop working even after a later reactivation.
+// Only active accounts can be suspended. Suspending a banned account would let ReactivateUser lift the ban.
+func (us *userServiceImpl) SuspendUser(ctx context.Context, userID string) error {
+	user, err := us.userRepository.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	switch user.Status {
+	case entity.StatusActive:
+	case entity.StatusBanned:
+		return apiError.ErrAccountBanned
+	case entity.StatusSuspended:
+		return apiError.ErrAccountSuspended
+	default:
+		return apiError.ErrAccountNotActive
+	}
+
+	return us.userRepository.Suspend(ctx, userID, time.Now())
+}
 
-	err := us.userRepository.Update(ctx, userID, updates)
+// ReactivateUser moves a suspended account back to active.
+// Accounts in any other status, banned ones included, are left untouched and ErrUserNotSuspended is returned.
+func (us *userServiceImpl) ReactivateUser(ctx context.Context, userID string) error {
+	user, err := us.userRepository.FindByID(ctx, userID)
 	if err != nil {
 		return err
 	}
+
+	if user.Status != entity.StatusSuspended {
+		return apiError.ErrUserNotSuspended
+	}
+
+	return us.ActivateUser(ctx, userID)
+}
+
+// ActivateUsers looks up the users by ID in a single query and by email one at a time, then activates them.
+// Suspended and banned users are skipped, since activation must not lift a suspension or ban, and a user named
+// more than once is only activated the first time.
+func (us *userServiceImpl) ActivateUsers(ctx context.Context, request *dto.ActivateUsersRequestDto) (*dto.ActivateUsersResponseDto, error) {
+	results := make([]dto.ActivationResultDto, 0, len(request.IDs)+len(request.Emails))
+
+	err := us.transactionManager.RunInTransaction(ctx, func(ctx context.Context) error {
+		ids := make([]string, 0, len(request.IDs))
+		for _, id := range request.IDs {
+			if parsed, err := uuid.Parse(id); err == nil {
+				ids = append(ids, parsed.String())
+			}
+		}
+		users, err := us.userRepository.FindByIDs(ctx, ids)
+		if err != nil {
+			return err
+		}
+
+		activated := make(map[string]bool)
+		for _, id := range request.IDs {
+			result := dto.ActivationResultDto{ID: id}
+			parsed, err := uuid.Parse(id)
+			if err != nil {
+				result.Status, result.Error = dto.ActivationStatusInvalidID, "ID is not a valid UUID"
+			} else if err := us.activateForBatch(ctx, users[parsed.String()], activated, &result); err != nil {
+				return err
+			}
+			results = append(results, result)
+		}
+
+		for _, email := range request.Emails {
+			result := dto.ActivationResultDto{Email: email}
+			user, err := us.userRepository.FindByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+			if err != nil && !errors.Is(err, postgres.ErrRecordNotFound) {
+				return err
+			}
+			if err := us.activateForBatch(ctx, user, activated, &result); err != nil {
+				return err
+			}
+			results = append(results, result)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.ActivateUsersResponseDto{Results: results}, nil
+}
+
+// activateForBatch activates a single user of ActivateUsers and fills in its result. A nil user wasn't found.
+// activated holds the users the batch has activated so far.
+func (us *userServiceImpl) activateForBatch(ctx context.Context, user *entity.User, activated map[string]bool, result *dto.ActivationResultDto) error {
+	if user == nil {
+		result.Status, result.Error = dto.ActivationStatusNotFound, "User not found"
+		return nil
+	}
+
+	id := user.ID.String()
+	result.UserID = id
+	switch {
+	case user.Status == entity.StatusSuspended || user.Status == entity.StatusBanned:
+		result.Status, result.Error = dto.ActivationStatusSkipped, "User is "+user.Status
+	case user.IsActive() || activated[id]:
+		result.Status = dto.ActivationStatusAlreadyActive
+	default:
+		if err := us.ActivateUser(ctx, id); err != nil {
+			return err
+		}
+		activated[id] = true
+		result.Status = dto.ActivationStatusActivated
+	}
 	return nil
 }
 
@@ -81,15 +389,85 @@ func (us *userServiceImpl) GetUserByID(ctx context.Context, userID string) (*dto
 		return nil, err
 	}
 
-	userDto := &dto.UserResponseDto{
-		ID:        user.ID.String(),
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Email:     user.Email,
-		CreatedAt: user.CreatedAt,
-		IsActive:  user.IsActive,
+	return newUserResponse(user), nil
+}
+
+// GetUsersByIDs validates and de-duplicates the IDs, then looks them all up at once.
+func (us *userServiceImpl) GetUsersByIDs(ctx context.Context, userIDs []string) (map[string]dto.UserResponseDto, error) {
+	ids := make([]string, 0, len(userIDs))
+	seen := make(map[string]bool, len(userIDs))
+	for _, userID := range userIDs {
+		parsed, err := uuid.Parse(userID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", apiError.ErrInvalidUserID, userID)
+		}
+		id := parsed.String()
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	users, err := us.userRepository.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := make(map[string]dto.UserResponseDto, len(users))
+	for id, user := range users {
+		resolved[id] = *newUserResponse(user)
+	}
+	return resolved, nil
+}
+
+// newUserResponse maps a user entity to the UserResponseDto shared with other features.
+func newUserResponse(user *entity.User) *dto.UserResponseDto {
+	return &dto.UserResponseDto{
+		ID:          user.ID.String(),
+		FirstName:   user.FirstName,
+		LastName:    user.LastName,
+		Email:       user.Email,
+		Username:    usernameOf(user),
+		Password:    user.Password,
+		PhoneNumber: user.PhoneNumber,
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+		IsActive:    user.IsActive(),
+		Status:      user.Status,
+		Provider:    user.Provider,
+		ProviderID:  user.ProviderID,
+		Role:        user.Role,
+		Locale:      user.Locale,
+		Timezone:    user.Timezone,
+		OrgID:       orgIDOf(user),
+
+		SessionsRevokedAt: user.SessionsRevokedAt,
+	}
+}
+
+// usernameOf returns the user's username, or an empty string when they have none.
+func usernameOf(user *entity.User) string {
+	if user.Username == nil {
+		return ""
 	}
-	return userDto, nil
+	return *user.Username
+}
+
+// orgIDOf returns the ID of the user's organization, or an empty string when they belong to none.
+func orgIDOf(user *entity.User) string {
+	if user.OrgID == nil {
+		return ""
+	}
+	return user.OrgID.String()
+}
+
+// GetUserByUsername retrieves a user by their username.
+func (us *userServiceImpl) GetUserByUsername(ctx context.Context, username string) (*dto.UserResponseDto, error) {
+	user, err := us.userRepository.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	return newUserResponse(user), nil
 }
 
 // GetUserByEmail retrieves a user by their email and returns a UserResponseDto containing the user's details.
@@ -100,15 +478,117 @@ func (us *userServiceImpl) GetUserByEmail(ctx context.Context, email string) (*d
 		return nil, err
 	}
 
-	userDto := &dto.UserResponseDto{
-		ID:         user.ID.String(),
-		FirstName:  user.FirstName,
-		LastName:   user.LastName,
-		Email:      user.Email,
-		Password:   user.Password,
-		CreatedAt:  user.CreatedAt,
-		IsActive:   user.IsActive,
-		ProviderID: user.ProviderID,
+	return newUserResponse(user), nil
+}
+
+// ListUsers returns one page of users for the admin user list, applying defaults for
+// missing paging and sorting parameters. Newest users come first by default.
+func (us *userServiceImpl) ListUsers(ctx context.Context, request *dto.ListUsersRequestDto) (*dto.UserListResponseDto, error) {
+	filter := ListFilter{
+		Search:  strings.TrimSpace(request.Search),
+		Status:  request.Status,
+		SortBy:  request.SortBy,
+		SortDir: request.SortDir,
+		Page:    request.Page,
+		Size:    request.Size,
+	}
+	if filter.Page == 0 {
+		filter.Page = defaultListPage
+	}
+	if filter.Size == 0 {
+		filter.Size = defaultListSize
+	}
+
+	users, total, err := us.userRepository.FindAll(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]dto.UserSummaryResponseDto, 0, len(users))
+	for _, user := range users {
+		items = append(items, dto.UserSummaryResponseDto{
+			ID:        user.ID.String(),
+			FirstName: user.FirstName,
+			LastName:  user.LastName,
+			Email:     user.Email,
+			Status:    user.Status,
+			Role:      user.Role,
+			Provider:  user.Provider,
+			OrgID:     orgIDOf(&user),
+			CreatedAt: user.CreatedAt,
+		})
+	}
+
+	return &dto.UserListResponseDto{Items: items, Total: total, Page: filter.Page, Size: filter.Size}, nil
+}
+
+// DeleteUser erases the user's personal data, revokes every session and soft-deletes the account.
+// The email is replaced with a placeholder so the address can be used to register again.
+func (us *userServiceImpl) DeleteUser(ctx context.Context, userID string) error {
+	err := us.userRepository.ErasePersonalData(ctx, userID, fmt.Sprintf("deleted-%s@invalid", userID), time.Now())
+	if err != nil {
+		return err
+	}
+
+	return us.userRepository.Delete(ctx, userID)
+}
+
+// SetNotificationPreference checks that the user exists, then saves their preference for the category.
+func (us *userServiceImpl) SetNotificationPreference(ctx context.Context, userID string, category string, enabled bool) error {
+	user, err := us.userRepository.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	return us.userRepository.SaveNotificationPreference(ctx, &entity.NotificationPreference{
+		UserID:   user.ID,
+		Category: category,
+		Enabled:  enabled,
+	})
+}
+
+// NotificationsEnabled looks up the user's preference for the category, defaulting to enabled.
+func (us *userServiceImpl) NotificationsEnabled(ctx context.Context, userID string, category string) (bool, error) {
+	preference, err := us.userRepository.FindNotificationPreference(ctx, userID, category)
+	if err != nil {
+		if errors.Is(err, postgres.ErrRecordNotFound) {
+			return true, nil
+		}
+		return false, err
 	}
-	return userDto, nil
+	return preference.Enabled, nil
+}
+
+// sanitizeName trims surrounding whitespace and drops control characters from a first or last name.
+// Names from requests are already validated, but names supplied by OAuth providers are not.
+func sanitizeName(name string) string {
+	return strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name))
+}
+
+// CreateOrganization inserts the organization and sets the owner's org_id to it.
+func (us *userServiceImpl) CreateOrganization(ctx context.Context, name string, ownerID string) (*dto.OrganizationResponseDto, error) {
+	owner, err := uuid.Parse(ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", apiError.ErrInvalidUserID, ownerID)
+	}
+
+	organization := &entity.Organization{Name: strings.TrimSpace(name), OwnerID: owner}
+	if err := us.userRepository.InsertOrganization(ctx, organization); err != nil {
+		return nil, err
+	}
+	if err := us.userRepository.SetOrganization(ctx, ownerID, organization.ID); err != nil {
+		return nil, err
+	}
+
+	return &dto.OrganizationResponseDto{
+		ID:        organization.ID.String(),
+		Name:      organization.Name,
+		OwnerID:   ownerID,
+		CreatedAt: organization.CreatedAt,
+	}, nil
 }
@@ -0,0 +1,297 @@
+package user_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/internal/features/user"
+	"github.com/npushpakumara/go-backend-template/internal/features/user/dto"
+	"github.com/npushpakumara/go-backend-template/internal/features/user/entity"
+	"github.com/npushpakumara/go-backend-template/internal/features/user/usertest"
+	"github.com/npushpakumara/go-backend-template/internal/postgres"
+	"github.com/npushpakumara/go-backend-template/internal/postgres/postgrestest"
+	apiError "github.com/npushpakumara/go-backend-template/pkg/errors"
+)
+
+// newTestUserService returns a user service backed by an in-memory repository.
+func newTestUserService(cfg *config.Config) (user.Service, *usertest.Repository) {
+	repo := usertest.NewRepository()
+	return user.NewUserService(repo, postgrestest.NopTransactionManager{}, cfg), repo
+}
+
+// insertUser adds a user with the given email and status to the repository.
+func insertUser(t *testing.T, repo *usertest.Repository, email, status string) *entity.User {
+	t.Helper()
+
+	u, err := repo.Insert(context.Background(), &entity.User{FirstName: "Ada", Email: email, Status: status, Role: entity.RoleUser})
+	if err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+	return u
+}
+
+func TestSuspendUser(t *testing.T) {
+	tests := []struct {
+		status     string
+		wantErr    error
+		wantStatus string
+	}{
+		{status: entity.StatusActive, wantStatus: entity.StatusSuspended},
+		{status: entity.StatusSuspended, wantErr: apiError.ErrAccountSuspended, wantStatus: entity.StatusSuspended},
+		{status: entity.StatusBanned, wantErr: apiError.ErrAccountBanned, wantStatus: entity.StatusBanned},
+		{status: entity.StatusPending, wantErr: apiError.ErrAccountNotActive, wantStatus: entity.StatusPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			service, repo := newTestUserService(&config.Config{})
+			u := insertUser(t, repo, "ada@example.com", tt.status)
+
+			err := service.SuspendUser(context.Background(), u.ID.String())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SuspendUser() error = %v, want %v", err, tt.wantErr)
+			}
+
+			stored, err := repo.FindByID(context.Background(), u.ID.String())
+			if err != nil {
+				t.Fatalf("FindByID() error = %v", err)
+			}
+			if stored.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", stored.Status, tt.wantStatus)
+			}
+			if revoked := stored.SessionsRevokedAt != nil; revoked != (tt.wantErr == nil) {
+				t.Errorf("sessions revoked = %v, want %v", revoked, tt.wantErr == nil)
+			}
+		})
+	}
+}
+
+func TestReactivateUser(t *testing.T) {
+	tests := []struct {
+		status     string
+		wantErr    error
+		wantStatus string
+	}{
+		{status: entity.StatusSuspended, wantStatus: entity.StatusActive},
+		{status: entity.StatusBanned, wantErr: apiError.ErrUserNotSuspended, wantStatus: entity.StatusBanned},
+		{status: entity.StatusActive, wantErr: apiError.ErrUserNotSuspended, wantStatus: entity.StatusActive},
+		{status: entity.StatusPending, wantErr: apiError.ErrUserNotSuspended, wantStatus: entity.StatusPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			service, repo := newTestUserService(&config.Config{})
+			u := insertUser(t, repo, "ada@example.com", tt.status)
+
+			err := service.ReactivateUser(context.Background(), u.ID.String())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReactivateUser() error = %v, want %v", err, tt.wantErr)
+			}
+
+			stored, err := repo.FindByID(context.Background(), u.ID.String())
+			if err != nil {
+				t.Fatalf("FindByID() error = %v", err)
+			}
+			if stored.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", stored.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+// UpdateStatus only makes the transitions SuspendUser and ReactivateUser allow, so it can't lift a ban
+// or suspend an account without revoking its sessions.
+func TestUpdateStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      string
+		newStatus   string
+		wantErr     error
+		wantStatus  string
+		wantRevoked bool
+	}{
+		{name: "suspend active", status: entity.StatusActive, newStatus: entity.StatusSuspended, wantStatus: entity.StatusSuspended, wantRevoked: true},
+		{name: "reactivate suspended", status: entity.StatusSuspended, newStatus: entity.StatusActive, wantStatus: entity.StatusActive},
+		{name: "activate banned", status: entity.StatusBanned, newStatus: entity.StatusActive, wantErr: apiError.ErrUserNotSuspended, wantStatus: entity.StatusBanned},
+		{name: "suspend banned", status: entity.StatusBanned, newStatus: entity.StatusSuspended, wantErr: apiError.ErrAccountBanned, wantStatus: entity.StatusBanned},
+		{name: "activate pending", status: entity.StatusPending, newStatus: entity.StatusActive, wantErr: apiError.ErrUserNotSuspended, wantStatus: entity.StatusPending},
+		{name: "ban", status: entity.StatusActive, newStatus: entity.StatusBanned, wantErr: apiError.ErrStatusChangeNotAllowed, wantStatus: entity.StatusActive},
+		{name: "reset to pending", status: entity.StatusBanned, newStatus: entity.StatusPending, wantErr: apiError.ErrStatusChangeNotAllowed, wantStatus: entity.StatusBanned},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, repo := newTestUserService(&config.Config{})
+			u := insertUser(t, repo, "ada@example.com", tt.status)
+
+			err := service.UpdateStatus(context.Background(), u.ID.String(), tt.newStatus)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdateStatus() error = %v, want %v", err, tt.wantErr)
+			}
+
+			stored, err := repo.FindByID(context.Background(), u.ID.String())
+			if err != nil {
+				t.Fatalf("FindByID() error = %v", err)
+			}
+			if stored.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", stored.Status, tt.wantStatus)
+			}
+			if revoked := stored.SessionsRevokedAt != nil; revoked != tt.wantRevoked {
+				t.Errorf("sessions revoked = %v, want %v", revoked, tt.wantRevoked)
+			}
+		})
+	}
+}
+
+// A ban can't be lifted by suspending the account and then reactivating it.
+func TestSuspendAndReactivateKeepsBan(t *testing.T) {
+	service, repo := newTestUserService(&config.Config{})
+	u := insertUser(t, repo, "ada@example.com", entity.StatusBanned)
+
+	if err := service.SuspendUser(context.Background(), u.ID.String()); !errors.Is(err, apiError.ErrAccountBanned) {
+		t.Fatalf("SuspendUser() error = %v, want ErrAccountBanned", err)
+	}
+	if err := service.ReactivateUser(context.Background(), u.ID.String()); !errors.Is(err, apiError.ErrUserNotSuspended) {
+		t.Fatalf("ReactivateUser() error = %v, want ErrUserNotSuspended", err)
+	}
+
+	stored, err := repo.FindByID(context.Background(), u.ID.String())
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	if stored.Status != entity.StatusBanned {
+		t.Errorf("status = %q, want %q", stored.Status, entity.StatusBanned)
+	}
+}
+
+func TestSuspendUserNotFound(t *testing.T) {
+	service, _ := newTestUserService(&config.Config{})
+
+	err := service.SuspendUser(context.Background(), "6f1c9a8e-4c1b-4d2e-9a55-0c5d2b7e1f00")
+	if !errors.Is(err, postgres.ErrRecordNotFound) {
+		t.Fatalf("SuspendUser() error = %v, want ErrRecordNotFound", err)
+	}
+}
+
+func TestUpdateProfileLeavesOtherFieldsUntouched(t *testing.T) {
+	service, repo := newTestUserService(&config.Config{})
+	u := insertUser(t, repo, "ada@example.com", entity.StatusActive)
+
+	name := "  Augusta\t"
+	if err := service.UpdateProfile(context.Background(), u.ID.String(), dto.ProfileUpdate{FirstName: &name}); err != nil {
+		t.Fatalf("UpdateProfile() error = %v", err)
+	}
+
+	stored, err := repo.FindByID(context.Background(), u.ID.String())
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	if stored.FirstName != "Augusta" {
+		t.Errorf("first name = %q, want %q", stored.FirstName, "Augusta")
+	}
+	if stored.Email != u.Email || stored.Status != u.Status || stored.Role != u.Role || stored.Password != u.Password {
+		t.Errorf("UpdateProfile() changed other fields: %+v, want them as in %+v", stored, u)
+	}
+}
+
+func TestCreateUserReportsDuplicates(t *testing.T) {
+	tests := []struct {
+		name     string
+		request  *dto.RegisterRequestDto
+		wantErr  error
+		otherErr error
+	}{
+		{
+			name:     "email",
+			request:  &dto.RegisterRequestDto{FirstName: "Ada", Email: "ada@example.com", Password: "hash"},
+			wantErr:  apiError.ErrEmailAlreadyExists,
+			otherErr: apiError.ErrUsernameTaken,
+		},
+		{
+			name:     "email of an OAuth sign-up",
+			request:  &dto.RegisterRequestDto{FirstName: "Ada", Email: "ada@example.com", Provider: "google", ProviderID: "g-1"},
+			wantErr:  apiError.ErrEmailAlreadyExists,
+			otherErr: apiError.ErrUsernameTaken,
+		},
+		{
+			name:     "username",
+			request:  &dto.RegisterRequestDto{FirstName: "Ada", Email: "other@example.com", Password: "hash", Username: "ada"},
+			wantErr:  apiError.ErrUsernameTaken,
+			otherErr: apiError.ErrEmailAlreadyExists,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, repo := newTestUserService(&config.Config{})
+			if _, err := repo.Insert(context.Background(), &entity.User{FirstName: "Ada", Email: "ada@example.com", Username: ptr("ada"), Role: entity.RoleUser}); err != nil {
+				t.Fatalf("insert user: %v", err)
+			}
+
+			created, err := service.CreateUser(context.Background(), tt.request)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateUser() error = %v, want %v", err, tt.wantErr)
+			}
+			if errors.Is(err, tt.otherErr) {
+				t.Errorf("CreateUser() error = %v, which also matches %v", err, tt.otherErr)
+			}
+			// The domain error wraps the repository's, so the cause is still logged and inspectable.
+			if !errors.Is(err, postgres.ErrKeyDuplicate) {
+				t.Errorf("CreateUser() error = %v, want it to wrap ErrKeyDuplicate", err)
+			}
+			if created != nil {
+				t.Errorf("CreateUser() = %+v, want nil", created)
+			}
+			if users := repo.Users(); len(users) != 1 {
+				t.Errorf("repository has %d users, want only the existing one", len(users))
+			}
+		})
+	}
+}
+
+func TestAcceptInvitation(t *testing.T) {
+	tests := []struct {
+		name    string
+		invited entity.User
+		wantErr error
+	}{
+		{name: "pending invitation", invited: entity.User{Status: entity.StatusPending}},
+		{name: "accepted invitation", invited: entity.User{Status: entity.StatusActive, Password: "hash"}, wantErr: apiError.ErrInvitationNotPending},
+		{name: "pending sign-up", invited: entity.User{Status: entity.StatusPending, Password: "hash"}, wantErr: apiError.ErrInvitationNotPending},
+		{name: "pending OAuth sign-up", invited: entity.User{Status: entity.StatusPending, Provider: "google", ProviderID: "g-1"}, wantErr: apiError.ErrInvitationNotPending},
+		{name: "suspended", invited: entity.User{Status: entity.StatusSuspended}, wantErr: apiError.ErrInvitationNotPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, repo := newTestUserService(&config.Config{})
+			tt.invited.Email, tt.invited.Role = "ada@example.com", entity.RoleUser
+			u, err := repo.Insert(context.Background(), &tt.invited)
+			if err != nil {
+				t.Fatalf("insert user: %v", err)
+			}
+			before := *u
+
+			name := "  Ada\t"
+			err = service.AcceptInvitation(context.Background(), u.ID.String(), dto.ProfileUpdate{FirstName: &name}, "new-hash")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AcceptInvitation() error = %v, want %v", err, tt.wantErr)
+			}
+
+			stored, err := repo.FindByID(context.Background(), u.ID.String())
+			if err != nil {
+				t.Fatalf("FindByID() error = %v", err)
+			}
+			if tt.wantErr != nil {
+				if stored.Status != before.Status || stored.Password != before.Password || stored.FirstName != before.FirstName {
+					t.Errorf("AcceptInvitation() changed the account to %+v", stored)
+				}
+				return
+			}
+			if stored.Status != entity.StatusActive || stored.Password != "new-hash" || stored.FirstName != "Ada" {
+				t.Errorf("stored user = %s/%s/%s, want Ada/new-hash/%s", stored.FirstName, stored.Password, stored.Status, entity.StatusActive)
+			}
+		})
+	}
+}
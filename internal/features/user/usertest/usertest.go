@@ -0,0 +1,465 @@
+// Package usertest provides an in-memory user.Repository for tests.
+package usertest
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/npushpakumara/go-backend-template/internal/features/user"
+	"github.com/npushpakumara/go-backend-template/internal/features/user/dto"
+	"github.com/npushpakumara/go-backend-template/internal/features/user/entity"
+	"github.com/npushpakumara/go-backend-template/internal/postgres"
+	"gorm.io/gorm"
+)
+
+// Names of the unique constraints reported when an insert clashes, as Postgres names them.
+const (
+	emailConstraint    = "uni_users_email"
+	usernameConstraint = "idx_users_username"
+)
+
+// Repository is a user.Repository that keeps users in memory. It reports the same errors as the
+// Postgres repository but ignores organization scoping, so tests of the organization filter need a
+// real database. It is safe for concurrent use.
+type Repository struct {
+	mu            sync.Mutex
+	users         map[uuid.UUID]*entity.User
+	history       []entity.PasswordHistory
+	preferences   map[string]*entity.NotificationPreference
+	organizations map[uuid.UUID]*entity.Organization
+}
+
+// NewRepository returns an empty Repository.
+func NewRepository() *Repository {
+	return &Repository{
+		users:         make(map[uuid.UUID]*entity.User),
+		preferences:   make(map[string]*entity.NotificationPreference),
+		organizations: make(map[uuid.UUID]*entity.Organization),
+	}
+}
+
+// Compile-time check that Repository implements user.Repository.
+var _ user.Repository = (*Repository)(nil)
+
+// Insert stores a copy of the user, assigning an ID and timestamps as GORM would.
+func (r *Repository) Insert(_ context.Context, u *entity.User) (*entity.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.users {
+		if existing.Email == u.Email {
+			return nil, &postgres.DuplicateKeyError{Constraint: emailConstraint}
+		}
+		if u.Username != nil && existing.Username != nil && *existing.Username == *u.Username {
+			return nil, &postgres.DuplicateKeyError{Constraint: usernameConstraint}
+		}
+	}
+
+	if u.ID == uuid.Nil {
+		u.ID = uuid.New()
+	}
+	now := time.Now()
+	u.Model = &gorm.Model{CreatedAt: now, UpdatedAt: now}
+	if u.Status == "" {
+		u.Status = entity.StatusPending
+	}
+
+	r.users[u.ID] = cloneUser(u)
+	return u, nil
+}
+
+// FindByEmail returns the user with the email.
+func (r *Repository) FindByEmail(_ context.Context, email string) (*entity.User, error) {
+	return r.find(func(u *entity.User) bool { return u.Email == email })
+}
+
+// FindByUsername returns the user with the username.
+func (r *Repository) FindByUsername(_ context.Context, username string) (*entity.User, error) {
+	return r.find(func(u *entity.User) bool { return u.Username != nil && *u.Username == username })
+}
+
+// FindByID returns the user with the ID.
+func (r *Repository) FindByID(_ context.Context, id string) (*entity.User, error) {
+	return r.find(func(u *entity.User) bool { return u.ID.String() == id })
+}
+
+// FindByIDs returns the users with the given IDs, keyed by ID.
+func (r *Repository) FindByIDs(_ context.Context, ids []string) (map[string]*entity.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	found := make(map[string]*entity.User, len(ids))
+	for _, u := range r.users {
+		if !isDeleted(u) && slices.Contains(ids, u.ID.String()) {
+			found[u.ID.String()] = cloneUser(u)
+		}
+	}
+	return found, nil
+}
+
+// SetStatus sets the user's status.
+func (r *Repository) SetStatus(_ context.Context, id string, status string) error {
+	return r.update(id, map[string]interface{}{"status": status})
+}
+
+// Suspend sets the user's status to suspended and records when their sessions were revoked.
+func (r *Repository) Suspend(_ context.Context, id string, revokedAt time.Time) error {
+	return r.update(id, map[string]interface{}{
+		"status":              entity.StatusSuspended,
+		"sessions_revoked_at": revokedAt,
+	})
+}
+
+// SetPassword stores the password hash and records when the user's sessions were revoked.
+func (r *Repository) SetPassword(_ context.Context, id string, hash string, revokedAt time.Time) error {
+	return r.update(id, map[string]interface{}{
+		"password":            hash,
+		"sessions_revoked_at": revokedAt,
+	})
+}
+
+// UpdateProfile sets the profile fields set in the update.
+func (r *Repository) UpdateProfile(_ context.Context, id string, update dto.ProfileUpdate) error {
+	updates := profileColumns(update)
+	if len(updates) == 0 {
+		return nil
+	}
+	return r.update(id, updates)
+}
+
+// profileColumns maps the fields set in the update to their columns, as the Postgres repository does.
+func profileColumns(update dto.ProfileUpdate) map[string]interface{} {
+	updates := map[string]interface{}{}
+	if update.FirstName != nil {
+		updates["first_name"] = *update.FirstName
+	}
+	if update.LastName != nil {
+		updates["last_name"] = *update.LastName
+	}
+	if update.PhoneNumber != nil {
+		updates["phone_number"] = *update.PhoneNumber
+	}
+	if update.Locale != nil {
+		updates["locale"] = *update.Locale
+	}
+	if update.Timezone != nil {
+		updates["timezone"] = *update.Timezone
+	}
+	return updates
+}
+
+// LinkProvider records the OAuth provider and the user's ID there.
+func (r *Repository) LinkProvider(_ context.Context, id string, provider string, providerID string) error {
+	return r.update(id, map[string]interface{}{
+		"provider":    provider,
+		"provider_id": providerID,
+	})
+}
+
+// ErasePersonalData replaces the user's personal data with the same placeholders as the Postgres repository.
+func (r *Repository) ErasePersonalData(_ context.Context, id string, email string, revokedAt time.Time) error {
+	return r.update(id, map[string]interface{}{
+		"first_name":          "Deleted",
+		"last_name":           "",
+		"email":               email,
+		"password":            "",
+		"phone_number":        "",
+		"provider_id":         "",
+		"sessions_revoked_at": revokedAt,
+	})
+}
+
+// SetOrganization makes the user a member of the organization.
+func (r *Repository) SetOrganization(_ context.Context, id string, orgID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u := r.lookup(id)
+	if u == nil {
+		return postgres.ErrRecordNotFound
+	}
+
+	updated := cloneUser(u)
+	updated.OrgID = &orgID
+	updated.UpdatedAt = time.Now()
+	r.users[u.ID] = updated
+	return nil
+}
+
+// update applies the updates to the user. Like the Postgres repository, it only accepts the columns
+// the typed update methods may write and reports ErrRecordNotFound when no user has the ID.
+func (r *Repository) update(id string, updates map[string]interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u := r.lookup(id)
+	if u == nil {
+		return postgres.ErrRecordNotFound
+	}
+
+	updated := cloneUser(u)
+	for column, value := range updates {
+		if err := setColumn(updated, column, value); err != nil {
+			return err
+		}
+	}
+	updated.UpdatedAt = time.Now()
+	r.users[u.ID] = updated
+	return nil
+}
+
+// ActivateInvitation sets the profile and password and activates the account only if it is still a pending invitation.
+func (r *Repository) ActivateInvitation(_ context.Context, id string, profile dto.ProfileUpdate, hash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u := r.lookup(id)
+	if u == nil || u.Status != entity.StatusPending || u.Password != "" || u.ProviderID != "" {
+		return false, nil
+	}
+
+	updated := cloneUser(u)
+	updates := profileColumns(profile)
+	updates["password"] = hash
+	updates["status"] = entity.StatusActive
+	for column, value := range updates {
+		if err := setColumn(updated, column, value); err != nil {
+			return false, err
+		}
+	}
+	updated.UpdatedAt = time.Now()
+	r.users[u.ID] = updated
+	return true, nil
+}
+
+// ReplacePasswordHash stores newHash only if the user's hash is still oldHash.
+func (r *Repository) ReplacePasswordHash(_ context.Context, id string, oldHash string, newHash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u := r.lookup(id)
+	if u == nil || u.Password != oldHash {
+		return false, nil
+	}
+	u.Password = newHash
+	return true, nil
+}
+
+// FindAll returns one page of the users matching the filter's status and search term, newest first.
+func (r *Repository) FindAll(_ context.Context, filter user.ListFilter) ([]entity.User, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	term := strings.ToLower(filter.Search)
+	var matches []entity.User
+	for _, u := range r.users {
+		if isDeleted(u) || (filter.Status != "" && u.Status != filter.Status) {
+			continue
+		}
+		if term != "" && !strings.Contains(strings.ToLower(u.FirstName+" "+u.LastName+" "+u.Email), term) {
+			continue
+		}
+		matches = append(matches, *cloneUser(u))
+	}
+	slices.SortFunc(matches, func(a, b entity.User) int { return b.CreatedAt.Compare(a.CreatedAt) })
+
+	total := int64(len(matches))
+	start := min((filter.Page-1)*filter.Size, len(matches))
+	end := min(start+filter.Size, len(matches))
+	return matches[start:end], total, nil
+}
+
+// Delete soft-deletes the user.
+func (r *Repository) Delete(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u := r.lookup(id)
+	if u == nil {
+		return postgres.ErrRecordNotFound
+	}
+	u.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	return nil
+}
+
+// InsertPasswordHistory records a replaced password hash.
+func (r *Repository) InsertPasswordHistory(_ context.Context, history *entity.PasswordHistory) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if history.ID == uuid.Nil {
+		history.ID = uuid.New()
+	}
+	history.Model = &gorm.Model{CreatedAt: time.Now()}
+	r.history = append(r.history, *history)
+	return nil
+}
+
+// FindPasswordHistory returns up to limit of the user's replaced hashes, most recent first.
+func (r *Repository) FindPasswordHistory(_ context.Context, userID string, limit int) ([]entity.PasswordHistory, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var history []entity.PasswordHistory
+	for i := len(r.history) - 1; i >= 0 && len(history) < limit; i-- {
+		if r.history[i].UserID.String() == userID {
+			history = append(history, r.history[i])
+		}
+	}
+	return history, nil
+}
+
+// PrunePasswordHistory deletes all but the keep most recent replaced hashes of the user.
+func (r *Repository) PrunePasswordHistory(_ context.Context, userID string, keep int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := 0
+	pruned := make([]entity.PasswordHistory, 0, len(r.history))
+	for i := len(r.history) - 1; i >= 0; i-- {
+		if r.history[i].UserID.String() == userID {
+			if kept == keep {
+				continue
+			}
+			kept++
+		}
+		pruned = append(pruned, r.history[i])
+	}
+	slices.Reverse(pruned)
+	r.history = pruned
+	return nil
+}
+
+// SaveNotificationPreference creates or replaces the user's preference for the category.
+func (r *Repository) SaveNotificationPreference(_ context.Context, preference *entity.NotificationPreference) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	saved := *preference
+	r.preferences[preferenceKey(preference.UserID.String(), preference.Category)] = &saved
+	return nil
+}
+
+// FindNotificationPreference returns the user's preference for the category.
+func (r *Repository) FindNotificationPreference(_ context.Context, userID string, category string) (*entity.NotificationPreference, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	preference, ok := r.preferences[preferenceKey(userID, category)]
+	if !ok {
+		return nil, postgres.ErrRecordNotFound
+	}
+	found := *preference
+	return &found, nil
+}
+
+// InsertOrganization stores the organization, assigning it an ID.
+func (r *Repository) InsertOrganization(_ context.Context, organization *entity.Organization) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if organization.ID == uuid.Nil {
+		organization.ID = uuid.New()
+	}
+	organization.Model = &gorm.Model{CreatedAt: time.Now()}
+	saved := *organization
+	r.organizations[organization.ID] = &saved
+	return nil
+}
+
+// Users returns a copy of every user that hasn't been deleted.
+func (r *Repository) Users() []entity.User {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	users := make([]entity.User, 0, len(r.users))
+	for _, u := range r.users {
+		if !isDeleted(u) {
+			users = append(users, *cloneUser(u))
+		}
+	}
+	return users
+}
+
+// find returns a copy of the first user that hasn't been deleted and matches.
+func (r *Repository) find(match func(u *entity.User) bool) (*entity.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, u := range r.users {
+		if !isDeleted(u) && match(u) {
+			return cloneUser(u), nil
+		}
+	}
+	return nil, postgres.ErrRecordNotFound
+}
+
+// lookup returns the stored user with the ID, or nil. The caller must hold mu.
+func (r *Repository) lookup(id string) *entity.User {
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return nil
+	}
+	u, ok := r.users[parsed]
+	if !ok || isDeleted(u) {
+		return nil
+	}
+	return u
+}
+
+// setColumn writes a single column of an update to the user.
+func setColumn(u *entity.User, column string, value interface{}) error {
+	switch column {
+	case "first_name":
+		u.FirstName = value.(string)
+	case "last_name":
+		u.LastName = value.(string)
+	case "email":
+		u.Email = value.(string)
+	case "password":
+		u.Password = value.(string)
+	case "phone_number":
+		u.PhoneNumber = value.(string)
+	case "status":
+		u.Status = value.(string)
+	case "provider":
+		u.Provider = value.(string)
+	case "provider_id":
+		u.ProviderID = value.(string)
+	case "locale":
+		u.Locale = value.(string)
+	case "timezone":
+		u.Timezone = value.(string)
+	case "sessions_revoked_at":
+		revokedAt := value.(time.Time)
+		u.SessionsRevokedAt = &revokedAt
+	default:
+		return fmt.Errorf("%w: %q", user.ErrColumnNotUpdatable, column)
+	}
+	return nil
+}
+
+// cloneUser copies the user so callers can't change the stored one.
+func cloneUser(u *entity.User) *entity.User {
+	clone := *u
+	if u.Model != nil {
+		model := *u.Model
+		clone.Model = &model
+	}
+	return &clone
+}
+
+// isDeleted reports whether the user has been soft-deleted.
+func isDeleted(u *entity.User) bool {
+	return u.Model != nil && u.DeletedAt.Valid
+}
+
+// preferenceKey identifies a notification preference in the map.
+func preferenceKey(userID, category string) string {
+	return userID + "/" + category
+}
@@ -0,0 +1,233 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/internal/features/webhook/entity"
+	"github.com/npushpakumara/go-backend-template/pkg/logging"
+	"github.com/npushpakumara/go-backend-template/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Headers sent with every delivery.
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	EventHeader     = "X-Webhook-Event"
+	EventIDHeader   = "X-Webhook-ID"
+)
+
+// Sign returns the hex-encoded HMAC-SHA256 of "<timestamp>.<body>" keyed with the subscription secret.
+// Including the timestamp lets receivers reject replayed deliveries.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// signatureHeaderValue formats the signature header as "t=<timestamp>,v1=<signature>".
+func signatureHeaderValue(secret string, timestamp int64, body []byte) string {
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, Sign(secret, timestamp, body))
+}
+
+// dispatcher delivers events to subscriptions in the background, retrying failed attempts
+// with exponential backoff and recording every attempt.
+type dispatcher struct {
+	client      *http.Client
+	repository  Repository
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+
+	// ctx is cancelled when shutdown runs out of time, abandoning in-flight deliveries and
+	// pending retries; wg tracks in-flight deliveries.
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	// mu guards closed against concurrent dispatches; pending counts in-flight deliveries.
+	mu      sync.Mutex
+	closed  bool
+	pending atomic.Int64
+}
+
+// newDispatcher creates a dispatcher configured from the webhook settings.
+func newDispatcher(repository Repository, cfg *config.WebhookConfig) *dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &dispatcher{
+		client:      &http.Client{Timeout: cfg.Timeout},
+		repository:  repository,
+		maxAttempts: max(cfg.MaxAttempts, 1),
+		baseDelay:   cfg.RetryBaseDelay,
+		maxDelay:    cfg.RetryMaxDelay,
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// dispatch delivers the payload to the subscription in a background goroutine, in the trace of ctx.
+// Events dispatched after shutdown has started are logged and dropped.
+func (d *dispatcher) dispatch(ctx context.Context, subscription entity.Subscription, eventID uuid.UUID, event string, payload []byte) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		logging.FromContext(d.ctx).Warnw("webhook.dispatcher.dispatch shutting down, dropping event",
+			"subscription", subscription.ID, "event", event)
+		return
+	}
+
+	deliveryCtx := tracing.Continue(d.ctx, ctx)
+	d.wg.Add(1)
+	d.pending.Add(1)
+	go func() {
+		defer d.wg.Done()
+		defer d.pending.Add(-1)
+		d.deliver(deliveryCtx, subscription, eventID, event, payload)
+	}()
+}
+
+// deliver posts the payload until the receiver answers with a 2xx status, attempts run out,
+// or ctx is cancelled. Each attempt is recorded as a Delivery.
+func (d *dispatcher) deliver(ctx context.Context, subscription entity.Subscription, eventID uuid.UUID, event string, payload []byte) {
+	logger := logging.FromContext(ctx)
+
+	for attempt := 1; attempt <= d.maxAttempts; attempt++ {
+		statusCode, err := d.post(ctx, subscription, eventID, event, payload)
+
+		delivery := &entity.Delivery{
+			SubscriptionID: subscription.ID,
+			EventID:        eventID,
+			Event:          event,
+			Attempt:        attempt,
+			StatusCode:     statusCode,
+			Succeeded:      err == nil,
+		}
+		if err != nil {
+			delivery.Error = err.Error()
+		}
+		// Record the attempt even when shutdown cancelled it.
+		if recordErr := d.repository.InsertDelivery(context.WithoutCancel(ctx), delivery); recordErr != nil {
+			logger.Errorw("webhook.dispatcher.deliver failed to record delivery", "subscription", subscription.ID, "err", recordErr)
+		}
+
+		if err == nil {
+			return
+		}
+
+		if attempt == d.maxAttempts {
+			logger.Errorw("webhook.dispatcher.deliver giving up", "subscription", subscription.ID, "event", event, "attempts", attempt, "err", err)
+			return
+		}
+
+		delay := d.backoff(attempt)
+		logger.Warnw("webhook.dispatcher.deliver attempt failed, retrying",
+			"subscription", subscription.ID, "event", event, "attempt", attempt, "next_delay", delay, "err", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// post sends a single signed request and returns the response status code.
+// Any status outside 2xx is reported as an error. The request is recorded as a client span, which
+// the receiver can join through the traceparent header.
+func (d *dispatcher) post(ctx context.Context, subscription entity.Subscription, eventID uuid.UUID, event string, payload []byte) (statusCode int, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "webhook.deliver "+event,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("webhook.subscription_id", subscription.ID.String()),
+			attribute.String("webhook.event_id", eventID.String()),
+		),
+	)
+	defer func() {
+		if statusCode != 0 {
+			span.SetAttributes(semconv.HTTPResponseStatusCode(statusCode))
+		}
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, event)
+	req.Header.Set(EventIDHeader, eventID.String())
+	req.Header.Set(SignatureHeader, signatureHeaderValue(subscription.Secret, time.Now().Unix(), payload))
+	tracing.Inject(ctx, req.Header)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Drain a bounded amount of the body so the connection can be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns the delay after the given failed attempt (starting at 1),
+// doubling the base delay each time and capping it at maxDelay when set.
+func (d *dispatcher) backoff(attempt int) time.Duration {
+	delay := d.baseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if d.maxDelay > 0 && delay >= d.maxDelay {
+			break
+		}
+	}
+	if d.maxDelay > 0 && delay > d.maxDelay {
+		delay = d.maxDelay
+	}
+	return delay
+}
+
+// stop stops accepting events and waits for in-flight deliveries, including their retries,
+// to finish or ctx to expire. When ctx expires first, the remaining deliveries are logged and abandoned.
+func (d *dispatcher) stop(ctx context.Context) error {
+	d.mu.Lock()
+	d.closed = true
+	d.mu.Unlock()
+	defer d.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		logging.FromContext(ctx).Warnw("webhook.dispatcher.stop deadline reached, abandoning deliveries", "remaining", d.pending.Load())
+		return ctx.Err()
+	}
+}
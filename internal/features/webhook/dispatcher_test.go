@@ -0,0 +1,223 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/internal/features/webhook/entity"
+	"github.com/npushpakumara/go-backend-template/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordingRepository is a Repository that records delivery attempts.
+type recordingRepository struct {
+	Repository
+
+	mu         sync.Mutex
+	deliveries []entity.Delivery
+}
+
+func (r *recordingRepository) InsertDelivery(_ context.Context, delivery *entity.Delivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deliveries = append(r.deliveries, *delivery)
+	return nil
+}
+
+// Deliveries returns the recorded attempts, oldest first.
+func (r *recordingRepository) Deliveries() []entity.Delivery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]entity.Delivery(nil), r.deliveries...)
+}
+
+// newTestDispatcher returns a dispatcher that tries each delivery up to maxAttempts times, 1ms apart.
+func newTestDispatcher(t *testing.T, maxAttempts int) (*dispatcher, *recordingRepository) {
+	t.Helper()
+
+	repo := &recordingRepository{}
+	d := newDispatcher(repo, &config.WebhookConfig{Timeout: time.Second, MaxAttempts: maxAttempts, RetryBaseDelay: time.Millisecond})
+	t.Cleanup(func() { _ = d.stop(context.Background()) })
+	return d, repo
+}
+
+// The delivery is recorded as a span of the request that emitted the event and forwards its trace.
+func TestDispatchContinuesTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	tracing.SetGlobal(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	traceparents := make(chan string, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparents <- r.Header.Get("traceparent")
+	}))
+	defer receiver.Close()
+
+	d, _ := newTestDispatcher(t, 1)
+	ctx, request := tracing.Tracer().Start(context.Background(), "request")
+	d.dispatch(ctx, entity.Subscription{ID: uuid.New(), URL: receiver.URL, Secret: "secret"}, uuid.New(), entity.EventUserCreated, []byte(`{}`))
+	request.End()
+	if err := d.stop(context.Background()); err != nil {
+		t.Fatalf("stop() error = %v", err)
+	}
+
+	var deliver sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == "webhook.deliver "+entity.EventUserCreated {
+			deliver = span
+		}
+	}
+	if deliver == nil {
+		t.Fatal("no webhook.deliver span was recorded")
+	}
+	if deliver.Parent().SpanID() != request.SpanContext().SpanID() {
+		t.Error("the delivery span isn't a child of the request span")
+	}
+
+	want := "00-" + deliver.SpanContext().TraceID().String() + "-" + deliver.SpanContext().SpanID().String() + "-01"
+	if got := <-traceparents; got != want {
+		t.Errorf("traceparent = %q, want %q", got, want)
+	}
+}
+
+func TestSign(t *testing.T) {
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(`1700000000.{"id":1}`))
+	want := hex.EncodeToString(mac.Sum(nil))
+
+	if got := Sign("secret", 1700000000, []byte(`{"id":1}`)); got != want {
+		t.Errorf("Sign() = %s, want %s", got, want)
+	}
+	if Sign("other", 1700000000, []byte(`{"id":1}`)) == want {
+		t.Error("Sign() with another secret gave the same signature")
+	}
+	if Sign("secret", 1700000001, []byte(`{"id":1}`)) == want {
+		t.Error("Sign() at another timestamp gave the same signature")
+	}
+}
+
+// A receiver can check the delivery against its signature header with the subscription secret.
+func TestDeliverySignature(t *testing.T) {
+	type received struct {
+		signature, event, eventID string
+		body                      []byte
+	}
+	deliveries := make(chan received, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		deliveries <- received{r.Header.Get(SignatureHeader), r.Header.Get(EventHeader), r.Header.Get(EventIDHeader), body}
+	}))
+	defer receiver.Close()
+
+	d, _ := newTestDispatcher(t, 1)
+	eventID := uuid.New()
+	payload := []byte(`{"event":"user.created"}`)
+	d.dispatch(context.Background(), entity.Subscription{ID: uuid.New(), URL: receiver.URL, Secret: "whsec"}, eventID, entity.EventUserCreated, payload)
+	got := <-deliveries
+
+	if got.event != entity.EventUserCreated || got.eventID != eventID.String() {
+		t.Errorf("event headers = %q, %q, want %q, %q", got.event, got.eventID, entity.EventUserCreated, eventID)
+	}
+
+	var timestamp int64
+	var signature string
+	if _, err := fmt.Sscanf(strings.Replace(got.signature, ",v1=", " ", 1), "t=%d %s", &timestamp, &signature); err != nil {
+		t.Fatalf("signature header %q isn't t=<timestamp>,v1=<signature>: %v", got.signature, err)
+	}
+	if age := time.Since(time.Unix(timestamp, 0)); age < 0 || age > time.Minute {
+		t.Errorf("signature timestamp %d is %v old, want about now", timestamp, age)
+	}
+	if want := Sign("whsec", timestamp, got.body); signature != want {
+		t.Errorf("signature = %s, want %s for t=%d", signature, want, timestamp)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	d := &dispatcher{baseDelay: time.Second, maxDelay: 5 * time.Second}
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 10: 5 * time.Second} {
+		if got := d.backoff(attempt); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+
+	uncapped := &dispatcher{baseDelay: time.Second}
+	if got := uncapped.backoff(5); got != 16*time.Second {
+		t.Errorf("uncapped backoff(5) = %v, want %v", got, 16*time.Second)
+	}
+}
+
+// Failed attempts are retried until the receiver accepts the delivery, and every attempt is recorded.
+func TestDeliverRetriesUntilAccepted(t *testing.T) {
+	var calls int
+	var mu sync.Mutex
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer receiver.Close()
+
+	d, repo := newTestDispatcher(t, 5)
+	d.dispatch(context.Background(), entity.Subscription{ID: uuid.New(), URL: receiver.URL, Secret: "whsec"}, uuid.New(), entity.EventUserCreated, []byte(`{}`))
+	if err := d.stop(context.Background()); err != nil {
+		t.Fatalf("stop() error = %v", err)
+	}
+
+	deliveries := repo.Deliveries()
+	if len(deliveries) != 3 {
+		t.Fatalf("recorded %d attempts, want 3", len(deliveries))
+	}
+	for i, delivery := range deliveries {
+		wantSucceeded := i == 2
+		if delivery.Attempt != i+1 || delivery.Succeeded != wantSucceeded {
+			t.Errorf("attempt %d = #%d succeeded %v, want #%d succeeded %v", i, delivery.Attempt, delivery.Succeeded, i+1, wantSucceeded)
+		}
+	}
+	if deliveries[0].StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("first attempt status = %d, want %d", deliveries[0].StatusCode, http.StatusServiceUnavailable)
+	}
+}
+
+func TestDeliverGivesUpAfterMaxAttempts(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	d, repo := newTestDispatcher(t, 3)
+	d.dispatch(context.Background(), entity.Subscription{ID: uuid.New(), URL: receiver.URL, Secret: "whsec"}, uuid.New(), entity.EventUserCreated, []byte(`{}`))
+	if err := d.stop(context.Background()); err != nil {
+		t.Fatalf("stop() error = %v", err)
+	}
+
+	deliveries := repo.Deliveries()
+	if len(deliveries) != 3 {
+		t.Fatalf("recorded %d attempts, want 3", len(deliveries))
+	}
+	for _, delivery := range deliveries {
+		if delivery.Succeeded || delivery.Error == "" {
+			t.Errorf("attempt %d succeeded %v with error %q, want a failure", delivery.Attempt, delivery.Succeeded, delivery.Error)
+		}
+	}
+}
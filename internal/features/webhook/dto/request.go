@@ -0,0 +1,17 @@
+package dto
+
+// CreateSubscriptionRequestDto is a Data Transfer Object (DTO) used to capture and validate a new webhook subscription.
+// The secret is optional; one is generated when it is left empty.
+type CreateSubscriptionRequestDto struct {
+	URL    string   `json:"url" binding:"required,http_url,max=2048"`
+	Events []string `json:"events" binding:"required,min=1,dive,oneof=user.created user.activated user.password_changed user.password_reset"`
+	Secret string   `json:"secret" binding:"omitempty,min=16,max=100"`
+}
+
+// UpdateSubscriptionRequestDto is a Data Transfer Object (DTO) used to change an existing webhook subscription.
+// Only the fields that are present are updated.
+type UpdateSubscriptionRequestDto struct {
+	URL    *string  `json:"url" binding:"omitempty,http_url,max=2048"`
+	Events []string `json:"events" binding:"omitempty,min=1,dive,oneof=user.created user.activated user.password_changed user.password_reset"`
+	Active *bool    `json:"active"`
+}
@@ -0,0 +1,31 @@
+package dto
+
+import "time"
+
+// SubscriptionResponseDto represents a webhook subscription without its signing secret.
+type SubscriptionResponseDto struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateSubscriptionResponseDto is returned once when a subscription is created.
+// It is the only response that includes the signing secret.
+type CreateSubscriptionResponseDto struct {
+	SubscriptionResponseDto
+	Secret string `json:"secret"`
+}
+
+// DeliveryResponseDto represents a single delivery attempt for a subscription.
+type DeliveryResponseDto struct {
+	ID         string    `json:"id"`
+	EventID    string    `json:"event_id"`
+	Event      string    `json:"event"`
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"status_code"`
+	Error      string    `json:"error,omitempty"`
+	Succeeded  bool      `json:"succeeded"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,82 @@
+package entity
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Events that can be delivered to webhook subscribers.
+const (
+	EventUserCreated         = "user.created"
+	EventUserActivated       = "user.activated"
+	EventUserPasswordChanged = "user.password_changed"
+	EventUserPasswordReset   = "user.password_reset"
+)
+
+// Subscription is an external endpoint that receives a signed HTTP POST for each event it subscribes to.
+type Subscription struct {
+	*gorm.Model
+	ID  uuid.UUID `gorm:"type:uuid;primaryKey"`
+	URL string    `gorm:"size:2048;not null"`
+	// Events is a comma-separated list of the event types delivered to this subscription.
+	Events string `gorm:"size:255;not null"`
+	// Secret signs every delivery so the receiver can verify it came from us.
+	Secret string `gorm:"size:100;not null"`
+	Active bool   `gorm:"not null;default:true"`
+}
+
+// TableName overrides the default table name used by GORM for the Subscription model.
+func (Subscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// GetEvents splits the Events string into a slice of individual event types.
+func (s *Subscription) GetEvents() []string {
+	if s.Events == "" {
+		return nil
+	}
+	return strings.Split(s.Events, ",")
+}
+
+// Subscribes reports whether the subscription wants the given event type.
+func (s *Subscription) Subscribes(event string) bool {
+	return slices.Contains(s.GetEvents(), event)
+}
+
+// BeforeCreate is a GORM hook that sets the ID field to a new UUID if it hasn't been set already.
+func (s *Subscription) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return
+}
+
+// Delivery records a single attempt to deliver an event to a subscription.
+// A delivery that is retried produces one row per attempt, all sharing the same EventID.
+type Delivery struct {
+	*gorm.Model
+	ID             uuid.UUID `gorm:"type:uuid;primaryKey"`
+	SubscriptionID uuid.UUID `gorm:"type:uuid;not null;index"`
+	EventID        uuid.UUID `gorm:"type:uuid;not null;index"`
+	Event          string    `gorm:"size:50;not null"`
+	Attempt        int       `gorm:"not null"`
+	StatusCode     int
+	Error          string `gorm:"type:text"`
+	Succeeded      bool   `gorm:"not null;default:false"`
+}
+
+// TableName overrides the default table name used by GORM for the Delivery model.
+func (Delivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// BeforeCreate is a GORM hook that sets the ID field to a new UUID if it hasn't been set already.
+func (d *Delivery) BeforeCreate(tx *gorm.DB) (err error) {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return
+}
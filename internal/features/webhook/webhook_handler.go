@@ -0,0 +1,144 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	userEntity "github.com/npushpakumara/go-backend-template/internal/features/user/entity"
+	"github.com/npushpakumara/go-backend-template/internal/features/webhook/dto"
+	"github.com/npushpakumara/go-backend-template/internal/postgres"
+	"github.com/npushpakumara/go-backend-template/internal/rbac"
+	"github.com/npushpakumara/go-backend-template/pkg"
+	apiError "github.com/npushpakumara/go-backend-template/pkg/errors"
+	"github.com/npushpakumara/go-backend-template/pkg/logging"
+)
+
+// Handler handles webhook subscription management requests.
+type Handler struct {
+	webhookService Service
+}
+
+// NewWebhookHandler creates a new Handler instance with the provided Service.
+func NewWebhookHandler(webhookService Service) *Handler {
+	return &Handler{webhookService}
+}
+
+// Router sets up the admin routes for managing webhook subscriptions.
+// Subscriptions receive the events of every organization, so the routes are only available to
+// administrators outside any organization.
+func Router(router *gin.Engine, handler *Handler, authMiddleware *jwt.GinJWTMiddleware) {
+	admin := router.Group("api/v1/admin")
+
+	admin.Use(authMiddleware.MiddlewareFunc(), rbac.RequireRole(userEntity.RoleAdmin), rbac.DenyOrgScoped())
+	{
+		admin.POST("/webhooks", handler.createSubscription)
+		admin.GET("/webhooks", handler.listSubscriptions)
+		admin.GET("/webhooks/:id", pkg.RequireUUIDParams("id"), handler.getSubscription)
+		admin.PATCH("/webhooks/:id", pkg.RequireUUIDParams("id"), handler.updateSubscription)
+		admin.DELETE("/webhooks/:id", pkg.RequireUUIDParams("id"), handler.deleteSubscription)
+		admin.GET("/webhooks/:id/deliveries", pkg.RequireUUIDParams("id"), handler.listDeliveries)
+	}
+}
+
+// createSubscription registers a new webhook subscription and returns its signing secret once.
+func (wh *Handler) createSubscription(ctx *gin.Context) {
+	logger := logging.FromContext(ctx)
+	var requestBody dto.CreateSubscriptionRequestDto
+
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		logger.Errorw("webhook.handler.createSubscription failed to get request body: v", err)
+		var details []*pkg.ValidationErrDetail
+		if vErrs, ok := err.(validator.ValidationErrors); ok {
+			details = pkg.ValidationErrorDetails(&requestBody, "json", vErrs)
+		}
+		ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "error", Message: "Invalid request body", Errors: details})
+		return
+	}
+
+	resp, err := wh.webhookService.CreateSubscription(ctx, &requestBody)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, apiError.ErrorResponse{Status: "error", Message: "Internal server error"})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, resp)
+}
+
+// listSubscriptions returns every webhook subscription.
+func (wh *Handler) listSubscriptions(ctx *gin.Context) {
+	resp, err := wh.webhookService.ListSubscriptions(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, apiError.ErrorResponse{Status: "error", Message: "Internal server error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// getSubscription returns the webhook subscription identified by the "id" path parameter.
+func (wh *Handler) getSubscription(ctx *gin.Context) {
+	resp, err := wh.webhookService.GetSubscription(ctx, ctx.Param("id"))
+	if err != nil {
+		respondWithError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// updateSubscription changes the webhook subscription identified by the "id" path parameter.
+func (wh *Handler) updateSubscription(ctx *gin.Context) {
+	logger := logging.FromContext(ctx)
+	var requestBody dto.UpdateSubscriptionRequestDto
+
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		logger.Errorw("webhook.handler.updateSubscription failed to get request body: v", err)
+		var details []*pkg.ValidationErrDetail
+		if vErrs, ok := err.(validator.ValidationErrors); ok {
+			details = pkg.ValidationErrorDetails(&requestBody, "json", vErrs)
+		}
+		ctx.JSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "error", Message: "Invalid request body", Errors: details})
+		return
+	}
+
+	resp, err := wh.webhookService.UpdateSubscription(ctx, ctx.Param("id"), &requestBody)
+	if err != nil {
+		respondWithError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// deleteSubscription removes the webhook subscription identified by the "id" path parameter.
+func (wh *Handler) deleteSubscription(ctx *gin.Context) {
+	if err := wh.webhookService.DeleteSubscription(ctx, ctx.Param("id")); err != nil {
+		respondWithError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, apiError.ErrorResponse{Status: "success", Message: "Webhook subscription deleted"})
+}
+
+// listDeliveries returns the recent delivery attempts for the subscription identified by the "id" path parameter.
+func (wh *Handler) listDeliveries(ctx *gin.Context) {
+	resp, err := wh.webhookService.ListDeliveries(ctx, ctx.Param("id"))
+	if err != nil {
+		respondWithError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// respondWithError maps service errors for a single subscription to an HTTP response.
+func respondWithError(ctx *gin.Context, err error) {
+	if errors.Is(err, postgres.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, apiError.ErrorResponse{Status: "error", Message: "Webhook subscription not found"})
+		return
+	}
+	ctx.JSON(http.StatusInternalServerError, apiError.ErrorResponse{Status: "error", Message: "Internal server error"})
+}
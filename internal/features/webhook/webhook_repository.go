@@ -0,0 +1,169 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+
+	"github.com/npushpakumara/go-backend-template/internal/features/webhook/entity"
+	"github.com/npushpakumara/go-backend-template/internal/postgres"
+	"github.com/npushpakumara/go-backend-template/pkg/logging"
+	"gorm.io/gorm"
+)
+
+// Repository defines the interface for webhook subscription and delivery data operations.
+type Repository interface {
+	// InsertSubscription adds a new subscription to the database.
+	InsertSubscription(ctx context.Context, subscription *entity.Subscription) (*entity.Subscription, error)
+
+	// FindSubscriptions returns every subscription, newest first.
+	FindSubscriptions(ctx context.Context) ([]entity.Subscription, error)
+
+	// FindSubscriptionByID retrieves a subscription by its ID.
+	FindSubscriptionByID(ctx context.Context, id string) (*entity.Subscription, error)
+
+	// FindActiveSubscriptions returns the active subscriptions.
+	FindActiveSubscriptions(ctx context.Context) ([]entity.Subscription, error)
+
+	// UpdateSubscription modifies the subscription with the given ID.
+	UpdateSubscription(ctx context.Context, id string, updates map[string]interface{}) error
+
+	// DeleteSubscription removes the subscription with the given ID.
+	DeleteSubscription(ctx context.Context, id string) error
+
+	// InsertDelivery records a delivery attempt.
+	InsertDelivery(ctx context.Context, delivery *entity.Delivery) error
+
+	// FindDeliveries returns the most recent delivery attempts for a subscription, newest first.
+	FindDeliveries(ctx context.Context, subscriptionID string, limit int) ([]entity.Delivery, error)
+}
+
+// webhookRepositoryImpl is a concrete implementation of the Repository interface.
+type webhookRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewWebhookRepository creates a new instance of webhookRepositoryImpl with the provided database connection.
+func NewWebhookRepository(db *gorm.DB) Repository {
+	return &webhookRepositoryImpl{db}
+}
+
+// InsertSubscription adds a new subscription to the database.
+func (wr *webhookRepositoryImpl) InsertSubscription(ctx context.Context, subscription *entity.Subscription) (*entity.Subscription, error) {
+	logger := logging.FromContext(ctx)
+	db := postgres.FromContext(ctx, wr.db)
+
+	logger.Debugw("webhook.db.InsertSubscription", "url", subscription.URL, "events", subscription.Events)
+	if err := db.WithContext(ctx).Create(subscription).Error; err != nil {
+		logger.Errorw("webhook.db.InsertSubscription failed to save: %v", err)
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// FindSubscriptions returns every subscription, newest first.
+func (wr *webhookRepositoryImpl) FindSubscriptions(ctx context.Context) ([]entity.Subscription, error) {
+	logger := logging.FromContext(ctx)
+	db := postgres.FromContext(ctx, wr.db)
+
+	var subscriptions []entity.Subscription
+	if err := db.WithContext(ctx).Order("created_at DESC").Find(&subscriptions).Error; err != nil {
+		logger.Errorw("webhook.db.FindSubscriptions failed to find subscriptions: %v", err)
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// FindSubscriptionByID retrieves a subscription by its ID.
+func (wr *webhookRepositoryImpl) FindSubscriptionByID(ctx context.Context, id string) (*entity.Subscription, error) {
+	logger := logging.FromContext(ctx)
+	db := postgres.FromContext(ctx, wr.db)
+
+	var subscription entity.Subscription
+	if err := db.WithContext(ctx).First(&subscription, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Warn("webhook.db.FindSubscriptionByID subscription not found")
+			return nil, postgres.ErrRecordNotFound
+		}
+		logger.Errorw("webhook.db.FindSubscriptionByID failed to find subscription: %v", err)
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// FindActiveSubscriptions returns the active subscriptions.
+// Event filtering is left to the caller since events are stored as a comma-separated list.
+func (wr *webhookRepositoryImpl) FindActiveSubscriptions(ctx context.Context) ([]entity.Subscription, error) {
+	logger := logging.FromContext(ctx)
+	db := postgres.FromContext(ctx, wr.db)
+
+	var subscriptions []entity.Subscription
+	if err := db.WithContext(ctx).Where("active = ?", true).Find(&subscriptions).Error; err != nil {
+		logger.Errorw("webhook.db.FindActiveSubscriptions failed to find subscriptions: %v", err)
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// UpdateSubscription modifies the subscription with the given ID.
+func (wr *webhookRepositoryImpl) UpdateSubscription(ctx context.Context, id string, updates map[string]interface{}) error {
+	logger := logging.FromContext(ctx)
+	db := postgres.FromContext(ctx, wr.db)
+
+	logger.Debugw("webhook.db.UpdateSubscription", "id", id, "updates", updates)
+
+	result := db.WithContext(ctx).Model(&entity.Subscription{}).Where("id = ?", id).Updates(updates)
+	if result.Error != nil {
+		logger.Errorw("webhook.db.UpdateSubscription failed to update subscription: %v", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		logger.Warn("webhook.db.UpdateSubscription subscription not found")
+		return postgres.ErrRecordNotFound
+	}
+	return nil
+}
+
+// DeleteSubscription removes the subscription with the given ID.
+func (wr *webhookRepositoryImpl) DeleteSubscription(ctx context.Context, id string) error {
+	logger := logging.FromContext(ctx)
+	db := postgres.FromContext(ctx, wr.db)
+
+	logger.Debugw("webhook.db.DeleteSubscription", "id", id)
+
+	result := db.WithContext(ctx).Where("id = ?", id).Delete(&entity.Subscription{})
+	if result.Error != nil {
+		logger.Errorw("webhook.db.DeleteSubscription failed to delete subscription: %v", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		logger.Warn("webhook.db.DeleteSubscription subscription not found")
+		return postgres.ErrRecordNotFound
+	}
+	return nil
+}
+
+// InsertDelivery records a delivery attempt.
+func (wr *webhookRepositoryImpl) InsertDelivery(ctx context.Context, delivery *entity.Delivery) error {
+	logger := logging.FromContext(ctx)
+	db := postgres.FromContext(ctx, wr.db)
+
+	if err := db.WithContext(ctx).Create(delivery).Error; err != nil {
+		logger.Errorw("webhook.db.InsertDelivery failed to save: %v", err)
+		return err
+	}
+	return nil
+}
+
+// FindDeliveries returns the most recent delivery attempts for a subscription, newest first.
+func (wr *webhookRepositoryImpl) FindDeliveries(ctx context.Context, subscriptionID string, limit int) ([]entity.Delivery, error) {
+	logger := logging.FromContext(ctx)
+	db := postgres.FromContext(ctx, wr.db)
+
+	var deliveries []entity.Delivery
+	if err := db.WithContext(ctx).Where("subscription_id = ?", subscriptionID).
+		Order("created_at DESC").Limit(limit).Find(&deliveries).Error; err != nil {
+		logger.Errorw("webhook.db.FindDeliveries failed to find deliveries: %v", err)
+		return nil, err
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,245 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/internal/features/webhook/dto"
+	"github.com/npushpakumara/go-backend-template/internal/features/webhook/entity"
+	"github.com/npushpakumara/go-backend-template/internal/postgres"
+	"github.com/npushpakumara/go-backend-template/pkg/logging"
+	"go.uber.org/fx"
+)
+
+// secretPrefix is prepended to generated signing secrets so they are easy to recognise in secret scanners.
+const secretPrefix = "whsec_"
+
+// deliveryHistoryLimit caps how many delivery attempts are returned for a subscription.
+const deliveryHistoryLimit = 100
+
+// Event is the JSON body posted to subscribers.
+type Event struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// UserEventData is the event data for the user.* events.
+type UserEventData struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+// Service defines the methods that the webhook service implements.
+type Service interface {
+	// CreateSubscription registers a new subscription. The signing secret is only ever returned from this call.
+	CreateSubscription(ctx context.Context, request *dto.CreateSubscriptionRequestDto) (*dto.CreateSubscriptionResponseDto, error)
+
+	// ListSubscriptions returns every subscription.
+	ListSubscriptions(ctx context.Context) ([]dto.SubscriptionResponseDto, error)
+
+	// GetSubscription returns the subscription with the given ID.
+	GetSubscription(ctx context.Context, id string) (*dto.SubscriptionResponseDto, error)
+
+	// UpdateSubscription changes the URL, events or active flag of a subscription.
+	UpdateSubscription(ctx context.Context, id string, request *dto.UpdateSubscriptionRequestDto) (*dto.SubscriptionResponseDto, error)
+
+	// DeleteSubscription removes the subscription with the given ID.
+	DeleteSubscription(ctx context.Context, id string) error
+
+	// ListDeliveries returns the most recent delivery attempts for a subscription.
+	ListDeliveries(ctx context.Context, id string) ([]dto.DeliveryResponseDto, error)
+
+	// Emit delivers the event to every active subscription that wants it.
+	// Delivery happens in the background, so Emit only fails if the subscribers can't be looked up.
+	Emit(ctx context.Context, event string, data interface{}) error
+}
+
+// webhookServiceImpl is a concrete implementation of the Service interface.
+type webhookServiceImpl struct {
+	webhookRepository Repository
+	dispatcher        *dispatcher
+}
+
+// NewWebhookService creates a new instance of webhookServiceImpl with the provided Repository.
+// On shutdown, new events are dropped and in-flight deliveries, retries included, are given
+// until the application's stop timeout to finish.
+func NewWebhookService(lc fx.Lifecycle, webhookRepository Repository, cfg *config.Config) Service {
+	d := newDispatcher(webhookRepository, &cfg.Webhook)
+	lc.Append(fx.Hook{OnStop: d.stop})
+	return &webhookServiceImpl{webhookRepository, d}
+}
+
+// CreateSubscription stores a new subscription, generating a signing secret when none is given.
+func (ws *webhookServiceImpl) CreateSubscription(ctx context.Context, request *dto.CreateSubscriptionRequestDto) (*dto.CreateSubscriptionResponseDto, error) {
+	logger := logging.FromContext(ctx)
+
+	secret := request.Secret
+	if secret == "" {
+		var err error
+		secret, err = generateSecret()
+		if err != nil {
+			logger.Errorw("webhook.service.CreateSubscription failed to generate secret", "err", err)
+			return nil, err
+		}
+	}
+
+	subscription, err := ws.webhookRepository.InsertSubscription(ctx, &entity.Subscription{
+		URL:    request.URL,
+		Events: strings.Join(request.Events, ","),
+		Secret: secret,
+		Active: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.CreateSubscriptionResponseDto{
+		SubscriptionResponseDto: *toSubscriptionResponseDto(subscription),
+		Secret:                  secret,
+	}, nil
+}
+
+// ListSubscriptions returns every subscription.
+func (ws *webhookServiceImpl) ListSubscriptions(ctx context.Context) ([]dto.SubscriptionResponseDto, error) {
+	subscriptions, err := ws.webhookRepository.FindSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]dto.SubscriptionResponseDto, 0, len(subscriptions))
+	for i := range subscriptions {
+		resp = append(resp, *toSubscriptionResponseDto(&subscriptions[i]))
+	}
+	return resp, nil
+}
+
+// GetSubscription returns the subscription with the given ID.
+func (ws *webhookServiceImpl) GetSubscription(ctx context.Context, id string) (*dto.SubscriptionResponseDto, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, postgres.ErrRecordNotFound
+	}
+
+	subscription, err := ws.webhookRepository.FindSubscriptionByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toSubscriptionResponseDto(subscription), nil
+}
+
+// UpdateSubscription applies the fields present in the request and returns the updated subscription.
+func (ws *webhookServiceImpl) UpdateSubscription(ctx context.Context, id string, request *dto.UpdateSubscriptionRequestDto) (*dto.SubscriptionResponseDto, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, postgres.ErrRecordNotFound
+	}
+
+	updates := map[string]interface{}{}
+	if request.URL != nil {
+		updates["url"] = *request.URL
+	}
+	if len(request.Events) > 0 {
+		updates["events"] = strings.Join(request.Events, ",")
+	}
+	if request.Active != nil {
+		updates["active"] = *request.Active
+	}
+
+	if len(updates) > 0 {
+		if err := ws.webhookRepository.UpdateSubscription(ctx, id, updates); err != nil {
+			return nil, err
+		}
+	}
+
+	return ws.GetSubscription(ctx, id)
+}
+
+// DeleteSubscription removes the subscription with the given ID.
+func (ws *webhookServiceImpl) DeleteSubscription(ctx context.Context, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return postgres.ErrRecordNotFound
+	}
+	return ws.webhookRepository.DeleteSubscription(ctx, id)
+}
+
+// ListDeliveries returns the most recent delivery attempts for the subscription with the given ID.
+func (ws *webhookServiceImpl) ListDeliveries(ctx context.Context, id string) ([]dto.DeliveryResponseDto, error) {
+	if _, err := ws.GetSubscription(ctx, id); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := ws.webhookRepository.FindDeliveries(ctx, id, deliveryHistoryLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]dto.DeliveryResponseDto, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		resp = append(resp, dto.DeliveryResponseDto{
+			ID:         delivery.ID.String(),
+			EventID:    delivery.EventID.String(),
+			Event:      delivery.Event,
+			Attempt:    delivery.Attempt,
+			StatusCode: delivery.StatusCode,
+			Error:      delivery.Error,
+			Succeeded:  delivery.Succeeded,
+			CreatedAt:  delivery.CreatedAt,
+		})
+	}
+	return resp, nil
+}
+
+// Emit builds the event payload once and hands it to the dispatcher for each matching subscription.
+func (ws *webhookServiceImpl) Emit(ctx context.Context, event string, data interface{}) error {
+	logger := logging.FromContext(ctx)
+
+	subscriptions, err := ws.webhookRepository.FindActiveSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	eventID := uuid.New()
+	payload, err := json.Marshal(Event{
+		ID:        eventID.String(),
+		Type:      event,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		logger.Errorw("webhook.service.Emit failed to marshal event", "event", event, "err", err)
+		return err
+	}
+
+	for _, subscription := range subscriptions {
+		if subscription.Subscribes(event) {
+			ws.dispatcher.dispatch(ctx, subscription, eventID, event, payload)
+		}
+	}
+	return nil
+}
+
+// generateSecret returns a new random signing secret.
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return secretPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// toSubscriptionResponseDto maps a Subscription entity to its response DTO, leaving out the secret.
+func toSubscriptionResponseDto(subscription *entity.Subscription) *dto.SubscriptionResponseDto {
+	return &dto.SubscriptionResponseDto{
+		ID:        subscription.ID.String(),
+		URL:       subscription.URL,
+		Events:    subscription.GetEvents(),
+		Active:    subscription.Active,
+		CreatedAt: subscription.CreatedAt,
+	}
+}
@@ -0,0 +1,116 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/npushpakumara/go-backend-template/internal/features/email"
+	apiError "github.com/npushpakumara/go-backend-template/pkg/errors"
+	"github.com/npushpakumara/go-backend-template/pkg/logging"
+	"gorm.io/gorm"
+)
+
+// pingTimeout bounds each dependency check made by the readiness probe.
+const pingTimeout = 2 * time.Second
+
+// Dependency statuses reported by the readiness probe.
+const (
+	statusOK          = "ok"
+	statusUnavailable = "unavailable"
+)
+
+// statusResponse is the body returned by the liveness probe.
+type statusResponse struct {
+	Status string `json:"status"`
+}
+
+// readinessResponse is the body returned by the readiness probe once started: the overall status
+// and the status of every dependency checked, e.g. {"db": "ok", "email": "ok"}.
+type readinessResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// check is a named dependency the readiness probe verifies.
+type check struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// Handler serves the liveness and readiness probes.
+type Handler struct {
+	readiness *Readiness
+	checks    []check
+}
+
+// NewHealthHandler creates a new Handler instance with the provided database and readiness flag.
+// The email provider is only checked when mail.health_check is enabled, since it calls out to the provider.
+func NewHealthHandler(db *gorm.DB, readiness *Readiness, emailCheck *email.ProviderCheck) *Handler {
+	checks := []check{{name: "db", run: func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	}}}
+	if emailCheck.Enabled() {
+		checks = append(checks, check{name: "email", run: emailCheck.Check})
+	}
+	return &Handler{readiness: readiness, checks: checks}
+}
+
+// Router sets up the probe routes. They sit outside /api/v1 and need no authentication.
+func Router(router *gin.Engine, handler *Handler) {
+	router.GET("/livez", handler.live)
+	router.GET("/readyz", handler.ready)
+}
+
+// live reports that the process is up and serving requests.
+func (h *Handler) live(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, statusResponse{Status: "ok"})
+}
+
+// ready returns 503 until startup, including database migrations, has finished,
+// and afterwards whenever a dependency can't be reached. The checks run concurrently,
+// each bounded by pingTimeout, and the body reports the status of every one of them.
+func (h *Handler) ready(ctx *gin.Context) {
+	if !h.readiness.Ready() {
+		ctx.JSON(http.StatusServiceUnavailable, apiError.ErrorResponse{Status: "error", Message: "Starting up"})
+		return
+	}
+
+	logger := logging.FromContext(ctx)
+	checkCtx, cancel := context.WithTimeout(ctx.Request.Context(), pingTimeout)
+	defer cancel()
+
+	errs := make([]error, len(h.checks))
+	var wg sync.WaitGroup
+	for i, c := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = c.run(checkCtx)
+		}()
+	}
+	wg.Wait()
+
+	resp := readinessResponse{Status: statusOK, Checks: make(map[string]string, len(h.checks))}
+	for i, c := range h.checks {
+		if errs[i] != nil {
+			logger.Errorw("health.handler.ready dependency is unavailable", "dependency", c.name, "err", errs[i])
+			resp.Status = "error"
+			resp.Checks[c.name] = statusUnavailable
+			continue
+		}
+		resp.Checks[c.name] = statusOK
+	}
+
+	if resp.Status != statusOK {
+		ctx.JSON(http.StatusServiceUnavailable, resp)
+		return
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
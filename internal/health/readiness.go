@@ -0,0 +1,34 @@
+package health
+
+import (
+	"sync/atomic"
+
+	"gorm.io/gorm"
+)
+
+// Readiness records whether the application has finished starting up and may receive traffic.
+type Readiness struct {
+	ready atomic.Bool
+}
+
+// NewReadiness creates a Readiness that starts out not ready.
+func NewReadiness() *Readiness {
+	return &Readiness{}
+}
+
+// MarkReady flags the application as ready to receive traffic.
+func (r *Readiness) MarkReady() {
+	r.ready.Store(true)
+}
+
+// Ready reports whether MarkReady has been called.
+func (r *Readiness) Ready() bool {
+	return r.ready.Load()
+}
+
+// MarkReadyWhenMigrated flags the application as ready once the database is available.
+// It takes the *gorm.DB only so that fx calls it after postgres.NewDatabase has returned,
+// which is after migrations and seeding have completed.
+func MarkReadyWhenMigrated(_ *gorm.DB, readiness *Readiness) {
+	readiness.MarkReady()
+}
@@ -0,0 +1,150 @@
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/internal/rbac"
+	apiError "github.com/npushpakumara/go-backend-template/pkg/errors"
+	"github.com/npushpakumara/go-backend-template/pkg/logging"
+)
+
+// Header is the request header carrying the client-chosen idempotency key.
+const Header = "Idempotency-Key"
+
+// ReplayedHeader is set on responses that were replayed from the store rather than produced by the handler.
+const ReplayedHeader = "Idempotent-Replayed"
+
+// maxKeyLength bounds the idempotency key so clients can't grow the store with huge keys.
+const maxKeyLength = 255
+
+// replayedHeaders are the response headers stored and replayed alongside the body.
+var replayedHeaders = []string{"Content-Type", "Location", "Set-Cookie"}
+
+// Middleware replays the stored response when a request is repeated with the same Idempotency-Key.
+type Middleware struct {
+	store Store
+}
+
+// NewIdempotencyMiddleware creates a Middleware backed by an in-process store using the configured TTL.
+func NewIdempotencyMiddleware(cfg *config.Config) *Middleware {
+	return &Middleware{store: NewMemoryStore(cfg.Server.IdempotencyTTL)}
+}
+
+// MiddlewareFunc returns the Gin handler. Requests without an Idempotency-Key header pass through untouched.
+// Keys are scoped to the route and the authenticated user, if any. A repeat of a completed request is
+// answered with the stored response, a repeat while the first is still running gets 409, and reusing a
+// key with a different request body gets 422. Server errors aren't stored, so those requests can be retried.
+func (m *Middleware) MiddlewareFunc() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := logging.FromContext(c)
+
+		key := strings.TrimSpace(c.GetHeader(Header))
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "error", Message: "Idempotency-Key must be at most 255 characters"})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			logger.Errorw("idempotency.MiddlewareFunc failed to read request body", "err", err)
+			c.AbortWithStatusJSON(http.StatusBadRequest, apiError.ErrorResponse{Status: "error", Message: "Invalid request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		storeKey := scopedKey(c, key)
+		sum := sha256.Sum256(body)
+		fingerprint := hex.EncodeToString(sum[:])
+
+		st, storedFingerprint, stored := m.store.Begin(storeKey, fingerprint)
+		switch {
+		case st == stateInFlight:
+			c.AbortWithStatusJSON(http.StatusConflict, apiError.ErrorResponse{Status: "error", Message: "A request with this Idempotency-Key is already in progress"})
+			return
+		case storedFingerprint != fingerprint:
+			c.AbortWithStatusJSON(http.StatusUnprocessableEntity, apiError.ErrorResponse{Status: "error", Message: "Idempotency-Key was already used with a different request body"})
+			return
+		case st == stateCompleted:
+			replay(c, stored)
+			return
+		}
+
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		// Release the key if the handler panics so the client can retry.
+		completed := false
+		defer func() {
+			if !completed {
+				m.store.Release(storeKey)
+			}
+		}()
+
+		c.Next()
+
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			return
+		}
+
+		header := http.Header{}
+		for _, name := range replayedHeaders {
+			if values := c.Writer.Header().Values(name); len(values) > 0 {
+				header[name] = values
+			}
+		}
+		m.store.Complete(storeKey, &Response{Status: c.Writer.Status(), Header: header, Body: writer.body.Bytes()})
+		completed = true
+	}
+}
+
+// scopedKey combines the client's key with the route and the authenticated user so the same key
+// can't collide across endpoints or users.
+func scopedKey(c *gin.Context, key string) string {
+	userID := ""
+	if identity, ok := rbac.CurrentIdentity(c); ok {
+		userID = identity.ID
+	}
+	return strings.Join([]string{c.Request.Method, c.FullPath(), userID, key}, "|")
+}
+
+// replay writes a stored response.
+func replay(c *gin.Context, response *Response) {
+	for name, values := range response.Header {
+		for _, value := range values {
+			c.Writer.Header().Add(name, value)
+		}
+	}
+	c.Writer.Header().Set(ReplayedHeader, "true")
+	c.Writer.WriteHeader(response.Status)
+	_, _ = c.Writer.Write(response.Body)
+	c.Abort()
+}
+
+// recordingWriter captures the response body while passing it through to the client.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write records the bytes and forwards them to the underlying writer.
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// WriteString records the string and forwards it to the underlying writer.
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,147 @@
+package idempotency
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestRouter serves POST /orders and POST /refunds behind the middleware. Each handled request is
+// counted and answered with 201 and its number, unless the handler is told otherwise.
+func newTestRouter(handle func(c *gin.Context, n int64)) (*gin.Engine, *atomic.Int64) {
+	gin.SetMode(gin.TestMode)
+
+	var calls atomic.Int64
+	m := &Middleware{store: NewMemoryStore(time.Hour)}
+	router := gin.New()
+	handler := func(c *gin.Context) {
+		n := calls.Add(1)
+		if handle != nil {
+			handle(c, n)
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"order": n})
+	}
+	router.POST("/orders", m.MiddlewareFunc(), handler)
+	router.POST("/refunds", m.MiddlewareFunc(), handler)
+	return router, &calls
+}
+
+func post(router *gin.Engine, path, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(Header, key)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMiddlewareReplaysCompletedRequest(t *testing.T) {
+	router, calls := newTestRouter(nil)
+
+	first := post(router, "/orders", "key-1", `{"item":"book"}`)
+	second := post(router, "/orders", "key-1", `{"item":"book"}`)
+
+	if calls.Load() != 1 {
+		t.Errorf("handler ran %d times, want 1", calls.Load())
+	}
+	if second.Code != http.StatusCreated || second.Body.String() != first.Body.String() {
+		t.Errorf("replay = %d %s, want %d %s", second.Code, second.Body, first.Code, first.Body)
+	}
+	if second.Header().Get(ReplayedHeader) != "true" || first.Header().Get(ReplayedHeader) != "" {
+		t.Errorf("%s = %q then %q, want only the replay marked", ReplayedHeader, first.Header().Get(ReplayedHeader), second.Header().Get(ReplayedHeader))
+	}
+	if got := second.Header().Get("Content-Type"); got != first.Header().Get("Content-Type") {
+		t.Errorf("replayed Content-Type = %q, want %q", got, first.Header().Get("Content-Type"))
+	}
+}
+
+func TestMiddlewareRejectsDifferentBody(t *testing.T) {
+	router, calls := newTestRouter(nil)
+
+	post(router, "/orders", "key-1", `{"item":"book"}`)
+	rec := post(router, "/orders", "key-1", `{"item":"lamp"}`)
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("handler ran %d times, want 1", calls.Load())
+	}
+}
+
+func TestMiddlewareRejectsConcurrentRepeat(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	router, calls := newTestRouter(func(c *gin.Context, n int64) {
+		close(started)
+		<-release
+		c.Status(http.StatusCreated)
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- post(router, "/orders", "key-1", `{}`) }()
+	<-started
+
+	if rec := post(router, "/orders", "key-1", `{}`); rec.Code != http.StatusConflict {
+		t.Errorf("repeat while in flight status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+	close(release)
+	if rec := <-done; rec.Code != http.StatusCreated {
+		t.Errorf("first request status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("handler ran %d times, want 1", calls.Load())
+	}
+}
+
+func TestMiddlewareRetriesServerErrors(t *testing.T) {
+	router, calls := newTestRouter(func(c *gin.Context, n int64) {
+		if n == 1 {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Status(http.StatusCreated)
+	})
+
+	if rec := post(router, "/orders", "key-1", `{}`); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("first status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	if rec := post(router, "/orders", "key-1", `{}`); rec.Code != http.StatusCreated {
+		t.Errorf("retry status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("handler ran %d times, want 2", calls.Load())
+	}
+}
+
+func TestMiddlewareScopesKeys(t *testing.T) {
+	router, calls := newTestRouter(nil)
+
+	post(router, "/orders", "key-1", `{}`)
+	if rec := post(router, "/refunds", "key-1", `{}`); rec.Header().Get(ReplayedHeader) != "" {
+		t.Error("a key used on another route was replayed")
+	}
+	post(router, "/orders", "", `{}`)
+	post(router, "/orders", "", `{}`)
+
+	if calls.Load() != 4 {
+		t.Errorf("handler ran %d times, want 4", calls.Load())
+	}
+}
+
+func TestMiddlewareRejectsLongKey(t *testing.T) {
+	router, calls := newTestRouter(nil)
+
+	if rec := post(router, "/orders", strings.Repeat("k", maxKeyLength+1), `{}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	if calls.Load() != 0 {
+		t.Errorf("handler ran %d times, want 0", calls.Load())
+	}
+}
@@ -0,0 +1,112 @@
+package idempotency
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// state describes where a key is in its lifecycle.
+type state int
+
+const (
+	// stateNew means the key was unknown and has now been reserved by the caller.
+	stateNew state = iota
+	// stateInFlight means another request holding the same key hasn't finished yet.
+	stateInFlight
+	// stateCompleted means a response was stored for the key and can be replayed.
+	stateCompleted
+)
+
+// Response is a stored response that is replayed for repeated requests.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// record is the store's entry for a single key.
+type record struct {
+	fingerprint string
+	response    *Response
+	expiresAt   time.Time
+}
+
+// Store keeps idempotency records for a limited time.
+type Store interface {
+	// Begin reserves the key for a new request. If the key is already known, it returns the
+	// existing record's state and, for completed keys, the stored fingerprint and response.
+	Begin(key, fingerprint string) (state, string, *Response)
+
+	// Complete stores the response for a key reserved with Begin.
+	Complete(key string, response *Response)
+
+	// Release forgets a key reserved with Begin so the request can be retried.
+	Release(key string)
+}
+
+// memoryStore is an in-process Store. Records are only shared between requests served by the
+// same instance, which is enough to absorb client retries but not a substitute for a shared cache
+// when running several replicas.
+type memoryStore struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	records   map[string]*record
+	lastSweep time.Time
+}
+
+// NewMemoryStore creates an in-process Store whose records expire after ttl.
+func NewMemoryStore(ttl time.Duration) Store {
+	return &memoryStore{ttl: ttl, records: make(map[string]*record), lastSweep: time.Now()}
+}
+
+// Begin reserves the key or reports the state of the existing record.
+func (s *memoryStore) Begin(key, fingerprint string) (state, string, *Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	if r, ok := s.records[key]; ok && now.Before(r.expiresAt) {
+		if r.response == nil {
+			return stateInFlight, r.fingerprint, nil
+		}
+		return stateCompleted, r.fingerprint, r.response
+	}
+
+	s.records[key] = &record{fingerprint: fingerprint, expiresAt: now.Add(s.ttl)}
+	return stateNew, fingerprint, nil
+}
+
+// Complete stores the response and restarts the record's TTL.
+func (s *memoryStore) Complete(key string, response *Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r, ok := s.records[key]; ok {
+		r.response = response
+		r.expiresAt = time.Now().Add(s.ttl)
+	}
+}
+
+// Release forgets the key.
+func (s *memoryStore) Release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+}
+
+// sweep drops expired records, at most once per TTL so lookups stay cheap. The caller must hold mu.
+func (s *memoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < s.ttl {
+		return
+	}
+	for key, r := range s.records {
+		if !now.Before(r.expiresAt) {
+			delete(s.records, key)
+		}
+	}
+	s.lastSweep = now
+}
@@ -0,0 +1,25 @@
+// Package metrics keeps the application's Prometheus collectors and serves them at /metrics,
+// so they can be scraped without an agent.
+package metrics
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// NewRegistry creates the registry holding every collector exposed by the application.
+// It is separate from prometheus.DefaultRegisterer so that only the application's collectors are served.
+func NewRegistry() *prometheus.Registry {
+	return prometheus.NewRegistry()
+}
+
+// Router exposes the registry at GET /metrics when metrics.enabled is set. Like the probes, the route
+// sits outside /api/v1 and needs no authentication, so it should only be reachable from the internal network.
+func Router(cfg *config.Config, router *gin.Engine, registry *prometheus.Registry) {
+	if !cfg.Metrics.Enabled {
+		return
+	}
+	router.GET("/metrics", gin.WrapH(promhttp.HandlerFor(registry, promhttp.HandlerOpts{})))
+}
@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/npushpakumara/go-backend-template/internal/config"
+)
+
+// ErrInvalidDatabaseURL is returned when db.url is set but isn't a usable postgres connection string.
+var ErrInvalidDatabaseURL = errors.New("invalid database url")
+
+// ErrInvalidSchemaName is returned when db.schema isn't a plain lowercase SQL identifier.
+var ErrInvalidSchemaName = errors.New("invalid database schema name")
+
+// schemaNamePattern only allows unquoted lowercase identifiers, so the schema name
+// can be placed in SQL and connection strings without escaping.
+var schemaNamePattern = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,62}$`)
+
+// Query parameters recognised in db.url for tuning the connection pool.
+// They are stripped from the connection string before it is handed to the driver.
+const (
+	poolMaxConnsParam        = "pool_max_conns"
+	poolMaxIdleConnsParam    = "pool_max_idle_conns"
+	poolMaxConnLifetimeParam = "pool_max_conn_lifetime"
+)
+
+// connectionSettings holds the DSN passed to the driver and the pool limits to apply once connected.
+type connectionSettings struct {
+	dsn         string
+	schema      string
+	maxOpen     int
+	maxIdle     int
+	maxLifetime time.Duration
+}
+
+// resolveConnectionSettings builds the connection settings from the database configuration.
+// When db.url is set it takes precedence over the individual host, port and credential fields,
+// and any pool parameters in its query string override the db.pool settings.
+// Either way the connection's search_path is set to db.schema so every table lives in it.
+func resolveConnectionSettings(cfg *config.DBConfig) (*connectionSettings, error) {
+	if !schemaNamePattern.MatchString(cfg.Schema) {
+		return nil, fmt.Errorf("%w: %q must match %s", ErrInvalidSchemaName, cfg.Schema, schemaNamePattern)
+	}
+
+	settings := &connectionSettings{
+		schema:      cfg.Schema,
+		maxOpen:     cfg.Pool.MaxOpen,
+		maxIdle:     cfg.Pool.MaxIdle,
+		maxLifetime: cfg.Pool.MaxLifetime,
+	}
+
+	if cfg.URL == "" {
+		settings.dsn = fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s search_path=%s",
+			cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Name, cfg.SSLMode, cfg.Schema)
+		return settings, nil
+	}
+
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		// The parse error echoes the URL, which would leak the password into the logs.
+		return nil, fmt.Errorf("%w: malformed connection string", ErrInvalidDatabaseURL)
+	}
+	if u.Scheme != "postgres" && u.Scheme != "postgresql" {
+		return nil, fmt.Errorf("%w: scheme must be postgres or postgresql, got %q", ErrInvalidDatabaseURL, u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("%w: missing host", ErrInvalidDatabaseURL)
+	}
+
+	query := u.Query()
+	if v := query.Get(poolMaxConnsParam); v != "" {
+		if settings.maxOpen, err = strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("%w: %s must be an integer", ErrInvalidDatabaseURL, poolMaxConnsParam)
+		}
+	}
+	if v := query.Get(poolMaxIdleConnsParam); v != "" {
+		if settings.maxIdle, err = strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("%w: %s must be an integer", ErrInvalidDatabaseURL, poolMaxIdleConnsParam)
+		}
+	}
+	if v := query.Get(poolMaxConnLifetimeParam); v != "" {
+		if settings.maxLifetime, err = time.ParseDuration(v); err != nil {
+			return nil, fmt.Errorf("%w: %s must be a duration", ErrInvalidDatabaseURL, poolMaxConnLifetimeParam)
+		}
+	}
+
+	// Postgres would reject the pool parameters as unknown runtime settings.
+	query.Del(poolMaxConnsParam)
+	query.Del(poolMaxIdleConnsParam)
+	query.Del(poolMaxConnLifetimeParam)
+	query.Set("search_path", cfg.Schema)
+	u.RawQuery = query.Encode()
+
+	settings.dsn = u.String()
+	return settings, nil
+}
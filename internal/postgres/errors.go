@@ -13,6 +13,30 @@ var (
 	ErrRecordNotFound      = errors.New("record not found")
 )
 
+// DuplicateKeyError reports a unique violation along with the constraint or index that was violated,
+// so callers can tell which of several unique columns clashed. It matches ErrKeyDuplicate.
+type DuplicateKeyError struct {
+	Constraint string
+}
+
+func (e *DuplicateKeyError) Error() string {
+	return ErrKeyDuplicate.Error() + ": " + e.Constraint
+}
+
+func (e *DuplicateKeyError) Unwrap() error {
+	return ErrKeyDuplicate
+}
+
+// NewDuplicateKeyError returns a DuplicateKeyError naming the constraint violated by err,
+// which is empty when err isn't a PostgreSQL error.
+func NewDuplicateKeyError(err error) *DuplicateKeyError {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return &DuplicateKeyError{Constraint: pgErr.ConstraintName}
+	}
+	return &DuplicateKeyError{}
+}
+
 // IsPgxError checks if the given error is a PostgreSQL error and returns a corresponding custom error.
 func IsPgxError(err error) error {
 	if err == nil {
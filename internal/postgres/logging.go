@@ -21,12 +21,14 @@ const msgPrefix = "[DB] "
 // Logger is a custom logger that implements GORM's logging interface.
 // It wraps a zap.SugaredLogger for structured logging.
 type Logger struct {
-	cfg glogger.Config // Configuration for the logger, including log levels and thresholds.
+	cfg         glogger.Config     // Configuration for the logger, including log levels and thresholds.
+	slowQueries *SlowQueryRecorder // Collects slow queries for the slow query report; may be nil.
 }
 
 // NewLogger creates and returns a new Logger instance for GORM.
-// It takes the slow SQL threshold, whether to ignore "record not found" errors, and the log level as inputs.
-func NewLogger(slowThreshold time.Duration, ignoreRecordNotFoundError bool, level zapcore.Level) *Logger {
+// It takes the slow SQL threshold, whether to ignore "record not found" errors, the log level,
+// and the recorder slow queries are added to, which may be nil, as inputs.
+func NewLogger(slowThreshold time.Duration, ignoreRecordNotFoundError bool, level zapcore.Level, slowQueries *SlowQueryRecorder) *Logger {
 	// Set up the logger configuration.
 	cfg := glogger.Config{
 		SlowThreshold:             slowThreshold,             // Threshold for slow SQL logging.
@@ -47,7 +49,7 @@ func NewLogger(slowThreshold time.Duration, ignoreRecordNotFoundError bool, leve
 	}
 
 	// Return the new Logger instance.
-	return &Logger{cfg: cfg}
+	return &Logger{cfg: cfg, slowQueries: slowQueries}
 }
 
 // LogMode sets the log level for the logger and returns a new logger instance with this configuration.
@@ -80,13 +82,20 @@ func (l *Logger) Error(ctx context.Context, msg string, args ...interface{}) {
 
 // Trace logs SQL queries and their execution times, as well as any errors that occurred.
 // It is used by GORM to log the details of each SQL operation.
+// Slow queries are recorded for the slow query report whatever the log level.
 func (l *Logger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin) // Calculate the time taken for the SQL query.
+	slow := elapsed > l.cfg.SlowThreshold && l.cfg.SlowThreshold != 0
+	if slow && l.slowQueries != nil {
+		sql, _ := fc()
+		l.slowQueries.Record(sql, elapsed)
+	}
+
 	// If the logger is set to silent, do nothing.
 	if l.cfg.LogLevel == glogger.Silent {
 		return
 	}
 
-	elapsed := time.Since(begin) // Calculate the time taken for the SQL query.
 	logger := l.fromContext(ctx) // Get the logger from the context.
 
 	// Log formats for different scenarios.
@@ -106,7 +115,7 @@ func (l *Logger) Trace(ctx context.Context, begin time.Time, fc func() (string,
 		} else {
 			logger.Errorf(traceErrStr, utils.FileWithLineNum(), err, float64(elapsed.Nanoseconds())/1e6, rows, sql)
 		}
-	case elapsed > l.cfg.SlowThreshold && l.cfg.SlowThreshold != 0 && l.cfg.LogLevel >= glogger.Warn:
+	case slow && l.cfg.LogLevel >= glogger.Warn:
 		// Log slow SQL queries if they exceed the configured slow threshold.
 		sql, rows := fc()
 		slowLog := fmt.Sprintf("SLOW SQL >= %v", l.cfg.SlowThreshold)
@@ -1,48 +1,55 @@
 package postgres
 
 import (
+	"context"
 	"fmt"
-	"log"
-	"time"
 
 	"github.com/npushpakumara/go-backend-template/internal/config"
 	"gorm.io/driver/postgres"
 
 	"go.uber.org/zap/zapcore"
 	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
 )
 
 // NewDatabase creates and configures a new database connection using GORM.
-func NewDatabase(cfg *config.Config) (*gorm.DB, error) {
+// Queries slower than db.slow_query.threshold are logged and added to slowQueries, which may be nil.
+// When db.replicas is set, reads outside transactions are sent to the replicas.
+// When tracing.enabled is set, every statement is recorded as a span.
+func NewDatabase(cfg *config.Config, slowQueries *SlowQueryRecorder) (*gorm.DB, error) {
 	// Initialize variables to hold the database connection, error, and logger
 	var (
 		db  *gorm.DB
 		err error
 		// Create a custom logger for GORM using the zap
-		logger = NewLogger(time.Second, true, zapcore.Level(cfg.DB.LogLevel))
+		logger = NewLogger(cfg.DB.SlowQuery.Threshold, true, zapcore.Level(cfg.DB.LogLevel), slowQueries)
 	)
 
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		cfg.DB.Host, cfg.DB.Port, cfg.DB.User, cfg.DB.Password, cfg.DB.Name, cfg.DB.SSLMode)
-
-	// Attempt to connect to the database up to 10 times with retries
-	for i := 0; i < 10; i++ {
-		// Try to open a database connection with GORM using the Postgres driver
-		db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
-			Logger: logger, // Use the custom logger for GORM logging
-		})
+	// Resolve the DSN and pool limits, either from db.url or from the individual fields
+	settings, err := resolveConnectionSettings(&cfg.DB)
+	if err != nil {
+		return nil, err
+	}
 
-		// If the connection was successful, exit the loop
-		if err == nil {
-			break
-		}
+	if cfg.DB.Retry.Strategy != RetryStrategyFixed && cfg.DB.Retry.Strategy != RetryStrategyExponential {
+		return nil, fmt.Errorf("invalid db.retry.strategy %q: must be %q or %q",
+			cfg.DB.Retry.Strategy, RetryStrategyFixed, RetryStrategyExponential)
+	}
 
-		// Log the error and retry after a short delay
-		log.Printf("Attempt %d: Failed to connect to the database: %v", i+1, err)
-		time.Sleep(500 * time.Millisecond)
+	// Bound the whole connection phase when a timeout is configured
+	ctx := context.Background()
+	if cfg.DB.Retry.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.DB.Retry.Timeout)
+		defer cancel()
 	}
 
-	// If we failed to connect after all attempts, return the error
+	// Try to open a database connection with GORM using the Postgres driver, retrying as configured
+	db, err = connectWithRetry(ctx, cfg.DB.Retry, func() (*gorm.DB, error) {
+		return gorm.Open(postgres.Open(settings.dsn), &gorm.Config{
+			Logger: logger, // Use the custom logger for GORM logging
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -54,11 +61,12 @@ func NewDatabase(cfg *config.Config) (*gorm.DB, error) {
 	}
 
 	// Configure the connection pool settings
-	pgDB.SetMaxOpenConns(cfg.DB.Pool.MaxOpen)        // Maximum number of open connections to the database
-	pgDB.SetMaxIdleConns(cfg.DB.Pool.MaxIdle)        // Maximum number of idle connections in the pool
-	pgDB.SetConnMaxLifetime(cfg.DB.Pool.MaxLifetime) // Maximum lifetime of a connection before it is reused
+	pgDB.SetMaxOpenConns(settings.maxOpen)        // Maximum number of open connections to the database
+	pgDB.SetMaxIdleConns(settings.maxIdle)        // Maximum number of idle connections in the pool
+	pgDB.SetConnMaxLifetime(settings.maxLifetime) // Maximum lifetime of a connection before it is reused
 
-	err = db.Exec("CREATE SCHEMA IF NOT EXISTS auc").Error
+	// The schema name was validated as a plain identifier, so it is safe to interpolate
+	err = db.Exec(fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", settings.schema)).Error
 	if err != nil {
 		return nil, err
 	}
@@ -72,6 +80,49 @@ func NewDatabase(cfg *config.Config) (*gorm.DB, error) {
 		}
 	}
 
+	if cfg.Tracing.Enabled {
+		if err := db.Use(queryTracing{}); err != nil {
+			return nil, err
+		}
+	}
+
+	// Route reads to the replicas, once the migrations have run against the primary
+	if len(cfg.DB.Replicas) > 0 {
+		if err := useReadReplicas(ctx, db, cfg, logger); err != nil {
+			return nil, err
+		}
+	}
+
 	// Return the successfully connected and configured GORM database instance
 	return db, nil
 }
+
+// useReadReplicas connects to every replica in db.replicas, with the same retries, pool limits
+// and schema as the primary, and registers the plugin that routes reads to them.
+func useReadReplicas(ctx context.Context, db *gorm.DB, cfg *config.Config, logger gormlogger.Interface) error {
+	pools := make([]gorm.ConnPool, 0, len(cfg.DB.Replicas))
+	for i, replica := range cfg.DB.Replicas {
+		replicaCfg := cfg.DB
+		replicaCfg.URL = replica
+		settings, err := resolveConnectionSettings(&replicaCfg)
+		if err != nil {
+			return fmt.Errorf("db.replicas[%d]: %w", i, err)
+		}
+
+		replicaDB, err := connectWithRetry(ctx, cfg.DB.Retry, func() (*gorm.DB, error) {
+			return gorm.Open(postgres.Open(settings.dsn), &gorm.Config{Logger: logger})
+		})
+		if err != nil {
+			return fmt.Errorf("db.replicas[%d]: %w", i, err)
+		}
+		sqlDB, err := replicaDB.DB()
+		if err != nil {
+			return err
+		}
+		sqlDB.SetMaxOpenConns(settings.maxOpen)
+		sqlDB.SetMaxIdleConns(settings.maxIdle)
+		sqlDB.SetConnMaxLifetime(settings.maxLifetime)
+		pools = append(pools, sqlDB)
+	}
+	return db.Use(&readReplicas{pools: pools})
+}
@@ -0,0 +1,34 @@
+package postgrestest
+
+import (
+	"context"
+
+	"github.com/npushpakumara/go-backend-template/internal/postgres"
+)
+
+// NopTransactionManager is a postgres.TransactionManager for services under test that are backed by
+// in-memory repositories. It runs functions directly with the caller's context, so nothing is rolled back.
+type NopTransactionManager struct{}
+
+// Compile-time check that NopTransactionManager implements postgres.TransactionManager.
+var _ postgres.TransactionManager = NopTransactionManager{}
+
+// Begin returns ctx unchanged.
+func (NopTransactionManager) Begin(ctx context.Context) (context.Context, error) {
+	return ctx, nil
+}
+
+// Commit does nothing.
+func (NopTransactionManager) Commit(context.Context) error {
+	return nil
+}
+
+// Rollback does nothing.
+func (NopTransactionManager) Rollback(context.Context) error {
+	return nil
+}
+
+// RunInTransaction calls fn with ctx.
+func (NopTransactionManager) RunInTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+)
+
+// usePrimaryKey is the statement setting that keeps reads on the primary.
+const usePrimaryKey = "postgres:use_primary"
+
+// UsePrimary returns a session whose reads go to the primary even when read replicas are configured,
+// for reads that must see a write the replicas may not have replayed yet. Reads inside a transaction
+// always go to the primary.
+func UsePrimary(db *gorm.DB) *gorm.DB {
+	return db.Set(usePrimaryKey, true)
+}
+
+// readReplicas is a GORM plugin that sends reads to the read replicas, in turn, and everything else
+// to the primary. It works like gorm.io/plugin/dbresolver with a single source and no policies.
+type readReplicas struct {
+	pools []gorm.ConnPool
+	next  atomic.Uint64
+}
+
+// Name identifies the plugin to gorm.DB.Use.
+func (r *readReplicas) Name() string {
+	return "postgres:read_replicas"
+}
+
+// Initialize registers the callbacks that route queries and row scans before GORM runs them.
+func (r *readReplicas) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Query().Before("gorm:query").Register("postgres:read_replicas", r.route); err != nil {
+		return err
+	}
+	return db.Callback().Row().Before("gorm:row").Register("postgres:read_replicas", r.route)
+}
+
+// route points the statement at the next replica unless it has to run on the primary: inside a transaction,
+// with a locking clause such as FOR UPDATE, when the session asked for UsePrimary, or for raw SQL that isn't a SELECT.
+func (r *readReplicas) route(db *gorm.DB) {
+	if db.Error != nil {
+		return
+	}
+	stmt := db.Statement
+	if _, inTransaction := stmt.ConnPool.(gorm.TxCommitter); inTransaction {
+		return
+	}
+	if _, locking := stmt.Clauses["FOR"]; locking {
+		return
+	}
+	if usePrimary, ok := db.Get(usePrimaryKey); ok && usePrimary == true {
+		return
+	}
+	// Raw SQL is already built, while SQL that GORM builds itself here is always a SELECT.
+	if sql := strings.TrimSpace(stmt.SQL.String()); sql != "" && !strings.HasPrefix(strings.ToUpper(sql), "SELECT") {
+		return
+	}
+
+	stmt.ConnPool = r.pools[(r.next.Add(1)-1)%uint64(len(r.pools))]
+}
@@ -0,0 +1,187 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+// errStub is returned by every stub pool call; the tests only look at which pool was called.
+var errStub = errors.New("stub pool")
+
+// stubPools records the name of each pool a statement was sent to.
+type stubPools struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+// Calls returns the pools called since the last call to Calls, oldest first.
+func (s *stubPools) Calls() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	calls := s.calls
+	s.calls = nil
+	return calls
+}
+
+// stubPool is a gorm.ConnPool that fails every statement after recording it.
+type stubPool struct {
+	name  string
+	pools *stubPools
+}
+
+func (p *stubPool) record() {
+	p.pools.mu.Lock()
+	defer p.pools.mu.Unlock()
+	p.pools.calls = append(p.pools.calls, p.name)
+}
+
+func (p *stubPool) PrepareContext(context.Context, string) (*sql.Stmt, error) {
+	p.record()
+	return nil, errStub
+}
+
+func (p *stubPool) ExecContext(context.Context, string, ...interface{}) (sql.Result, error) {
+	p.record()
+	return nil, errStub
+}
+
+func (p *stubPool) QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error) {
+	p.record()
+	return nil, errStub
+}
+
+func (p *stubPool) QueryRowContext(context.Context, string, ...interface{}) *sql.Row {
+	p.record()
+	return nil
+}
+
+// BeginTx starts a stub transaction, whose statements are recorded as "<name> tx".
+func (p *stubPool) BeginTx(context.Context, *sql.TxOptions) (gorm.ConnPool, error) {
+	return &stubTx{&stubPool{name: p.name + " tx", pools: p.pools}}, nil
+}
+
+// stubTx is a stub transaction. Embedding the interface rather than the pool leaves out BeginTx,
+// so GORM doesn't nest transactions.
+type stubTx struct {
+	gorm.ConnPool
+}
+
+func (*stubTx) Commit() error   { return nil }
+func (*stubTx) Rollback() error { return nil }
+
+// replicaTestModel is a table the routed statements read and write.
+type replicaTestModel struct {
+	ID   uint
+	Name string
+}
+
+// newRoutedDB returns a database whose primary and two replicas are stub pools.
+func newRoutedDB(t *testing.T) (*gorm.DB, *stubPools) {
+	t.Helper()
+
+	pools := &stubPools{}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: &stubPool{name: "primary", pools: pools}}), &gorm.Config{
+		DisableAutomaticPing: true,
+		Logger:               gormLogger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	replicas := &readReplicas{pools: []gorm.ConnPool{
+		&stubPool{name: "replica 1", pools: pools},
+		&stubPool{name: "replica 2", pools: pools},
+	}}
+	if err := db.Use(replicas); err != nil {
+		t.Fatalf("Use() error = %v", err)
+	}
+	return db, pools
+}
+
+func TestReadReplicasRouting(t *testing.T) {
+	db, pools := newRoutedDB(t)
+
+	tests := []struct {
+		name string
+		run  func(db *gorm.DB)
+		want []string
+	}{
+		{
+			name: "reads alternate between the replicas",
+			run: func(db *gorm.DB) {
+				db.Find(&[]replicaTestModel{})
+				db.First(&replicaTestModel{}, 1)
+				db.Model(&replicaTestModel{}).Count(new(int64))
+			},
+			want: []string{"replica 1", "replica 2", "replica 1"},
+		},
+		{
+			name: "raw select",
+			run:  func(db *gorm.DB) { _, _ = db.Raw("SELECT name FROM replica_test_models").Rows() },
+			want: []string{"replica 2"},
+		},
+		// GORM wraps each write in a transaction of its own.
+		{
+			name: "insert",
+			run:  func(db *gorm.DB) { db.Create(&replicaTestModel{Name: "Ada"}) },
+			want: []string{"primary tx"},
+		},
+		{
+			name: "insert without a transaction",
+			run: func(db *gorm.DB) {
+				db.Session(&gorm.Session{SkipDefaultTransaction: true}).Create(&replicaTestModel{Name: "Ada"})
+			},
+			want: []string{"primary"},
+		},
+		{
+			name: "update",
+			run:  func(db *gorm.DB) { db.Model(&replicaTestModel{ID: 1}).Update("name", "Augusta") },
+			want: []string{"primary tx"},
+		},
+		{
+			name: "raw update",
+			run:  func(db *gorm.DB) { _, _ = db.Raw("UPDATE replica_test_models SET name = 'Ada' RETURNING id").Rows() },
+			want: []string{"primary"},
+		},
+		{
+			name: "select for update",
+			run: func(db *gorm.DB) {
+				db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&replicaTestModel{}, 1)
+			},
+			want: []string{"primary"},
+		},
+		{
+			name: "use primary",
+			run:  func(db *gorm.DB) { UsePrimary(db).Find(&[]replicaTestModel{}) },
+			want: []string{"primary"},
+		},
+		{
+			name: "transaction",
+			run: func(db *gorm.DB) {
+				_ = db.Transaction(func(tx *gorm.DB) error {
+					tx.Find(&[]replicaTestModel{})
+					tx.Create(&replicaTestModel{Name: "Ada"})
+					return nil
+				})
+			},
+			want: []string{"primary tx", "primary tx"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(db)
+			if got := pools.Calls(); !slices.Equal(got, tt.want) {
+				t.Errorf("statements went to %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	"gorm.io/gorm"
+)
+
+// Supported values for db.retry.strategy.
+const (
+	RetryStrategyFixed       = "fixed"
+	RetryStrategyExponential = "exponential"
+)
+
+// dialFunc opens a database connection. It is a parameter of connectWithRetry so the loop doesn't depend on a live database.
+type dialFunc func() (*gorm.DB, error)
+
+// connectWithRetry calls dial until it succeeds, the configured number of attempts is used up,
+// or ctx is done. Between attempts it sleeps for the delay computed by the configured strategy.
+func connectWithRetry(ctx context.Context, cfg config.DBRetryConfig, dial dialFunc) (*gorm.DB, error) {
+	attempts := max(cfg.Attempts, 1)
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		var db *gorm.DB
+		db, err = dial()
+		if err == nil {
+			return db, nil
+		}
+
+		if attempt == attempts {
+			break
+		}
+
+		delay := retryDelay(cfg, attempt)
+		log.Printf("Attempt %d/%d: Failed to connect to the database: %v (retrying in %s)", attempt, attempts, err, delay)
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("database connection aborted after %d attempts: %w (last error: %v)", attempt, ctx.Err(), err)
+		case <-time.After(delay):
+		}
+	}
+
+	return nil, fmt.Errorf("failed to connect to the database after %d attempts: %w", attempts, err)
+}
+
+// retryDelay returns how long to wait after the given failed attempt (starting at 1).
+// The exponential strategy doubles the base delay on every attempt; both strategies are capped at MaxDelay when it is set.
+func retryDelay(cfg config.DBRetryConfig, attempt int) time.Duration {
+	delay := cfg.BaseDelay
+	if cfg.Strategy == RetryStrategyExponential {
+		for i := 1; i < attempt; i++ {
+			delay *= 2
+			// Stop doubling once past the cap, which also keeps the shift from overflowing.
+			if cfg.MaxDelay > 0 && delay >= cfg.MaxDelay {
+				break
+			}
+		}
+	}
+	if cfg.MaxDelay > 0 && delay > cfg.MaxDelay {
+		delay = cfg.MaxDelay
+	}
+	return delay
+}
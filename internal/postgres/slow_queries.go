@@ -0,0 +1,247 @@
+package postgres
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/pkg/clock"
+	"github.com/npushpakumara/go-backend-template/pkg/logging"
+	"go.uber.org/fx"
+)
+
+// SlowQueryRecorder keeps the most recent slow queries in a ring buffer, normalized so that queries
+// differing only in their literal values are reported as one pattern. A nil recorder records nothing.
+type SlowQueryRecorder struct {
+	clock clock.Clock
+	top   int
+
+	mu      sync.Mutex
+	samples []slowQuery
+	next    int
+	full    bool
+}
+
+// slowQuery is one slow execution of a normalized query.
+type slowQuery struct {
+	pattern  string
+	duration time.Duration
+	at       time.Time
+}
+
+// SlowQueryPattern aggregates the slow executions of one normalized query.
+type SlowQueryPattern struct {
+	Query   string  `json:"query"`
+	Count   int     `json:"count"`
+	AvgMS   float64 `json:"avg_ms"`
+	MaxMS   float64 `json:"max_ms"`
+	TotalMS float64 `json:"total_ms"`
+}
+
+// SlowQueryReport lists the patterns that took the most time in total over a window, slowest first.
+// Samples counts every slow query in the window, including those of patterns beyond the top ones.
+type SlowQueryReport struct {
+	Since    time.Time          `json:"since"`
+	Samples  int                `json:"samples"`
+	Patterns []SlowQueryPattern `json:"patterns"`
+}
+
+// NewSlowQueryRecorder creates a recorder keeping the last db.slow_query.buffer_size slow queries,
+// or nil when the slow query threshold is disabled.
+func NewSlowQueryRecorder(cfg *config.Config, clk clock.Clock) *SlowQueryRecorder {
+	if cfg.DB.SlowQuery.Threshold <= 0 {
+		return nil
+	}
+	return &SlowQueryRecorder{
+		clock:   clk,
+		top:     cfg.DB.SlowQuery.ReportTop,
+		samples: make([]slowQuery, cfg.DB.SlowQuery.BufferSize),
+	}
+}
+
+// Record normalizes the query and stores it, replacing the oldest sample once the buffer is full.
+func (r *SlowQueryRecorder) Record(sql string, duration time.Duration) {
+	if r == nil {
+		return
+	}
+	sample := slowQuery{pattern: NormalizeSQL(sql), duration: duration, at: r.clock.Now()}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.samples[r.next] = sample
+	r.next = (r.next + 1) % len(r.samples)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Report aggregates the samples recorded within the window. Older samples may already have been
+// replaced, so a busy database reports at most the buffer's worth of slow queries.
+func (r *SlowQueryRecorder) Report(window time.Duration) SlowQueryReport {
+	if r == nil {
+		return SlowQueryReport{Patterns: []SlowQueryPattern{}}
+	}
+	since := r.clock.Now().Add(-window)
+
+	r.mu.Lock()
+	stored := r.samples[:r.next]
+	if r.full {
+		stored = r.samples
+	}
+	byPattern := map[string]*SlowQueryPattern{}
+	report := SlowQueryReport{Since: since}
+	for _, sample := range stored {
+		if sample.at.Before(since) {
+			continue
+		}
+		report.Samples++
+		p, ok := byPattern[sample.pattern]
+		if !ok {
+			p = &SlowQueryPattern{Query: sample.pattern}
+			byPattern[sample.pattern] = p
+		}
+		ms := float64(sample.duration.Nanoseconds()) / 1e6
+		p.Count++
+		p.TotalMS += ms
+		p.MaxMS = max(p.MaxMS, ms)
+	}
+	r.mu.Unlock()
+
+	report.Patterns = make([]SlowQueryPattern, 0, len(byPattern))
+	for _, p := range byPattern {
+		p.AvgMS = p.TotalMS / float64(p.Count)
+		report.Patterns = append(report.Patterns, *p)
+	}
+	sort.Slice(report.Patterns, func(i, j int) bool {
+		if report.Patterns[i].TotalMS != report.Patterns[j].TotalMS {
+			return report.Patterns[i].TotalMS > report.Patterns[j].TotalMS
+		}
+		return report.Patterns[i].Query < report.Patterns[j].Query
+	})
+	if r.top > 0 && len(report.Patterns) > r.top {
+		report.Patterns = report.Patterns[:r.top]
+	}
+	return report
+}
+
+// StartSlowQueryReport logs the slow query report every db.slow_query.report_interval, covering the
+// queries since the previous one. Intervals without slow queries are not logged.
+func StartSlowQueryReport(lc fx.Lifecycle, cfg *config.Config, recorder *SlowQueryRecorder) {
+	interval := cfg.DB.SlowQuery.ReportInterval
+	if recorder == nil || interval <= 0 {
+		return
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+				ticker := time.NewTicker(interval)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-stop:
+						return
+					case <-ticker.C:
+						if report := recorder.Report(interval); report.Samples > 0 {
+							logging.DefaultLogger().Warnw("postgres.slow_queries report",
+								"since", report.Since, "samples", report.Samples, "patterns", report.Patterns)
+						}
+					}
+				}
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			close(stop)
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+}
+
+var (
+	// inList matches a parenthesized list of two or more placeholders, e.g. the values of IN (...).
+	inList = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)+\s*\)`)
+	// tupleList matches two or more consecutive parenthesized placeholder tuples, e.g. multi-row VALUES.
+	tupleList = regexp.MustCompile(`(\([?,\s]*\))(?:\s*,\s*\([?,\s]*\))+`)
+	// spaces matches runs of whitespace.
+	spaces = regexp.MustCompile(`\s+`)
+)
+
+// NormalizeSQL reduces a query to its pattern: string and numeric literals and bind parameters become ?,
+// lists of placeholders collapse to one, so IN lists and multi-row inserts of any length match,
+// and whitespace is collapsed. Quoted identifiers are kept as they are.
+func NormalizeSQL(sql string) string {
+	var b strings.Builder
+	b.Grow(len(sql))
+
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == '\'':
+			// A string literal, where '' is an escaped quote.
+			i++
+			for i < len(sql) {
+				if sql[i] == '\'' {
+					if i+1 < len(sql) && sql[i+1] == '\'' {
+						i += 2
+						continue
+					}
+					break
+				}
+				i++
+			}
+			i++
+			b.WriteByte('?')
+		case c == '"':
+			// A quoted identifier is copied through.
+			end := strings.IndexByte(sql[i+1:], '"')
+			if end < 0 {
+				b.WriteString(sql[i:])
+				i = len(sql)
+				continue
+			}
+			b.WriteString(sql[i : i+end+2])
+			i += end + 2
+		case c == '$' && i+1 < len(sql) && isDigit(sql[i+1]):
+			i++
+			for i < len(sql) && isDigit(sql[i]) {
+				i++
+			}
+			b.WriteByte('?')
+		case isDigit(c) && (i == 0 || !isIdentChar(sql[i-1])):
+			for i < len(sql) && (isDigit(sql[i]) || sql[i] == '.') {
+				i++
+			}
+			b.WriteByte('?')
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+
+	normalized := spaces.ReplaceAllString(strings.TrimSpace(b.String()), " ")
+	normalized = inList.ReplaceAllString(normalized, "(?)")
+	return tupleList.ReplaceAllString(normalized, "$1")
+}
+
+// isDigit reports whether c is an ASCII digit.
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// isIdentChar reports whether c may appear in an unquoted identifier, so that digits inside names such as t1 are kept.
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '.' || isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,156 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/pkg/clock"
+)
+
+func TestNormalizeSQL(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{
+			name: "bind parameters",
+			sql:  `SELECT * FROM "users" WHERE email = $1 AND status = $2`,
+			want: `SELECT * FROM "users" WHERE email = ? AND status = ?`,
+		},
+		{
+			name: "string literals",
+			sql:  `SELECT * FROM "users" WHERE email = 'ada@example.com'`,
+			want: `SELECT * FROM "users" WHERE email = ?`,
+		},
+		{
+			name: "escaped quotes in a string literal",
+			sql:  `SELECT * FROM "users" WHERE last_name = 'O''Brien' AND first_name = 'Ada'`,
+			want: `SELECT * FROM "users" WHERE last_name = ? AND first_name = ?`,
+		},
+		{
+			name: "numeric literals",
+			sql:  `SELECT * FROM "orders" WHERE total > 10.50 LIMIT 20 OFFSET 40`,
+			want: `SELECT * FROM "orders" WHERE total > ? LIMIT ? OFFSET ?`,
+		},
+		{
+			name: "digits in identifiers",
+			sql:  `SELECT t1.id FROM users t1 JOIN "orders2" o2 ON o2.user_id = t1.id WHERE t1.score = 3`,
+			want: `SELECT t1.id FROM users t1 JOIN "orders2" o2 ON o2.user_id = t1.id WHERE t1.score = ?`,
+		},
+		{
+			name: "quoted identifiers with quotes and digits",
+			sql:  `SELECT "it's 42" FROM "users" WHERE "users"."id" = 7`,
+			want: `SELECT "it's 42" FROM "users" WHERE "users"."id" = ?`,
+		},
+		{
+			name: "IN lists of any length",
+			sql:  `SELECT * FROM "users" WHERE id IN ($1, $2, $3, $4)`,
+			want: `SELECT * FROM "users" WHERE id IN (?)`,
+		},
+		{
+			name: "IN lists of literals",
+			sql:  `SELECT * FROM "users" WHERE status IN ('active','banned')`,
+			want: `SELECT * FROM "users" WHERE status IN (?)`,
+		},
+		{
+			name: "multi-row inserts",
+			sql:  `INSERT INTO "users" ("email","status") VALUES ($1,$2),($3,$4),($5,$6)`,
+			want: `INSERT INTO "users" ("email","status") VALUES (?)`,
+		},
+		{
+			name: "whitespace",
+			sql:  "  SELECT *\n\tFROM \"users\"\n  WHERE id = $1  ",
+			want: `SELECT * FROM "users" WHERE id = ?`,
+		},
+		{
+			name: "unterminated quoted identifier",
+			sql:  `SELECT * FROM "users`,
+			want: `SELECT * FROM "users`,
+		},
+		{
+			name: "unterminated string literal",
+			sql:  `SELECT * FROM "users" WHERE email = 'ada`,
+			want: `SELECT * FROM "users" WHERE email = ?`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeSQL(tt.sql); got != tt.want {
+				t.Errorf("NormalizeSQL(%q) = %q, want %q", tt.sql, got, tt.want)
+			}
+		})
+	}
+}
+
+// Queries that differ only in their values or in the length of their lists share a pattern.
+func TestNormalizeSQLGroupsQueries(t *testing.T) {
+	queries := [][]string{
+		{
+			`SELECT * FROM "users" WHERE id IN (1, 2)`,
+			`SELECT * FROM "users" WHERE id IN ($1,$2,$3)`,
+			`SELECT  *  FROM "users"  WHERE id IN ('a', 'b', 'c', 'd')`,
+		},
+		{
+			`INSERT INTO "audit_logs" ("action") VALUES ('login')`,
+			`INSERT INTO "audit_logs" ("action") VALUES ($1),($2)`,
+		},
+	}
+	for _, group := range queries {
+		want := NormalizeSQL(group[0])
+		for _, sql := range group[1:] {
+			if got := NormalizeSQL(sql); got != want {
+				t.Errorf("NormalizeSQL(%q) = %q, want %q like %q", sql, got, want, group[0])
+			}
+		}
+	}
+}
+
+func TestSlowQueryRecorderReport(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	cfg := &config.Config{}
+	cfg.DB.SlowQuery.Threshold = 100 * time.Millisecond
+	cfg.DB.SlowQuery.BufferSize = 4
+	cfg.DB.SlowQuery.ReportTop = 2
+	recorder := NewSlowQueryRecorder(cfg, clk)
+
+	// The oldest sample falls outside the window, and the buffer keeps only the last four.
+	recorder.Record(`SELECT * FROM "sessions" WHERE id = 1`, 900*time.Millisecond)
+	clk.Advance(2 * time.Minute)
+	recorder.Record(`SELECT * FROM "users" WHERE id = 1`, 200*time.Millisecond)
+	recorder.Record(`SELECT * FROM "users" WHERE id = 2`, 400*time.Millisecond)
+	recorder.Record(`DELETE FROM "tokens" WHERE expires_at < '2024-01-01'`, 300*time.Millisecond)
+	recorder.Record(`UPDATE "users" SET status = 'active' WHERE id = 3`, 100*time.Millisecond)
+
+	report := recorder.Report(time.Minute)
+	if report.Samples != 4 {
+		t.Errorf("Samples = %d, want 4", report.Samples)
+	}
+	want := []SlowQueryPattern{
+		{Query: `SELECT * FROM "users" WHERE id = ?`, Count: 2, AvgMS: 300, MaxMS: 400, TotalMS: 600},
+		{Query: `DELETE FROM "tokens" WHERE expires_at < ?`, Count: 1, AvgMS: 300, MaxMS: 300, TotalMS: 300},
+	}
+	if len(report.Patterns) != len(want) {
+		t.Fatalf("Patterns = %+v, want %+v", report.Patterns, want)
+	}
+	for i := range want {
+		if report.Patterns[i] != want[i] {
+			t.Errorf("Patterns[%d] = %+v, want %+v", i, report.Patterns[i], want[i])
+		}
+	}
+}
+
+func TestSlowQueryRecorderDisabled(t *testing.T) {
+	cfg := &config.Config{}
+	recorder := NewSlowQueryRecorder(cfg, clock.New())
+	if recorder != nil {
+		t.Fatal("NewSlowQueryRecorder() without a threshold returned a recorder")
+	}
+
+	// A nil recorder records nothing and reports no patterns.
+	recorder.Record(`SELECT 1`, time.Second)
+	if report := recorder.Report(time.Hour); report.Samples != 0 || report.Patterns == nil || len(report.Patterns) != 0 {
+		t.Errorf("Report() = %+v, want an empty report", report)
+	}
+}
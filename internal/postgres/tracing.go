@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/npushpakumara/go-backend-template/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// tracingSpanKey is the statement setting holding the span of the statement being run.
+const tracingSpanKey = "postgres:tracing_span"
+
+// tracedStatement is what the before callback hands to the after callback.
+type tracedStatement struct {
+	span   trace.Span
+	parent context.Context
+}
+
+// queryTracing is a GORM plugin that records a client span for every statement, as a child of the span
+// on the statement's context. The span carries the SQL with its placeholders, never the bound values.
+type queryTracing struct{}
+
+// Name identifies the plugin to gorm.DB.Use.
+func (queryTracing) Name() string {
+	return "postgres:tracing"
+}
+
+// Initialize registers the callbacks that start a span before each kind of statement and end it after.
+func (t queryTracing) Initialize(db *gorm.DB) error {
+	const startName, endName = "postgres:tracing_start", "postgres:tracing_end"
+	callbacks := db.Callback()
+	return errors.Join(
+		callbacks.Create().Before("gorm:create").Register(startName, t.start("create")),
+		callbacks.Create().After("gorm:create").Register(endName, t.end),
+		callbacks.Query().Before("gorm:query").Register(startName, t.start("query")),
+		callbacks.Query().After("gorm:query").Register(endName, t.end),
+		callbacks.Update().Before("gorm:update").Register(startName, t.start("update")),
+		callbacks.Update().After("gorm:update").Register(endName, t.end),
+		callbacks.Delete().Before("gorm:delete").Register(startName, t.start("delete")),
+		callbacks.Delete().After("gorm:delete").Register(endName, t.end),
+		callbacks.Row().Before("gorm:row").Register(startName, t.start("row")),
+		callbacks.Row().After("gorm:row").Register(endName, t.end),
+		callbacks.Raw().Before("gorm:raw").Register(startName, t.start("raw")),
+		callbacks.Raw().After("gorm:raw").Register(endName, t.end),
+	)
+}
+
+// start begins the span of a statement of the given kind and points the statement at its context.
+func (queryTracing) start(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		stmt := db.Statement
+		parent := stmt.Context
+		if parent == nil {
+			parent = context.Background()
+		}
+
+		name := operation
+		if stmt.Table != "" {
+			name += " " + stmt.Table
+		}
+		ctx, span := tracing.Tracer().Start(parent, name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(semconv.DBSystemPostgreSQL),
+		)
+		if stmt.Table != "" {
+			span.SetAttributes(semconv.DBCollectionName(stmt.Table))
+		}
+		stmt.Context = ctx
+		db.InstanceSet(tracingSpanKey, &tracedStatement{span: span, parent: parent})
+	}
+}
+
+// end finishes the span with the statement GORM built and its outcome, and restores the statement's context
+// so a statement run again on the same instance isn't nested in the finished span.
+func (queryTracing) end(db *gorm.DB) {
+	value, ok := db.InstanceGet(tracingSpanKey)
+	if !ok {
+		return
+	}
+	traced := value.(*tracedStatement)
+	stmt := db.Statement
+	stmt.Context = traced.parent
+
+	span := traced.span
+	if sql := strings.TrimSpace(stmt.SQL.String()); sql != "" {
+		span.SetAttributes(semconv.DBQueryText(sql))
+	}
+	span.SetAttributes(attribute.Int64("db.rows_affected", db.RowsAffected))
+	// A lookup that finds nothing is an answer rather than a failure.
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/npushpakumara/go-backend-template/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+func TestQueryTracingRecordsStatementSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	tracing.SetGlobal(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: &stubPool{name: "primary", pools: &stubPools{}}}), &gorm.Config{
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		Logger:                 gormLogger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	if err := db.Use(queryTracing{}); err != nil {
+		t.Fatalf("Use() error = %v", err)
+	}
+
+	ctx, request := tracing.Tracer().Start(context.Background(), "request")
+	session := db.WithContext(ctx)
+	session.Where("name = ?", "Ada").Find(&[]replicaTestModel{})
+	session.Create(&replicaTestModel{Name: "Ada"})
+	request.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("recorded %d spans, want the two statements and the request", len(spans))
+	}
+	for i, name := range []string{"query replica_test_models", "create replica_test_models"} {
+		span := spans[i]
+		if span.Name() != name {
+			t.Errorf("span %d name = %q, want %q", i, span.Name(), name)
+		}
+		// Both statements are children of the request, not of each other.
+		if span.Parent().SpanID() != request.SpanContext().SpanID() {
+			t.Errorf("span %q isn't a child of the request span", span.Name())
+		}
+		// The stub pool fails every statement.
+		if span.Status().Code != codes.Error {
+			t.Errorf("span %q status = %v, want %v", span.Name(), span.Status().Code, codes.Error)
+		}
+	}
+
+	var query string
+	for _, kv := range spans[0].Attributes() {
+		if kv.Key == "db.query.text" {
+			query = kv.Value.AsString()
+		}
+	}
+	if !strings.HasPrefix(query, "SELECT") || !strings.Contains(query, "name = $1") || strings.Contains(query, "Ada") {
+		t.Errorf("db.query.text = %q, want the SELECT with placeholders and without values", query)
+	}
+}
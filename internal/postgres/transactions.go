@@ -15,6 +15,9 @@ type TransactionManager interface {
 	Commit(ctx context.Context) error
 	// Rolls back the current transaction associated with the context.
 	Rollback(ctx context.Context) error
+	// RunInTransaction runs fn with a context carrying a new transaction. The transaction is committed
+	// when fn returns nil and rolled back when it returns an error or panics; the panic is then re-raised.
+	RunInTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
 // transactionManagerImpl is a concrete implementation of the TransactionManager interface.
@@ -59,3 +62,26 @@ func (tm *transactionManagerImpl) Rollback(ctx context.Context) error {
 	}
 	return tx.Rollback().Error
 }
+
+// RunInTransaction begins a transaction, runs fn with it and commits it if fn succeeds.
+// The transaction is rolled back exactly once on any error or panic, and never after a commit has been attempted.
+func (tm *transactionManagerImpl) RunInTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	txCtx, err := tm.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	finished := false
+	defer func() {
+		if !finished {
+			tm.Rollback(txCtx)
+		}
+	}()
+
+	if err := fn(txCtx); err != nil {
+		return err
+	}
+
+	finished = true
+	return tm.Commit(txCtx)
+}
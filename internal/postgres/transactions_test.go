@@ -0,0 +1,135 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+// txEvents records what happens to the transactions of a txPool.
+type txEvents struct {
+	events []string
+}
+
+// txPool is a gorm.ConnPool whose transactions record their outcome. Statements aren't run.
+type txPool struct {
+	gorm.ConnPool
+	events    *txEvents
+	commitErr error
+}
+
+func (p *txPool) BeginTx(context.Context, *sql.TxOptions) (gorm.ConnPool, error) {
+	p.events.events = append(p.events.events, "begin")
+	return &recordingTx{pool: p}, nil
+}
+
+// recordingTx is a transaction of a txPool.
+type recordingTx struct {
+	gorm.ConnPool
+	pool *txPool
+}
+
+func (tx *recordingTx) Commit() error {
+	tx.pool.events.events = append(tx.pool.events.events, "commit")
+	return tx.pool.commitErr
+}
+
+func (tx *recordingTx) Rollback() error {
+	tx.pool.events.events = append(tx.pool.events.events, "rollback")
+	return nil
+}
+
+// newTransactionManager returns a transaction manager over pool.
+func newTransactionManager(t *testing.T, pool *txPool) (TransactionManager, *gorm.DB) {
+	t.Helper()
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: pool}), &gorm.Config{
+		DisableAutomaticPing: true,
+		Logger:               gormLogger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	return NewTransactionManager(db), db
+}
+
+func TestRunInTransactionCommits(t *testing.T) {
+	events := &txEvents{}
+	tm, db := newTransactionManager(t, &txPool{events: events})
+
+	err := tm.RunInTransaction(context.Background(), func(ctx context.Context) error {
+		// Repositories running with the context use the transaction rather than the database.
+		tx := FromContext(ctx, db)
+		if tx == db {
+			t.Error("FromContext() returned the database, want the transaction")
+		}
+		if _, ok := tx.Statement.ConnPool.(*recordingTx); !ok {
+			t.Errorf("transaction runs on %T, want the pool's transaction", tx.Statement.ConnPool)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RunInTransaction() error = %v", err)
+	}
+	if want := []string{"begin", "commit"}; !slices.Equal(events.events, want) {
+		t.Errorf("transaction events = %v, want %v", events.events, want)
+	}
+}
+
+func TestRunInTransactionRollsBackOnError(t *testing.T) {
+	events := &txEvents{}
+	tm, _ := newTransactionManager(t, &txPool{events: events})
+	errFailed := errors.New("failed")
+
+	err := tm.RunInTransaction(context.Background(), func(context.Context) error {
+		return errFailed
+	})
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("RunInTransaction() error = %v, want %v", err, errFailed)
+	}
+	if want := []string{"begin", "rollback"}; !slices.Equal(events.events, want) {
+		t.Errorf("transaction events = %v, want %v", events.events, want)
+	}
+}
+
+func TestRunInTransactionRollsBackOnPanic(t *testing.T) {
+	events := &txEvents{}
+	tm, _ := newTransactionManager(t, &txPool{events: events})
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("recovered %v, want the panic to be re-raised", r)
+			}
+		}()
+		_ = tm.RunInTransaction(context.Background(), func(context.Context) error {
+			panic("boom")
+		})
+	}()
+
+	if want := []string{"begin", "rollback"}; !slices.Equal(events.events, want) {
+		t.Errorf("transaction events = %v, want %v", events.events, want)
+	}
+}
+
+func TestRunInTransactionDoesNotRollBackFailedCommit(t *testing.T) {
+	events := &txEvents{}
+	errCommit := errors.New("commit failed")
+	tm, _ := newTransactionManager(t, &txPool{events: events, commitErr: errCommit})
+
+	err := tm.RunInTransaction(context.Background(), func(context.Context) error {
+		return nil
+	})
+	if !errors.Is(err, errCommit) {
+		t.Fatalf("RunInTransaction() error = %v, want %v", err, errCommit)
+	}
+	if want := []string{"begin", "commit"}; !slices.Equal(events.events, want) {
+		t.Errorf("transaction events = %v, want %v", events.events, want)
+	}
+}
@@ -3,16 +3,53 @@ package postgres
 import (
 	"log"
 
+	apiKeyEntity "github.com/npushpakumara/go-backend-template/internal/features/apikey/entity"
+	auditEntity "github.com/npushpakumara/go-backend-template/internal/features/audit/entity"
+	authEntity "github.com/npushpakumara/go-backend-template/internal/features/auth/entity"
+	emailEntities "github.com/npushpakumara/go-backend-template/internal/features/email/entities"
+	loginHistoryEntity "github.com/npushpakumara/go-backend-template/internal/features/loginhistory/entity"
 	"github.com/npushpakumara/go-backend-template/internal/features/user/entity"
+	webhookEntity "github.com/npushpakumara/go-backend-template/internal/features/webhook/entity"
 	"gorm.io/gorm"
 )
 
 // migrateAndSeed is a function that performs database migration and seeding.
 func migrateAndSeed(db *gorm.DB) error {
-	err := db.AutoMigrate(&entity.User{})
+	err := db.AutoMigrate(&entity.Organization{}, &entity.User{}, &entity.PasswordHistory{}, &entity.NotificationPreference{}, &apiKeyEntity.APIKey{}, &auditEntity.AuditLog{}, &emailEntities.Suppression{}, &emailEntities.FailedEmail{},
+		&webhookEntity.Subscription{}, &webhookEntity.Delivery{}, &loginHistoryEntity.LoginEvent{}, &authEntity.PasswordResetRequest{})
 	if err != nil {
 		log.Fatal("failed to migrate database:", err)
 		return err
 	}
+
+	if err := backfillUserStatus(db); err != nil {
+		log.Fatal("failed to backfill user status:", err)
+		return err
+	}
+
+	// Keyset pagination of login history walks this index in order.
+	err = db.Exec("CREATE INDEX IF NOT EXISTS idx_login_events_user_cursor ON login_events (user_id, created_at DESC, id DESC)").Error
+	if err != nil {
+		log.Fatal("failed to create login history index:", err)
+		return err
+	}
+
+	// Password reset throttling counts an email's recent requests through this index.
+	err = db.Exec("CREATE INDEX IF NOT EXISTS idx_password_reset_requests_email_created ON password_reset_requests (email, created_at)").Error
+	if err != nil {
+		log.Fatal("failed to create password reset request index:", err)
+		return err
+	}
 	return nil
 }
+
+// backfillUserStatus populates the status column from the legacy is_active column.
+// New columns are created as pending, so only previously active users need updating.
+func backfillUserStatus(db *gorm.DB) error {
+	if !db.Migrator().HasColumn(&entity.User{}, "is_active") {
+		return nil
+	}
+	return db.Model(&entity.User{}).
+		Where("is_active = ? AND status = ?", true, entity.StatusPending).
+		Update("status", entity.StatusActive).Error
+}
@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"github.com/npushpakumara/go-backend-template/pkg/clock"
+)
+
+// Status is the state of a client's bucket after a request has been counted against it.
+type Status struct {
+	// Allowed reports whether the request fits in the current window.
+	Allowed bool
+	// Limit is the number of requests allowed per window.
+	Limit int
+	// Remaining is the number of requests left in the current window.
+	Remaining int
+	// Reset is when the current window ends and the bucket is refilled.
+	Reset time.Time
+}
+
+// bucket counts the requests made by one client in the current window.
+type bucket struct {
+	count int
+	reset time.Time
+}
+
+// Limiter allows up to limit requests per client in each fixed window. Buckets live in process
+// memory, so with several replicas every instance enforces the limit on its own.
+type Limiter struct {
+	mu        sync.Mutex
+	clock     clock.Clock
+	limit     int
+	window    time.Duration
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// NewLimiter creates a Limiter allowing limit requests per window for each key.
+func NewLimiter(clk clock.Clock, limit int, window time.Duration) *Limiter {
+	return &Limiter{clock: clk, limit: limit, window: window, buckets: make(map[string]*bucket), lastSweep: clk.Now()}
+}
+
+// Take counts a request against the key's bucket and returns the bucket's state.
+// Rejected requests aren't counted, so a client that keeps retrying isn't locked out past the window.
+func (l *Limiter) Take(key string) Status {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok || !now.Before(b.reset) {
+		b = &bucket{reset: now.Add(l.window)}
+		l.buckets[key] = b
+	}
+
+	status := Status{Limit: l.limit, Reset: b.reset}
+	if b.count < l.limit {
+		b.count++
+		status.Allowed = true
+	}
+	status.Remaining = l.limit - b.count
+	return status
+}
+
+// sweep drops buckets whose window has ended, at most once per window.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.window {
+		return
+	}
+	for key, b := range l.buckets {
+		if !now.Before(b.reset) {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/pkg/clock"
+	apiError "github.com/npushpakumara/go-backend-template/pkg/errors"
+	"github.com/npushpakumara/go-backend-template/pkg/logging"
+)
+
+const (
+	// LimitHeader is the number of requests allowed per window.
+	LimitHeader = "X-RateLimit-Limit"
+
+	// RemainingHeader is the number of requests the client has left in the current window.
+	RemainingHeader = "X-RateLimit-Remaining"
+
+	// ResetHeader is the number of seconds until the current window ends.
+	ResetHeader = "X-RateLimit-Reset"
+)
+
+// Middleware limits each client IP to the configured number of requests per window. Every response
+// it governs carries the X-RateLimit-* headers describing the client's quota; requests over the limit
+// get 429 with a Retry-After header. It does nothing when rate limiting is disabled.
+func Middleware(cfg *config.Config, clk clock.Clock) gin.HandlerFunc {
+	if !cfg.RateLimit.Enabled {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	return Limit(NewLimiter(clk, cfg.RateLimit.Requests, cfg.RateLimit.Window), clk, func(c *gin.Context) string {
+		return c.ClientIP()
+	})
+}
+
+// Limit enforces the limiter on the requests it handles, counting each against the bucket named by key.
+// It sets the same headers as Middleware, so it can be used for tighter limits on individual routes.
+func Limit(limiter *Limiter, clk clock.Clock, key func(*gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status := limiter.Take(key(c))
+
+		resetAfter := secondsUntil(clk.Now(), status.Reset)
+		c.Header(LimitHeader, strconv.Itoa(status.Limit))
+		c.Header(RemainingHeader, strconv.Itoa(status.Remaining))
+		c.Header(ResetHeader, strconv.Itoa(resetAfter))
+
+		if !status.Allowed {
+			logging.FromContext(c.Request.Context()).Warnw("ratelimit.Limit rejected request", "path", c.FullPath(), "client_ip", c.ClientIP())
+			c.Header("Retry-After", strconv.Itoa(resetAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, apiError.ErrorResponse{Status: "error", Message: "Too many requests"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// secondsUntil rounds the time left until t up to whole seconds, so clients never retry too early.
+func secondsUntil(now, t time.Time) int {
+	return int(math.Ceil(t.Sub(now).Seconds()))
+}
@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/npushpakumara/go-backend-template/internal/config"
+	"github.com/npushpakumara/go-backend-template/pkg/clock"
+)
+
+func TestMiddlewareHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	clk := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	cfg := &config.Config{}
+	cfg.RateLimit = config.RateLimitConfig{Enabled: true, Requests: 3, Window: time.Minute}
+
+	router := gin.New()
+	router.Use(Middleware(cfg, clk))
+	router.GET("/ping", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	request := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ping", nil))
+		return rec
+	}
+
+	for i := range 3 {
+		rec := request()
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("request %d status = %d, want %d", i+1, rec.Code, http.StatusNoContent)
+		}
+		if got := rec.Header().Get(LimitHeader); got != "3" {
+			t.Errorf("request %d %s = %q, want %q", i+1, LimitHeader, got, "3")
+		}
+		if got, want := rec.Header().Get(RemainingHeader), strconv.Itoa(2-i); got != want {
+			t.Errorf("request %d %s = %q, want %q", i+1, RemainingHeader, got, want)
+		}
+		if got := rec.Header().Get("Retry-After"); got != "" {
+			t.Errorf("request %d Retry-After = %q, want none", i+1, got)
+		}
+	}
+
+	clk.Advance(20 * time.Second)
+	rec := request()
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("request over the limit status = %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+	if got := rec.Header().Get(RemainingHeader); got != "0" {
+		t.Errorf("request over the limit %s = %q, want %q", RemainingHeader, got, "0")
+	}
+	if got := rec.Header().Get("Retry-After"); got != "40" {
+		t.Errorf("request over the limit Retry-After = %q, want %q", got, "40")
+	}
+
+	// A new window refills the quota.
+	clk.Advance(40 * time.Second)
+	if rec := request(); rec.Code != http.StatusNoContent {
+		t.Errorf("request in the next window status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+}
@@ -0,0 +1,166 @@
+package rbac
+
+import (
+	"context"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	userDto "github.com/npushpakumara/go-backend-template/internal/features/user/dto"
+	apiError "github.com/npushpakumara/go-backend-template/pkg/errors"
+)
+
+// IdentityKey is the gin context key under which the authenticated user's identity is stored.
+const IdentityKey = "id"
+
+// AuthTimeKey is the JWT claim holding the time the user originally signed in.
+// Unlike orig_iat it is carried over unchanged when a token is refreshed.
+const AuthTimeKey = "auth_time"
+
+// ActorKey is the JWT claim carried by impersonated sessions. As in RFC 8693, it is an object
+// whose "sub" member is the ID of the administrator acting as the user.
+const ActorKey = "act"
+
+// OrgKey is the JWT claim naming the organization the user belonged to when the session started.
+// Sessions of users outside any organization don't carry it.
+const OrgKey = "org"
+
+// orgIDKey is the gin context key under which SetOrg stores the organization the request is scoped to.
+const orgIDKey = "org_id"
+
+// impersonatorKey is the gin context key under which SetImpersonator stores the administrator's ID.
+const impersonatorKey = "impersonator"
+
+// RequireRole is a Gin middleware that only allows requests whose authenticated identity holds one of the given roles.
+// It must run after the JWT middleware, which is responsible for storing the identity in the context.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity, ok := c.Get(IdentityKey)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, apiError.ErrorResponse{Status: "error", Message: "Unauthorized"})
+			return
+		}
+
+		user, ok := identity.(*userDto.UserResponseDto)
+		if !ok || !slices.Contains(roles, user.Role) {
+			c.AbortWithStatusJSON(http.StatusForbidden, apiError.ErrorResponse{Status: "error", Message: "Forbidden"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// userKey is the key under which SetIdentity stores the user in the request's context.
+type userKey struct{}
+
+// SetIdentity stores the user loaded for the request in the gin context, where RequireRole and
+// CurrentIdentity find it, and in the request's context, so that CurrentUser finds it from code
+// that only receives a context.Context. It lives as long as the request.
+func SetIdentity(c *gin.Context, user *userDto.UserResponseDto) {
+	c.Set(IdentityKey, user)
+	c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), userKey{}, user))
+}
+
+// CurrentUser returns the authenticated user of the request ctx belongs to, if any, without another lookup.
+// ctx may be the gin context, the request's context or a context derived from either.
+func CurrentUser(ctx context.Context) (*userDto.UserResponseDto, bool) {
+	// gin contexts, and contexts derived from them, answer string keys from their own values.
+	if user, ok := ctx.Value(IdentityKey).(*userDto.UserResponseDto); ok {
+		return user, true
+	}
+	user, ok := ctx.Value(userKey{}).(*userDto.UserResponseDto)
+	return user, ok
+}
+
+// CurrentIdentity returns the authenticated user's identity stored in the gin context, if any.
+func CurrentIdentity(c *gin.Context) (*userDto.UserResponseDto, bool) {
+	identity, ok := c.Get(IdentityKey)
+	if !ok {
+		return nil, false
+	}
+	user, ok := identity.(*userDto.UserResponseDto)
+	return user, ok
+}
+
+// ActorClaim returns the value of the act claim for a session impersonated by the given administrator.
+func ActorClaim(actorID string) map[string]interface{} {
+	return map[string]interface{}{"sub": actorID}
+}
+
+// Actor returns the ID of the administrator named by the act claim of the given token claims, if the token has one.
+func Actor(claims map[string]interface{}) (string, bool) {
+	act, ok := claims[ActorKey].(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	actorID, ok := act["sub"].(string)
+	return actorID, ok && actorID != ""
+}
+
+// SetImpersonator marks the request as made by the given administrator on behalf of the authenticated user.
+func SetImpersonator(c *gin.Context, actorID string) {
+	c.Set(impersonatorKey, actorID)
+}
+
+// Impersonator returns the ID of the administrator impersonating the authenticated user, if the request
+// was made with an impersonated session.
+func Impersonator(c *gin.Context) (string, bool) {
+	actorID := c.GetString(impersonatorKey)
+	return actorID, actorID != ""
+}
+
+// DenyImpersonation is a Gin middleware that rejects requests made with an impersonated session,
+// for actions only the user themselves may take. It must run after the JWT middleware.
+func DenyImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := Impersonator(c); ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, apiError.ErrorResponse{Status: "error", Message: "Not allowed while impersonating a user"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// DenyOrgScoped is a Gin middleware that rejects requests scoped to an organization, for routes that manage
+// the whole platform, such as webhook subscriptions, API keys and the email admin routes. Only administrators
+// outside any organization reach them. It must run after the JWT middleware.
+func DenyOrgScoped() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := CurrentOrg(c); ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, apiError.ErrorResponse{Status: "error", Message: "Forbidden"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// orgKey is the key under which SetOrg and WithoutOrg store the organization in a context.
+type orgKey struct{}
+
+// SetOrg scopes the request to the given organization. Like SetIdentity, it stores the organization in
+// the gin context and in the request's context, where CurrentOrg finds it; user queries made on behalf
+// of the request are then limited to the organization's users.
+func SetOrg(c *gin.Context, orgID string) {
+	c.Set(orgIDKey, orgID)
+	c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), orgKey{}, orgID))
+}
+
+// WithoutOrg returns a copy of ctx that isn't scoped to any organization, for the rare lookup that must see
+// users of every organization, such as the administrator behind an impersonated session.
+func WithoutOrg(ctx context.Context) context.Context {
+	return context.WithValue(ctx, orgKey{}, "")
+}
+
+// CurrentOrg returns the organization the request ctx belongs to is scoped to, if any.
+// ctx may be the gin context, the request's context or a context derived from either.
+func CurrentOrg(ctx context.Context) (string, bool) {
+	// A context derived with WithoutOrg answers before the gin context it wraps.
+	if orgID, ok := ctx.Value(orgKey{}).(string); ok {
+		return orgID, orgID != ""
+	}
+	orgID, ok := ctx.Value(orgIDKey).(string)
+	return orgID, ok && orgID != ""
+}
@@ -0,0 +1,45 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor is returned when a cursor string can't be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor marks a position in a list ordered by creation time and then ID, both descending.
+// Keyset pagination with a cursor stays fast however deep the client pages, unlike OFFSET.
+type Cursor struct {
+	Time time.Time
+	ID   string
+}
+
+// Encode returns the cursor as an opaque, URL-safe string of the form base64("<unix_nano>_<id>").
+func Encode(c Cursor) string {
+	raw := strconv.FormatInt(c.Time.UnixNano(), 10) + "_" + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// Decode parses a string produced by Encode.
+func Decode(s string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	nanos, id, ok := strings.Cut(string(raw), "_")
+	if !ok || id == "" {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return Cursor{Time: time.Unix(0, n).UTC(), ID: id}, nil
+}
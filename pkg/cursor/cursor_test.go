@@ -0,0 +1,54 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	cursors := []Cursor{
+		{Time: time.Date(2024, 1, 1, 12, 0, 0, 123456789, time.UTC), ID: "0b6c1e2a-8f4d-4c3b-9a7e-5d2f1c0b9a8e"},
+		// Times in another zone come back in UTC, at the same instant.
+		{Time: time.Date(2024, 6, 30, 23, 59, 59, 1, time.FixedZone("UTC+2", 2*60*60)), ID: "id_with_underscores"},
+		{Time: time.Unix(0, 0).UTC(), ID: "1"},
+	}
+	for _, c := range cursors {
+		encoded := Encode(c)
+		if _, err := base64.RawURLEncoding.DecodeString(encoded); err != nil {
+			t.Errorf("Encode(%v) = %q, which isn't URL-safe base64: %v", c, encoded, err)
+		}
+
+		decoded, err := Decode(encoded)
+		if err != nil {
+			t.Fatalf("Decode(%q) error = %v", encoded, err)
+		}
+		if !decoded.Time.Equal(c.Time) || decoded.ID != c.ID {
+			t.Errorf("Decode(Encode(%v)) = %v", c, decoded)
+		}
+		if decoded.Time.Location() != time.UTC {
+			t.Errorf("Decode() time is in %s, want UTC", decoded.Time.Location())
+		}
+	}
+}
+
+func TestDecodeRejectsInvalidCursors(t *testing.T) {
+	encode := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+
+	tests := map[string]string{
+		"not base64":        "!!!",
+		"padded base64":     base64.URLEncoding.EncodeToString([]byte("1_abc")),
+		"no separator":      encode("1704110400000000000"),
+		"no id":             encode("1704110400000000000_"),
+		"time not a number": encode("yesterday_0b6c1e2a"),
+		"empty":             "",
+	}
+	for name, s := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Decode(s); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("Decode(%q) error = %v, want ErrInvalidCursor", s, err)
+			}
+		})
+	}
+}
@@ -11,15 +11,101 @@ var ErrInvalidToken = errors.New("invalid jwt token")
 // to activate their account before they can proceed.
 var ErrAccountNotActive = errors.New("user is not active")
 
+// ErrAccountSuspended is returned when a user whose account has been suspended
+// by an administrator attempts to sign in.
+var ErrAccountSuspended = errors.New("user is suspended")
+
+// ErrAccountBanned is returned when a user whose account has been banned attempts to sign in.
+var ErrAccountBanned = errors.New("user is banned")
+
+// ErrInvalidUserID is returned when a user ID passed to a lookup is not a UUID.
+var ErrInvalidUserID = errors.New("invalid user id")
+
+// ErrUserNotSuspended is returned when an administrator attempts to reactivate
+// an account that is not currently suspended.
+var ErrUserNotSuspended = errors.New("user is not suspended")
+
+// ErrStatusChangeNotAllowed is returned when an administrator asks for an account status that can't be set
+// directly, such as banned or pending.
+var ErrStatusChangeNotAllowed = errors.New("status change not allowed")
+
 // ErrIncorrectPassword is returned when a user provides an incorrect password
 // during authentication. This prevents unauthorized access to the account.
 var ErrIncorrectPassword = errors.New("incorrect password")
 
+// ErrPasswordReused is returned when a user tries to change their password
+// to one they have used before, including their current password.
+var ErrPasswordReused = errors.New("password has been used before")
+
+// ErrPasswordTooLong is returned when a password is longer than the 72 bytes bcrypt can hash.
+var ErrPasswordTooLong = errors.New("password exceeds 72 bytes")
+
+// ErrPasswordBreached is returned when a new password appears in a known data breach
+// and must not be used.
+var ErrPasswordBreached = errors.New("password found in a data breach")
+
 // ErrEmailLinkedToOauth is returned when a user attempts to sign up or log in
 // using an email that is already associated with an OAuth account. This error
 // informs the user that they should use their OAuth provider to log in instead.
 var ErrEmailLinkedToOauth = errors.New("email associated with oauth account")
 
+// ErrEmailLinkedToOtherProvider is returned when a user signs in through an OAuth provider with an email
+// that belongs to an account linked to a different provider. See ProviderConflictError.
+var ErrEmailLinkedToOtherProvider = errors.New("email associated with another oauth provider")
+
+// ErrEmailRegisteredWithPassword is returned when a user signs in through an OAuth provider with the email
+// of a password account that hasn't been verified yet, so it can't be linked safely.
+var ErrEmailRegisteredWithPassword = errors.New("email registered with an unverified password account")
+
+// ProviderConflictError reports the OAuth provider that an email is already linked to,
+// so the user can be told which provider to sign in with. It matches ErrEmailLinkedToOtherProvider.
+type ProviderConflictError struct {
+	Provider string
+}
+
+func (e *ProviderConflictError) Error() string {
+	return ErrEmailLinkedToOtherProvider.Error() + ": " + e.Provider
+}
+
+func (e *ProviderConflictError) Unwrap() error {
+	return ErrEmailLinkedToOtherProvider
+}
+
+// ErrUserAlreadyRegistered is returned when an administrator invites an email that already belongs
+// to an account created some other way, or to an account that is already active.
+var ErrUserAlreadyRegistered = errors.New("user already registered")
+
+// ErrVerificationEmailNotSent is returned when an account was created but its verification email
+// could not be sent. The account exists, and the email can be requested again.
+var ErrVerificationEmailNotSent = errors.New("verification email could not be sent")
+
+// ErrUsernameTaken is returned when signing up with a username that already belongs to another account.
+var ErrUsernameTaken = errors.New("username is already taken")
+
+// ErrUsernameNotEnabled is returned when signing up with a username while username sign-in is disabled.
+var ErrUsernameNotEnabled = errors.New("usernames are not enabled")
+
+// ErrInvitationNotPending is returned when an invitation link is used for an account that has
+// already been activated or was not created by an invitation.
+var ErrInvitationNotPending = errors.New("invitation is no longer pending")
+
+// ErrSignupClosed is returned when an account would be created while auth.allow_signup is disabled,
+// or through OAuth while sign-up is limited to invited people.
+var ErrSignupClosed = errors.New("sign-up is closed")
+
+// ErrInvitationRequired is returned when signing up without an invitation while auth.invite_only is enabled.
+var ErrInvitationRequired = errors.New("sign-up requires an invitation")
+
+// ErrInvalidAPIKey is returned when a presented API key is unknown or has been revoked.
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+// ErrReauthenticationRequired is returned when a sensitive action needs the user to confirm their
+// password or to have signed in recently.
+var ErrReauthenticationRequired = errors.New("recent authentication required")
+
+// ErrEmailAlreadyExists is returned when creating a user with an email that already belongs to another account.
+var ErrEmailAlreadyExists = errors.New("email already exists")
+
 // ErrorResponse represents the structure of an error response.
 // It includes a status, a message, and optionally additional error details.
 type ErrorResponse struct {
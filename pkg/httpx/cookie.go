@@ -0,0 +1,46 @@
+package httpx
+
+import (
+	"net/http"
+	"strings"
+)
+
+// CookieOptions holds the attributes shared by every cookie the application writes.
+type CookieOptions struct {
+	Secure   bool
+	Domain   string
+	Path     string
+	SameSite http.SameSite
+	// ScriptReadable leaves out the HttpOnly attribute so the frontend's JavaScript can read the cookie.
+	// Only set it for values that are safe to expose, such as a CSRF token.
+	ScriptReadable bool
+}
+
+// SetCookie writes a cookie with the given attributes, HttpOnly unless ScriptReadable is set.
+// A negative maxAge deletes the cookie and zero makes it a session cookie.
+// All cookies go through here so that their Secure, Domain, Path and SameSite attributes stay consistent.
+func SetCookie(w http.ResponseWriter, name, value string, maxAge int, opts CookieOptions) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		MaxAge:   maxAge,
+		Path:     opts.Path,
+		Domain:   opts.Domain,
+		Secure:   opts.Secure,
+		HttpOnly: !opts.ScriptReadable,
+		SameSite: opts.SameSite,
+	})
+}
+
+// ParseSameSite maps "lax", "strict" or "none" to the matching http.SameSite mode.
+// Anything else falls back to Lax, the browsers' own default.
+func ParseSameSite(mode string) http.SameSite {
+	switch strings.ToLower(mode) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
@@ -0,0 +1,84 @@
+package httpx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSetCookie(t *testing.T) {
+	tests := []struct {
+		name   string
+		maxAge int
+		opts   CookieOptions
+		want   string
+	}{
+		{
+			name:   "all attributes",
+			maxAge: 3600,
+			opts:   CookieOptions{Secure: true, Domain: "example.com", Path: "/", SameSite: http.SameSiteLaxMode},
+			want:   "session=value; Path=/; Domain=example.com; Max-Age=3600; HttpOnly; Secure; SameSite=Lax",
+		},
+		{
+			name:   "strict and insecure",
+			maxAge: 60,
+			opts:   CookieOptions{Path: "/api", SameSite: http.SameSiteStrictMode},
+			want:   "session=value; Path=/api; Max-Age=60; HttpOnly; SameSite=Strict",
+		},
+		{
+			name:   "cross-site",
+			maxAge: 60,
+			opts:   CookieOptions{Secure: true, Path: "/", SameSite: http.SameSiteNoneMode},
+			want:   "session=value; Path=/; Max-Age=60; HttpOnly; Secure; SameSite=None",
+		},
+		{
+			name:   "script readable",
+			maxAge: 60,
+			opts:   CookieOptions{Secure: true, Path: "/", SameSite: http.SameSiteLaxMode, ScriptReadable: true},
+			want:   "session=value; Path=/; Max-Age=60; Secure; SameSite=Lax",
+		},
+		{
+			name:   "session cookie",
+			maxAge: 0,
+			opts:   CookieOptions{Path: "/", SameSite: http.SameSiteLaxMode},
+			want:   "session=value; Path=/; HttpOnly; SameSite=Lax",
+		},
+		{
+			name:   "deleted",
+			maxAge: -1,
+			opts:   CookieOptions{Secure: true, Domain: "example.com", Path: "/", SameSite: http.SameSiteLaxMode},
+			want:   "session=value; Path=/; Domain=example.com; Max-Age=0; HttpOnly; Secure; SameSite=Lax",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			SetCookie(w, "session", "value", tt.maxAge, tt.opts)
+			if got := w.Header().Get("Set-Cookie"); got != tt.want {
+				t.Errorf("SetCookie() wrote %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSameSite(t *testing.T) {
+	tests := []struct {
+		mode string
+		want http.SameSite
+	}{
+		{mode: "lax", want: http.SameSiteLaxMode},
+		{mode: "strict", want: http.SameSiteStrictMode},
+		{mode: "Strict", want: http.SameSiteStrictMode},
+		{mode: "none", want: http.SameSiteNoneMode},
+		{mode: "NONE", want: http.SameSiteNoneMode},
+		{mode: "", want: http.SameSiteLaxMode},
+		{mode: "unknown", want: http.SameSiteLaxMode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			if got := ParseSameSite(tt.mode); got != tt.want {
+				t.Errorf("ParseSameSite(%q) = %v, want %v", tt.mode, got, tt.want)
+			}
+		})
+	}
+}
@@ -31,9 +31,22 @@ type Config struct {
 	Encoding     string        // Log output format: "console" or "json"
 	Level        zapcore.Level // Default log level (e.g., Info, Debug, Error)
 	Development  bool          // Whether the logger is in development mode
-	LogToFile    bool          // Whether to log to a file (automatically enabled in production)
+	LogToFile    bool          // Whether to also log to a file in LogDirectory
 	LogDirectory string        // Directory where log files will be stored
 	Production   bool          // Whether the application is in production mode
+	MaxSize      int           // Size in megabytes at which the log file is rotated; 0 disables rotation
+	MaxBackups   int           // Number of rotated log files to keep; 0 keeps them all
+	MaxAge       time.Duration // How long rotated log files are kept; 0 keeps them forever
+
+	DisableCaller      bool          // Whether to leave the calling file and line out of entries
+	StacktraceLevel    zapcore.Level // Lowest level whose entries carry a stacktrace
+	DisableStacktrace  bool          // Whether to leave stacktraces out at every level
+	DevelopmentEncoder bool          // Whether to use zap's development encoder settings
+	// SamplingInitial and SamplingThereafter rate-limit repeated entries outside development: each second,
+	// the first SamplingInitial entries with the same level and message are logged, then every
+	// SamplingThereafter-th. A SamplingInitial of 0 disables sampling.
+	SamplingInitial    int
+	SamplingThereafter int
 }
 
 // conf is the default logger configuration.
@@ -43,23 +56,18 @@ var conf = &Config{
 	Development:  true,              // Development mode enabled by default
 	LogToFile:    false,             // By default, do not log to a file
 	LogDirectory: "./logs",          // Default directory for log files 	// By default, not in production mode
+	MaxSize:      100,               // Rotate the log file at 100 MB
+
+	StacktraceLevel: zapcore.WarnLevel, // Stacktraces from warnings up, as in development
 }
 
 // SetConfig updates the logging configuration for the default logger.
-// It automatically enables file logging if the application is in production mode.
+// The configuration is copied whole and taken as given, so explicit settings such as LogToFile win, and later
+// changes to c have no effect. Defaults, such as file logging in production, are derived by the configuration.
 // Must be called before DefaultLogger() to take effect.
 func SetConfig(c *Config) {
-	conf = &Config{
-		Encoding:     c.Encoding,
-		Level:        c.Level,
-		Development:  c.Development,
-		LogDirectory: c.LogDirectory,
-	}
-
-	// Enable file logging automatically if in production mode
-	if !conf.Development {
-		conf.LogToFile = true
-	}
+	copied := *c
+	conf = &copied
 }
 
 // SetLevel updates the logging level for the default logger.
@@ -67,47 +75,78 @@ func SetLevel(l zapcore.Level) {
 	conf.Level = l
 }
 
+// samplingTick is the window over which repeated log entries are counted for sampling.
+const samplingTick = time.Second
+
+// logFileName is the name of the active log file inside the log directory.
+// Rotated files get a timestamp before the extension, e.g. app-2006-01-02T15-04-05.000.log.
+const logFileName = "app.log"
+
 // NewLogger creates a new logger instance based on the provided configuration.
 // It returns a SugaredLogger, which is a wrapper around zap's Logger that provides
-// a more user-friendly API.
+// a more user-friendly API. The encoding comes from conf.Encoding alone, whatever the mode.
+// Logs always go to standard output; when file logging is enabled
+// they are also written to a size-rotated file in the log directory. If that file can't be
+// opened, logging carries on to standard output alone.
 func NewLogger(conf *Config) *zap.SugaredLogger {
-	// Create a default encoder configuration
+	// Create the encoder configuration, with zap's development settings when asked for
 	ec := zap.NewProductionEncoderConfig()
+	if conf.DevelopmentEncoder {
+		ec = zap.NewDevelopmentEncoderConfig()
+		if conf.Encoding != "json" {
+			ec.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		}
+	}
 	ec.EncodeTime = zapcore.ISO8601TimeEncoder // Set time format to ISO8601
 
-	// Set up output paths, starting with standard output
-	outputPaths := []string{"stdout"}
-	errorOutputPaths := []string{"stderr"}
+	var encoder zapcore.Encoder
+	if conf.Encoding == "json" {
+		encoder = zapcore.NewJSONEncoder(ec)
+	} else {
+		encoder = zapcore.NewConsoleEncoder(ec)
+	}
 
-	// If logging to a file is enabled, add a file output path
+	// Standard output is always a destination
+	sinks := []zapcore.WriteSyncer{zapcore.Lock(os.Stdout)}
+
+	// If logging to a file is enabled, add the rotating log file
 	if conf.LogToFile {
-		// Ensure the log directory exists
-		if err := os.MkdirAll(conf.LogDirectory, os.ModePerm); err != nil {
-			fmt.Printf("Failed to create log directory: %v\n", err)
-			outputPaths = append(outputPaths, "stdout") // Fallback to stdout if directory creation fails
+		if file, err := openLogFile(conf); err != nil {
+			fmt.Printf("Failed to open log file, logging to stdout only: %v\n", err)
 		} else {
-			// Generate the log file name based on the current date
-			logFileName := filepath.Join(conf.LogDirectory, time.Now().Format("2006-01-02")+".log")
-			outputPaths = append(outputPaths, logFileName)
+			sinks = append(sinks, file)
 		}
 	}
 
-	// Create the logger configuration
-	cfg := zap.Config{
-		Encoding:         conf.Encoding,                    // Set the log format (console or JSON)
-		EncoderConfig:    ec,                               // Apply the encoder configuration
-		Level:            zap.NewAtomicLevelAt(conf.Level), // Set the log level
-		Development:      conf.Development,                 // Enable development mode if set
-		OutputPaths:      outputPaths,                      // Log output destinations
-		ErrorOutputPaths: errorOutputPaths,                 // Error log output destinations
+	core := zapcore.NewCore(encoder, zapcore.NewMultiWriteSyncer(sinks...), zap.NewAtomicLevelAt(conf.Level))
+
+	// Sample repeated entries in production so a hot loop or a flood of SQL logs can't overwhelm storage.
+	// Development keeps every entry.
+	if !conf.Development && conf.SamplingInitial > 0 {
+		core = zapcore.NewSamplerWithOptions(core, samplingTick, conf.SamplingInitial, conf.SamplingThereafter)
+	}
+
+	// Mirror the options zap.Config.Build would apply, with the caller and stacktrace policy from conf
+	opts := []zap.Option{zap.ErrorOutput(zapcore.Lock(os.Stderr))}
+	if !conf.DisableCaller {
+		opts = append(opts, zap.AddCaller())
 	}
+	if !conf.DisableStacktrace {
+		opts = append(opts, zap.AddStacktrace(conf.StacktraceLevel))
+	}
+	if conf.Development {
+		opts = append(opts, zap.Development())
+	}
+
+	return zap.New(core, opts...).Sugar() // Return the SugaredLogger
+}
 
-	// Build the logger and handle any errors
-	logger, err := cfg.Build()
-	if err != nil {
-		logger = zap.NewNop() // Fallback to a no-op logger if building fails
+// openLogFile ensures the log directory exists and opens the rotating log file inside it.
+func openLogFile(conf *Config) (*rotatingFile, error) {
+	if err := os.MkdirAll(conf.LogDirectory, os.ModePerm); err != nil {
+		return nil, err
 	}
-	return logger.Sugar() // Return the SugaredLogger
+	return newRotatingFile(filepath.Join(conf.LogDirectory, logFileName), int64(conf.MaxSize)<<20, conf.MaxBackups, conf.MaxAge)
 }
 
 // DefaultLogger returns the default logger for the application.
@@ -151,3 +190,38 @@ func FromContext(ctx context.Context) *zap.SugaredLogger {
 	// If no logger is found in the context, return the default logger
 	return DefaultLogger()
 }
+
+// requestIDKey is the key used to store the request ID in the context.
+const requestIDKey contextKey = "request_id"
+
+// WithRequestID returns a copy of ctx carrying the given request ID.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	if gCtx, ok := ctx.(*gin.Context); ok {
+		ctx = gCtx.Request.Context()
+	}
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestIDFromContext returns the request ID stored in the context, or an empty string if there is none.
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if gCtx, ok := ctx.(*gin.Context); ok && gCtx != nil {
+		ctx = gCtx.Request.Context()
+	}
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// Continue returns base carrying the request ID of from, with a logger tagged with it. Background workers
+// use it so that work queued by a request can be correlated with it in the logs while running under the
+// worker's own cancellation. base is returned unchanged when from has no request ID.
+func Continue(base, from context.Context) context.Context {
+	requestID := RequestIDFromContext(from)
+	if requestID == "" {
+		return base
+	}
+	ctx := WithRequestID(base, requestID)
+	return WithLogger(ctx, FromContext(base).With("request_id", requestID))
+}
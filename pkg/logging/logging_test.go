@@ -0,0 +1,59 @@
+package logging
+
+import (
+	"reflect"
+	"testing"
+)
+
+// populatedConfig returns a Config with every field set to a value other than its zero value,
+// so a field SetConfig drops can't go unnoticed, including fields added to Config later.
+func populatedConfig(t *testing.T) Config {
+	t.Helper()
+
+	var c Config
+	v := reflect.ValueOf(&c).Elem()
+	for i := range v.NumField() {
+		field := v.Field(i)
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(v.Type().Field(i).Name)
+		case reflect.Bool:
+			field.SetBool(true)
+		case reflect.Int, reflect.Int8, reflect.Int64:
+			field.SetInt(int64(i + 1))
+		default:
+			t.Fatalf("Config.%s is a %s, which populatedConfig can't set", v.Type().Field(i).Name, field.Kind())
+		}
+	}
+	return c
+}
+
+func TestSetConfigKeepsEveryField(t *testing.T) {
+	saved := conf
+	t.Cleanup(func() { conf = saved })
+
+	want := populatedConfig(t)
+	given := want
+
+	SetConfig(&given)
+
+	if *conf != want {
+		t.Errorf("conf = %+v, want %+v", *conf, want)
+	}
+}
+
+// The logger's configuration is a copy, so the caller changing its Config afterwards has no effect.
+func TestSetConfigCopiesTheConfig(t *testing.T) {
+	saved := conf
+	t.Cleanup(func() { conf = saved })
+
+	want := populatedConfig(t)
+	given := want
+
+	SetConfig(&given)
+	given = Config{}
+
+	if *conf != want {
+		t.Errorf("conf = %+v after changing the caller's Config, want %+v", *conf, want)
+	}
+}
@@ -0,0 +1,96 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestRotatingFile opens app.log in a temporary directory with a clock that advances a second per call.
+func newTestRotatingFile(t *testing.T, maxSize int64, maxBackups int) (*rotatingFile, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	r, err := newRotatingFile(filepath.Join(dir, "app.log"), maxSize, maxBackups, 0)
+	if err != nil {
+		t.Fatalf("newRotatingFile() error = %v", err)
+	}
+	t.Cleanup(func() { r.file.Close() })
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	r.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	return r, dir
+}
+
+func write(t *testing.T, r *rotatingFile, s string) {
+	t.Helper()
+	if _, err := r.Write([]byte(s)); err != nil {
+		t.Fatalf("Write(%q) error = %v", s, err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile(%s) error = %v", path, err)
+	}
+	return string(b)
+}
+
+func TestRotatingFileRotates(t *testing.T) {
+	r, dir := newTestRotatingFile(t, 10, 2)
+
+	write(t, r, "first\n")
+	write(t, r, "second\n")
+	write(t, r, "third\n")
+	write(t, r, "fourth\n")
+
+	if got := readFile(t, filepath.Join(dir, "app.log")); got != "fourth\n" {
+		t.Errorf("app.log = %q, want %q", got, "fourth\n")
+	}
+
+	// Three rotations, of which the oldest backup is pruned.
+	backups, _ := filepath.Glob(filepath.Join(dir, "app-*.log"))
+	want := []string{"second\n", "third\n"}
+	if len(backups) != len(want) {
+		t.Fatalf("backups = %v, want %d files", backups, len(want))
+	}
+	for i, backup := range backups {
+		if got := readFile(t, backup); got != want[i] {
+			t.Errorf("%s = %q, want %q", filepath.Base(backup), got, want[i])
+		}
+	}
+}
+
+func TestRotatingFileKeepsLoggingWhenRenameFails(t *testing.T) {
+	r, dir := newTestRotatingFile(t, 10, 0)
+
+	// A non-empty directory where the backup should go makes the rename fail.
+	blocker := filepath.Join(dir, "app-2024-05-01T12-00-01.000.log")
+	if err := os.MkdirAll(filepath.Join(blocker, "keep"), 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+
+	write(t, r, "first\n")
+	if _, err := r.Write([]byte("second\n")); err == nil {
+		t.Error("Write() error = nil, want the rotation error")
+	}
+	// The next rotation gets a new timestamp and succeeds.
+	write(t, r, "third\n")
+
+	backups, _ := filepath.Glob(filepath.Join(dir, "app-*.log"))
+	if len(backups) != 2 || backups[0] != blocker {
+		t.Fatalf("backups = %v, want the blocking directory and one rotated file", backups)
+	}
+	if got := readFile(t, backups[1]); got != "first\nsecond\n" {
+		t.Errorf("backup = %q, want %q", got, "first\nsecond\n")
+	}
+	if got := readFile(t, filepath.Join(dir, "app.log")); got != "third\n" {
+		t.Errorf("app.log = %q, want %q", got, "third\n")
+	}
+}
@@ -0,0 +1,29 @@
+package pkg
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	apiError "github.com/npushpakumara/go-backend-template/pkg/errors"
+)
+
+// RequireUUIDParams is a Gin middleware that rejects the request with a 400 unless each of the
+// named path parameters is a valid UUID. IDs that aren't UUIDs would otherwise reach Postgres,
+// fail the uuid cast and surface as a 500.
+func RequireUUIDParams(params ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, param := range params {
+			value := c.Param(param)
+			if _, err := uuid.Parse(value); err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, apiError.ErrorResponse{
+					Status:  "error",
+					Message: "Invalid path parameters",
+					Errors:  NewValidationErrorDetails(param, param+" must be a valid UUID", value),
+				})
+				return
+			}
+		}
+		c.Next()
+	}
+}
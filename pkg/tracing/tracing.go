@@ -0,0 +1,96 @@
+// Package tracing records OpenTelemetry spans for the application and propagates them as
+// W3C Trace Context (https://www.w3.org/TR/trace-context/): the trace an incoming request belongs to
+// is carried on its context, added to its logs, handed to the background workers it starts and
+// forwarded to the services they call.
+//
+// Spans are recorded by the global tracer provider, which NewProvider's caller installs. Until then,
+// and whenever tracing is disabled, the global provider is OpenTelemetry's no-op one.
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/npushpakumara/go-backend-template/pkg/logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies the spans recorded by the application's own instrumentation.
+const instrumentationName = "github.com/npushpakumara/go-backend-template"
+
+// Options configure the spans exported by NewProvider.
+type Options struct {
+	// Endpoint is the host and port of the OTLP/HTTP collector, such as "otel-collector:4318".
+	Endpoint string
+	// Insecure exports over plain HTTP rather than HTTPS.
+	Insecure bool
+	// ServiceName names the application in the exported spans.
+	ServiceName string
+	// SampleRatio is the fraction of new traces that are recorded. Traces continued from a caller
+	// follow the caller's sampling decision.
+	SampleRatio float64
+}
+
+// NewProvider returns a tracer provider that batches spans and exports them to the OTLP collector
+// in opts. The exporter connects lazily, so an unreachable collector doesn't stop the application;
+// its spans are dropped instead. The caller must Shutdown the provider to flush the last batch.
+func NewProvider(ctx context.Context, opts Options) (*sdktrace.TracerProvider, error) {
+	exporterOpts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(opts.Endpoint)}
+	if opts.Insecure {
+		exporterOpts = append(exporterOpts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, exporterOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(opts.ServiceName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	), nil
+}
+
+// SetGlobal makes provider record the application's spans and installs the W3C Trace Context propagator.
+func SetGlobal(provider trace.TracerProvider) {
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+}
+
+// Tracer returns the tracer of the application's own instrumentation.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Extract returns ctx carrying the caller's span from the traceparent header, if the header is valid.
+func Extract(ctx context.Context, header http.Header) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// Inject sets the traceparent header for an outbound call made within the span of ctx.
+func Inject(ctx context.Context, header http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// Continue returns base carrying the span of from, with a logger tagged with its trace ID.
+// Background workers use it so that the spans of work queued by a request are children of the
+// request's span while running under the worker's own cancellation. base is returned unchanged
+// when from isn't traced.
+func Continue(base, from context.Context) context.Context {
+	if gCtx, ok := from.(*gin.Context); ok {
+		from = gCtx.Request.Context()
+	}
+	sc := trace.SpanContextFromContext(from)
+	if !sc.IsValid() {
+		return base
+	}
+	ctx := trace.ContextWithSpanContext(base, sc)
+	return logging.WithLogger(ctx, logging.FromContext(base).With("trace_id", sc.TraceID().String()))
+}
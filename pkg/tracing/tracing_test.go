@@ -0,0 +1,37 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestContinue(t *testing.T) {
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa},
+		TraceFlags: trace.FlagsSampled,
+	})
+	request := trace.ContextWithSpanContext(context.Background(), sc)
+
+	// The worker's context isn't cancelled with the request's but carries its span.
+	base, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx := Continue(base, request)
+
+	if got := trace.SpanContextFromContext(ctx); !got.Equal(sc) {
+		t.Errorf("continued span = %v, want %v", got, sc)
+	}
+	cancel()
+	if ctx.Err() == nil {
+		t.Error("continued context isn't cancelled with the base context")
+	}
+}
+
+func TestContinueWithoutTrace(t *testing.T) {
+	base := context.Background()
+	if ctx := Continue(base, context.Background()); ctx != base {
+		t.Error("Continue() of an untraced context didn't return the base context")
+	}
+}
@@ -3,11 +3,97 @@ package pkg
 import (
 	"fmt"
 	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
 	"github.com/npushpakumara/go-backend-template/pkg/logging"
 )
 
+// RegisterValidations registers the application's custom validation tags with gin's validator.
+// It must be called before any request is bound.
+func RegisterValidations() error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return fmt.Errorf("unexpected validator engine %T", binding.Validator.Engine())
+	}
+	if err := v.RegisterValidation("password_strength", passwordStrength); err != nil {
+		return err
+	}
+	if err := v.RegisterValidation("username", username); err != nil {
+		return err
+	}
+	if err := v.RegisterValidation("person_name", personName); err != nil {
+		return err
+	}
+	return v.RegisterValidation("max_bytes", maxBytes)
+}
+
+// maxBytes validates that a string is at most the given number of bytes long once UTF-8 encoded,
+// unlike max, which counts characters. It is used for passwords, which bcrypt limits to 72 bytes.
+func maxBytes(fl validator.FieldLevel) bool {
+	limit, err := strconv.Atoi(fl.Param())
+	if err != nil {
+		return false
+	}
+	return len(fl.Field().String()) <= limit
+}
+
+// username validates a sign-in name: 3 to 30 ASCII letters, digits, dots, dashes and underscores,
+// starting with a letter. It can never contain "@", so it is never mistaken for an email.
+func username(fl validator.FieldLevel) bool {
+	name := fl.Field().String()
+	if len(name) < 3 || len(name) > 30 {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case i > 0 && (r >= '0' && r <= '9' || r == '.' || r == '-' || r == '_'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// personName validates a first or last name: it must be valid UTF-8, not blank, and free of control characters,
+// which could break the emails and pages it is shown in. Surrounding whitespace is trimmed when the name is stored.
+func personName(fl validator.FieldLevel) bool {
+	name := strings.TrimSpace(fl.Field().String())
+	if name == "" || !utf8.ValidString(name) {
+		return false
+	}
+	for _, r := range name {
+		if unicode.IsControl(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// passwordStrength validates that a password mixes upper and lower case letters, digits and special characters.
+func passwordStrength(fl validator.FieldLevel) bool {
+	var upper, lower, digit, special bool
+	for _, r := range fl.Field().String() {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			special = true
+		}
+	}
+	return upper && lower && digit && special
+}
+
 // ValidationErrDetail represents detailed information about a validation error.
 // It includes the field name, the value that failed validation, and a message explaining the error.
 type ValidationErrDetail struct {
@@ -46,6 +132,30 @@ func ValidationErrorDetails(obj interface{}, tag string, errs validator.Validati
 			message = fmt.Sprintf("greater than or quauls to %s", err.Param())
 		case "numeric":
 			message = fmt.Sprintf("%s must be numeric", tagName)
+		case "password_strength":
+			message = fmt.Sprintf("%s must contain upper and lower case letters, a digit and a special character", tagName)
+		case "http_url":
+			message = fmt.Sprintf("%s must be an http or https URL", tagName)
+		case "oneof":
+			message = fmt.Sprintf("%s must be one of [%s]", tagName, err.Param())
+		case "max":
+			message = fmt.Sprintf("%s must be at most %s", tagName, err.Param())
+		case "max_bytes":
+			message = fmt.Sprintf("%s must be at most %s bytes", tagName, err.Param())
+		case "e164":
+			message = fmt.Sprintf("%s must be a phone number in E.164 format such as +447700900123", tagName)
+		case "timezone":
+			message = fmt.Sprintf("%s must be an IANA time zone such as Europe/London", tagName)
+		case "bcp47_language_tag":
+			message = fmt.Sprintf("%s must be a BCP 47 language tag such as en-GB", tagName)
+		case "username":
+			message = fmt.Sprintf("%s must be 3 to 30 letters, digits, dots, dashes or underscores, starting with a letter", tagName)
+		case "person_name":
+			message = fmt.Sprintf("%s must not be blank or contain control characters", tagName)
+		case "required_without":
+			message = fmt.Sprintf("%s is required", tagName)
+		case "uuid":
+			message = fmt.Sprintf("%s must be a valid UUID", tagName)
 		default:
 			logging.DefaultLogger().Warnf("unknown validation tag. tag:%s", err.ActualTag())
 			message = fmt.Sprintf("invalid %s", tagName)
@@ -60,6 +170,22 @@ func ValidationErrorDetails(obj interface{}, tag string, errs validator.Validati
 	return errors
 }
 
+// BindQuery binds the request's query parameters into obj, a pointer to a struct using "form" and
+// "binding" tags, and validates them. When validation fails it returns the error together with
+// the details to send back to the client. A value that can't be converted to the field's type,
+// such as letters for an int, yields a single detail carrying the parse error.
+func BindQuery(ctx *gin.Context, obj interface{}) ([]*ValidationErrDetail, error) {
+	err := ctx.ShouldBindQuery(obj)
+	if err == nil {
+		return nil, nil
+	}
+
+	if vErrs, ok := err.(validator.ValidationErrors); ok {
+		return ValidationErrorDetails(obj, "form", vErrs), err
+	}
+	return []*ValidationErrDetail{{Message: err.Error()}}, err
+}
+
 // NewValidationErrorDetails returns ValidationErrDetail list with given validation errors
 func NewValidationErrorDetails(field, message string, value interface{}) []*ValidationErrDetail {
 	return []*ValidationErrDetail{